@@ -467,10 +467,11 @@ func (s *Seeder) SeedShifts(orgID string, users []models.User, participants []mo
 				status = statusOptions[rand.Intn(len(statusOptions))]
 			}
 
+			careWorker := careWorkers[rand.Intn(len(careWorkers))]
 			shift := models.Shift{
 				ID:            uuid.New().String(),
 				ParticipantID: participants[rand.Intn(len(participants))].ID,
-				StaffID:       careWorkers[rand.Intn(len(careWorkers))].ID,
+				StaffID:       &careWorker.ID,
 				StartTime:     startTime,
 				EndTime:       endTime,
 				Status:        status,
@@ -0,0 +1,93 @@
+// Package accounting exports invoices and payments to accounting systems, either as
+// import files for Xero and MYOB or by pushing them to a connector over HTTP.
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Export formats
+const (
+	FormatXero = "xero"
+	FormatMYOB = "myob"
+	FormatHTTP = "http"
+)
+
+// DateLayout is the Australian date format both Xero and MYOB imports expect
+const DateLayout = "02/01/2006"
+
+// Invoice is a sales invoice in the shape accounting systems import
+type Invoice struct {
+	ID           string    `json:"id"` // the invoice's ID in the CRM
+	Number       string    `json:"number"`
+	Reference    string    `json:"reference"`
+	ContactName  string    `json:"contact_name"`
+	ContactEmail string    `json:"contact_email"`
+	IssueDate    time.Time `json:"issue_date"`
+	DueDate      time.Time `json:"due_date"`
+	Lines        []Line    `json:"lines"`
+	Total        float64   `json:"total"`
+}
+
+// Line is a single invoice line. Amounts exclude GST.
+type Line struct {
+	ItemCode    string  `json:"item_code"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitAmount  float64 `json:"unit_amount"`
+	Amount      float64 `json:"amount"`
+	TaxAmount   float64 `json:"tax_amount"`
+	Taxable     bool    `json:"taxable"`
+}
+
+// Payment is money received against an invoice
+type Payment struct {
+	ID            string    `json:"id"` // the payment's ID in the CRM
+	InvoiceNumber string    `json:"invoice_number"`
+	ContactName   string    `json:"contact_name"`
+	Date          time.Time `json:"date"`
+	Amount        float64   `json:"amount"`
+	Method        string    `json:"method"`
+	Reference     string    `json:"reference"`
+}
+
+// Result is what an export produced. File exporters fill in the file; connectors leave it
+// empty. Invoices and Payments map the ID of every record the export took to the ID the
+// accounting system gave it, which is empty for file exports.
+type Result struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Invoices    map[string]string
+	Payments    map[string]string
+}
+
+func newResult() *Result {
+	return &Result{Invoices: map[string]string{}, Payments: map[string]string{}}
+}
+
+// AccountingExporter sends invoices and payments to an accounting system. Exporters that
+// cannot take payments leave them out of the result so they are offered again later.
+// A connector that fails part way returns the records it did push along with the error.
+type AccountingExporter interface {
+	Format() string
+	Export(ctx context.Context, invoices []Invoice, payments []Payment) (*Result, error)
+}
+
+// New returns the file exporter for a format with its default accounts
+func New(format string) (AccountingExporter, error) {
+	switch format {
+	case FormatXero:
+		return NewXeroExporter(), nil
+	case FormatMYOB:
+		return NewMYOBExporter(), nil
+	}
+	return nil, fmt.Errorf("unknown accounting export format %q", format)
+}
+
+// money formats an amount with two decimal places
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
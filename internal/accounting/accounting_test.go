@@ -0,0 +1,131 @@
+package accounting
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleInvoices() []Invoice {
+	issued := time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC)
+	return []Invoice{{
+		ID:          "invoice-1",
+		Number:      "INV-000001",
+		Reference:   "430000001",
+		ContactName: "Jane Smith",
+		IssueDate:   issued,
+		DueDate:     issued.AddDate(0, 0, 30),
+		Lines: []Line{
+			{ItemCode: "01_011_0107_1_1", Description: "Self-Care\tWeekday", Quantity: 2, UnitAmount: 67.56, Amount: 135.12},
+			{Description: "Transport", Quantity: 5, UnitAmount: 0.99, Amount: 4.95},
+		},
+		Total: 140.07,
+	}}
+}
+
+func samplePayments() []Payment {
+	return []Payment{{
+		ID:            "payment-1",
+		InvoiceNumber: "INV-000001",
+		ContactName:   "Jane Smith",
+		Date:          time.Date(2024, 7, 20, 0, 0, 0, 0, time.UTC),
+		Amount:        140.07,
+		Method:        "bank_transfer",
+		Reference:     "EFT123",
+	}}
+}
+
+func TestXeroExporter(t *testing.T) {
+	result, err := NewXeroExporter().Export(context.Background(), sampleInvoices(), samplePayments())
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", result.ContentType)
+	assert.Equal(t, map[string]string{"invoice-1": ""}, result.Invoices)
+	assert.Empty(t, result.Payments)
+
+	records, err := csv.NewReader(bytes.NewReader(result.Data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, XeroSalesInvoiceHeader, records[0])
+
+	line := records[1]
+	assert.Equal(t, "Jane Smith", line[0])
+	assert.Equal(t, "INV-000001", line[10])
+	assert.Equal(t, "15/07/2024", line[12])
+	assert.Equal(t, "14/08/2024", line[13])
+	assert.Equal(t, "01_011_0107_1_1", line[14])
+	assert.Equal(t, "2", line[16])
+	assert.Equal(t, "67.56", line[17])
+	assert.Equal(t, "200", line[19])
+	assert.Equal(t, "GST Free Income", line[20])
+}
+
+func TestMYOBExporter(t *testing.T) {
+	result, err := NewMYOBExporter().Export(context.Background(), sampleInvoices(), samplePayments())
+	require.NoError(t, err)
+	assert.Equal(t, "application/zip", result.ContentType)
+	assert.Len(t, result.Invoices, 1)
+	assert.Equal(t, map[string]string{"payment-1": ""}, result.Payments)
+
+	archive, err := zip.NewReader(bytes.NewReader(result.Data), int64(len(result.Data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[file.Name] = string(content)
+	}
+
+	sales := strings.Split(strings.TrimSuffix(files["SERVICE_SALES.TXT"], "\r\n"), "\r\n")
+	require.Len(t, sales, 3)
+	fields := strings.Split(sales[1], "\t")
+	require.Len(t, fields, len(MYOBServiceSalesHeader))
+	assert.Equal(t, "Self-Care Weekday", fields[4])
+	assert.Equal(t, "135.12", fields[6])
+	assert.Equal(t, "FRE", fields[8])
+	assert.Equal(t, "30", fields[12])
+
+	receipts := strings.Split(strings.TrimSuffix(files["CUSTOMER_PAYMENTS.TXT"], "\r\n"), "\r\n")
+	require.Len(t, receipts, 2)
+	assert.Equal(t, "Jane Smith\t1-1110\tEFT123\t20/07/2024\tINV-000001\t140.07\tPayment; Jane Smith\tbank_transfer", receipts[1])
+}
+
+func TestHTTPConnector(t *testing.T) {
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received = append(received, r.URL.Path)
+
+		if r.URL.Path == "/payments" {
+			http.Error(w, "payments are closed", http.StatusConflict)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": "xero-" + body["number"].(string)})
+	}))
+	defer server.Close()
+
+	connector := NewHTTPConnector(server.URL+"/", "secret")
+	result, err := connector.Export(context.Background(), sampleInvoices(), samplePayments())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "payments are closed")
+	assert.Equal(t, []string{"/invoices", "/payments"}, received)
+
+	// The invoice that was accepted is still reported
+	require.NotNil(t, result)
+	assert.Equal(t, map[string]string{"invoice-1": "xero-INV-000001"}, result.Invoices)
+	assert.Empty(t, result.Payments)
+}
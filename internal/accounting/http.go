@@ -0,0 +1,100 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPConnector pushes invoices and payments as JSON to a connector service that forwards
+// them to the accounting system. Invoices are posted to {BaseURL}/invoices and payments to
+// {BaseURL}/payments; the connector replies with the ID the accounting system assigned.
+type HTTPConnector struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// NewHTTPConnector creates a connector for the service at baseURL
+func NewHTTPConnector(baseURL, token string) *HTTPConnector {
+	return &HTTPConnector{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (h *HTTPConnector) Format() string {
+	return FormatHTTP
+}
+
+// Export pushes invoices before payments so a payment never arrives ahead of its invoice.
+// It stops at the first failure and returns what was pushed so far with the error.
+func (h *HTTPConnector) Export(ctx context.Context, invoices []Invoice, payments []Payment) (*Result, error) {
+	result := newResult()
+
+	for _, invoice := range invoices {
+		externalID, err := h.post(ctx, "/invoices", invoice)
+		if err != nil {
+			return result, fmt.Errorf("invoice %s: %w", invoice.Number, err)
+		}
+		result.Invoices[invoice.ID] = externalID
+	}
+
+	for _, payment := range payments {
+		externalID, err := h.post(ctx, "/payments", payment)
+		if err != nil {
+			return result, fmt.Errorf("payment for invoice %s: %w", payment.InvoiceNumber, err)
+		}
+		result.Payments[payment.ID] = externalID
+	}
+
+	return result, nil
+}
+
+func (h *HTTPConnector) post(ctx context.Context, path string, payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("connector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var reply struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return "", fmt.Errorf("decoding connector reply: %w", err)
+	}
+	if reply.ID == "" {
+		return "", fmt.Errorf("connector reply has no id")
+	}
+	return reply.ID, nil
+}
@@ -0,0 +1,161 @@
+package accounting
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MYOBServiceSalesHeader is the column order of the service sales file written for MYOB's
+// Import Data > Sales > Service Sales
+var MYOBServiceSalesHeader = []string{
+	"Co./Last Name",
+	"Invoice #",
+	"Date",
+	"Customer PO",
+	"Description",
+	"Account #",
+	"Amount",
+	"Inc-Tax Amount",
+	"Tax Code",
+	"GST Amount",
+	"Journal Memo",
+	"Payment Is Due",
+	"Balance Due Days",
+}
+
+// MYOBCustomerPaymentsHeader is the column order of the customer payments file written for
+// MYOB's Import Data > Receive Payments
+var MYOBCustomerPaymentsHeader = []string{
+	"Co./Last Name",
+	"Deposit Account #",
+	"ID #",
+	"Date",
+	"Invoice #",
+	"Amount Applied",
+	"Memo",
+	"Payment Method",
+}
+
+// MYOBExporter writes invoices as a service sales import and payments as a customer payments
+// import, delivered together in a zip
+type MYOBExporter struct {
+	IncomeAccount  string // income account for every line
+	TaxCode        string // tax code for GST free lines
+	TaxableTaxCode string // tax code for lines that attract GST
+	DepositAccount string // account payments are deposited to
+}
+
+// NewMYOBExporter uses the income and cheque accounts of MYOB's default Australian chart of accounts
+func NewMYOBExporter() *MYOBExporter {
+	return &MYOBExporter{
+		IncomeAccount:  "4-1000",
+		TaxCode:        "FRE",
+		TaxableTaxCode: "GST",
+		DepositAccount: "1-1110",
+	}
+}
+
+func (m *MYOBExporter) Format() string {
+	return FormatMYOB
+}
+
+func (m *MYOBExporter) Export(ctx context.Context, invoices []Invoice, payments []Payment) (*Result, error) {
+	result := newResult()
+
+	// MYOB separates records with a blank line; each line of a record is one invoice line
+	sales := [][]string{MYOBServiceSalesHeader}
+	for i, invoice := range invoices {
+		if i > 0 {
+			sales = append(sales, nil)
+		}
+		balanceDueDays := int(invoice.DueDate.Sub(invoice.IssueDate).Hours() / 24)
+		for _, line := range invoice.Lines {
+			taxCode := m.TaxCode
+			if line.Taxable {
+				taxCode = m.TaxableTaxCode
+			}
+			sales = append(sales, []string{
+				invoice.ContactName,
+				invoice.Number,
+				invoice.IssueDate.Format(DateLayout),
+				invoice.Reference,
+				line.Description,
+				m.IncomeAccount,
+				money(line.Amount),
+				money(line.Amount + line.TaxAmount),
+				taxCode,
+				money(line.TaxAmount),
+				"Sale; " + invoice.ContactName,
+				"2", // due in a given number of days
+				strconv.Itoa(balanceDueDays),
+			})
+		}
+		result.Invoices[invoice.ID] = ""
+	}
+
+	receipts := [][]string{MYOBCustomerPaymentsHeader}
+	for i, payment := range payments {
+		if i > 0 {
+			receipts = append(receipts, nil)
+		}
+		receipts = append(receipts, []string{
+			payment.ContactName,
+			m.DepositAccount,
+			payment.Reference,
+			payment.Date.Format(DateLayout),
+			payment.InvoiceNumber,
+			money(payment.Amount),
+			"Payment; " + payment.ContactName,
+			payment.Method,
+		})
+		result.Payments[payment.ID] = ""
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	files := []struct {
+		name    string
+		records [][]string
+	}{
+		{"SERVICE_SALES.TXT", sales},
+		{"CUSTOMER_PAYMENTS.TXT", receipts},
+	}
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(tabDelimited(file.records))); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	result.Filename = "myob_import_" + time.Now().Format("20060102_150405") + ".zip"
+	result.ContentType = "application/zip"
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+// tabDelimited joins records into MYOB's tab separated text with CRLF line endings. Tabs
+// and line breaks inside a value would break the import so they become spaces.
+func tabDelimited(records [][]string) string {
+	clean := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+	var b strings.Builder
+	for _, record := range records {
+		for i, value := range record {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			b.WriteString(clean.Replace(value))
+		}
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
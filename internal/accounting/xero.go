@@ -0,0 +1,108 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strconv"
+	"time"
+)
+
+// XeroSalesInvoiceHeader is the column order of Xero's sales invoice import template.
+// Columns marked with an asterisk are required by Xero.
+var XeroSalesInvoiceHeader = []string{
+	"*ContactName",
+	"EmailAddress",
+	"POAddressLine1",
+	"POAddressLine2",
+	"POAddressLine3",
+	"POAddressLine4",
+	"POCity",
+	"PORegion",
+	"POPostalCode",
+	"POCountry",
+	"*InvoiceNumber",
+	"Reference",
+	"*InvoiceDate",
+	"*DueDate",
+	"InventoryItemCode",
+	"*Description",
+	"*Quantity",
+	"*UnitAmount",
+	"Discount",
+	"*AccountCode",
+	"*TaxType",
+	"TrackingName1",
+	"TrackingOption1",
+	"TrackingName2",
+	"TrackingOption2",
+	"Currency",
+	"BrandingTheme",
+}
+
+// XeroExporter writes invoices as a Xero sales invoice CSV. Xero has no payment import,
+// so payments are left to be reconciled against the bank feed.
+type XeroExporter struct {
+	AccountCode    string // revenue account
+	TaxType        string // tax rate for GST free lines
+	TaxableTaxType string // tax rate for lines that attract GST
+}
+
+// NewXeroExporter uses the Sales account and tax rates of Xero's default Australian chart of accounts
+func NewXeroExporter() *XeroExporter {
+	return &XeroExporter{
+		AccountCode:    "200",
+		TaxType:        "GST Free Income",
+		TaxableTaxType: "GST on Income",
+	}
+}
+
+func (x *XeroExporter) Format() string {
+	return FormatXero
+}
+
+func (x *XeroExporter) Export(ctx context.Context, invoices []Invoice, payments []Payment) (*Result, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(XeroSalesInvoiceHeader); err != nil {
+		return nil, err
+	}
+
+	result := newResult()
+	for _, invoice := range invoices {
+		for _, line := range invoice.Lines {
+			taxType := x.TaxType
+			if line.Taxable {
+				taxType = x.TaxableTaxType
+			}
+			record := make([]string, len(XeroSalesInvoiceHeader))
+			record[0] = invoice.ContactName
+			record[1] = invoice.ContactEmail
+			record[10] = invoice.Number
+			record[11] = invoice.Reference
+			record[12] = invoice.IssueDate.Format(DateLayout)
+			record[13] = invoice.DueDate.Format(DateLayout)
+			record[14] = line.ItemCode
+			record[15] = line.Description
+			record[16] = strconv.FormatFloat(line.Quantity, 'f', -1, 64)
+			record[17] = money(line.UnitAmount)
+			record[19] = x.AccountCode
+			record[20] = taxType
+			record[25] = "AUD"
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+		result.Invoices[invoice.ID] = ""
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	result.Filename = "xero_sales_invoices_" + time.Now().Format("20060102_150405") + ".csv"
+	result.ContentType = "text/csv"
+	result.Data = buf.Bytes()
+	return result, nil
+}
@@ -0,0 +1,338 @@
+// Package catalogue parses the NDIS Support Catalogue (Pricing Arrangements and
+// Price Limits) published by the NDIA as CSV or XLSX.
+package catalogue
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Price limit regions as they appear in the catalogue columns
+const (
+	RegionACT        = "ACT"
+	RegionNSW        = "NSW"
+	RegionNT         = "NT"
+	RegionQLD        = "QLD"
+	RegionSA         = "SA"
+	RegionTAS        = "TAS"
+	RegionVIC        = "VIC"
+	RegionWA         = "WA"
+	RegionRemote     = "REMOTE"
+	RegionVeryRemote = "VERY_REMOTE"
+)
+
+// Participant remoteness classifications (Modified Monash Model 6 and 7)
+const (
+	RemotenessRemote     = "remote"
+	RemotenessVeryRemote = "very_remote"
+)
+
+// Support purposes that group support categories into plan budgets
+const (
+	PurposeCore             = "core"
+	PurposeCapacityBuilding = "capacity_building"
+	PurposeCapital          = "capital"
+)
+
+// Regions lists every price limit region in catalogue column order
+var Regions = []string{
+	RegionACT, RegionNSW, RegionNT, RegionQLD, RegionSA, RegionTAS, RegionVIC, RegionWA, RegionRemote, RegionVeryRemote,
+}
+
+// ErrMissingColumn is returned when a required catalogue column is not present
+var ErrMissingColumn = errors.New("catalogue is missing a required column")
+
+// Item is a single support item row from the catalogue
+type Item struct {
+	Number                  string
+	Name                    string
+	RegistrationGroupNumber string
+	RegistrationGroupName   string
+	SupportCategoryNumber   string
+	SupportCategoryName     string
+	Unit                    string // H, E, D, WK, MON, YR
+	Quote                   bool
+	StartDate               *time.Time
+	EndDate                 *time.Time
+	Prices                  map[string]float64 // region => price limit, absent when the item has no limit
+	NonFaceToFace           bool
+	ProviderTravel          bool
+	ShortNoticeCancellation bool
+	NDIARequestedReports    bool
+	IrregularSIL            bool
+	Type                    string // Price Limited Supports, Quotable Supports, Unit Price = $1
+}
+
+// column keys are header names lowercased with everything but letters and digits removed
+var columnAliases = map[string][]string{
+	"number":       {"supportitemnumber", "itemnumber"},
+	"name":         {"supportitemname", "itemname"},
+	"reg_number":   {"registrationgroupnumber"},
+	"reg_name":     {"registrationgroupname"},
+	"cat_number":   {"supportcategorynumber", "supportcategorynumberpace"},
+	"cat_name":     {"supportcategoryname", "supportcategorynamepace"},
+	"unit":         {"unit", "uom", "unitofmeasure"},
+	"quote":        {"quote"},
+	"start_date":   {"startdate"},
+	"end_date":     {"enddate"},
+	"non_f2f":      {"nonfacetofacesupportprovision", "nonfacetoface"},
+	"travel":       {"providertravel"},
+	"short_notice": {"shortnoticecancellations", "shortnoticecancellation"},
+	"reports":      {"ndiarequestedreports"},
+	"irregular":    {"irregularsilsupports"},
+	"type":         {"type"},
+}
+
+var regionAliases = map[string][]string{
+	RegionACT:        {"act"},
+	RegionNSW:        {"nsw"},
+	RegionNT:         {"nt"},
+	RegionQLD:        {"qld"},
+	RegionSA:         {"sa"},
+	RegionTAS:        {"tas"},
+	RegionVIC:        {"vic"},
+	RegionWA:         {"wa"},
+	RegionRemote:     {"remote"},
+	RegionVeryRemote: {"veryremote"},
+}
+
+// ParseCSV parses a catalogue exported as CSV
+func ParseCSV(r io.Reader) ([]Item, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalogue csv: %w", err)
+	}
+	return ParseRows(rows)
+}
+
+// ParseRows parses catalogue rows where the first row holds the column headers
+func ParseRows(rows [][]string) ([]Item, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: catalogue is empty", ErrMissingColumn)
+	}
+
+	index := make(map[string]int)
+	for i, header := range rows[0] {
+		key := normalizeHeader(header)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range columnAliases {
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	for _, required := range []string{"number", "name", "unit"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, required)
+		}
+	}
+
+	regionColumns := make(map[string]int)
+	for region, aliases := range regionAliases {
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				regionColumns[region] = i
+				break
+			}
+		}
+	}
+
+	items := make([]Item, 0, len(rows)-1)
+	for lineNo, row := range rows[1:] {
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		number := get("number")
+		if number == "" {
+			continue
+		}
+
+		item := Item{
+			Number:                  number,
+			Name:                    get("name"),
+			RegistrationGroupNumber: get("reg_number"),
+			RegistrationGroupName:   get("reg_name"),
+			SupportCategoryNumber:   get("cat_number"),
+			SupportCategoryName:     get("cat_name"),
+			Unit:                    strings.ToUpper(get("unit")),
+			Quote:                   parseFlag(get("quote")),
+			NonFaceToFace:           parseFlag(get("non_f2f")),
+			ProviderTravel:          parseFlag(get("travel")),
+			ShortNoticeCancellation: parseFlag(get("short_notice")),
+			NDIARequestedReports:    parseFlag(get("reports")),
+			IrregularSIL:            parseFlag(get("irregular")),
+			Type:                    get("type"),
+			Prices:                  make(map[string]float64),
+		}
+
+		var err error
+		if item.StartDate, err = parseDate(get("start_date")); err != nil {
+			return nil, fmt.Errorf("row %d: invalid start date: %w", lineNo+2, err)
+		}
+		if item.EndDate, err = parseDate(get("end_date")); err != nil {
+			return nil, fmt.Errorf("row %d: invalid end date: %w", lineNo+2, err)
+		}
+
+		for region, i := range regionColumns {
+			if i >= len(row) {
+				continue
+			}
+			price, ok, err := parsePrice(row[i])
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid %s price: %w", lineNo+2, region, err)
+			}
+			if ok {
+				item.Prices[region] = price
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// RegionFor resolves the price limit region for a participant's state and remoteness
+func RegionFor(state, remoteness string) string {
+	switch strings.ToLower(strings.TrimSpace(remoteness)) {
+	case RemotenessRemote:
+		return RegionRemote
+	case RemotenessVeryRemote:
+		return RegionVeryRemote
+	}
+	return NormalizeState(state)
+}
+
+// PurposeFor returns the support purpose of a support category number ("1", "01")
+// or a support item number ("01_011_0107_1_1"), or "" if unknown
+func PurposeFor(category string) string {
+	category = strings.TrimSpace(category)
+	if i := strings.IndexByte(category, '_'); i >= 0 {
+		category = category[:i]
+	}
+	number, err := strconv.Atoi(category)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case number >= 1 && number <= 4:
+		return PurposeCore
+	case number == 5 || number == 6:
+		return PurposeCapital
+	case number >= 7 && number <= 15:
+		return PurposeCapacityBuilding
+	}
+	return ""
+}
+
+// NormalizeCategory formats a support category number as two digits, e.g. "1" as "01"
+func NormalizeCategory(category string) string {
+	category = strings.TrimSpace(category)
+	if i := strings.IndexByte(category, '_'); i >= 0 {
+		category = category[:i]
+	}
+	number, err := strconv.Atoi(category)
+	if err != nil || number <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%02d", number)
+}
+
+// NormalizeState maps a state name or abbreviation to its catalogue region, or "" if unknown
+func NormalizeState(state string) string {
+	switch normalizeHeader(state) {
+	case "act", "australiancapitalterritory":
+		return RegionACT
+	case "nsw", "newsouthwales":
+		return RegionNSW
+	case "nt", "northernterritory":
+		return RegionNT
+	case "qld", "queensland":
+		return RegionQLD
+	case "sa", "southaustralia":
+		return RegionSA
+	case "tas", "tasmania":
+		return RegionTAS
+	case "vic", "victoria":
+		return RegionVIC
+	case "wa", "westernaustralia":
+		return RegionWA
+	}
+	return ""
+}
+
+func normalizeHeader(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func parseFlag(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes", "true", "1":
+		return true
+	}
+	return false
+}
+
+func parsePrice(s string) (float64, bool, error) {
+	s = strings.TrimSpace(s)
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	if s == "" || s == "-" || strings.EqualFold(s, "n/a") {
+		return 0, false, nil
+	}
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return price, true, nil
+}
+
+var dateLayouts = []string{"20060102", "2/01/2006", "02/01/2006", "2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// excelEpoch is day zero for spreadsheet serial dates
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func parseDate(s string) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	// Spreadsheets without cell formatting store dates as a day count
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 && serial < 100000 {
+		t := excelEpoch.AddDate(0, 0, int(serial))
+		return &t, nil
+	}
+	return nil, fmt.Errorf("unrecognised date %q", s)
+}
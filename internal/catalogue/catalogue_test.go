@@ -0,0 +1,107 @@
+package catalogue
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleCSV = `Support Item Number,Support Item Name,Registration Group Number,Registration Group Name,Support Category Number,Support Category Name,Unit,Quote,Start date,End Date,ACT,NSW,NT,QLD,SA,TAS,VIC,WA,Remote,Very Remote,Non-Face-to-Face Support Provision,Provider Travel,Short Notice Cancellations.,NDIA Requested Reports,Irregular SIL Supports,Type
+01_011_0107_1_1,"Assistance With Self-Care Activities - Standard - Weekday Daytime",0107,Daily Personal Activities,1,Assistance with Daily Life,H,N,20240701,99991231,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$94.58,$101.34,Y,Y,Y,Y,N,Price Limited Supports
+05_122403183_0103_1_2,Wheelchair,0103,Assistive Products,5,Assistive Technology,E,Y,1/07/2024,,,,,,,,,,,,N,N,N,N,N,Quotable Supports
+`
+
+func TestParseCSV(t *testing.T) {
+	items, err := ParseCSV(strings.NewReader(sampleCSV))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	item := items[0]
+	assert.Equal(t, "01_011_0107_1_1", item.Number)
+	assert.Equal(t, "0107", item.RegistrationGroupNumber)
+	assert.Equal(t, "1", item.SupportCategoryNumber)
+	assert.Equal(t, "H", item.Unit)
+	assert.False(t, item.Quote)
+	assert.True(t, item.ProviderTravel)
+	assert.True(t, item.ShortNoticeCancellation)
+	assert.True(t, item.NonFaceToFace)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), *item.StartDate)
+	assert.Len(t, item.Prices, 10)
+	assert.Equal(t, 67.56, item.Prices[RegionSA])
+	assert.Equal(t, 101.34, item.Prices[RegionVeryRemote])
+
+	quoted := items[1]
+	assert.True(t, quoted.Quote)
+	assert.Empty(t, quoted.Prices)
+	assert.Nil(t, quoted.EndDate)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), *quoted.StartDate)
+}
+
+func TestParseCSVMissingColumns(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("Item,Price\n01,10\n"))
+	assert.ErrorIs(t, err, ErrMissingColumn)
+}
+
+func TestParseXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Catalogue" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Support Item Number</t></si><si><t>Support Item Name</t></si><si><t>Unit</t></si><si><t>SA</t></si><si><r><t>Self-Care </t></r><r><t>Weekday</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="inlineStr"><is><t>Start Date</t></is></c></row>
+			<row r="2"><c r="A2" t="inlineStr"><is><t>01_011_0107_1_1</t></is></c><c r="B2" t="s"><v>4</v></c><c r="C2" t="inlineStr"><is><t>H</t></is></c><c r="D2"><v>67.56</v></c><c r="E2"><v>45474</v></c></row>
+		</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	items, err := ParseXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "01_011_0107_1_1", items[0].Number)
+	assert.Equal(t, "Self-Care Weekday", items[0].Name)
+	assert.Equal(t, 67.56, items[0].Prices[RegionSA])
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), *items[0].StartDate)
+}
+
+func TestRegionFor(t *testing.T) {
+	assert.Equal(t, RegionSA, RegionFor("South Australia", ""))
+	assert.Equal(t, RegionNSW, RegionFor("nsw", ""))
+	assert.Equal(t, RegionRemote, RegionFor("WA", RemotenessRemote))
+	assert.Equal(t, RegionVeryRemote, RegionFor("", RemotenessVeryRemote))
+	assert.Equal(t, "", RegionFor("Auckland", ""))
+}
+
+func TestPurposeFor(t *testing.T) {
+	assert.Equal(t, PurposeCore, PurposeFor("01_011_0107_1_1"))
+	assert.Equal(t, PurposeCore, PurposeFor("4"))
+	assert.Equal(t, PurposeCapital, PurposeFor("05"))
+	assert.Equal(t, PurposeCapacityBuilding, PurposeFor("07_002_0106_8_3"))
+	assert.Equal(t, "", PurposeFor("99"))
+	assert.Equal(t, "", PurposeFor(""))
+
+	assert.Equal(t, "01", NormalizeCategory("1"))
+	assert.Equal(t, "15", NormalizeCategory("15_037_0117_1_3"))
+	assert.Equal(t, "", NormalizeCategory("core"))
+}
+
+func TestTravelCaps(t *testing.T) {
+	assert.Equal(t, 30, TravelTimeCap(""))
+	assert.Equal(t, 60, TravelTimeCap(RemotenessVeryRemote))
+
+	assert.Equal(t, 0.85, KilometreRate(0.85))
+	assert.Equal(t, KilometreRateCap, KilometreRate(1.20))
+	assert.Equal(t, KilometreRateCap, KilometreRate(0))
+}
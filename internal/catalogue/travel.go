@@ -0,0 +1,31 @@
+package catalogue
+
+// Provider travel limits from the Pricing Arrangements. Time spent travelling to a
+// participant can be claimed at the support's hourly rate up to a cap that depends on
+// where the participant lives; kilometres driven are non-labour costs claimed per km.
+const (
+	TravelTimeCapMinutes       = 30   // MMM 1-3 (cities and large regional towns)
+	RemoteTravelTimeCapMinutes = 60   // MMM 4-7 (small towns, remote and very remote)
+	KilometreRateCap           = 0.99 // per km in a vehicle that is not modified
+)
+
+// ActivityBasedTransportItem is the support item for kilometres driven with the participant
+// in the vehicle, such as to and from community activities
+const ActivityBasedTransportItem = "04_590_0125_6_1"
+
+// TravelTimeCap returns the minutes of provider travel claimable for one support
+func TravelTimeCap(remoteness string) int {
+	switch remoteness {
+	case RemotenessRemote, RemotenessVeryRemote:
+		return RemoteTravelTimeCapMinutes
+	}
+	return TravelTimeCapMinutes
+}
+
+// KilometreRate caps a per km rate at the catalogue limit
+func KilometreRate(rate float64) float64 {
+	if rate <= 0 || rate > KilometreRateCap {
+		return KilometreRateCap
+	}
+	return rate
+}
@@ -20,6 +20,10 @@ type Config struct {
 	SMTPPort           int
 	SMTPUsername       string
 	SMTPPassword       string
+	SMTPFrom           string
+	AccountingURL      string
+	AccountingToken    string
+	PostcodesFile      string
 }
 
 func Load() *Config {
@@ -36,6 +40,10 @@ func Load() *Config {
 		SMTPPort:           parseInt(getEnv("SMTP_PORT", "587")),
 		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
 		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", ""),
+		AccountingURL:      getEnv("ACCOUNTING_CONNECTOR_URL", ""),
+		AccountingToken:    getEnv("ACCOUNTING_CONNECTOR_TOKEN", ""),
+		PostcodesFile:      getEnv("GEOCODER_POSTCODES_FILE", ""),
 	}
 }
 
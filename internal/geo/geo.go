@@ -0,0 +1,86 @@
+// Package geo turns addresses into coordinates and measures how far apart they are.
+//
+// Geocoders are pluggable. The default, PostcodeGeocoder, works offline from a table of
+// postcode centroids, which places an address at the middle of its postcode rather than at
+// the street: close enough to judge how far a worker would travel to a shift.
+package geo
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned when a geocoder cannot place an address
+var ErrNotFound = errors.New("address not found")
+
+const earthRadiusKm = 6371.0
+
+// Point is a position in decimal degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Address is an Australian street address. Geocoders use as much of it as they can.
+type Address struct {
+	Street   string
+	Suburb   string
+	State    string
+	Postcode string
+}
+
+// Geocoder places addresses
+type Geocoder interface {
+	Geocode(address Address) (Point, error)
+}
+
+// Distance returns the great-circle distance between two points in kilometres
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+var (
+	statePostcode = regexp.MustCompile(`(?i)\b(NSW|VIC|QLD|SA|WA|TAS|NT|ACT)?\s*(\d{4})\s*$`)
+	stateOnly     = regexp.MustCompile(`(?i)\b(NSW|VIC|QLD|SA|WA|TAS|NT|ACT)\s*$`)
+)
+
+// ParseAddress reads a one-line address such as "12 Smith St, Glenelg SA 5045". The
+// postcode and state are taken from the end of the line and the suburb from the part before
+// them, after the last comma. Whatever can't be recognised is left as the street.
+func ParseAddress(text string) Address {
+	var address Address
+	rest := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(text), ",."))
+
+	if match := statePostcode.FindStringSubmatchIndex(rest); match != nil {
+		address.Postcode = rest[match[4]:match[5]]
+		if match[2] >= 0 {
+			address.State = strings.ToUpper(rest[match[2]:match[3]])
+		}
+		rest = rest[:match[0]]
+	} else if match := stateOnly.FindStringSubmatchIndex(rest); match != nil {
+		address.State = strings.ToUpper(rest[match[2]:match[3]])
+		rest = rest[:match[0]]
+	}
+	rest = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), ","))
+
+	if address.Postcode != "" || address.State != "" {
+		if comma := strings.LastIndex(rest, ","); comma >= 0 {
+			address.Suburb = strings.TrimSpace(rest[comma+1:])
+			rest = strings.TrimSpace(rest[:comma])
+		} else if !strings.ContainsAny(rest, "0123456789") {
+			address.Suburb, rest = rest, ""
+		}
+	}
+	address.Street = rest
+	return address
+}
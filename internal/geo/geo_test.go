@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	adelaide := Point{-34.9285, 138.6007}
+	melbourne := Point{-37.8136, 144.9631}
+	glenelg := Point{-34.9803, 138.5156}
+
+	assert.InDelta(t, 654, Distance(adelaide, melbourne), 5)
+	assert.InDelta(t, 9.6, Distance(adelaide, glenelg), 0.5)
+	assert.Equal(t, Distance(adelaide, melbourne), Distance(melbourne, adelaide))
+	assert.Zero(t, Distance(adelaide, adelaide))
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		text string
+		want Address
+	}{
+		{"12 Smith St, Glenelg SA 5045", Address{Street: "12 Smith St", Suburb: "Glenelg", State: "SA", Postcode: "5045"}},
+		{"Unit 4, 7 King William Rd, North Adelaide, SA 5006", Address{Street: "Unit 4, 7 King William Rd", Suburb: "North Adelaide", State: "SA", Postcode: "5006"}},
+		{"Glenelg 5045", Address{Suburb: "Glenelg", Postcode: "5045"}},
+		{"Norwood sa", Address{Suburb: "Norwood", State: "SA"}},
+		{"Participant home", Address{Street: "Participant home"}},
+		{"", Address{}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseAddress(tt.text), tt.text)
+	}
+}
+
+func TestPostcodeGeocoder(t *testing.T) {
+	g := DefaultGeocoder()
+
+	point, err := g.Geocode(Address{Street: "1 Jetty Rd", Suburb: "Glenelg", State: "SA", Postcode: "5045"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{-34.9803, 138.5156}, point)
+
+	// Without a postcode the suburb is used
+	point, err = g.Geocode(Address{Suburb: "north adelaide", State: "sa"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{-34.9065, 138.5930}, point)
+
+	_, err = g.Geocode(Address{Suburb: "Nowhere", State: "SA", Postcode: "5999"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLoadPostcodes(t *testing.T) {
+	centroids, err := LoadPostcodes(strings.NewReader("postcode,suburb,state,latitude,longitude\n" +
+		"5045,Glenelg,sa,-34.98,138.51\n" +
+		"5045,Glenelg East,SA,-34.96,138.53\n"))
+	require.NoError(t, err)
+	require.Len(t, centroids, 2)
+	assert.Equal(t, "SA", centroids[0].State)
+
+	g := NewPostcodeGeocoder(centroids)
+	point, err := g.Geocode(Address{Postcode: "5045"})
+	require.NoError(t, err)
+	assert.InDelta(t, -34.97, point.Lat, 1e-9)
+	assert.InDelta(t, 138.52, point.Lng, 1e-9)
+
+	point, err = g.Geocode(Address{Suburb: "Glenelg East", State: "SA"})
+	require.NoError(t, err)
+	assert.Equal(t, Point{-34.96, 138.53}, point)
+
+	_, err = LoadPostcodes(strings.NewReader("pc,name,lat,lng,state\n"))
+	assert.Error(t, err)
+	_, err = LoadPostcodes(strings.NewReader("postcode,suburb,state,latitude,longitude\n5045,Glenelg,SA,south,138.51\n"))
+	assert.EqualError(t, err, `line 2: invalid latitude "south"`)
+}
@@ -0,0 +1,169 @@
+package geo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Centroid is the middle of a postcode, or of one suburb within it
+type Centroid struct {
+	Postcode string
+	Suburb   string
+	State    string
+	Point    Point
+}
+
+// PostcodeGeocoder places addresses at the centroid of their postcode, or of their suburb when
+// there is no postcode. It never looks at the street.
+type PostcodeGeocoder struct {
+	byPostcode map[string]Point
+	bySuburb   map[string]Point // "suburb|state", lower case
+}
+
+// NewPostcodeGeocoder builds a geocoder from centroids. Postcodes listed more than once, one
+// row per suburb, are placed at the average of their rows.
+func NewPostcodeGeocoder(centroids []Centroid) *PostcodeGeocoder {
+	g := &PostcodeGeocoder{byPostcode: map[string]Point{}, bySuburb: map[string]Point{}}
+	sums := map[string]Point{}
+	counts := map[string]int{}
+	for _, centroid := range centroids {
+		sum := sums[centroid.Postcode]
+		sums[centroid.Postcode] = Point{Lat: sum.Lat + centroid.Point.Lat, Lng: sum.Lng + centroid.Point.Lng}
+		counts[centroid.Postcode]++
+		if centroid.Suburb != "" {
+			g.bySuburb[suburbKey(centroid.Suburb, centroid.State)] = centroid.Point
+		}
+	}
+	for postcode, sum := range sums {
+		n := float64(counts[postcode])
+		g.byPostcode[postcode] = Point{Lat: sum.Lat / n, Lng: sum.Lng / n}
+	}
+	return g
+}
+
+// DefaultGeocoder returns a geocoder for the built-in postcodes, which cover the capital
+// cities and larger regional centres. Load a complete table with LoadPostcodes for anywhere
+// else.
+func DefaultGeocoder() *PostcodeGeocoder {
+	return NewPostcodeGeocoder(builtinCentroids)
+}
+
+// LoadPostcodes reads a CSV of postcode centroids with the header
+// postcode,suburb,state,latitude,longitude
+func LoadPostcodes(r io.Reader) ([]Centroid, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(strings.Join(header, ",")) != "postcode,suburb,state,latitude,longitude" {
+		return nil, fmt.Errorf("unexpected postcode header: %s", strings.Join(header, ","))
+	}
+
+	centroids := []Centroid{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return centroids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		lat, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude %q", line, record[3])
+		}
+		lng, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude %q", line, record[4])
+		}
+		centroids = append(centroids, Centroid{
+			Postcode: strings.TrimSpace(record[0]),
+			Suburb:   strings.TrimSpace(record[1]),
+			State:    strings.ToUpper(strings.TrimSpace(record[2])),
+			Point:    Point{Lat: lat, Lng: lng},
+		})
+	}
+}
+
+func (g *PostcodeGeocoder) Geocode(address Address) (Point, error) {
+	if point, ok := g.byPostcode[strings.TrimSpace(address.Postcode)]; ok {
+		return point, nil
+	}
+	if address.Suburb != "" {
+		if point, ok := g.bySuburb[suburbKey(address.Suburb, address.State)]; ok {
+			return point, nil
+		}
+	}
+	return Point{}, ErrNotFound
+}
+
+func suburbKey(suburb, state string) string {
+	return strings.ToLower(strings.TrimSpace(suburb)) + "|" + strings.ToLower(strings.TrimSpace(state))
+}
+
+// builtinCentroids places the postcodes of the capital cities and larger regional centres
+var builtinCentroids = []Centroid{
+	// South Australia
+	{"5000", "Adelaide", "SA", Point{-34.9285, 138.6007}},
+	{"5006", "North Adelaide", "SA", Point{-34.9065, 138.5930}},
+	{"5031", "Mile End", "SA", Point{-34.9256, 138.5730}},
+	{"5034", "Goodwood", "SA", Point{-34.9510, 138.5870}},
+	{"5045", "Glenelg", "SA", Point{-34.9803, 138.5156}},
+	{"5061", "Unley", "SA", Point{-34.9500, 138.6070}},
+	{"5067", "Norwood", "SA", Point{-34.9210, 138.6300}},
+	{"5082", "Prospect", "SA", Point{-34.8830, 138.5950}},
+	{"5092", "Modbury", "SA", Point{-34.8330, 138.6840}},
+	{"5108", "Salisbury", "SA", Point{-34.7580, 138.6410}},
+	{"5112", "Elizabeth", "SA", Point{-34.7110, 138.6700}},
+	{"5118", "Gawler", "SA", Point{-34.5980, 138.7450}},
+	{"5158", "Hallett Cove", "SA", Point{-35.0780, 138.5100}},
+	{"5162", "Morphett Vale", "SA", Point{-35.1270, 138.5210}},
+	{"5211", "Victor Harbor", "SA", Point{-35.5520, 138.6180}},
+	{"5251", "Mount Barker", "SA", Point{-35.0660, 138.8560}},
+	{"5253", "Murray Bridge", "SA", Point{-35.1200, 139.2730}},
+	{"5290", "Mount Gambier", "SA", Point{-37.8290, 140.7830}},
+	{"5540", "Port Pirie", "SA", Point{-33.1850, 138.0160}},
+	{"5600", "Whyalla", "SA", Point{-33.0330, 137.5650}},
+	{"5700", "Port Augusta", "SA", Point{-32.4920, 137.7650}},
+
+	// New South Wales and the ACT
+	{"2000", "Sydney", "NSW", Point{-33.8688, 151.2093}},
+	{"2010", "Surry Hills", "NSW", Point{-33.8840, 151.2120}},
+	{"2150", "Parramatta", "NSW", Point{-33.8150, 151.0010}},
+	{"2170", "Liverpool", "NSW", Point{-33.9200, 150.9230}},
+	{"2300", "Newcastle", "NSW", Point{-32.9280, 151.7810}},
+	{"2500", "Wollongong", "NSW", Point{-34.4250, 150.8930}},
+	{"2650", "Wagga Wagga", "NSW", Point{-35.1080, 147.3690}},
+	{"2601", "Canberra", "ACT", Point{-35.2810, 149.1300}},
+
+	// Victoria
+	{"3000", "Melbourne", "VIC", Point{-37.8136, 144.9631}},
+	{"3121", "Richmond", "VIC", Point{-37.8230, 145.0000}},
+	{"3220", "Geelong", "VIC", Point{-38.1470, 144.3610}},
+	{"3350", "Ballarat", "VIC", Point{-37.5620, 143.8500}},
+	{"3550", "Bendigo", "VIC", Point{-36.7570, 144.2790}},
+
+	// Queensland
+	{"4000", "Brisbane", "QLD", Point{-27.4698, 153.0251}},
+	{"4217", "Surfers Paradise", "QLD", Point{-28.0020, 153.4300}},
+	{"4350", "Toowoomba", "QLD", Point{-27.5600, 151.9500}},
+	{"4810", "Townsville", "QLD", Point{-19.2590, 146.8170}},
+	{"4870", "Cairns", "QLD", Point{-16.9200, 145.7710}},
+
+	// Western Australia
+	{"6000", "Perth", "WA", Point{-31.9523, 115.8613}},
+	{"6160", "Fremantle", "WA", Point{-32.0560, 115.7470}},
+
+	// Tasmania and the Northern Territory
+	{"7000", "Hobart", "TAS", Point{-42.8821, 147.3272}},
+	{"7250", "Launceston", "TAS", Point{-41.4390, 147.1350}},
+	{"0800", "Darwin", "NT", Point{-12.4634, 130.8456}},
+	{"0870", "Alice Springs", "NT", Point{-23.6980, 133.8800}},
+}
@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/accounting"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Accounting export statuses
+const (
+	AccountingExportCompleted = "completed"
+	AccountingExportPartial   = "partial"
+	AccountingExportFailed    = "failed"
+)
+
+type CreateAccountingExportRequest struct {
+	Format     string   `json:"format" binding:"required,oneof=xero myob http"`
+	StartDate  string   `json:"start_date"` // YYYY-MM-DD, limits invoices by issue date
+	EndDate    string   `json:"end_date"`   // YYYY-MM-DD, inclusive
+	InvoiceIDs []string `json:"invoice_ids"`
+}
+
+// accountingExporter returns the exporter for a format, or nil when the HTTP connector is not configured
+func (h *Handler) accountingExporter(format string) (accounting.AccountingExporter, error) {
+	if format == accounting.FormatHTTP {
+		if h.Config == nil || h.Config.AccountingURL == "" {
+			return nil, nil
+		}
+		return accounting.NewHTTPConnector(h.Config.AccountingURL, h.Config.AccountingToken), nil
+	}
+	return accounting.New(format)
+}
+
+// exportedRecords selects the IDs of records of a type already exported in a format
+func (h *Handler) exportedRecords(orgID, format, recordType string) *gorm.DB {
+	return h.DB.Model(&models.AccountingExportRecord{}).Select("record_id").
+		Where("organization_id = ? AND format = ? AND record_type = ?", orgID, format, recordType)
+}
+
+// accountingInvoice maps an invoice with its participant and lines to the export shape
+func accountingInvoice(invoice models.Invoice, loc *time.Location) accounting.Invoice {
+	lines := make([]accounting.Line, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		itemCode := ""
+		if line.SupportItem != nil {
+			itemCode = line.SupportItem.ItemNumber
+		}
+		lines = append(lines, accounting.Line{
+			ItemCode:    itemCode,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitAmount:  line.UnitPrice,
+			Amount:      line.Amount,
+			TaxAmount:   line.GSTAmount,
+			Taxable:     line.GSTCode == "P1",
+		})
+	}
+
+	return accounting.Invoice{
+		ID:           invoice.ID,
+		Number:       invoice.InvoiceNumber,
+		Reference:    invoice.Participant.NDISNumber,
+		ContactName:  invoice.Participant.FirstName + " " + invoice.Participant.LastName,
+		ContactEmail: invoice.Participant.Email,
+		IssueDate:    invoice.IssueDate.In(loc),
+		DueDate:      invoice.DueDate.In(loc),
+		Lines:        lines,
+		Total:        invoice.Total,
+	}
+}
+
+// CreateAccountingExport sends invoices and payments that have not yet been exported in the
+// requested format. Payments are only sent once their invoice has been.
+func (h *Handler) CreateAccountingExport(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req CreateAccountingExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	exporter, err := h.accountingExporter(req.Format)
+	if err != nil || exporter == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CONNECTOR_NOT_CONFIGURED",
+				"message": "No accounting connector is configured",
+			},
+		})
+		return
+	}
+
+	orgTz, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		orgTz = time.UTC
+	}
+
+	invoiceQuery := h.DB.Where("organization_id = ? AND status <> ?", orgID, "void").
+		Where("id NOT IN (?)", h.exportedRecords(orgID.(string), req.Format, "invoice"))
+	if len(req.InvoiceIDs) > 0 {
+		invoiceQuery = invoiceQuery.Where("id IN ?", req.InvoiceIDs)
+	}
+	if req.StartDate != "" || req.EndDate != "" {
+		periodStart, startErr := time.ParseInLocation("2006-01-02", req.StartDate, orgTz)
+		periodEnd, endErr := time.ParseInLocation("2006-01-02", req.EndDate, orgTz)
+		if startErr != nil || endErr != nil || periodEnd.Before(periodStart) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE_RANGE",
+					"message": "Provide a valid start_date and end_date (YYYY-MM-DD)",
+				},
+			})
+			return
+		}
+		invoiceQuery = invoiceQuery.Where("issue_date >= ? AND issue_date < ?", periodStart, periodEnd.AddDate(0, 0, 1))
+	}
+
+	var invoices []models.Invoice
+	if err := invoiceQuery.Preload("Participant").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("service_date ASC") }).
+		Preload("Lines.SupportItem").
+		Order("sequence_number ASC").Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoices",
+			},
+		})
+		return
+	}
+
+	// Payments follow their invoice, whether it was exported earlier or is in this export
+	invoiceIDs := make([]string, 0, len(invoices))
+	for _, invoice := range invoices {
+		invoiceIDs = append(invoiceIDs, invoice.ID)
+	}
+	paymentQuery := h.DB.Where("organization_id = ?", orgID).
+		Where("id NOT IN (?)", h.exportedRecords(orgID.(string), req.Format, "payment")).
+		Where("(invoice_id IN (?) OR invoice_id IN ?)", h.exportedRecords(orgID.(string), req.Format, "invoice"), invoiceIDs)
+	if len(req.InvoiceIDs) > 0 {
+		paymentQuery = paymentQuery.Where("invoice_id IN ?", req.InvoiceIDs)
+	}
+
+	var payments []models.Payment
+	if err := paymentQuery.Order("payment_date ASC, created_at ASC").Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch payments",
+			},
+		})
+		return
+	}
+
+	// Payments need their invoice's number and contact, which may come from an earlier export
+	paidInvoices := map[string]models.Invoice{}
+	for _, invoice := range invoices {
+		paidInvoices[invoice.ID] = invoice
+	}
+	missing := []string{}
+	for _, payment := range payments {
+		if _, ok := paidInvoices[payment.InvoiceID]; !ok {
+			missing = append(missing, payment.InvoiceID)
+		}
+	}
+	if len(missing) > 0 {
+		var earlier []models.Invoice
+		h.DB.Where("id IN ?", missing).Preload("Participant").Find(&earlier)
+		for _, invoice := range earlier {
+			paidInvoices[invoice.ID] = invoice
+		}
+	}
+
+	exportInvoices := make([]accounting.Invoice, 0, len(invoices))
+	for _, invoice := range invoices {
+		exportInvoices = append(exportInvoices, accountingInvoice(invoice, orgTz))
+	}
+	exportPayments := make([]accounting.Payment, 0, len(payments))
+	for _, payment := range payments {
+		invoice := paidInvoices[payment.InvoiceID]
+		exportPayments = append(exportPayments, accounting.Payment{
+			ID:            payment.ID,
+			InvoiceNumber: invoice.InvoiceNumber,
+			ContactName:   invoice.Participant.FirstName + " " + invoice.Participant.LastName,
+			Date:          payment.PaymentDate.In(orgTz),
+			Amount:        payment.Amount,
+			Method:        payment.Method,
+			Reference:     payment.Reference,
+		})
+	}
+
+	result, exportErr := exporter.Export(c.Request.Context(), exportInvoices, exportPayments)
+	if result == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EXPORT_FAILED",
+				"message": "Failed to generate accounting export",
+				"details": exportErr.Error(),
+			},
+		})
+		return
+	}
+	if exportErr == nil && len(result.Invoices) == 0 && len(result.Payments) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOTHING_TO_EXPORT",
+				"message": "All invoices and payments have already been exported in this format",
+			},
+		})
+		return
+	}
+
+	export := models.AccountingExport{
+		OrganizationID: orgID.(string),
+		Format:         req.Format,
+		Status:         AccountingExportCompleted,
+		InvoiceCount:   len(result.Invoices),
+		PaymentCount:   len(result.Payments),
+		Filename:       result.Filename,
+		ContentType:    result.ContentType,
+		Data:           result.Data,
+		CreatedBy:      userID,
+	}
+	if exportErr != nil {
+		export.Error = exportErr.Error()
+		export.Status = AccountingExportFailed
+		if export.InvoiceCount+export.PaymentCount > 0 {
+			export.Status = AccountingExportPartial
+		}
+	}
+
+	// Record what was sent, in the order it was sent, so it is never sent twice
+	records := []models.AccountingExportRecord{}
+	for _, invoice := range exportInvoices {
+		if externalID, ok := result.Invoices[invoice.ID]; ok {
+			records = append(records, models.AccountingExportRecord{RecordType: "invoice", RecordID: invoice.ID, ExternalID: externalID})
+		}
+	}
+	for _, payment := range exportPayments {
+		if externalID, ok := result.Payments[payment.ID]; ok {
+			records = append(records, models.AccountingExportRecord{RecordType: "payment", RecordID: payment.ID, ExternalID: externalID})
+		}
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&export).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			records[i].OrganizationID = export.OrganizationID
+			records[i].ExportID = export.ID
+			records[i].Format = export.Format
+			if err := tx.Create(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record accounting export",
+			},
+		})
+		return
+	}
+	export.Records = records
+
+	if exportErr != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CONNECTOR_ERROR",
+				"message": "The accounting connector rejected the export; records sent before the failure are marked exported",
+				"details": exportErr.Error(),
+			},
+			"data": export,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    export,
+		"message": "Accounting export created successfully",
+	})
+}
+
+func (h *Handler) GetAccountingExports(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	format := c.Query("format")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.AccountingExport{}).Where("organization_id = ?", orgID)
+	if format != "" {
+		query = query.Where("format = ?", format)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var exports []models.AccountingExport
+	if err := query.Omit("data").Limit(limit).Offset(offset).Order("created_at DESC").Find(&exports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch accounting exports",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"exports": exports,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) GetAccountingExport(c *gin.Context) {
+	exportID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var export models.AccountingExport
+	if err := h.DB.Omit("data").Where("id = ? AND organization_id = ?", exportID, orgID).
+		Preload("Records", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EXPORT_NOT_FOUND",
+				"message": "Accounting export not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// DownloadAccountingExport returns the import file generated by a Xero or MYOB export
+func (h *Handler) DownloadAccountingExport(c *gin.Context) {
+	exportID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var export models.AccountingExport
+	if err := h.DB.Where("id = ? AND organization_id = ?", exportID, orgID).First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EXPORT_NOT_FOUND",
+				"message": "Accounting export not found",
+			},
+		})
+		return
+	}
+	if len(export.Data) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_EXPORT_FILE",
+				"message": "This export was sent to a connector and has no file",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+export.Filename)
+	c.Data(http.StatusOK, export.ContentType, export.Data)
+}
@@ -502,7 +502,7 @@ func (h *Handler) seedShifts(tx *gorm.DB, orgIds []string, prefix string, count
 		shift := models.Shift{
 			ID:            uuid.New().String(),
 			ParticipantID: participant.ID,
-			StaffID:       staffMember.ID,
+			StaffID:       &staffMember.ID,
 			StartTime:     startTime,
 			EndTime:       endTime,
 			ServiceType:   serviceTypes[rand.Intn(len(serviceTypes))],
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// recordAudit adds an entry to the organization's audit log for the current user. The old
+// and new values are stored as JSON.
+func recordAudit(tx *gorm.DB, c *gin.Context, orgID, action, entityType, entityID string, oldValues, newValues interface{}) error {
+	oldJSON, err := json.Marshal(oldValues)
+	if err != nil {
+		return err
+	}
+	newJSON, err := json.Marshal(newValues)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AuditLog{
+		OrganizationID: orgID,
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		OldValues:      string(oldJSON),
+		NewValues:      string(newJSON),
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		entry.UserID = &userID
+	}
+	return tx.Create(&entry).Error
+}
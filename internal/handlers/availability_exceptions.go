@@ -0,0 +1,711 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// exceptionTypes are the kinds of availability exception a worker can record
+var exceptionTypes = map[string]bool{
+	"unavailable":  true,
+	"limited":      true,
+	"extended":     true,
+	"special_rate": true,
+}
+
+// errExceptionReviewed is returned when an exception is decided twice at once
+var errExceptionReviewed = errors.New("exception has already been reviewed")
+
+// availabilityException is a stored exception with its start and end read as times of day,
+// which not every driver will scan into a time.Time
+type availabilityException struct {
+	models.WorkerAvailabilityException
+	StartTime timeOfDay
+	EndTime   timeOfDay
+}
+
+// ToDTO converts the exception for API responses
+func (e *availabilityException) ToDTO() models.WorkerAvailabilityExceptionDTO {
+	exception := e.WorkerAvailabilityException
+	exception.StartTime, exception.EndTime = e.StartTime.clock(), e.EndTime.clock()
+	return exception.ToDTO()
+}
+
+// period returns when the exception applies in the given location
+func (e *availabilityException) period(loc *time.Location) (time.Time, time.Time) {
+	period := timeOff{ExceptionDate: e.ExceptionDate, StartTime: e.StartTime, EndTime: e.EndTime}.period(loc)
+	return period.Start, period.End
+}
+
+// clock returns the time of day on 1 January of year 0, the way time columns are written
+func (t timeOfDay) clock() *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	clock := time.Date(0, 1, 1, t.Minutes/60, t.Minutes%60, 0, 0, time.UTC)
+	return &clock
+}
+
+// parseExceptionTimes reads the optional start and end of a partial day exception. Both
+// must be given, or neither for the whole day.
+func parseExceptionTimes(start, end string) (*time.Time, *time.Time, error) {
+	if start == "" && end == "" {
+		return nil, nil, nil
+	}
+	if start == "" || end == "" {
+		return nil, nil, errors.New("start_time and end_time must be given together")
+	}
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid start time %q: use HH:MM", start)
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid end time %q: use HH:MM", end)
+	}
+	if !endTime.After(startTime) {
+		return nil, nil, errors.New("end time must be after start time")
+	}
+	return &startTime, &endTime, nil
+}
+
+// organizationUsers limits a query to users in the organization
+func (wah *WorkerAvailabilityHandler) organizationUsers(orgID interface{}) *gorm.DB {
+	return wah.handler.DB.Model(&models.User{}).Select("id").Where("organization_id = ?", orgID)
+}
+
+// fetchAvailabilityException loads an exception belonging to someone in the current user's
+// organization that they are allowed to see, writing the error response if there isn't one
+func (wah *WorkerAvailabilityHandler) fetchAvailabilityException(c *gin.Context) (*availabilityException, bool) {
+	orgID, exists := c.Get("org_id")
+	if wah.handler.GetUserIDFromContext(c) == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return nil, false
+	}
+
+	var exception availabilityException
+	err := wah.handler.DB.Where("id = ? AND user_id IN (?)", c.Param("id"), wah.organizationUsers(orgID)).
+		First(&exception).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			wah.handler.SendErrorResponse(c, http.StatusNotFound, "Availability exception not found", nil)
+			return nil, false
+		}
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return nil, false
+	}
+
+	if !wah.handler.CanUserAccessResource(c, "manage_availability", exception.UserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return nil, false
+	}
+	return &exception, true
+}
+
+// reloadAvailabilityException reads an exception back after it has been written
+func (wah *WorkerAvailabilityHandler) reloadAvailabilityException(id string) (*availabilityException, error) {
+	var exception availabilityException
+	return &exception, wah.handler.DB.Where("id = ?", id).First(&exception).Error
+}
+
+// GetAvailabilityExceptions lists leave and other availability exceptions. Managers see the
+// whole organization unless they ask for one worker with ?user_id=; everyone else sees only
+// their own. Filter with ?status=pending|approved|rejected, ?type= and ?start_date= and
+// ?end_date= (inclusive).
+func (wah *WorkerAvailabilityHandler) GetAvailabilityExceptions(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	targetUserID := c.Query("user_id")
+	if targetUserID != "" && !wah.handler.CanUserAccessResource(c, "view_availability", targetUserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	if targetUserID == "" && !isManagerRole(wah.handler.GetUserRoleFromContext(c)) {
+		targetUserID = userID
+	}
+
+	query := wah.handler.DB.Where("user_id IN (?)", wah.organizationUsers(orgID))
+	if targetUserID != "" {
+		query = query.Where("user_id = ?", targetUserID)
+	}
+
+	switch c.Query("status") {
+	case "":
+	case models.ExceptionPending:
+		query = query.Where("is_approved = ? AND rejected_at IS NULL", false)
+	case models.ExceptionApproved:
+		query = query.Where("is_approved = ?", true)
+	case models.ExceptionRejected:
+		query = query.Where("is_approved = ? AND rejected_at IS NOT NULL", false)
+	default:
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid status filter", nil)
+		return
+	}
+
+	if exceptionType := c.Query("type"); exceptionType != "" {
+		query = query.Where("exception_type = ?", exceptionType)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		from, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid start date format", err)
+			return
+		}
+		query = query.Where("exception_date >= ?", from)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		to, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid end date format", err)
+			return
+		}
+		query = query.Where("exception_date < ?", to.AddDate(0, 0, 1))
+	}
+
+	var exceptions []availabilityException
+	if err := query.Order("exception_date ASC, start_time ASC").Find(&exceptions).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch availability exceptions", err)
+		return
+	}
+
+	dtos := make([]models.WorkerAvailabilityExceptionDTO, 0, len(exceptions))
+	for _, exception := range exceptions {
+		dtos = append(dtos, exception.ToDTO())
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exceptions": dtos,
+		"total":      len(dtos),
+	})
+}
+
+// CreateAvailabilityException records leave or other change to a worker's usual
+// availability on one day. Give start_time and end_time for part of the day. Exceptions
+// wait for a manager to approve them before the scheduler takes them into account.
+func (wah *WorkerAvailabilityHandler) CreateAvailabilityException(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		UserID        string   `json:"user_id"`
+		ExceptionDate string   `json:"exception_date" binding:"required"`
+		ExceptionType string   `json:"exception_type" binding:"required"`
+		StartTime     string   `json:"start_time"`
+		EndTime       string   `json:"end_time"`
+		MaxHours      *float64 `json:"max_hours"`
+		HourlyRate    *float64 `json:"hourly_rate"`
+		Reason        string   `json:"reason"`
+		Notes         string   `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	// Use current user if no user_id specified or check permissions
+	targetUserID := userID
+	if req.UserID != "" && req.UserID != userID {
+		if !wah.handler.CanUserAccessResource(c, "manage_availability", req.UserID) {
+			wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+			return
+		}
+		targetUserID = req.UserID
+	}
+
+	var worker models.User
+	if err := wah.handler.DB.Where("id = ? AND organization_id = ?", targetUserID, orgID).First(&worker).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusNotFound, "Worker not found", err)
+		return
+	}
+
+	if !exceptionTypes[req.ExceptionType] {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception type", nil)
+		return
+	}
+	exceptionDate, err := time.Parse("2006-01-02", req.ExceptionDate)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception date format", err)
+		return
+	}
+	startTime, endTime, err := parseExceptionTimes(req.StartTime, req.EndTime)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception times", err)
+		return
+	}
+
+	exception := models.WorkerAvailabilityException{
+		UserID:        targetUserID,
+		ExceptionDate: exceptionDate,
+		ExceptionType: req.ExceptionType,
+		StartTime:     startTime,
+		EndTime:       endTime,
+		MaxHours:      req.MaxHours,
+		HourlyRate:    req.HourlyRate,
+		Reason:        req.Reason,
+		Notes:         req.Notes,
+	}
+
+	if err := wah.handler.DB.Create(&exception).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create availability exception", err)
+		return
+	}
+
+	title := "Availability exception requested"
+	message := fmt.Sprintf("%s %s has asked for %s on %s.", worker.FirstName, worker.LastName,
+		exceptionLabel(req.ExceptionType), exceptionDate.Format("Mon 2 Jan"))
+	for _, manager := range wah.handler.organizationManagers(orgID.(string)) {
+		if manager.ID != userID {
+			wah.handler.notifyUser(manager, models.NotificationAvailabilityException, title, message,
+				models.JSONB{"exception_id": exception.ID, "user_id": targetUserID})
+		}
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception": exception.ToDTO(),
+		"message":   "Availability exception created successfully",
+	})
+}
+
+// exceptionLabel describes an exception type in a notification
+func exceptionLabel(exceptionType string) string {
+	switch exceptionType {
+	case "unavailable":
+		return "time off"
+	case "limited":
+		return "limited availability"
+	case "extended":
+		return "extended availability"
+	default:
+		return "a special rate"
+	}
+}
+
+// UpdateAvailabilityException changes an exception. Changing an exception that has already
+// been approved or rejected sends it back to a manager for review.
+func (wah *WorkerAvailabilityHandler) UpdateAvailabilityException(c *gin.Context) {
+	exception, ok := wah.fetchAvailabilityException(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ExceptionDate *string  `json:"exception_date"`
+		ExceptionType *string  `json:"exception_type"`
+		StartTime     *string  `json:"start_time"`
+		EndTime       *string  `json:"end_time"`
+		MaxHours      *float64 `json:"max_hours"`
+		HourlyRate    *float64 `json:"hourly_rate"`
+		Reason        *string  `json:"reason"`
+		Notes         *string  `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	updates := map[string]interface{}{
+		"is_approved": false,
+		"approved_by": nil,
+		"approved_at": nil,
+		"rejected_by": nil,
+		"rejected_at": nil,
+	}
+
+	if req.ExceptionDate != nil {
+		exceptionDate, err := time.Parse("2006-01-02", *req.ExceptionDate)
+		if err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception date format", err)
+			return
+		}
+		updates["exception_date"] = exceptionDate
+	}
+	if req.ExceptionType != nil {
+		if !exceptionTypes[*req.ExceptionType] {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception type", nil)
+			return
+		}
+		updates["exception_type"] = *req.ExceptionType
+	}
+
+	// Times are replaced together; empty strings make it a whole day exception again
+	if req.StartTime != nil || req.EndTime != nil {
+		var start, end string
+		if req.StartTime != nil {
+			start = *req.StartTime
+		}
+		if req.EndTime != nil {
+			end = *req.EndTime
+		}
+		startTime, endTime, err := parseExceptionTimes(start, end)
+		if err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid exception times", err)
+			return
+		}
+		updates["start_time"] = startTime
+		updates["end_time"] = endTime
+	}
+
+	if req.MaxHours != nil {
+		updates["max_hours"] = *req.MaxHours
+	}
+	if req.HourlyRate != nil {
+		updates["hourly_rate"] = *req.HourlyRate
+	}
+	if req.Reason != nil {
+		updates["reason"] = *req.Reason
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	if err := wah.handler.DB.Model(&models.WorkerAvailabilityException{}).Where("id = ?", exception.ID).
+		Updates(updates).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update availability exception", err)
+		return
+	}
+
+	exception, err := wah.reloadAvailabilityException(exception.ID)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception": exception.ToDTO(),
+		"message":   "Availability exception updated successfully",
+	})
+}
+
+// DeleteAvailabilityException withdraws an exception
+func (wah *WorkerAvailabilityHandler) DeleteAvailabilityException(c *gin.Context) {
+	exception, ok := wah.fetchAvailabilityException(c)
+	if !ok {
+		return
+	}
+
+	if err := wah.handler.DB.Delete(&models.WorkerAvailabilityException{}, "id = ?", exception.ID).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete availability exception", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"message": "Availability exception deleted successfully",
+	})
+}
+
+// reviewAvailabilityException loads an exception for a manager to review, writing the error
+// response if the current user isn't a manager or the exception isn't found
+func (wah *WorkerAvailabilityHandler) reviewAvailabilityException(c *gin.Context) (*availabilityException, bool) {
+	if !isManagerRole(wah.handler.GetUserRoleFromContext(c)) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Only managers can review availability exceptions", nil)
+		return nil, false
+	}
+	return wah.fetchAvailabilityException(c)
+}
+
+// conflictingShifts returns the scheduled shifts the worker is booked on while an
+// unavailable exception applies. Other kinds of exception don't take the worker away.
+func (wah *WorkerAvailabilityHandler) conflictingShifts(c *gin.Context, exception *availabilityException) ([]models.Shift, error) {
+	shifts := []models.Shift{}
+	if exception.ExceptionType != "unavailable" {
+		return shifts, nil
+	}
+
+	loc, err := wah.handler.getOrganizationTimezone(c.GetString("org_id"))
+	if err != nil {
+		loc = time.UTC
+	}
+	from, to := exception.period(loc)
+	err = wah.handler.DB.Where("staff_id = ? AND status = ? AND start_time < ? AND end_time > ?",
+		exception.UserID, "scheduled", to, from).
+		Preload("Participant").Order("start_time").Find(&shifts).Error
+	return shifts, err
+}
+
+// shiftSummaries describes shifts in an exception response
+func shiftSummaries(shifts []models.Shift) []gin.H {
+	summaries := make([]gin.H, 0, len(shifts))
+	for _, shift := range shifts {
+		summaries = append(summaries, gin.H{
+			"id":               shift.ID,
+			"participant_id":   shift.ParticipantID,
+			"participant_name": shift.Participant.FirstName + " " + shift.Participant.LastName,
+			"start_time":       shift.StartTime,
+			"end_time":         shift.EndTime,
+			"service_type":     shift.ServiceType,
+			"location":         shift.Location,
+		})
+	}
+	return summaries
+}
+
+// releaseShifts takes the worker off each shift, leaving it unfilled so it can be broadcast
+// as an open shift. Shifts that have been reassigned or cancelled in the meantime are
+// skipped. It returns the IDs of the shifts released.
+func releaseShifts(tx *gorm.DB, c *gin.Context, orgID, staffID string, shifts []models.Shift) ([]string, error) {
+	released := []string{}
+	for _, shift := range shifts {
+		result := tx.Model(&models.Shift{}).Where("id = ? AND staff_id = ? AND status = ?", shift.ID, staffID, "scheduled").
+			Updates(staffChange(nil))
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		if err := tx.Where("shift_id = ?", shift.ID).Delete(&models.PublishedShift{}).Error; err != nil {
+			return nil, err
+		}
+		if err := recordAudit(tx, c, orgID, "release_shift", "shift", shift.ID,
+			gin.H{"staff_id": staffID}, gin.H{"staff_id": nil}); err != nil {
+			return nil, err
+		}
+		released = append(released, shift.ID)
+	}
+	return released, nil
+}
+
+type ReviewAvailabilityExceptionRequest struct {
+	Notes         string `json:"notes,omitempty"`
+	ReleaseShifts bool   `json:"release_shifts,omitempty"`
+}
+
+// ApproveAvailabilityException approves an exception so the scheduler works around it, and
+// lists the shifts the worker is already booked on that now conflict with it. With
+// release_shifts the worker is taken off those shifts so they can be offered to others.
+func (wah *WorkerAvailabilityHandler) ApproveAvailabilityException(c *gin.Context) {
+	exception, ok := wah.reviewAvailabilityException(c)
+	if !ok {
+		return
+	}
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID := c.GetString("org_id")
+
+	var req ReviewAvailabilityExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	if exception.Status() != models.ExceptionPending {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "Availability exception has already been reviewed", nil)
+		return
+	}
+
+	conflicts, err := wah.conflictingShifts(c, exception)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load conflicting shifts", err)
+		return
+	}
+
+	released := []string{}
+	err = wah.handler.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.WorkerAvailabilityException{}).
+			Where("id = ? AND is_approved = ? AND rejected_at IS NULL", exception.ID, false).
+			Updates(map[string]interface{}{
+				"is_approved":  true,
+				"approved_by":  userID,
+				"approved_at":  now,
+				"review_notes": req.Notes,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errExceptionReviewed
+		}
+		if err := recordAudit(tx, c, orgID, "approve_availability_exception", "availability_exception", exception.ID,
+			gin.H{"status": models.ExceptionPending}, gin.H{"status": models.ExceptionApproved, "notes": req.Notes}); err != nil {
+			return err
+		}
+
+		if req.ReleaseShifts {
+			var err error
+			released, err = releaseShifts(tx, c, orgID, exception.UserID, conflicts)
+			return err
+		}
+		return nil
+	})
+	if err == errExceptionReviewed {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "Availability exception has already been reviewed", nil)
+		return
+	}
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to approve availability exception", err)
+		return
+	}
+
+	exception, err = wah.reloadAvailabilityException(exception.ID)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	message := fmt.Sprintf("Your request for %s on %s has been approved.",
+		exceptionLabel(exception.ExceptionType), exception.ExceptionDate.Format("Mon 2 Jan"))
+	if len(released) > 0 {
+		message += fmt.Sprintf(" You have been taken off %d shift(s) during this time.", len(released))
+	}
+	wah.notifyWorker(exception, "Availability exception approved", message, released)
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception":          exception.ToDTO(),
+		"conflicting_shifts": shiftSummaries(conflicts),
+		"released_shifts":    released,
+		"message":            "Availability exception approved successfully",
+	})
+}
+
+// RejectAvailabilityException turns down a pending exception
+func (wah *WorkerAvailabilityHandler) RejectAvailabilityException(c *gin.Context) {
+	exception, ok := wah.reviewAvailabilityException(c)
+	if !ok {
+		return
+	}
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID := c.GetString("org_id")
+
+	var req ReviewAvailabilityExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	err := wah.handler.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.WorkerAvailabilityException{}).
+			Where("id = ? AND is_approved = ? AND rejected_at IS NULL", exception.ID, false).
+			Updates(map[string]interface{}{
+				"rejected_by":  userID,
+				"rejected_at":  time.Now(),
+				"review_notes": req.Notes,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errExceptionReviewed
+		}
+		return recordAudit(tx, c, orgID, "reject_availability_exception", "availability_exception", exception.ID,
+			gin.H{"status": models.ExceptionPending}, gin.H{"status": models.ExceptionRejected, "notes": req.Notes})
+	})
+	if err == errExceptionReviewed {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "Availability exception has already been reviewed", nil)
+		return
+	}
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to reject availability exception", err)
+		return
+	}
+
+	exception, err = wah.reloadAvailabilityException(exception.ID)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	message := fmt.Sprintf("Your request for %s on %s has been declined.",
+		exceptionLabel(exception.ExceptionType), exception.ExceptionDate.Format("Mon 2 Jan"))
+	if req.Notes != "" {
+		message += " " + req.Notes
+	}
+	wah.notifyWorker(exception, "Availability exception declined", message, nil)
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception": exception.ToDTO(),
+		"message":   "Availability exception rejected",
+	})
+}
+
+// GetExceptionConflicts lists the shifts the worker is booked on while the exception applies
+func (wah *WorkerAvailabilityHandler) GetExceptionConflicts(c *gin.Context) {
+	exception, ok := wah.fetchAvailabilityException(c)
+	if !ok {
+		return
+	}
+
+	conflicts, err := wah.conflictingShifts(c, exception)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load conflicting shifts", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception":          exception.ToDTO(),
+		"conflicting_shifts": shiftSummaries(conflicts),
+	})
+}
+
+// ReleaseConflictingShifts takes the worker off the shifts that clash with their approved
+// leave, leaving them unfilled so they can be broadcast as open shifts
+func (wah *WorkerAvailabilityHandler) ReleaseConflictingShifts(c *gin.Context) {
+	exception, ok := wah.reviewAvailabilityException(c)
+	if !ok {
+		return
+	}
+
+	if exception.Status() != models.ExceptionApproved {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "Only approved exceptions can release shifts", nil)
+		return
+	}
+
+	conflicts, err := wah.conflictingShifts(c, exception)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load conflicting shifts", err)
+		return
+	}
+
+	var released []string
+	err = wah.handler.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		released, err = releaseShifts(tx, c, c.GetString("org_id"), exception.UserID, conflicts)
+		return err
+	})
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to release shifts", err)
+		return
+	}
+
+	if len(released) > 0 {
+		wah.notifyWorker(exception, "Shifts released",
+			fmt.Sprintf("You have been taken off %d shift(s) during your %s on %s.", len(released),
+				exceptionLabel(exception.ExceptionType), exception.ExceptionDate.Format("Mon 2 Jan")), released)
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"exception":       exception.ToDTO(),
+		"released_shifts": released,
+		"message":         fmt.Sprintf("%d shift(s) released", len(released)),
+	})
+}
+
+// notifyWorker tells the worker an exception has been reviewed
+func (wah *WorkerAvailabilityHandler) notifyWorker(exception *availabilityException, title, message string, released []string) {
+	var worker models.User
+	if err := wah.handler.DB.Where("id = ?", exception.UserID).First(&worker).Error; err != nil {
+		return
+	}
+	data := models.JSONB{"exception_id": exception.ID, "status": exception.Status()}
+	if len(released) > 0 {
+		data["released_shifts"] = released
+	}
+	wah.handler.notifyUser(worker, models.NotificationAvailabilityException, title, message, data)
+}
@@ -1,27 +1,37 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/rates"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// BillingRecord represents a billing/invoice record
-type BillingRecord struct {
-	ID            string     `json:"id"`
-	ParticipantID string     `json:"participant_id"`
-	ShiftIDs      []string   `json:"shift_ids"`
-	InvoiceNumber string     `json:"invoice_number"`
-	Amount        float64    `json:"amount"`
-	Status        string     `json:"status"` // draft, sent, paid, overdue
-	IssueDate     time.Time  `json:"issue_date"`
-	DueDate       time.Time  `json:"due_date"`
-	PaidDate      *time.Time `json:"paid_date,omitempty"`
-	Description   string     `json:"description"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+// roundCurrency rounds an amount to whole cents
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// formatInvoiceNumber renders a sequence number as a human readable invoice number
+func formatInvoiceNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%06d", sequence)
+}
+
+// invoicePaymentTermsDays returns the organization's payment terms, defaulting to 30 days
+func (h *Handler) invoicePaymentTermsDays(orgID string) int {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return 30
+	}
+	return settings.InvoicePaymentTermsDays
 }
 
 func (h *Handler) GetBilling(c *gin.Context) {
@@ -37,12 +47,36 @@ func (h *Handler) GetBilling(c *gin.Context) {
 		return
 	}
 
-	// For MVP, generate mock billing data based on completed shifts
-	var shifts []models.Shift
-	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
-		Where("participants.organization_id = ? AND shifts.status = ?", orgID, "completed")
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+	participantID := c.Query("participant_id")
 
-	if err := query.Preload("Participant").Find(&shifts).Error; err != nil {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.Invoice{}).Where("organization_id = ?", orgID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var invoices []models.Invoice
+	if err := query.Preload("Participant").
+		Limit(limit).Offset(offset).Order("issue_date DESC, sequence_number DESC").
+		Find(&invoices).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -53,89 +87,252 @@ func (h *Handler) GetBilling(c *gin.Context) {
 		return
 	}
 
-	// Generate mock billing records
-	billingRecords := []BillingRecord{}
-	participantBilling := make(map[string]*BillingRecord)
-
-	for _, shift := range shifts {
-		if _, exists := participantBilling[shift.ParticipantID]; !exists {
-			billingRecord := &BillingRecord{
-				ID:            "bill_" + shift.ParticipantID,
-				ParticipantID: shift.ParticipantID,
-				ShiftIDs:      []string{},
-				InvoiceNumber: "INV-2025-" + shift.ParticipantID[0:8],
-				Amount:        0,
-				Status:        "draft",
-				IssueDate:     time.Now(),
-				DueDate:       time.Now().Add(30 * 24 * time.Hour),
-				Description:   "Care services for " + shift.Participant.FirstName + " " + shift.Participant.LastName,
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-			}
-			participantBilling[shift.ParticipantID] = billingRecord
-		}
-
-		// Calculate shift cost
-		hours := shift.EndTime.Sub(shift.StartTime).Hours()
-		cost := hours * shift.HourlyRate
-
-		participantBilling[shift.ParticipantID].Amount += cost
-		participantBilling[shift.ParticipantID].ShiftIDs = append(
-			participantBilling[shift.ParticipantID].ShiftIDs,
-			shift.ID,
-		)
-	}
-
-	// Convert map to slice
-	for _, billing := range participantBilling {
-		billingRecords = append(billingRecords, *billing)
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"billing": billingRecords,
+			"billing": invoices,
 			"pagination": gin.H{
-				"page":        1,
-				"limit":       len(billingRecords),
-				"total":       len(billingRecords),
-				"total_pages": 1,
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
 			},
 		},
 	})
 }
 
 func (h *Handler) GetBillingRecord(c *gin.Context) {
-	billingID := c.Param("id")
-
-	// For MVP, return mock data
-	billingRecord := BillingRecord{
-		ID:            billingID,
-		ParticipantID: "participant_1",
-		InvoiceNumber: "INV-2025-001",
-		Amount:        450.00,
-		Status:        "sent",
-		IssueDate:     time.Now().Add(-7 * 24 * time.Hour),
-		DueDate:       time.Now().Add(23 * 24 * time.Hour),
-		Description:   "Care services",
-		CreatedAt:     time.Now().Add(-7 * 24 * time.Hour),
-		UpdatedAt:     time.Now(),
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var invoice models.Invoice
+	if err := h.DB.Where("id = ? AND organization_id = ?", invoiceID, orgID).
+		Preload("Participant").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("service_date ASC") }).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("payment_date ASC") }).
+		Preload("CreditNotes", func(db *gorm.DB) *gorm.DB { return db.Order("sequence_number ASC") }).
+		Preload("CreditNotes.Lines").
+		Preload("Deliveries", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_FOUND",
+					"message": "Invoice not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice",
+			},
+		})
+		return
 	}
+	invoice.Chain = h.invoiceChain(invoice)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    billingRecord,
+		"data":    invoice,
 	})
 }
 
 type GenerateInvoiceRequest struct {
 	ParticipantID string   `json:"participant_id" binding:"required"`
-	ShiftIDs      []string `json:"shift_ids" binding:"required"`
-	DueDate       string   `json:"due_date"`
+	ShiftIDs      []string `json:"shift_ids" binding:"required,min=1"`
+	DueDate       string   `json:"due_date"` // YYYY-MM-DD, defaults to the organization's payment terms
 	Description   string   `json:"description"`
 }
 
+// errShiftsAlreadyInvoiced is returned when another invoice claimed a shift first
+var errShiftsAlreadyInvoiced = errors.New("one or more shifts have already been invoiced")
+
+// buildShiftInvoiceLines prices a completed shift as hourly service lines. A shift that
+// crosses penalty rate bands at different rates gets one line per band. A shift cancelled
+// at short notice gets a single cancellation line for its charge.
+func buildShiftInvoiceLines(shift models.Shift) []models.InvoiceLine {
+	shiftID := shift.ID
+
+	service := shift.ServiceType
+	if shift.SupportItem != nil {
+		service = shift.SupportItem.ItemNumber + " " + shift.SupportItem.Name
+	}
+	if isChargedCancellation(shift) {
+		quantity := roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours())
+		unitPrice := shift.CancellationCharge
+		if quantity > 0 {
+			unitPrice = roundCurrency(shift.CancellationCharge / quantity)
+		}
+		return []models.InvoiceLine{{
+			ShiftID:       &shiftID,
+			SupportItemID: shift.SupportItemID,
+			LineType:      "cancellation",
+			Description:   fmt.Sprintf("Short notice cancellation: %s - %s", service, shift.StartTime.Format("02/01/2006 15:04")),
+			ServiceDate:   shift.StartTime,
+			Quantity:      quantity,
+			Unit:          "H",
+			UnitPrice:     unitPrice,
+			GSTCode:       "P2",
+			Amount:        billableAmount(shift),
+		}}
+	}
+	line := models.InvoiceLine{
+		ShiftID:       &shiftID,
+		SupportItemID: shift.SupportItemID,
+		LineType:      "service",
+		Description:   fmt.Sprintf("%s - %s", service, shift.StartTime.Format("02/01/2006 15:04")),
+		ServiceDate:   shift.StartTime,
+		Quantity:      roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours()),
+		Unit:          "H",
+		UnitPrice:     shift.HourlyRate,
+		GSTCode:       "P2",
+		Amount:        shiftCost(shift),
+	}
+	if len(shift.CostBands) == 0 {
+		return []models.InvoiceLine{line}
+	}
+
+	singleRate := true
+	for _, band := range shift.CostBands {
+		singleRate = singleRate && band.Rate == shift.CostBands[0].Rate
+	}
+	if singleRate {
+		line.UnitPrice = shift.CostBands[0].Rate
+		if band := shift.CostBands[0].Band; len(shift.CostBands) == 1 && band != rates.BandWeekday {
+			line.Description += " (" + rates.BandName(band) + ")"
+		}
+		return []models.InvoiceLine{line}
+	}
+
+	lines := make([]models.InvoiceLine, 0, len(shift.CostBands))
+	for _, band := range shift.CostBands {
+		bandLine := line
+		bandLine.Description = fmt.Sprintf("%s - %s (%s)", service, band.StartTime.Format("02/01/2006 15:04"), rates.BandName(band.Band))
+		bandLine.ServiceDate = band.StartTime
+		bandLine.Quantity = band.Hours
+		bandLine.UnitPrice = band.Rate
+		bandLine.Amount = band.Amount
+		lines = append(lines, bandLine)
+	}
+	return lines
+}
+
+// checkInvoiceable returns the status, error code and message explaining why a shift
+// cannot be invoiced, or an empty code when it can
+func checkInvoiceable(shift models.Shift) (int, string, string) {
+	switch {
+	case shift.Status != "completed" && !isChargedCancellation(shift):
+		return http.StatusBadRequest, "SHIFT_NOT_COMPLETED", "Only completed shifts and short notice cancellations can be invoiced"
+	case shift.ClaimLineID != nil:
+		return http.StatusConflict, "SHIFT_ALREADY_CLAIMED", "Shift has been claimed from the NDIA"
+	case shift.InvoiceID != nil:
+		return http.StatusConflict, "SHIFT_ALREADY_INVOICED", "Shift has already been invoiced"
+	}
+	return 0, "", ""
+}
+
+// invoiceLinesFor prices shifts as invoice lines, with travel for the completed ones
+func (h *Handler) invoiceLinesFor(db *gorm.DB, shifts []models.Shift, remoteness, orgID string) []models.InvoiceLine {
+	lines := []models.InvoiceLine{}
+	for _, shift := range shifts {
+		lines = append(lines, buildShiftInvoiceLines(shift)...)
+		if shift.Status == "completed" {
+			for _, charge := range h.travelCharges(db, shift, remoteness, orgID) {
+				lines = append(lines, charge.invoiceLine(shift))
+			}
+		}
+	}
+	return lines
+}
+
+// issueInvoice numbers and saves a priced invoice, locks its shifts against it and trues
+// up each shift's budget drawdown to the amount invoiced. Call it inside a transaction.
+func issueInvoice(tx *gorm.DB, invoice *models.Invoice, shifts []models.Shift, userID string) error {
+	sequence, err := models.NextSequenceNumber(tx, invoice.OrganizationID, models.SequenceTypeInvoice)
+	if err != nil {
+		return err
+	}
+	invoice.SequenceNumber = sequence
+	invoice.InvoiceNumber = formatInvoiceNumber(sequence)
+
+	if err := tx.Create(invoice).Error; err != nil {
+		return err
+	}
+
+	// Lock the shifts against this invoice; a concurrent invoice that got there first wins
+	shiftIDs := make([]string, 0, len(shifts))
+	for _, shift := range shifts {
+		shiftIDs = append(shiftIDs, shift.ID)
+	}
+	result := tx.Model(&models.Shift{}).
+		Where("id IN ? AND invoice_id IS NULL AND claim_line_id IS NULL", shiftIDs).
+		Update("invoice_id", invoice.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(shiftIDs)) {
+		return errShiftsAlreadyInvoiced
+	}
+
+	// True up each shift's budget drawdown to the amount invoiced
+	for i := range shifts {
+		invoiced := 0.0
+		for _, line := range invoice.Lines {
+			if line.ShiftID != nil && *line.ShiftID == shifts[i].ID {
+				invoiced += line.Amount
+			}
+		}
+		_, err := models.DrawDownShift(tx, &shifts[i], roundCurrency(invoiced), models.BudgetTransactionInvoice,
+			"Invoiced on "+invoice.InvoiceNumber, userID, &invoice.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyInvoiceTotals recalculates invoice totals from its lines and payments
+func applyInvoiceTotals(invoice *models.Invoice) {
+	subtotal := 0.0
+	gst := 0.0
+	for _, line := range invoice.Lines {
+		subtotal += line.Amount
+		gst += line.GSTAmount
+	}
+	invoice.Subtotal = roundCurrency(subtotal)
+	invoice.GSTAmount = roundCurrency(gst)
+	invoice.Total = roundCurrency(subtotal + gst)
+	invoice.BalanceDue = roundCurrency(invoice.Total - invoice.AmountPaid)
+}
+
 func (h *Handler) GenerateInvoice(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
 	var req GenerateInvoiceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -149,24 +346,133 @@ func (h *Handler) GenerateInvoice(c *gin.Context) {
 		return
 	}
 
-	// For MVP, return mock generated invoice
-	invoiceRecord := BillingRecord{
-		ID:            "bill_" + req.ParticipantID + "_" + time.Now().Format("20060102"),
-		ParticipantID: req.ParticipantID,
-		ShiftIDs:      req.ShiftIDs,
-		InvoiceNumber: "INV-2025-" + time.Now().Format("001"),
-		Amount:        375.50, // Mock calculation
-		Status:        "draft",
-		IssueDate:     time.Now(),
-		DueDate:       time.Now().Add(30 * 24 * time.Hour),
-		Description:   req.Description,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+	// Verify participant belongs to organization
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", req.ParticipantID, orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PARTICIPANT",
+				"message": "Participant not found",
+			},
+		})
+		return
 	}
 
+	// De-duplicate requested shifts
+	shiftIDs := make([]string, 0, len(req.ShiftIDs))
+	seen := make(map[string]bool)
+	for _, id := range req.ShiftIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			shiftIDs = append(shiftIDs, id)
+		}
+	}
+
+	var shifts []models.Shift
+	if err := h.DB.Where("id IN ? AND participant_id = ?", shiftIDs, participant.ID).
+		Preload("SupportItem").
+		Preload("CostBands", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		Order("start_time ASC").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shifts",
+			},
+		})
+		return
+	}
+
+	if len(shifts) != len(shiftIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SHIFTS",
+				"message": "One or more shifts were not found for this participant",
+			},
+		})
+		return
+	}
+
+	for _, shift := range shifts {
+		if status, code, message := checkInvoiceable(shift); code != "" {
+			c.JSON(status, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    code,
+					"message": message,
+					"details": shift.ID,
+				},
+			})
+			return
+		}
+	}
+
+	issueDate := time.Now()
+	dueDate := issueDate.AddDate(0, 0, h.invoicePaymentTermsDays(orgID.(string)))
+	if req.DueDate != "" {
+		parsedDue, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid due date format (YYYY-MM-DD expected)",
+				},
+			})
+			return
+		}
+		dueDate = parsedDue
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "Care services for " + participant.FirstName + " " + participant.LastName
+	}
+
+	invoice := models.Invoice{
+		OrganizationID: orgID.(string),
+		ParticipantID:  participant.ID,
+		Status:         "draft",
+		IssueDate:      issueDate,
+		DueDate:        dueDate,
+		Description:    description,
+		CreatedBy:      userID,
+	}
+	invoice.Lines = h.invoiceLinesFor(h.DB, shifts, participant.Remoteness, orgID.(string))
+	applyInvoiceTotals(&invoice)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		return issueInvoice(tx, &invoice, shifts, userID)
+	})
+	if err != nil {
+		if errors.Is(err, errShiftsAlreadyInvoiced) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_ALREADY_INVOICED",
+					"message": "One or more shifts have already been invoiced",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to generate invoice",
+			},
+		})
+		return
+	}
+
+	// Fetch invoice with related data
+	h.DB.Preload("Participant").Preload("Lines").First(&invoice, "id = ?", invoice.ID)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"data":    invoiceRecord,
+		"data":    invoice,
 		"message": "Invoice generated successfully",
 	})
 }
@@ -178,8 +484,26 @@ type PaymentRequest struct {
 	Reference   string    `json:"reference"`
 }
 
+// Payments that cannot be applied to an invoice's current balance
+var (
+	errInvoiceAlreadyPaid    = errors.New("invoice has no outstanding balance")
+	errPaymentExceedsBalance = errors.New("payment exceeds the outstanding balance")
+)
+
 func (h *Handler) MarkAsPaid(c *gin.Context) {
-	billingID := c.Param("id")
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
 
 	var req PaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -194,38 +518,152 @@ func (h *Handler) MarkAsPaid(c *gin.Context) {
 		return
 	}
 
-	// For MVP, return updated record
-	now := time.Now()
-	billingRecord := BillingRecord{
-		ID:            billingID,
-		ParticipantID: "participant_1",
-		InvoiceNumber: "INV-2025-001",
-		Amount:        req.Amount,
-		Status:        "paid",
-		IssueDate:     now.Add(-7 * 24 * time.Hour),
-		DueDate:       now.Add(23 * 24 * time.Hour),
-		PaidDate:      &now,
-		Description:   "Care services",
-		CreatedAt:     now.Add(-7 * 24 * time.Hour),
-		UpdatedAt:     now,
+	var invoice models.Invoice
+	if err := h.DB.Where("id = ? AND organization_id = ?", invoiceID, orgID).First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_FOUND",
+					"message": "Invoice not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice",
+			},
+		})
+		return
+	}
+
+	amount := roundCurrency(req.Amount)
+	paymentDate := req.PaymentDate
+	if paymentDate.IsZero() {
+		paymentDate = time.Now()
 	}
 
+	payment := models.Payment{
+		InvoiceID:      invoice.ID,
+		OrganizationID: invoice.OrganizationID,
+		Amount:         amount,
+		PaymentDate:    paymentDate,
+		Method:         req.Method,
+		Reference:      req.Reference,
+		RecordedBy:     userID,
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Re-read the balance under a row lock so concurrent payments are applied one at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", invoice.ID).Error; err != nil {
+			return err
+		}
+		if invoice.Status == "paid" || invoice.BalanceDue <= 0 {
+			return errInvoiceAlreadyPaid
+		}
+		if amount > invoice.BalanceDue {
+			return errPaymentExceedsBalance
+		}
+
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+
+		amountPaid := roundCurrency(invoice.AmountPaid + amount)
+		return tx.Model(&invoice).Updates(invoiceSettled(invoice, amountPaid, invoice.CreditedAmount, paymentDate)).Error
+	})
+	if errors.Is(err, errInvoiceAlreadyPaid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVOICE_ALREADY_PAID",
+				"message": "Invoice has no outstanding balance",
+			},
+		})
+		return
+	}
+	if errors.Is(err, errPaymentExceedsBalance) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PAYMENT_EXCEEDS_BALANCE",
+				"message": fmt.Sprintf("Payment exceeds the outstanding balance of %.2f", invoice.BalanceDue),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record payment",
+			},
+		})
+		return
+	}
+
+	// Fetch updated invoice
+	h.DB.Preload("Lines").Preload("Payments").Preload("CreditNotes").First(&invoice, "id = ?", invoice.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    billingRecord,
+		"data":    invoice,
 		"message": "Payment recorded successfully",
 	})
 }
 
 func (h *Handler) DownloadInvoice(c *gin.Context) {
-	billingID := c.Param("id")
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
 
-	// For MVP, return mock PDF response
-	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", "attachment; filename=invoice_"+billingID+".pdf")
+	var invoice models.Invoice
+	if err := h.DB.Where("id = ? AND organization_id = ?", invoiceID, orgID).First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_FOUND",
+					"message": "Invoice not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice",
+			},
+		})
+		return
+	}
 
-	// Return mock PDF content (in real implementation, generate actual PDF)
-	mockPDFContent := "%PDF-1.4\n1 0 obj\n<<\n/Type /Catalog\n/Pages 2 0 R\n>>\nendobj\n\n2 0 obj\n<<\n/Type /Pages\n/Kids [3 0 R]\n/Count 1\n>>\nendobj\n\n3 0 obj\n<<\n/Type /Page\n/Parent 2 0 R\n/MediaBox [0 0 612 792]\n>>\nendobj\n\nxref\n0 4\n0000000000 65535 f \n0000000010 00000 n \n0000000053 00000 n \n0000000104 00000 n \ntrailer\n<<\n/Size 4\n/Root 1 0 R\n>>\nstartxref\n164\n%%EOF"
+	document, err := h.invoiceDocument(invoice.ID, h.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PDF_GENERATION_ERROR",
+				"message": "Failed to generate invoice PDF",
+			},
+		})
+		return
+	}
 
-	c.String(http.StatusOK, mockPDFContent)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", document.OriginalFilename))
+	c.File(document.FilePath)
 }
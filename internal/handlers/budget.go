@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// shiftCost is what a shift is billed at: its penalty rate total, or its hours at the
+// base rate for shifts that were never priced
+func shiftCost(shift models.Shift) float64 {
+	if shift.TotalCost > 0 {
+		return roundCurrency(shift.TotalCost)
+	}
+	hours := roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours())
+	return roundCurrency(hours * shift.HourlyRate)
+}
+
+// budgetWarning checks whether booking cost on top of the participant's scheduled shifts
+// would spend more than their remaining budget before the plan ends. It returns an empty
+// string when the booking fits or no budget has been set.
+func (h *Handler) budgetWarning(participant models.Participant, start time.Time, cost float64) string {
+	funding := participant.Funding
+	if funding.TotalBudget <= 0 {
+		return ""
+	}
+	if funding.PlanEndDate != nil && start.After(*funding.PlanEndDate) {
+		return "" // Falls in a later plan
+	}
+
+	// Shifts not yet completed have not drawn down the budget
+	query := h.DB.Model(&models.Shift{}).
+		Where("participant_id = ? AND status IN ?", participant.ID, []string{"scheduled", "in_progress"})
+	if funding.PlanEndDate != nil {
+		query = query.Where("start_time <= ?", *funding.PlanEndDate)
+	}
+	var committed float64
+	query.Select("COALESCE(SUM(total_cost), 0)").Scan(&committed)
+
+	projected := roundCurrency(committed + cost)
+	if projected <= funding.RemainingBudget {
+		return ""
+	}
+
+	message := fmt.Sprintf("Scheduled supports of %s exceed the participant's remaining budget of %s",
+		formatMoney(projected), formatMoney(funding.RemainingBudget))
+	if funding.PlanEndDate != nil {
+		message += " before their plan ends on " + funding.PlanEndDate.Format("02/01/2006")
+	}
+	return message
+}
+
+func (h *Handler) GetParticipantBudgetLedger(c *gin.Context) {
+	participantID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).First(&participant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PARTICIPANT_NOT_FOUND",
+					"message": "Participant not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant",
+			},
+		})
+		return
+	}
+
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	transactionType := c.Query("type")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.BudgetTransaction{}).Where("participant_id = ?", participant.ID)
+	if transactionType != "" {
+		query = query.Where("transaction_type = ?", transactionType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var transactions []models.BudgetTransaction
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC, id DESC").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch budget ledger",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"funding":      participant.Funding,
+			"transactions": transactions,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"math"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+)
+
+// cancellationPolicy returns the organization's short notice window in hours and the
+// percentage of the booked fee charged for cancellations inside it
+func (h *Handler) cancellationPolicy(orgID string) (int, float64) {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return 48, 100
+	}
+	return settings.CancellationNoticeHours, settings.CancellationChargeRate
+}
+
+// noticeHours is how much notice a cancellation given at noticeAt gave before the shift
+func noticeHours(shift models.Shift, noticeAt time.Time) float64 {
+	return math.Max(0, roundCurrency(shift.StartTime.Sub(noticeAt).Hours()))
+}
+
+// cancellationCharge works out the fee for a cancellation with the given notice. Only
+// cancellations by the participant inside the organization's notice window are charged,
+// and only for support items the NDIS allows short notice cancellation claims for.
+func (h *Handler) cancellationCharge(shift models.Shift, cancelledBy string, notice float64, orgID string) float64 {
+	if cancelledBy != "participant" {
+		return 0
+	}
+	window, rate := h.cancellationPolicy(orgID)
+	if rate <= 0 || notice >= float64(window) {
+		return 0
+	}
+	if shift.SupportItemID != nil {
+		var item models.SupportItem
+		if err := h.DB.First(&item, "id = ?", *shift.SupportItemID).Error; err == nil && !item.ShortNoticeCancellation {
+			return 0
+		}
+	}
+	return roundCurrency(shiftCost(shift) * rate / 100)
+}
+
+// isChargedCancellation reports whether a shift was cancelled at short notice and is billable
+func isChargedCancellation(shift models.Shift) bool {
+	return (shift.Status == "cancelled" || shift.Status == "no_show") && shift.CancellationCharge > 0
+}
+
+// billableAmount is what a shift is invoiced or claimed at: its cost once completed, or its
+// cancellation charge when it was cancelled at short notice
+func billableAmount(shift models.Shift) float64 {
+	if isChargedCancellation(shift) {
+		return roundCurrency(shift.CancellationCharge)
+	}
+	return shiftCost(shift)
+}
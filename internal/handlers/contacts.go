@@ -0,0 +1,730 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ExternalContactRequest struct {
+	Type             string          `json:"type" binding:"required,oneof=plan_manager support_coordinator gp other"`
+	CompanyName      string          `json:"company_name"`
+	ContactName      string          `json:"contact_name"`
+	Email            string          `json:"email" binding:"omitempty,email"`
+	Phone            string          `json:"phone"`
+	ABN              string          `json:"abn"`
+	Address          *models.Address `json:"address"`
+	PreferredChannel string          `json:"preferred_channel" binding:"omitempty,oneof=email portal"`
+	PortalName       string          `json:"portal_name"`
+	Notes            string          `json:"notes"`
+}
+
+type UpdateExternalContactRequest struct {
+	Type             *string         `json:"type,omitempty" binding:"omitempty,oneof=plan_manager support_coordinator gp other"`
+	CompanyName      *string         `json:"company_name,omitempty"`
+	ContactName      *string         `json:"contact_name,omitempty"`
+	Email            *string         `json:"email,omitempty" binding:"omitempty,email"`
+	Phone            *string         `json:"phone,omitempty"`
+	ABN              *string         `json:"abn,omitempty"`
+	Address          *models.Address `json:"address,omitempty"`
+	PreferredChannel *string         `json:"preferred_channel,omitempty" binding:"omitempty,oneof=email portal"`
+	PortalName       *string         `json:"portal_name,omitempty"`
+	Notes            *string         `json:"notes,omitempty"`
+	IsActive         *bool           `json:"is_active,omitempty"`
+}
+
+type ParticipantContactRequest struct {
+	ExternalContactID string `json:"external_contact_id" binding:"required"`
+	Role              string `json:"role" binding:"required,oneof=plan_manager support_coordinator gp other"`
+	IsPayer           bool   `json:"is_payer"`
+	Notes             string `json:"notes"`
+}
+
+type UpdateParticipantContactRequest struct {
+	IsPayer *bool   `json:"is_payer,omitempty"`
+	Notes   *string `json:"notes,omitempty"`
+}
+
+// contactProblem returns why a contact cannot be saved, or an empty string
+func contactProblem(contact models.ExternalContact) string {
+	if strings.TrimSpace(contact.CompanyName) == "" && strings.TrimSpace(contact.ContactName) == "" {
+		return "A company name or contact name is required"
+	}
+	if contact.PreferredChannel == models.DeliveryChannelEmail && contact.Email == "" {
+		return "An email address is required to deliver invoices by email"
+	}
+	return ""
+}
+
+func (h *Handler) GetExternalContacts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	contactType := c.Query("type")
+	isActive := c.Query("is_active")
+	search := c.Query("search")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.ExternalContact{}).Where("organization_id = ?", orgID)
+
+	if contactType != "" {
+		query = query.Where("type = ?", contactType)
+	}
+
+	if isActive != "" {
+		activeFilter, _ := strconv.ParseBool(isActive)
+		query = query.Where("is_active = ?", activeFilter)
+	}
+
+	if search != "" {
+		searchTerm := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(company_name) LIKE ? OR LOWER(contact_name) LIKE ? OR LOWER(email) LIKE ?", searchTerm, searchTerm, searchTerm)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var contacts []models.ExternalContact
+	if err := query.Limit(limit).Offset(offset).Order("company_name ASC, contact_name ASC").Find(&contacts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch contacts",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"contacts": contacts,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) GetExternalContact(c *gin.Context) {
+	contactID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var contact models.ExternalContact
+	if err := h.DB.Where("id = ? AND organization_id = ?", contactID, orgID).First(&contact).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CONTACT_NOT_FOUND",
+					"message": "Contact not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch contact",
+			},
+		})
+		return
+	}
+
+	// The participants this contact works with
+	var links []models.ParticipantContact
+	h.DB.Where("external_contact_id = ?", contact.ID).Order("created_at ASC").Find(&links)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"contact":      contact,
+			"participants": links,
+		},
+	})
+}
+
+func (h *Handler) CreateExternalContact(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req ExternalContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	contact := models.ExternalContact{
+		OrganizationID:   orgID.(string),
+		Type:             req.Type,
+		CompanyName:      strings.TrimSpace(req.CompanyName),
+		ContactName:      strings.TrimSpace(req.ContactName),
+		Email:            strings.TrimSpace(req.Email),
+		Phone:            req.Phone,
+		ABN:              req.ABN,
+		PreferredChannel: req.PreferredChannel,
+		PortalName:       req.PortalName,
+		Notes:            req.Notes,
+		IsActive:         true,
+	}
+	if req.Address != nil {
+		contact.Address = *req.Address
+	}
+	if contact.PreferredChannel == "" {
+		contact.PreferredChannel = models.DeliveryChannelEmail
+	}
+	if problem := contactProblem(contact); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": problem,
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Create(&contact).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create contact",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    contact,
+		"message": "Contact created successfully",
+	})
+}
+
+func (h *Handler) UpdateExternalContact(c *gin.Context) {
+	contactID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateExternalContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var contact models.ExternalContact
+	if err := h.DB.Where("id = ? AND organization_id = ?", contactID, orgID).First(&contact).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CONTACT_NOT_FOUND",
+					"message": "Contact not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch contact",
+			},
+		})
+		return
+	}
+
+	// Apply the changes to a copy first so the result can be validated as a whole
+	updated := contact
+	updates := make(map[string]interface{})
+	if req.Type != nil {
+		updated.Type = *req.Type
+		updates["type"] = updated.Type
+	}
+	if req.CompanyName != nil {
+		updated.CompanyName = strings.TrimSpace(*req.CompanyName)
+		updates["company_name"] = updated.CompanyName
+	}
+	if req.ContactName != nil {
+		updated.ContactName = strings.TrimSpace(*req.ContactName)
+		updates["contact_name"] = updated.ContactName
+	}
+	if req.Email != nil {
+		updated.Email = strings.TrimSpace(*req.Email)
+		updates["email"] = updated.Email
+	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+	if req.ABN != nil {
+		updates["abn"] = *req.ABN
+	}
+	if req.Address != nil {
+		updates["address_street"] = req.Address.Street
+		updates["address_suburb"] = req.Address.Suburb
+		updates["address_state"] = req.Address.State
+		updates["address_postcode"] = req.Address.Postcode
+		updates["address_country"] = req.Address.Country
+	}
+	if req.PreferredChannel != nil {
+		updated.PreferredChannel = *req.PreferredChannel
+		updates["preferred_channel"] = updated.PreferredChannel
+	}
+	if req.PortalName != nil {
+		updates["portal_name"] = *req.PortalName
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if problem := contactProblem(updated); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": problem,
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Model(&contact).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update contact",
+			},
+		})
+		return
+	}
+
+	h.DB.First(&contact, "id = ?", contact.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    contact,
+		"message": "Contact updated successfully",
+	})
+}
+
+// DeleteExternalContact removes a contact and unlinks it from every participant
+func (h *Handler) DeleteExternalContact(c *gin.Context) {
+	contactID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var contact models.ExternalContact
+	if err := h.DB.Where("id = ? AND organization_id = ?", contactID, orgID).First(&contact).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CONTACT_NOT_FOUND",
+					"message": "Contact not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch contact",
+			},
+		})
+		return
+	}
+
+	// Soft delete the contact so past invoice deliveries still show who they went to
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("external_contact_id = ?", contact.ID).Delete(&models.ParticipantContact{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&contact).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete contact",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Contact deleted successfully",
+	})
+}
+
+func (h *Handler) GetParticipantContacts(c *gin.Context) {
+	participantID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	var links []models.ParticipantContact
+	if err := h.DB.Where("participant_id = ?", participant.ID).
+		Preload("ExternalContact").
+		Order("role ASC, created_at ASC").
+		Find(&links).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant contacts",
+			},
+		})
+		return
+	}
+
+	payer := h.invoicePayer(h.DB, participant)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"contacts": links,
+			"payer":    payer,
+		},
+	})
+}
+
+// AddParticipantContact links an external contact to a participant. Marking the link as
+// the payer moves the participant's invoices to that contact.
+func (h *Handler) AddParticipantContact(c *gin.Context) {
+	participantID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req ParticipantContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	var contact models.ExternalContact
+	if err := h.DB.Where("id = ? AND organization_id = ?", req.ExternalContactID, orgID).First(&contact).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_CONTACT",
+				"message": "Contact not found",
+			},
+		})
+		return
+	}
+
+	var count int64
+	h.DB.Model(&models.ParticipantContact{}).
+		Where("participant_id = ? AND external_contact_id = ? AND role = ?", participant.ID, contact.ID, req.Role).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CONTACT_ALREADY_LINKED",
+				"message": "Contact is already linked to the participant in this role",
+			},
+		})
+		return
+	}
+
+	link := models.ParticipantContact{
+		ParticipantID:     participant.ID,
+		ExternalContactID: contact.ID,
+		Role:              req.Role,
+		IsPayer:           req.IsPayer,
+		Notes:             req.Notes,
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// A participant has one payer
+		if link.IsPayer {
+			if err := tx.Model(&models.ParticipantContact{}).
+				Where("participant_id = ? AND is_payer = ?", participant.ID, true).
+				Update("is_payer", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&link).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to link contact",
+			},
+		})
+		return
+	}
+
+	link.ExternalContact = contact
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    link,
+		"message": "Contact linked successfully",
+	})
+}
+
+func (h *Handler) UpdateParticipantContact(c *gin.Context) {
+	participantID := c.Param("id")
+	linkID := c.Param("contactId")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateParticipantContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var link models.ParticipantContact
+	if err := h.DB.Joins("JOIN participants ON participant_contacts.participant_id = participants.id").
+		Where("participant_contacts.id = ? AND participant_contacts.participant_id = ? AND participants.organization_id = ?", linkID, participantID, orgID).
+		First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CONTACT_NOT_FOUND",
+				"message": "Participant contact not found",
+			},
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.IsPayer != nil {
+		updates["is_payer"] = *req.IsPayer
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if req.IsPayer != nil && *req.IsPayer {
+			if err := tx.Model(&models.ParticipantContact{}).
+				Where("participant_id = ? AND is_payer = ? AND id <> ?", link.ParticipantID, true, link.ID).
+				Update("is_payer", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&link).Updates(updates).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update participant contact",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("ExternalContact").First(&link, "id = ?", link.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    link,
+		"message": "Participant contact updated successfully",
+	})
+}
+
+func (h *Handler) RemoveParticipantContact(c *gin.Context) {
+	participantID := c.Param("id")
+	linkID := c.Param("contactId")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var link models.ParticipantContact
+	if err := h.DB.Joins("JOIN participants ON participant_contacts.participant_id = participants.id").
+		Where("participant_contacts.id = ? AND participant_contacts.participant_id = ? AND participants.organization_id = ?", linkID, participantID, orgID).
+		First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CONTACT_NOT_FOUND",
+				"message": "Participant contact not found",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Delete(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to remove participant contact",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Participant contact removed successfully",
+	})
+}
@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+	"gorm.io/gorm"
+)
+
+type MandatoryCredentialRequest struct {
+	Name           string `json:"name" binding:"required"`
+	Category       string `json:"category"`
+	Description    string `json:"description"`
+	RequiresExpiry bool   `json:"requires_expiry"`
+	IsActive       *bool  `json:"is_active,omitempty"` // defaults to true
+}
+
+// CredentialOverride lets a manager assign a worker who does not meet the organization's
+// mandatory credentials. The reason is kept in the audit log.
+type CredentialOverride struct {
+	OverrideCredentials bool   `json:"override_credentials,omitempty"`
+	OverrideReason      string `json:"override_reason,omitempty"`
+}
+
+// credentialGap is a mandatory credential a worker does not meet
+type credentialGap struct {
+	Credential string `json:"credential"`
+	Status     string `json:"status"` // missing, expired, unverified, no_expiry
+	Message    string `json:"message"`
+}
+
+// credentialRank orders statuses from worst to best, so a worker holding the credential
+// twice is judged on their better record
+var credentialRank = map[string]int{
+	models.CredentialMissing:    0,
+	models.CredentialExpired:    1,
+	models.CredentialNoExpiry:   2,
+	models.CredentialUnverified: 3,
+	models.CredentialCompliant:  4,
+}
+
+// credentialStatus checks a worker's active skills against a mandatory credential at a
+// time, returning the status and the skill it was judged on, if any
+func credentialStatus(credential models.MandatoryCredential, skills []models.WorkerSkill, at time.Time) (string, *models.WorkerSkill) {
+	status := models.CredentialMissing
+	var held *models.WorkerSkill
+	for i := range skills {
+		skill := &skills[i]
+		if !strings.EqualFold(strings.TrimSpace(skill.SkillName), credential.Name) {
+			continue
+		}
+
+		current := models.CredentialCompliant
+		switch {
+		case skill.ExpiryDate != nil && !skill.ExpiryDate.After(at):
+			current = models.CredentialExpired
+		case credential.RequiresExpiry && skill.ExpiryDate == nil:
+			current = models.CredentialNoExpiry
+		case skill.VerifiedAt == nil:
+			current = models.CredentialUnverified
+		}
+		if held == nil || credentialRank[current] > credentialRank[status] {
+			status, held = current, skill
+		}
+	}
+	return status, held
+}
+
+// describeGap explains why a worker does not meet a credential
+func describeGap(credential models.MandatoryCredential, status string, skill *models.WorkerSkill) credentialGap {
+	gap := credentialGap{Credential: credential.Name, Status: status}
+	switch status {
+	case models.CredentialMissing:
+		gap.Message = fmt.Sprintf("No %s on record", credential.Name)
+	case models.CredentialExpired:
+		gap.Message = fmt.Sprintf("%s expired on %s", credential.Name, skill.ExpiryDate.Format("2006-01-02"))
+	case models.CredentialNoExpiry:
+		gap.Message = fmt.Sprintf("%s has no expiry date recorded", credential.Name)
+	case models.CredentialUnverified:
+		gap.Message = fmt.Sprintf("%s has not been verified by a manager", credential.Name)
+	}
+	return gap
+}
+
+// mandatoryCredentials returns the organization's active mandatory credentials
+func (h *Handler) mandatoryCredentials(orgID string) ([]models.MandatoryCredential, error) {
+	var credentials []models.MandatoryCredential
+	err := h.DB.Where("organization_id = ? AND is_active = ?", orgID, true).Order("name").Find(&credentials).Error
+	return credentials, err
+}
+
+// credentialGaps returns the organization's mandatory credentials a worker will not meet at
+// the given time
+func (h *Handler) credentialGaps(orgID, staffID string, at time.Time) ([]credentialGap, error) {
+	credentials, err := h.mandatoryCredentials(orgID)
+	if err != nil || len(credentials) == 0 {
+		return nil, err
+	}
+
+	var skills []models.WorkerSkill
+	if err := h.DB.Where("user_id = ? AND is_active = ?", staffID, true).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+
+	gaps := []credentialGap{}
+	for _, credential := range credentials {
+		if status, skill := credentialStatus(credential, skills, at); status != models.CredentialCompliant {
+			gaps = append(gaps, describeGap(credential, status, skill))
+		}
+	}
+	return gaps, nil
+}
+
+// credentialLapse returns when a worker stops meeting the mandatory credentials between from
+// and to, or nil if they meet them throughout. Skills only ever expire, so a gap found at
+// some time lasts, and it is enough to check from and each expiry date in between.
+func credentialLapse(credentials []models.MandatoryCredential, skills []models.WorkerSkill, from, to time.Time) *scheduling.Lapse {
+	checks := []time.Time{from}
+	for _, skill := range skills {
+		if skill.ExpiryDate != nil && skill.ExpiryDate.After(from) && skill.ExpiryDate.Before(to) {
+			checks = append(checks, *skill.ExpiryDate)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Before(checks[j]) })
+
+	for _, at := range checks {
+		for _, credential := range credentials {
+			if status, skill := credentialStatus(credential, skills, at); status != models.CredentialCompliant {
+				return &scheduling.Lapse{From: at, Message: describeGap(credential, status, skill).Message}
+			}
+		}
+	}
+	return nil
+}
+
+// checkCredentials refuses to assign a worker who will not meet the organization's mandatory
+// credentials when the shift starts, unless a manager overrides it with a reason. It writes
+// the error response and returns false when the assignment can't go ahead; otherwise it
+// returns the gaps that were overridden, if any.
+func (h *Handler) checkCredentials(c *gin.Context, orgID, staffID string, at time.Time, override CredentialOverride) ([]credentialGap, bool) {
+	if staffID == "" {
+		return nil, true
+	}
+
+	gaps, err := h.credentialGaps(orgID, staffID, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check the worker's credentials",
+			},
+		})
+		return nil, false
+	}
+	if len(gaps) == 0 {
+		return nil, true
+	}
+
+	if !override.OverrideCredentials {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDENTIALS_NOT_MET",
+				"message": "Staff member does not meet the organization's mandatory credentials",
+				"details": gaps,
+			},
+		})
+		return nil, false
+	}
+	if !isManagerRole(h.GetUserRoleFromContext(c)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Only managers can override mandatory credentials",
+			},
+		})
+		return nil, false
+	}
+	if strings.TrimSpace(override.OverrideReason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OVERRIDE_REASON_REQUIRED",
+				"message": "A reason is required to override mandatory credentials",
+			},
+		})
+		return nil, false
+	}
+	return gaps, true
+}
+
+// recordCredentialOverride notes in the audit log that a worker was assigned to a shift
+// without meeting the mandatory credentials
+func recordCredentialOverride(tx *gorm.DB, c *gin.Context, orgID, shiftID, staffID string, override CredentialOverride, gaps []credentialGap) error {
+	if len(gaps) == 0 {
+		return nil
+	}
+	return recordAudit(tx, c, orgID, "override_credentials", "shift", shiftID, nil, gin.H{
+		"staff_id": staffID,
+		"reason":   strings.TrimSpace(override.OverrideReason),
+		"gaps":     gaps,
+	})
+}
+
+// GetMandatoryCredentials lists the credentials the organization requires of its workers
+func (h *Handler) GetMandatoryCredentials(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var credentials []models.MandatoryCredential
+	if err := h.DB.Where("organization_id = ?", orgID).Order("name").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch mandatory credentials",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"credentials": credentials,
+		},
+	})
+}
+
+// CreateMandatoryCredential adds a credential every worker must hold
+func (h *Handler) CreateMandatoryCredential(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req MandatoryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	credential := models.MandatoryCredential{
+		OrganizationID: orgID.(string),
+		Name:           strings.TrimSpace(req.Name),
+		Category:       req.Category,
+		Description:    req.Description,
+		RequiresExpiry: req.RequiresExpiry,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		CreatedBy:      h.GetUserIDFromContext(c),
+	}
+	if h.credentialExists(orgID.(string), credential.Name, "") {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDENTIAL_EXISTS",
+				"message": "A mandatory credential with this name already exists",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Create(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create mandatory credential",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    credential,
+		"message": "Mandatory credential created successfully",
+	})
+}
+
+// credentialExists reports whether the organization already requires a credential with the
+// name, ignoring the credential with excludeID
+func (h *Handler) credentialExists(orgID, name, excludeID string) bool {
+	query := h.DB.Model(&models.MandatoryCredential{}).Where("organization_id = ? AND LOWER(name) = ?", orgID, strings.ToLower(name))
+	if excludeID != "" {
+		query = query.Where("id != ?", excludeID)
+	}
+	var existing int64
+	query.Count(&existing)
+	return existing > 0
+}
+
+// UpdateMandatoryCredential changes a mandatory credential, or retires it with is_active
+func (h *Handler) UpdateMandatoryCredential(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req MandatoryCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var credential models.MandatoryCredential
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&credential).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDENTIAL_NOT_FOUND",
+				"message": "Mandatory credential not found",
+			},
+		})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if h.credentialExists(orgID.(string), name, credential.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDENTIAL_EXISTS",
+				"message": "A mandatory credential with this name already exists",
+			},
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":            name,
+		"category":        req.Category,
+		"description":     req.Description,
+		"requires_expiry": req.RequiresExpiry,
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if err := h.DB.Model(&credential).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update mandatory credential",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    credential,
+		"message": "Mandatory credential updated successfully",
+	})
+}
+
+// DeleteMandatoryCredential stops requiring a credential
+func (h *Handler) DeleteMandatoryCredential(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	result := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Delete(&models.MandatoryCredential{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete mandatory credential",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDENTIAL_NOT_FOUND",
+				"message": "Mandatory credential not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mandatory credential deleted successfully",
+	})
+}
+
+// AddDefaultCredentials requires the standard NDIS worker checks: Worker Screening, Working
+// With Children, First Aid and CPR. Credentials the organization already has are left alone.
+func (h *Handler) AddDefaultCredentials(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	added := []models.MandatoryCredential{}
+	for _, credential := range models.DefaultMandatoryCredentials {
+		if h.credentialExists(orgID.(string), credential.Name, "") {
+			continue
+		}
+		credential.OrganizationID = orgID.(string)
+		credential.IsActive = true
+		credential.CreatedBy = h.GetUserIDFromContext(c)
+		added = append(added, credential)
+	}
+
+	if len(added) > 0 {
+		if err := h.DB.Create(&added).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to add default credentials",
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"added": added,
+		},
+		"message": fmt.Sprintf("%d default credential(s) added", len(added)),
+	})
+}
+
+// GetCredentialCompliance checks each care worker, or the worker given by ?user_id=,
+// against the mandatory credentials as of today. Add ?non_compliant=true to list only the
+// workers who can't be assigned.
+func (h *Handler) GetCredentialCompliance(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var staff []models.User
+	var err error
+	if userID := c.Query("user_id"); userID != "" {
+		err = h.DB.Where("id = ? AND organization_id = ?", userID, orgID).Find(&staff).Error
+	} else {
+		staff, err = h.careWorkers(orgID.(string))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load workers",
+			},
+		})
+		return
+	}
+
+	credentials, err := h.mandatoryCredentials(orgID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch mandatory credentials",
+			},
+		})
+		return
+	}
+
+	ids := make([]string, 0, len(staff))
+	for _, user := range staff {
+		ids = append(ids, user.ID)
+	}
+	var skills []models.WorkerSkill
+	if len(ids) > 0 {
+		if err := h.DB.Where("user_id IN ? AND is_active = ?", ids, true).Find(&skills).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to load worker skills",
+				},
+			})
+			return
+		}
+	}
+	byWorker := map[string][]models.WorkerSkill{}
+	for _, skill := range skills {
+		byWorker[skill.UserID] = append(byWorker[skill.UserID], skill)
+	}
+
+	now := time.Now()
+	onlyGaps := c.Query("non_compliant") == "true"
+	workers := []gin.H{}
+	nonCompliant := 0
+	for _, user := range staff {
+		gaps := []credentialGap{}
+		for _, credential := range credentials {
+			if status, skill := credentialStatus(credential, byWorker[user.ID], now); status != models.CredentialCompliant {
+				gaps = append(gaps, describeGap(credential, status, skill))
+			}
+		}
+		if len(gaps) > 0 {
+			nonCompliant++
+		} else if onlyGaps {
+			continue
+		}
+		workers = append(workers, gin.H{
+			"user_id":   user.ID,
+			"name":      user.FirstName + " " + user.LastName,
+			"compliant": len(gaps) == 0,
+			"gaps":      gaps,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"credentials":   credentials,
+			"workers":       workers,
+			"non_compliant": nonCompliant,
+		},
+	})
+}
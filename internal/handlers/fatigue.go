@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+)
+
+// fatigueRuleSettings maps the fatigue rule names used in organization settings to the
+// scheduler's reason codes
+var fatigueRuleSettings = map[string]string{
+	"rest_period":      scheduling.ReasonRestPeriod,
+	"daily_hours":      scheduling.ReasonDailyHours,
+	"weekly_hours":     scheduling.ReasonWeeklyHours,
+	"consecutive_days": scheduling.ReasonConsecutiveDays,
+}
+
+// fatigueRules returns how the organization enforces each fatigue rule. Every rule blocks
+// unless the organization has chosen to only warn.
+func (h *Handler) fatigueRules(orgID string) scheduling.Rules {
+	rules := scheduling.Rules{}
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return rules
+	}
+	for name, enforcement := range settings.FatigueRules {
+		if rule, ok := fatigueRuleSettings[name]; ok {
+			if value, ok := enforcement.(string); ok {
+				rules[rule] = value
+			}
+		}
+	}
+	return rules
+}
+
+// fatigueViolations returns the rest period and working hours rules a booking would break
+// for the staff member
+func (h *Handler) fatigueViolations(orgID, staffID string, shift models.Shift, participant models.Participant) ([]scheduling.Violation, error) {
+	loc, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		loc = time.UTC
+	}
+	var staff models.User
+	if err := h.DB.First(&staff, "id = ?", staffID).Error; err != nil {
+		return nil, err
+	}
+	workers, err := h.schedulingWorkers([]models.User{staff}, shift.StartTime, shift.EndTime, loc)
+	if err != nil {
+		return nil, err
+	}
+	return workers[0].Fatigue(schedulingShift(shift, participant, nil, nil), loc, h.fatigueRules(orgID)), nil
+}
+
+// fatigueConflict checks a shift generated for a staff member against the fatigue rules,
+// returning the conflict when a rule the organization enforces is broken
+func (h *Handler) fatigueConflict(orgID, staffID string, shift models.Shift, participant models.Participant) (*bookingConflict, error) {
+	if staffID == "" {
+		return nil, nil
+	}
+
+	violations, err := h.fatigueViolations(orgID, staffID, shift, participant)
+	if err != nil {
+		return nil, err
+	}
+	for _, violation := range violations {
+		if violation.Blocks() {
+			return &bookingConflict{
+				Code:    "FATIGUE_RULES_BROKEN",
+				Message: "Staff member would break the organization's fatigue rules",
+				Details: violations,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkFatigue checks a booking against the staff member's rest periods and working hours.
+// It writes the error response and returns false when a rule the organization enforces is
+// broken, otherwise it returns the rules that are only warned about.
+func (h *Handler) checkFatigue(c *gin.Context, orgID, staffID string, shift models.Shift, participant models.Participant) ([]scheduling.Violation, bool) {
+	warnings := []scheduling.Violation{}
+	if staffID == "" {
+		return warnings, true
+	}
+
+	violations, err := h.fatigueViolations(orgID, staffID, shift, participant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load the worker's schedule",
+			},
+		})
+		return nil, false
+	}
+
+	for _, violation := range violations {
+		if violation.Blocks() {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "FATIGUE_RULES_BROKEN",
+					"message": "Staff member would break the organization's fatigue rules",
+					"details": violations,
+				},
+			})
+			return nil, false
+		}
+	}
+	return append(warnings, violations...), true
+}
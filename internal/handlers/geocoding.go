@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/config"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+)
+
+// newGeocoder returns the built-in postcode geocoder, or one for the postcode table in
+// GEOCODER_POSTCODES_FILE when that is configured
+func newGeocoder(cfg *config.Config) geo.Geocoder {
+	if cfg == nil || cfg.PostcodesFile == "" {
+		return geo.DefaultGeocoder()
+	}
+
+	file, err := os.Open(cfg.PostcodesFile)
+	if err == nil {
+		defer file.Close()
+		var centroids []geo.Centroid
+		if centroids, err = geo.LoadPostcodes(file); err == nil {
+			return geo.NewPostcodeGeocoder(centroids)
+		}
+	}
+	log.Printf("Failed to load postcodes from %s, using the built-in table: %v", cfg.PostcodesFile, err)
+	return geo.DefaultGeocoder()
+}
+
+// geocodeAddress fills in an address's coordinates. Coordinates sent with the address were
+// placed by hand and are kept; otherwise an address that can't be placed is left without any.
+func (h *Handler) geocodeAddress(address *models.Address) error {
+	if address.Latitude != nil && address.Longitude != nil {
+		return nil
+	}
+	address.Latitude, address.Longitude = nil, nil
+	if address.Suburb == "" && address.Postcode == "" {
+		return geo.ErrNotFound
+	}
+
+	point, err := h.Geocoder.Geocode(geo.Address{
+		Street:   address.Street,
+		Suburb:   address.Suburb,
+		State:    address.State,
+		Postcode: address.Postcode,
+	})
+	if err != nil {
+		return err
+	}
+	address.Latitude, address.Longitude = &point.Lat, &point.Lng
+	return nil
+}
+
+// geocodeShift places a shift from its location. Locations that can't be placed, such as
+// "Participant home", are left without coordinates and the participant's address is used.
+func (h *Handler) geocodeShift(shift *models.Shift) {
+	shift.Latitude, shift.Longitude = nil, nil
+	address := geo.ParseAddress(shift.Location)
+	if address.Suburb == "" && address.Postcode == "" {
+		return
+	}
+	if point, err := h.Geocoder.Geocode(address); err == nil {
+		shift.Latitude, shift.Longitude = &point.Lat, &point.Lng
+	}
+}
+
+// addressPoint returns where an address has been placed, or nil if it hasn't been
+func addressPoint(address models.Address) *geo.Point {
+	if address.Latitude == nil || address.Longitude == nil {
+		return nil
+	}
+	return &geo.Point{Lat: *address.Latitude, Lng: *address.Longitude}
+}
+
+// shiftPoint returns where a shift takes place: its own location when that could be placed,
+// otherwise the participant's address
+func shiftPoint(shift models.Shift, participant models.Participant) *geo.Point {
+	if shift.Latitude != nil && shift.Longitude != nil {
+		return &geo.Point{Lat: *shift.Latitude, Lng: *shift.Longitude}
+	}
+	return addressPoint(participant.Address)
+}
+
+// GeocodeAddresses places the participant and worker home addresses recorded before
+// geocoding was available, or since changed by hand in the database. Addresses that already
+// have coordinates are left alone.
+func (h *Handler) GeocodeAddresses(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participants []models.Participant
+	if err := h.DB.Where("organization_id = ? AND (address_latitude IS NULL OR address_longitude IS NULL)", orgID).
+		Find(&participants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participants",
+			},
+		})
+		return
+	}
+	var preferences []models.WorkerPreferences
+	if err := h.DB.Joins("JOIN users ON worker_preferences.user_id = users.id").
+		Where("users.organization_id = ? AND (worker_preferences.home_latitude IS NULL OR worker_preferences.home_longitude IS NULL)", orgID).
+		Find(&preferences).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch worker preferences",
+			},
+		})
+		return
+	}
+
+	unplaced := []gin.H{}
+	placedParticipants := 0
+	for _, participant := range participants {
+		if err := h.geocodeAddress(&participant.Address); err != nil {
+			unplaced = append(unplaced, gin.H{"participant_id": participant.ID, "reason": err.Error()})
+			continue
+		}
+		if err := h.DB.Model(&participant).Updates(map[string]interface{}{
+			"address_latitude":  participant.Address.Latitude,
+			"address_longitude": participant.Address.Longitude,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update participant",
+				},
+			})
+			return
+		}
+		placedParticipants++
+	}
+
+	placedWorkers := 0
+	for _, p := range preferences {
+		if err := h.geocodeAddress(&p.HomeAddress); err != nil {
+			unplaced = append(unplaced, gin.H{"user_id": p.UserID, "reason": err.Error()})
+			continue
+		}
+		if err := h.DB.Model(&p).Updates(map[string]interface{}{
+			"home_latitude":  p.HomeAddress.Latitude,
+			"home_longitude": p.HomeAddress.Longitude,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update worker preferences",
+				},
+			})
+			return
+		}
+		placedWorkers++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"participants": placedParticipants,
+			"workers":      placedWorkers,
+			"unplaced":     unplaced,
+		},
+	})
+}
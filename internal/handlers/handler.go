@@ -5,20 +5,29 @@ import (
 	
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/config"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
 	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/middleware"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/notify"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	DB     *gorm.DB
-	Config *config.Config
+	DB       *gorm.DB
+	Config   *config.Config
+	Mailer   notify.Mailer // nil when SMTP is not configured
+	Geocoder geo.Geocoder
 }
 
 func NewHandler(db *gorm.DB, cfg *config.Config) *Handler {
-	return &Handler{
-		DB:     db,
-		Config: cfg,
+	h := &Handler{
+		DB:       db,
+		Config:   cfg,
+		Geocoder: newGeocoder(cfg),
 	}
+	if cfg != nil && cfg.SMTPHost != "" {
+		h.Mailer = notify.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	return h
 }
 
 // Helper methods for handlers
@@ -132,6 +141,22 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				participants.POST("", h.CreateParticipant)
 				participants.PUT("/:id", h.UpdateParticipant)
 				participants.DELETE("/:id", h.DeleteParticipant)
+				participants.GET("/:id/budget-ledger", h.GetParticipantBudgetLedger)
+				participants.GET("/:id/plans", h.GetParticipantPlans)
+				participants.GET("/:id/plans/:planId", h.GetParticipantPlan)
+				participants.POST("/:id/plans", middleware.RequireRole("admin", "manager"), h.CreateParticipantPlan)
+				participants.PUT("/:id/plans/:planId", middleware.RequireRole("admin", "manager"), h.UpdateParticipantPlan)
+				participants.GET("/:id/contacts", h.GetParticipantContacts)
+				participants.POST("/:id/contacts", middleware.RequireRole("admin", "manager"), h.AddParticipantContact)
+				participants.PUT("/:id/contacts/:contactId", middleware.RequireRole("admin", "manager"), h.UpdateParticipantContact)
+				participants.DELETE("/:id/contacts/:contactId", middleware.RequireRole("admin", "manager"), h.RemoveParticipantContact)
+				participants.GET("/:id/requirements", h.GetParticipantRequirements)
+				participants.POST("/:id/requirements", middleware.RequireRole("admin", "manager"), h.AddParticipantRequirement)
+				participants.PUT("/:id/requirements/:requirementId", middleware.RequireRole("admin", "manager"), h.UpdateParticipantRequirement)
+				participants.DELETE("/:id/requirements/:requirementId", middleware.RequireRole("admin", "manager"), h.RemoveParticipantRequirement)
+				participants.GET("/:id/workers", h.GetParticipantWorkers)
+				participants.PUT("/:id/workers/:staffId", middleware.RequireRole("admin", "manager"), h.SetParticipantWorker)
+				participants.DELETE("/:id/workers/:staffId", middleware.RequireRole("admin", "manager"), h.RemoveParticipantWorker)
 			}
 
 			// Shift routes
@@ -143,6 +168,77 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				shifts.PUT("/:id", h.UpdateShift)
 				shifts.PATCH("/:id/status", h.UpdateShiftStatus)
 				shifts.DELETE("/:id", h.DeleteShift)
+				shifts.GET("/:id/suggestions", middleware.RequireRole("admin", "manager"), h.GetShiftSuggestions)
+				shifts.POST("/:id/assign", middleware.RequireRole("admin", "manager"), h.AssignShift)
+				shifts.POST("/:id/broadcast", middleware.RequireRole("admin", "manager"), h.BroadcastShift)
+				shifts.POST("/:id/requirements", middleware.RequireRole("admin", "manager"), h.AddShiftRequirement)
+			}
+
+			// Shift swap and drop request routes
+			shiftSwaps := protected.Group("/shift-swaps")
+			{
+				shiftSwaps.GET("", h.GetShiftSwaps)
+				shiftSwaps.GET("/:id", h.GetShiftSwap)
+				shiftSwaps.POST("", h.CreateShiftSwap)
+				shiftSwaps.POST("/:id/accept", h.AcceptShiftSwap)
+				shiftSwaps.POST("/:id/cancel", h.CancelShiftSwap)
+				shiftSwaps.POST("/:id/approve", middleware.RequireRole("admin", "manager"), h.ApproveShiftSwap)
+				shiftSwaps.POST("/:id/reject", middleware.RequireRole("admin", "manager"), h.RejectShiftSwap)
+			}
+
+			// Open shift broadcast and claim routes
+			shiftBroadcasts := protected.Group("/shift-broadcasts")
+			{
+				shiftBroadcasts.GET("", middleware.RequireRole("admin", "manager"), h.GetShiftBroadcasts)
+				shiftBroadcasts.GET("/:id", middleware.RequireRole("admin", "manager"), h.GetShiftBroadcast)
+				shiftBroadcasts.DELETE("/:id", middleware.RequireRole("admin", "manager"), h.CancelShiftBroadcast)
+			}
+
+			shiftClaims := protected.Group("/shift-claims")
+			{
+				shiftClaims.POST("/:id/approve", middleware.RequireRole("admin", "manager"), h.ApproveShiftClaim)
+				shiftClaims.POST("/:id/reject", middleware.RequireRole("admin", "manager"), h.RejectShiftClaim)
+			}
+
+			openShifts := protected.Group("/open-shifts")
+			{
+				openShifts.GET("", h.GetOpenShifts)
+				openShifts.POST("/:id/claim", h.ClaimOpenShift)
+			}
+
+			// Recurring shift series routes
+			shiftSeries := protected.Group("/shift-series")
+			{
+				shiftSeries.GET("", h.GetShiftSeriesList)
+				shiftSeries.GET("/:id", h.GetShiftSeries)
+				shiftSeries.POST("", middleware.RequireRole("admin", "manager"), h.CreateShiftSeries)
+				shiftSeries.PUT("/:id/occurrences/:shiftId", middleware.RequireRole("admin", "manager"), h.UpdateSeriesOccurrence)
+				shiftSeries.DELETE("/:id/occurrences/:shiftId", middleware.RequireRole("admin", "manager"), h.DeleteSeriesOccurrence)
+			}
+
+			// Roster template and publishing routes
+			rosterTemplates := protected.Group("/roster-templates")
+			{
+				rosterTemplates.GET("", h.GetRosterTemplates)
+				rosterTemplates.GET("/:id", h.GetRosterTemplate)
+				rosterTemplates.POST("", middleware.RequireRole("admin", "manager"), h.CreateRosterTemplate)
+				rosterTemplates.PUT("/:id", middleware.RequireRole("admin", "manager"), h.UpdateRosterTemplate)
+				rosterTemplates.DELETE("/:id", middleware.RequireRole("admin", "manager"), h.DeleteRosterTemplate)
+				rosterTemplates.POST("/:id/apply", middleware.RequireRole("admin", "manager"), h.ApplyRosterTemplate)
+			}
+
+			rosters := protected.Group("/rosters")
+			{
+				rosters.GET("/changes", middleware.RequireRole("admin", "manager"), h.GetRosterChanges)
+				rosters.POST("/publish", middleware.RequireRole("admin", "manager"), h.PublishRoster)
+				rosters.POST("/solve", middleware.RequireRole("admin", "manager"), h.SolveRoster)
+			}
+
+			// Notification routes for the current user
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", h.GetNotifications)
+				notifications.PATCH("/:id/read", h.MarkNotificationRead)
 			}
 
 			// Document routes
@@ -169,6 +265,19 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				// Organization settings routes
 				organization.GET("/settings", h.GetOrganizationSettings)
 				organization.PUT("/settings", middleware.RequireRole("admin"), h.UpdateOrganizationSettings)
+				organization.GET("/rate-card", h.GetRateCard)
+				organization.PUT("/rate-card", middleware.RequireRole("admin"), h.UpdateRateCard)
+
+				// Mandatory worker credentials
+				organization.GET("/credentials", h.GetMandatoryCredentials)
+				organization.POST("/credentials", middleware.RequireRole("admin", "manager"), h.CreateMandatoryCredential)
+				organization.POST("/credentials/defaults", middleware.RequireRole("admin", "manager"), h.AddDefaultCredentials)
+				organization.GET("/credentials/compliance", middleware.RequireRole("admin", "manager"), h.GetCredentialCompliance)
+				organization.PUT("/credentials/:id", middleware.RequireRole("admin", "manager"), h.UpdateMandatoryCredential)
+				organization.DELETE("/credentials/:id", middleware.RequireRole("admin", "manager"), h.DeleteMandatoryCredential)
+
+				// Place addresses recorded before geocoding
+				organization.POST("/geocode", middleware.RequireRole("admin", "manager"), h.GeocodeAddresses)
 
 				// Organization subscription routes
 				organization.GET("/subscription", middleware.RequireRole("admin"), h.GetOrganizationSubscription)
@@ -219,6 +328,16 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				emergencyContacts.DELETE("/:id", h.DeleteEmergencyContact)
 			}
 
+			// External contacts such as plan managers, support coordinators and GPs
+			contacts := protected.Group("/contacts")
+			{
+				contacts.GET("", h.GetExternalContacts)
+				contacts.GET("/:id", h.GetExternalContact)
+				contacts.POST("", middleware.RequireRole("admin", "manager"), h.CreateExternalContact)
+				contacts.PUT("/:id", middleware.RequireRole("admin", "manager"), h.UpdateExternalContact)
+				contacts.DELETE("/:id", middleware.RequireRole("admin", "manager"), h.DeleteExternalContact)
+			}
+
 			// Care Plan routes
 			carePlans := protected.Group("/care-plans")
 			{
@@ -235,9 +354,57 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 			{
 				billing.GET("", h.GetBilling)
 				billing.GET("/:id", h.GetBillingRecord)
-				billing.POST("/generate", h.GenerateInvoice)
-				billing.POST("/:id/payment", h.MarkAsPaid)
+				billing.POST("/generate", middleware.RequireRole("admin", "manager"), h.GenerateInvoice)
+				billing.POST("/:id/payment", middleware.RequireRole("admin", "manager"), h.MarkAsPaid)
 				billing.GET("/:id/download", h.DownloadInvoice)
+				billing.POST("/:id/credit-notes", middleware.RequireRole("admin", "manager"), h.CreateCreditNote)
+				billing.POST("/:id/void", middleware.RequireRole("admin", "manager"), h.VoidInvoice)
+				billing.POST("/:id/reissue", middleware.RequireRole("admin", "manager"), h.ReissueInvoice)
+				billing.POST("/:id/send", middleware.RequireRole("admin", "manager"), h.SendInvoice)
+				billing.POST("/receivables/run", middleware.RequireRole("admin", "manager"), h.RunReceivables)
+				billing.POST("/portal-exports", middleware.RequireRole("admin", "manager"), h.ExportPortalInvoices)
+			}
+
+			// Worker travel reimbursement routes
+			reimbursements := protected.Group("/reimbursements")
+			{
+				reimbursements.GET("", h.GetReimbursements)
+				reimbursements.PATCH("/:id/status", middleware.RequireRole("admin", "manager"), h.UpdateReimbursementStatus)
+			}
+
+			// Accounting export routes
+			accountingExports := protected.Group("/accounting/exports")
+			{
+				accountingExports.GET("", h.GetAccountingExports)
+				accountingExports.GET("/:id", h.GetAccountingExport)
+				accountingExports.POST("", middleware.RequireRole("admin", "manager"), h.CreateAccountingExport)
+				accountingExports.GET("/:id/download", h.DownloadAccountingExport)
+			}
+
+			// NDIA bulk claim routes
+			ndiaClaims := protected.Group("/ndia-claims")
+			{
+				ndiaClaims.GET("", h.GetNDIAClaims)
+				ndiaClaims.GET("/:id", h.GetNDIAClaim)
+				ndiaClaims.GET("/:id/download", h.DownloadNDIAClaim)
+				ndiaClaims.POST("/generate", middleware.RequireRole("admin", "manager"), h.GenerateNDIAClaim)
+				ndiaClaims.POST("/remittance", middleware.RequireRole("admin", "manager"), h.ImportNDIARemittance)
+			}
+
+			// NDIS support catalogue routes
+			supportItems := protected.Group("/support-items")
+			{
+				supportItems.GET("", h.GetSupportItems)
+				supportItems.GET("/:id", h.GetSupportItem)
+				supportItems.POST("/import", middleware.RequireSuperAdmin(), h.ImportSupportCatalogue)
+			}
+
+			// Public holidays used for penalty rates
+			publicHolidays := protected.Group("/public-holidays")
+			{
+				publicHolidays.GET("", h.GetPublicHolidays)
+				publicHolidays.POST("", middleware.RequireRole("admin"), h.CreatePublicHoliday)
+				publicHolidays.DELETE("/:id", middleware.RequireRole("admin"), h.DeletePublicHoliday)
 			}
 
 			// Reports routes
@@ -249,6 +416,7 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				reports.GET("/service-hours", h.GetServiceHoursReport)
 				reports.GET("/participants", h.GetParticipantReport)
 				reports.GET("/staff-performance", h.GetStaffPerformance)
+				reports.GET("/aged-receivables", h.GetAgedReceivables)
 				reports.GET("/:type/export", h.ExportReport)
 				reports.GET("/templates", h.GetReportTemplates)
 			}
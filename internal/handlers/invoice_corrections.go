@@ -0,0 +1,705 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// errInvoiceNotVoidable is returned when an invoice was paid or voided while it was being voided
+	errInvoiceNotVoidable = errors.New("invoice has been paid or voided")
+
+	errInvoiceVoid          = errors.New("invoice is void")
+	errInvalidInvoiceLine   = errors.New("line is not on the invoice")
+	errCreditExceedsLine    = errors.New("credit exceeds what is left on the line")
+	errCreditExceedsBalance = errors.New("credit exceeds the outstanding balance")
+)
+
+type CreditNoteRequest struct {
+	Reason string                  `json:"reason" binding:"required"`
+	Amount float64                 `json:"amount" binding:"omitempty,gt=0"` // including GST, spread across the invoice lines
+	Lines  []CreditNoteLineRequest `json:"lines" binding:"omitempty,dive"`
+}
+
+type CreditNoteLineRequest struct {
+	InvoiceLineID string  `json:"invoice_line_id" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"` // including GST
+}
+
+type VoidInvoiceRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ReissueInvoiceRequest struct {
+	Reason      string   `json:"reason"`    // required unless the invoice is already void
+	ShiftIDs    []string `json:"shift_ids"` // defaults to the shifts on the original invoice
+	DueDate     string   `json:"due_date"`  // YYYY-MM-DD, defaults to the organization's payment terms
+	Description string   `json:"description"`
+}
+
+// invoiceChain lists the invoices an invoice replaced and was replaced by, oldest first.
+// It returns nil for an invoice that was never re-issued.
+func (h *Handler) invoiceChain(invoice models.Invoice) []models.InvoiceChainLink {
+	if invoice.ReplacesInvoiceID == nil && invoice.ReplacedByInvoiceID == nil {
+		return nil
+	}
+
+	link := func(i models.Invoice) models.InvoiceChainLink {
+		return models.InvoiceChainLink{ID: i.ID, InvoiceNumber: i.InvoiceNumber, Status: i.Status, IssueDate: i.IssueDate, Total: i.Total}
+	}
+
+	// Walk back to the first invoice, then forward to the latest. The seen set guards
+	// against a corrupt chain looping forever.
+	seen := map[string]bool{invoice.ID: true}
+	first := invoice
+	for first.ReplacesInvoiceID != nil && !seen[*first.ReplacesInvoiceID] {
+		var previous models.Invoice
+		if h.DB.First(&previous, "id = ? AND organization_id = ?", *first.ReplacesInvoiceID, invoice.OrganizationID).Error != nil {
+			break
+		}
+		seen[previous.ID] = true
+		first = previous
+	}
+
+	chain := []models.InvoiceChainLink{link(first)}
+	current := first
+	seen = map[string]bool{first.ID: true}
+	for current.ReplacedByInvoiceID != nil && !seen[*current.ReplacedByInvoiceID] {
+		var next models.Invoice
+		if h.DB.First(&next, "id = ? AND organization_id = ?", *current.ReplacedByInvoiceID, invoice.OrganizationID).Error != nil {
+			break
+		}
+		seen[next.ID] = true
+		chain = append(chain, link(next))
+		current = next
+	}
+	return chain
+}
+
+// fetchInvoice loads an organization's invoice, writing the error response when it cannot
+func (h *Handler) fetchInvoice(c *gin.Context, invoiceID string, orgID interface{}, invoice *models.Invoice) bool {
+	if err := h.DB.Where("id = ? AND organization_id = ?", invoiceID, orgID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("service_date ASC, id ASC") }).
+		First(invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_FOUND",
+					"message": "Invoice not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// splitCredit divides a credit including GST into its GST free and GST parts in the
+// proportions of the invoice line it credits
+func splitCredit(line models.InvoiceLine, credit float64) (float64, float64) {
+	total := line.Amount + line.GSTAmount
+	if total <= 0 {
+		return credit, 0
+	}
+	gst := roundCurrency(credit * line.GSTAmount / total)
+	return roundCurrency(credit - gst), gst
+}
+
+// creditShiftBudgets returns funding for the shifts a credit note touched, so each shift
+// draws down what was invoiced for it less everything credited against it
+func creditShiftBudgets(tx *gorm.DB, invoice models.Invoice, creditNote models.CreditNote, userID string) error {
+	shiftIDs := []string{}
+	seen := map[string]bool{}
+	for _, line := range creditNote.Lines {
+		if line.ShiftID != nil && !seen[*line.ShiftID] {
+			seen[*line.ShiftID] = true
+			shiftIDs = append(shiftIDs, *line.ShiftID)
+		}
+	}
+
+	for _, shiftID := range shiftIDs {
+		var shift models.Shift
+		if err := tx.First(&shift, "id = ?", shiftID).Error; err != nil {
+			return err
+		}
+
+		invoiced := 0.0
+		for _, line := range invoice.Lines {
+			if line.ShiftID != nil && *line.ShiftID == shiftID {
+				invoiced += line.Amount
+			}
+		}
+		var credited float64
+		if err := tx.Model(&models.CreditNoteLine{}).
+			Joins("JOIN credit_notes ON credit_notes.id = credit_note_lines.credit_note_id").
+			Where("credit_notes.invoice_id = ? AND credit_note_lines.shift_id = ?", invoice.ID, shiftID).
+			Select("COALESCE(SUM(credit_note_lines.amount), 0)").Scan(&credited).Error; err != nil {
+			return err
+		}
+
+		_, err := models.DrawDownShift(tx, &shift, roundCurrency(invoiced-credited), models.BudgetTransactionCredit,
+			"Credited on "+creditNote.CreditNoteNumber, userID, &invoice.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// voidInvoice cancels an unpaid invoice and releases its shifts back to unbilled, returning
+// their budget drawdown to what they cost before they were invoiced. Call it inside a transaction.
+func (h *Handler) voidInvoice(tx *gorm.DB, invoice *models.Invoice, reason, userID string) error {
+	now := time.Now()
+	result := tx.Model(&models.Invoice{}).
+		Where("id = ? AND status <> ? AND amount_paid = 0", invoice.ID, "void").
+		Updates(map[string]interface{}{
+			"status":      "void",
+			"voided_at":   now,
+			"voided_by":   userID,
+			"void_reason": reason,
+			"balance_due": 0,
+			"document_id": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return errInvoiceNotVoidable
+	}
+
+	var shifts []models.Shift
+	if err := tx.Where("invoice_id = ?", invoice.ID).
+		Preload("SupportItem").
+		Preload("CostBands", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		Find(&shifts).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Shift{}).Where("invoice_id = ?", invoice.ID).Update("invoice_id", nil).Error; err != nil {
+		return err
+	}
+
+	for i := range shifts {
+		shifts[i].InvoiceID = nil
+		amount := billableAmount(shifts[i])
+		if shifts[i].Status == "completed" {
+			amount = h.completedShiftAmount(tx, shifts[i], invoice.OrganizationID)
+		}
+		_, err := models.DrawDownShift(tx, &shifts[i], amount, models.BudgetTransactionVoid,
+			"Released from voided "+invoice.InvoiceNumber, userID, &invoice.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	invoice.Status = "void"
+	invoice.VoidedAt = &now
+	invoice.VoidedBy = &userID
+	invoice.VoidReason = reason
+	invoice.BalanceDue = 0
+	return nil
+}
+
+// CreateCreditNote credits an invoice line by line, or spreads an amount across its lines,
+// and returns the credited funding to the participant's budget
+func (h *Handler) CreateCreditNote(c *gin.Context) {
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req CreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Amount == 0) == (len(req.Lines) == 0) {
+		details := "Provide either an amount or lines to credit"
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid credit note data",
+				"details": details,
+			},
+		})
+		return
+	}
+
+	var invoice models.Invoice
+	if !h.fetchInvoice(c, invoiceID, orgID, &invoice) {
+		return
+	}
+
+	var creditNote models.CreditNote
+	var badLine string   // the line an errInvalidInvoiceLine or errCreditExceedsLine is about
+	var lineLeft float64 // what was left to credit on it
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Re-read the balance under a row lock so concurrent credits and payments are applied
+		// one at a time, each against what the ones before it left
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", invoice.ID).Error; err != nil {
+			return err
+		}
+		if invoice.Status == "void" {
+			return errInvoiceVoid
+		}
+
+		// What is left to credit on each line, including GST
+		var previous []struct {
+			InvoiceLineID string
+			Credited      float64
+		}
+		if err := tx.Model(&models.CreditNoteLine{}).
+			Joins("JOIN credit_notes ON credit_notes.id = credit_note_lines.credit_note_id").
+			Where("credit_notes.invoice_id = ?", invoice.ID).
+			Select("credit_note_lines.invoice_line_id, SUM(credit_note_lines.amount + credit_note_lines.gst_amount) AS credited").
+			Group("credit_note_lines.invoice_line_id").Scan(&previous).Error; err != nil {
+			return err
+		}
+		remaining := map[string]float64{}
+		for _, line := range invoice.Lines {
+			remaining[line.ID] = line.Amount + line.GSTAmount
+		}
+		for _, credit := range previous {
+			remaining[credit.InvoiceLineID] = roundCurrency(remaining[credit.InvoiceLineID] - credit.Credited)
+		}
+
+		credits := map[string]float64{}
+		if len(req.Lines) > 0 {
+			for _, line := range req.Lines {
+				badLine = line.InvoiceLineID
+				if _, ok := remaining[line.InvoiceLineID]; !ok {
+					return errInvalidInvoiceLine
+				}
+				credits[line.InvoiceLineID] = roundCurrency(credits[line.InvoiceLineID] + line.Amount)
+				if credits[line.InvoiceLineID] > remaining[line.InvoiceLineID] {
+					lineLeft = remaining[line.InvoiceLineID]
+					return errCreditExceedsLine
+				}
+			}
+		} else {
+			// Spread the amount over the lines in proportion to what is left on each, with the
+			// last line taking any rounding
+			available := 0.0
+			creditable := []models.InvoiceLine{}
+			for _, line := range invoice.Lines {
+				if remaining[line.ID] > 0 {
+					available += remaining[line.ID]
+					creditable = append(creditable, line)
+				}
+			}
+			amount := roundCurrency(req.Amount)
+			allocated := 0.0
+			for i, line := range creditable {
+				share := roundCurrency(amount * remaining[line.ID] / available)
+				if i == len(creditable)-1 {
+					share = roundCurrency(amount - allocated)
+				}
+				credits[line.ID] = share
+				allocated += share
+			}
+		}
+
+		total := 0.0
+		for _, credit := range credits {
+			total += credit
+		}
+		total = roundCurrency(total)
+		if total > invoice.BalanceDue {
+			return errCreditExceedsBalance
+		}
+
+		now := time.Now()
+		creditNote = models.CreditNote{
+			OrganizationID: invoice.OrganizationID,
+			InvoiceID:      invoice.ID,
+			IssueDate:      now,
+			Reason:         req.Reason,
+			Amount:         total,
+			CreatedBy:      userID,
+		}
+		for _, line := range invoice.Lines {
+			if credits[line.ID] <= 0 {
+				continue
+			}
+			amount, gst := splitCredit(line, credits[line.ID])
+			creditNote.Lines = append(creditNote.Lines, models.CreditNoteLine{
+				InvoiceLineID: line.ID,
+				ShiftID:       line.ShiftID,
+				Description:   line.Description,
+				Amount:        amount,
+				GSTAmount:     gst,
+			})
+		}
+
+		sequence, err := models.NextSequenceNumber(tx, invoice.OrganizationID, models.SequenceTypeCreditNote)
+		if err != nil {
+			return err
+		}
+		creditNote.SequenceNumber = sequence
+		creditNote.CreditNoteNumber = fmt.Sprintf("CN-%06d", sequence)
+		if err := tx.Create(&creditNote).Error; err != nil {
+			return err
+		}
+
+		credited := roundCurrency(invoice.CreditedAmount + total)
+		if err := tx.Model(&invoice).Updates(invoiceSettled(invoice, invoice.AmountPaid, credited, now)).Error; err != nil {
+			return err
+		}
+		return creditShiftBudgets(tx, invoice, creditNote, userID)
+	})
+	if errors.Is(err, errInvoiceVoid) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVOICE_VOID",
+				"message": "A void invoice cannot be credited",
+			},
+		})
+		return
+	}
+	if errors.Is(err, errInvalidInvoiceLine) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_INVOICE_LINE",
+				"message": "Line is not on this invoice",
+				"details": badLine,
+			},
+		})
+		return
+	}
+	if errors.Is(err, errCreditExceedsLine) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDIT_EXCEEDS_LINE",
+				"message": fmt.Sprintf("Credit exceeds the %.2f left to credit on the line", lineLeft),
+				"details": badLine,
+			},
+		})
+		return
+	}
+	if errors.Is(err, errCreditExceedsBalance) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CREDIT_EXCEEDS_BALANCE",
+				"message": fmt.Sprintf("Credit exceeds the outstanding balance of %.2f", invoice.BalanceDue),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create credit note",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Payments").
+		Preload("CreditNotes", func(db *gorm.DB) *gorm.DB { return db.Order("sequence_number ASC") }).
+		Preload("CreditNotes.Lines").
+		First(&invoice, "id = ?", invoice.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    invoice,
+		"message": "Credit note created successfully",
+	})
+}
+
+// VoidInvoice cancels an unpaid invoice so its shifts can be corrected and billed again
+func (h *Handler) VoidInvoice(c *gin.Context) {
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req VoidInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var invoice models.Invoice
+	if !h.fetchInvoice(c, invoiceID, orgID, &invoice) {
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		return h.voidInvoice(tx, &invoice, req.Reason, userID)
+	})
+	if err != nil {
+		if errors.Is(err, errInvoiceNotVoidable) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_VOIDABLE",
+					"message": "Only unpaid invoices that are not already void can be voided; credit paid invoices instead",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to void invoice",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Payments").Preload("CreditNotes").First(&invoice, "id = ?", invoice.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    invoice,
+		"message": "Invoice voided successfully",
+	})
+}
+
+// ReissueInvoice replaces an invoice with a new one under a new number, voiding the
+// original first if it is not already void. The new invoice is priced from the shifts
+// as they are now, so shifts corrected after a void are billed at their corrected cost.
+func (h *Handler) ReissueInvoice(c *gin.Context) {
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req ReissueInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var original models.Invoice
+	if !h.fetchInvoice(c, invoiceID, orgID, &original) {
+		return
+	}
+	if original.ReplacedByInvoiceID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVOICE_ALREADY_REISSUED",
+				"message": "Invoice has already been re-issued",
+				"details": *original.ReplacedByInvoiceID,
+			},
+		})
+		return
+	}
+	if original.Status != "void" && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "A reason is required to void the original invoice",
+			},
+		})
+		return
+	}
+
+	shiftIDs := req.ShiftIDs
+	if len(shiftIDs) == 0 {
+		seen := map[string]bool{}
+		for _, line := range original.Lines {
+			if line.ShiftID != nil && !seen[*line.ShiftID] {
+				seen[*line.ShiftID] = true
+				shiftIDs = append(shiftIDs, *line.ShiftID)
+			}
+		}
+	}
+
+	var participant models.Participant
+	h.DB.First(&participant, "id = ?", original.ParticipantID)
+
+	var shifts []models.Shift
+	if err := h.DB.Where("id IN ? AND participant_id = ?", shiftIDs, original.ParticipantID).
+		Preload("SupportItem").
+		Preload("CostBands", func(db *gorm.DB) *gorm.DB { return db.Order("start_time ASC") }).
+		Order("start_time ASC").Find(&shifts).Error; err != nil || len(shifts) == 0 || len(shifts) != len(shiftIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SHIFTS",
+				"message": "One or more shifts were not found for this participant",
+			},
+		})
+		return
+	}
+	for i := range shifts {
+		// Shifts still on the original are released when it is voided below
+		if shifts[i].InvoiceID != nil && *shifts[i].InvoiceID == original.ID {
+			shifts[i].InvoiceID = nil
+		}
+		if status, code, message := checkInvoiceable(shifts[i]); code != "" {
+			c.JSON(status, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    code,
+					"message": message,
+					"details": shifts[i].ID,
+				},
+			})
+			return
+		}
+	}
+
+	issueDate := time.Now()
+	dueDate := issueDate.AddDate(0, 0, h.invoicePaymentTermsDays(original.OrganizationID))
+	if req.DueDate != "" {
+		parsedDue, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid due date format (YYYY-MM-DD expected)",
+				},
+			})
+			return
+		}
+		dueDate = parsedDue
+	}
+
+	description := req.Description
+	if description == "" {
+		description = original.Description
+	}
+
+	replacesID := original.ID
+	invoice := models.Invoice{
+		OrganizationID:    original.OrganizationID,
+		ParticipantID:     original.ParticipantID,
+		Status:            "draft",
+		IssueDate:         issueDate,
+		DueDate:           dueDate,
+		Description:       description,
+		ReplacesInvoiceID: &replacesID,
+		CreatedBy:         userID,
+	}
+	invoice.Lines = h.invoiceLinesFor(h.DB, shifts, participant.Remoteness, original.OrganizationID)
+	applyInvoiceTotals(&invoice)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if original.Status != "void" {
+			if err := h.voidInvoice(tx, &original, req.Reason, userID); err != nil {
+				return err
+			}
+		}
+		if err := issueInvoice(tx, &invoice, shifts, userID); err != nil {
+			return err
+		}
+
+		// Only one re-issue can win the original
+		result := tx.Model(&models.Invoice{}).
+			Where("id = ? AND replaced_by_invoice_id IS NULL", original.ID).
+			Update("replaced_by_invoice_id", invoice.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errInvoiceNotVoidable
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvoiceNotVoidable):
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_VOIDABLE",
+					"message": "Only unpaid invoices can be re-issued; credit paid invoices instead",
+				},
+			})
+		case errors.Is(err, errShiftsAlreadyInvoiced):
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_ALREADY_INVOICED",
+					"message": "One or more shifts have already been invoiced",
+				},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to re-issue invoice",
+				},
+			})
+		}
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Lines").First(&invoice, "id = ?", invoice.ID)
+	invoice.Chain = h.invoiceChain(invoice)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    invoice,
+		"message": "Invoice re-issued successfully",
+	})
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// InvoicePayer is who a participant's invoices are sent to and how
+type InvoicePayer struct {
+	Name              string  `json:"name"`
+	Email             string  `json:"email,omitempty"`
+	Channel           string  `json:"channel"` // email, portal
+	PortalName        string  `json:"portal_name,omitempty"`
+	ExternalContactID *string `json:"external_contact_id,omitempty"` // nil when invoices go to the participant
+	IsPlanManager     bool    `json:"is_plan_manager"`
+}
+
+type SendInvoiceRequest struct {
+	Channel string `json:"channel" binding:"omitempty,oneof=email portal"` // defaults to the payer's preferred channel
+}
+
+type PortalExportRequest struct {
+	ExternalContactID string `json:"external_contact_id"` // limits the export to one payer
+}
+
+// invoicePayer works out who pays a participant's invoices: the contact linked as payer,
+// then for plan managed participants their linked plan manager or the plan manager on
+// their funding details, and otherwise the participant themselves
+func (h *Handler) invoicePayer(db *gorm.DB, participant models.Participant) InvoicePayer {
+	var links []models.ParticipantContact
+	db.Where("participant_id = ?", participant.ID).Preload("ExternalContact").Order("created_at ASC").Find(&links)
+
+	var payer, planManager *models.ParticipantContact
+	for i := range links {
+		link := &links[i]
+		// Deleted or inactive contacts leave the link pointing at nothing usable
+		if link.ExternalContact.ID == "" || !link.ExternalContact.IsActive {
+			continue
+		}
+		if link.IsPayer && payer == nil {
+			payer = link
+		}
+		if link.Role == models.ContactRolePlanManager && planManager == nil {
+			planManager = link
+		}
+	}
+	if payer == nil && participant.Funding.ManagementType == "plan" {
+		payer = planManager
+	}
+
+	if payer != nil {
+		contact := payer.ExternalContact
+		return InvoicePayer{
+			Name:              contact.DisplayName(),
+			Email:             contact.Email,
+			Channel:           contact.PreferredChannel,
+			PortalName:        contact.PortalName,
+			ExternalContactID: &contact.ID,
+			IsPlanManager:     payer.Role == models.ContactRolePlanManager,
+		}
+	}
+
+	if participant.Funding.ManagementType == "plan" && participant.Funding.PlanManagerEmail != "" {
+		return InvoicePayer{
+			Name:          participant.Funding.PlanManagerName,
+			Email:         participant.Funding.PlanManagerEmail,
+			Channel:       models.DeliveryChannelEmail,
+			IsPlanManager: true,
+		}
+	}
+	return InvoicePayer{
+		Name:    strings.TrimSpace(participant.FirstName + " " + participant.LastName),
+		Email:   participant.Email,
+		Channel: models.DeliveryChannelEmail,
+	}
+}
+
+// invoiceEmail writes the email an invoice is delivered in, with its PDF attached
+func invoiceEmail(organization models.Organization, invoice models.Invoice, payer InvoicePayer, pdf []byte, loc *time.Location) notify.Message {
+	participant := invoice.Participant.FirstName + " " + invoice.Participant.LastName
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Dear %s,\n\n", payer.Name)
+	fmt.Fprintf(&body, "Please find attached invoice %s for supports provided to %s", invoice.InvoiceNumber, participant)
+	if invoice.Participant.NDISNumber != "" {
+		fmt.Fprintf(&body, " (NDIS number %s)", invoice.Participant.NDISNumber)
+	}
+	body.WriteString(".\n\n")
+	fmt.Fprintf(&body, "The amount of $%.2f is due on %s.\n\n", invoice.BalanceDue, invoice.DueDate.In(loc).Format("02/01/2006"))
+	fmt.Fprintf(&body, "Kind regards,\n%s\n", organization.Name)
+
+	return notify.Message{
+		To:      []string{payer.Email},
+		Subject: fmt.Sprintf("Invoice %s from %s", invoice.InvoiceNumber, organization.Name),
+		Body:    body.String(),
+		Attachments: []notify.Attachment{{
+			Filename:    invoice.InvoiceNumber + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdf,
+		}},
+	}
+}
+
+// invoicePDF returns the bytes of an invoice's PDF, rendering it if needed
+func (h *Handler) invoicePDF(invoiceID, userID string) ([]byte, error) {
+	document, err := h.invoiceDocument(invoiceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(document.FilePath)
+}
+
+// SendInvoice delivers an invoice to its payer by email, or queues it for upload to the payer's portal
+func (h *Handler) SendInvoice(c *gin.Context) {
+	invoiceID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	// The body is optional
+	var req SendInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var invoice models.Invoice
+	if err := h.DB.Where("id = ? AND organization_id = ?", invoiceID, orgID).Preload("Participant").First(&invoice).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVOICE_NOT_FOUND",
+				"message": "Invoice not found",
+			},
+		})
+		return
+	}
+	if invoice.Status == "void" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVOICE_VOID",
+				"message": "A void invoice cannot be sent",
+			},
+		})
+		return
+	}
+
+	payer := h.invoicePayer(h.DB, invoice.Participant)
+	channel := req.Channel
+	if channel == "" {
+		channel = payer.Channel
+	}
+
+	delivery := models.InvoiceDelivery{
+		OrganizationID:    invoice.OrganizationID,
+		InvoiceID:         invoice.ID,
+		ExternalContactID: payer.ExternalContactID,
+		Channel:           channel,
+		CreatedBy:         userID,
+	}
+
+	if channel == models.DeliveryChannelPortal {
+		var queued int64
+		h.DB.Model(&models.InvoiceDelivery{}).
+			Where("invoice_id = ? AND status = ?", invoice.ID, models.DeliveryStatusQueued).
+			Count(&queued)
+		if queued > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_ALREADY_QUEUED",
+					"message": "Invoice is already waiting to be exported for portal upload",
+				},
+			})
+			return
+		}
+
+		delivery.Recipient = payer.PortalName
+		if delivery.Recipient == "" {
+			delivery.Recipient = payer.Name
+		}
+		delivery.Status = models.DeliveryStatusQueued
+		if err := h.DB.Create(&delivery).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to queue invoice",
+				},
+			})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"data":    delivery,
+			"message": "Invoice queued for portal upload",
+		})
+		return
+	}
+
+	if h.Mailer == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EMAIL_NOT_CONFIGURED",
+				"message": "Email delivery is not configured",
+			},
+		})
+		return
+	}
+	if payer.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_PAYER_EMAIL",
+				"message": fmt.Sprintf("%s has no email address", payer.Name),
+			},
+		})
+		return
+	}
+
+	pdf, err := h.invoicePDF(invoice.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PDF_ERROR",
+				"message": "Failed to generate invoice PDF",
+			},
+		})
+		return
+	}
+
+	var organization models.Organization
+	h.DB.Where("id = ?", invoice.OrganizationID).First(&organization)
+	orgTz, err := h.getOrganizationTimezone(invoice.OrganizationID)
+	if err != nil {
+		orgTz = time.UTC
+	}
+
+	delivery.Recipient = payer.Email
+	delivery.Status = models.DeliveryStatusSent
+	if err := h.Mailer.Send(invoiceEmail(organization, invoice, payer, pdf, orgTz)); err != nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.Error = err.Error()
+	} else {
+		now := time.Now()
+		delivery.DeliveredAt = &now
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		if delivery.Status != models.DeliveryStatusSent {
+			return nil
+		}
+		return tx.Model(&models.Invoice{}).Where("id = ? AND status = ?", invoice.ID, "draft").Update("status", "sent").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record invoice delivery",
+			},
+		})
+		return
+	}
+
+	if delivery.Status == models.DeliveryStatusFailed {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"data":    delivery,
+			"error": gin.H{
+				"code":    "DELIVERY_FAILED",
+				"message": "Failed to email invoice",
+				"details": delivery.Error,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    delivery,
+		"message": "Invoice sent successfully",
+	})
+}
+
+// ExportPortalInvoices bundles the invoices queued for portal upload into a zip of PDFs with
+// a manifest, and marks them exported
+func (h *Handler) ExportPortalInvoices(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req PortalExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	// Invoices voided since they were queued are dropped from the queue
+	h.DB.Model(&models.InvoiceDelivery{}).
+		Where("organization_id = ? AND status = ? AND invoice_id IN (?)", orgID, models.DeliveryStatusQueued,
+			h.DB.Model(&models.Invoice{}).Select("id").Where("organization_id = ? AND status = ?", orgID, "void")).
+		Updates(map[string]interface{}{"status": models.DeliveryStatusFailed, "error": "Invoice was voided"})
+
+	query := h.DB.Where("organization_id = ? AND status = ?", orgID, models.DeliveryStatusQueued)
+	if req.ExternalContactID != "" {
+		query = query.Where("external_contact_id = ?", req.ExternalContactID)
+	}
+	var deliveries []models.InvoiceDelivery
+	if err := query.Order("created_at ASC").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch queued invoices",
+			},
+		})
+		return
+	}
+	if len(deliveries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOTHING_TO_EXPORT",
+				"message": "No invoices are waiting for portal upload",
+			},
+		})
+		return
+	}
+
+	orgTz, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		orgTz = time.UTC
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	var manifest bytes.Buffer
+	rows := csv.NewWriter(&manifest)
+	rows.Write([]string{"Invoice Number", "Participant", "NDIS Number", "Issue Date", "Due Date", "Total", "Balance Due", "Portal", "File"})
+
+	deliveryIDs := []string{}
+	invoiceIDs := []string{}
+	for _, delivery := range deliveries {
+		var invoice models.Invoice
+		if err := h.DB.Preload("Participant").First(&invoice, "id = ?", delivery.InvoiceID).Error; err != nil {
+			continue
+		}
+		pdf, err := h.invoicePDF(invoice.ID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PDF_ERROR",
+					"message": "Failed to generate invoice PDF",
+					"details": invoice.InvoiceNumber,
+				},
+			})
+			return
+		}
+
+		filename := invoice.InvoiceNumber + ".pdf"
+		file, err := archive.Create(filename)
+		if err == nil {
+			_, err = file.Write(pdf)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "EXPORT_ERROR",
+					"message": "Failed to build export",
+				},
+			})
+			return
+		}
+		rows.Write([]string{
+			invoice.InvoiceNumber,
+			strings.TrimSpace(invoice.Participant.FirstName + " " + invoice.Participant.LastName),
+			invoice.Participant.NDISNumber,
+			invoice.IssueDate.In(orgTz).Format("02/01/2006"),
+			invoice.DueDate.In(orgTz).Format("02/01/2006"),
+			fmt.Sprintf("%.2f", invoice.Total),
+			fmt.Sprintf("%.2f", invoice.BalanceDue),
+			delivery.Recipient,
+			filename,
+		})
+		deliveryIDs = append(deliveryIDs, delivery.ID)
+		invoiceIDs = append(invoiceIDs, invoice.ID)
+	}
+
+	rows.Flush()
+	file, err := archive.Create("manifest.csv")
+	if err == nil {
+		_, err = file.Write(manifest.Bytes())
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EXPORT_ERROR",
+				"message": "Failed to build export",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.InvoiceDelivery{}).
+			Where("id IN ?", deliveryIDs).
+			Updates(map[string]interface{}{"status": models.DeliveryStatusExported, "delivered_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Invoice{}).Where("id IN ? AND status = ?", invoiceIDs, "draft").Update("status", "sent").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to mark invoices exported",
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=portal-invoices-"+now.In(orgTz).Format("20060102-150405")+".zip")
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/pdf"
+	"gorm.io/gorm"
+)
+
+// Invoice page layout in points
+const (
+	invoiceMargin       = 40.0
+	invoiceRight        = pdf.PageWidth - invoiceMargin
+	invoiceTableBottom  = 760.0
+	invoiceFooterY      = 815.0
+	invoiceRowHeight    = 16.0
+	invoiceLineSpacing  = 11.0
+	invoiceDescWidth    = 225.0
+	invoiceColDate      = invoiceMargin + 4
+	invoiceColDesc      = invoiceMargin + 66
+	invoiceColQty       = 380.0
+	invoiceColUnitPrice = 445.0
+	invoiceColGST       = 458.0
+	invoiceColAmount    = invoiceRight - 4
+)
+
+var invoiceTextGrey = pdf.Color{R: 0.35, G: 0.35, B: 0.35}
+
+// invoicePDFData is everything printed on a tax invoice
+type invoicePDFData struct {
+	Invoice      models.Invoice
+	Organization models.Organization
+	Branding     models.OrganizationBranding
+	Location     *time.Location
+	TermsDays    int
+	Logo         *pdf.Image
+}
+
+// formatMoney renders an amount as dollars with thousands separators, e.g. $1,234.50
+func formatMoney(amount float64) string {
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+	s := strconv.FormatFloat(roundCurrency(amount), 'f', 2, 64)
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+
+	if negative {
+		return "-$" + b.String() + cents
+	}
+	return "$" + b.String() + cents
+}
+
+// formatABN groups an 11 digit ABN as 12 345 678 901
+func formatABN(abn string) string {
+	digits := strings.ReplaceAll(abn, " ", "")
+	if len(digits) != 11 {
+		return abn
+	}
+	return digits[:2] + " " + digits[2:5] + " " + digits[5:8] + " " + digits[8:]
+}
+
+// formatAddress joins the non-empty parts of an address on one line
+func formatAddress(address models.Address) string {
+	parts := []string{}
+	for _, part := range []string{address.Street, address.Suburb, strings.TrimSpace(address.State + " " + address.Postcode)} {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// loadInvoicePDFData gathers the invoice, organization and branding needed to render a PDF
+func (h *Handler) loadInvoicePDFData(invoiceID string) (*invoicePDFData, error) {
+	var data invoicePDFData
+	if err := h.DB.Preload("Participant").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("service_date ASC, id ASC") }).
+		Preload("Lines.SupportItem").
+		Preload("Payments").
+		First(&data.Invoice, "id = ?", invoiceID).Error; err != nil {
+		return nil, err
+	}
+	if err := h.DB.First(&data.Organization, "id = ?", data.Invoice.OrganizationID).Error; err != nil {
+		return nil, err
+	}
+	h.DB.Where("organization_id = ?", data.Invoice.OrganizationID).First(&data.Branding)
+
+	location, err := h.getOrganizationTimezone(data.Invoice.OrganizationID)
+	if err != nil {
+		location = time.UTC
+	}
+	data.Location = location
+	data.TermsDays = h.invoicePaymentTermsDays(data.Invoice.OrganizationID)
+
+	if path := h.resolveLogoPath(data.Branding.LogoURL); path != "" {
+		if logo, err := pdf.LoadImage(path); err == nil {
+			data.Logo = logo
+		}
+	}
+
+	return &data, nil
+}
+
+// resolveLogoPath maps a branding logo URL to a readable local file. Remote logos are not
+// fetched so rendering stays fast and repeatable.
+func (h *Handler) resolveLogoPath(logoURL string) string {
+	if logoURL == "" || strings.HasPrefix(logoURL, "http://") || strings.HasPrefix(logoURL, "https://") {
+		return ""
+	}
+
+	candidates := []string{logoURL, strings.TrimPrefix(logoURL, "/")}
+	if h.Config != nil && h.Config.UploadPath != "" {
+		candidates = append(candidates, filepath.Join(h.Config.UploadPath, strings.TrimPrefix(logoURL, "/uploads/")))
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// renderInvoicePDF lays out a tax invoice. The output depends only on its input so
+// rendering the same invoice twice gives identical bytes.
+func renderInvoicePDF(data *invoicePDFData) ([]byte, error) {
+	invoice := data.Invoice
+	org := data.Organization
+	dateFormat := func(t time.Time) string { return t.In(data.Location).Format("02/01/2006") }
+
+	primary, err := pdf.ParseHexColor(data.Branding.PrimaryColor)
+	if err != nil {
+		primary = pdf.Color{R: 0.4, G: 0.494, B: 0.918}
+	}
+
+	doc := pdf.New()
+	doc.SetInfo("Tax Invoice "+invoice.InvoiceNumber, org.Name, invoice.IssueDate)
+	doc.AddPage()
+
+	// Brand band and heading
+	doc.SetFillColor(primary)
+	doc.Rect(0, 0, pdf.PageWidth, 8, true)
+
+	y := 40.0
+	if data.Logo != nil {
+		w, h := pdf.FitImage(data.Logo, 140, 50)
+		doc.Image(data.Logo, invoiceMargin, y-10, w, h)
+		y += h + 6
+	}
+
+	doc.SetFont(pdf.HelveticaBold, 22)
+	doc.SetFillColor(primary)
+	doc.TextRight(invoiceRight, 55, "TAX INVOICE")
+
+	// Supplier details
+	doc.SetFont(pdf.HelveticaBold, 12)
+	doc.SetFillColor(pdf.Black)
+	y += 12
+	doc.Text(invoiceMargin, y, org.Name)
+	doc.SetFont(pdf.Helvetica, 9)
+	doc.SetFillColor(invoiceTextGrey)
+	supplier := []string{}
+	if org.ABN != "" {
+		supplier = append(supplier, "ABN "+formatABN(org.ABN))
+	}
+	if org.NDISReg.RegistrationNumber != "" {
+		supplier = append(supplier, "NDIS Registration "+org.NDISReg.RegistrationNumber)
+	}
+	if address := formatAddress(org.Address); address != "" {
+		supplier = append(supplier, address)
+	}
+	if org.Phone != "" {
+		supplier = append(supplier, "Phone "+org.Phone)
+	}
+	if org.Email != "" {
+		supplier = append(supplier, org.Email)
+	}
+	for _, line := range supplier {
+		y += invoiceLineSpacing + 1
+		doc.Text(invoiceMargin, y, line)
+	}
+
+	// Invoice details
+	meta := [][2]string{
+		{"Invoice number", invoice.InvoiceNumber},
+		{"Issue date", dateFormat(invoice.IssueDate)},
+		{"Due date", dateFormat(invoice.DueDate)},
+	}
+	metaY := 80.0
+	for _, row := range meta {
+		doc.SetFont(pdf.Helvetica, 9)
+		doc.SetFillColor(invoiceTextGrey)
+		doc.TextRight(invoiceRight-100, metaY, row[0])
+		doc.SetFont(pdf.HelveticaBold, 9)
+		doc.SetFillColor(pdf.Black)
+		doc.TextRight(invoiceRight, metaY, row[1])
+		metaY += invoiceLineSpacing + 3
+	}
+
+	// Bill to
+	if metaY > y {
+		y = metaY
+	}
+	y += 24
+	doc.SetFont(pdf.HelveticaBold, 10)
+	doc.SetFillColor(primary)
+	doc.Text(invoiceMargin, y, "BILL TO")
+	doc.SetFillColor(pdf.Black)
+	y += invoiceLineSpacing + 3
+	doc.SetFont(pdf.HelveticaBold, 10)
+	doc.Text(invoiceMargin, y, strings.TrimSpace(invoice.Participant.FirstName+" "+invoice.Participant.LastName))
+	doc.SetFont(pdf.Helvetica, 9)
+	if invoice.Participant.NDISNumber != "" {
+		y += invoiceLineSpacing + 1
+		doc.Text(invoiceMargin, y, "NDIS number "+invoice.Participant.NDISNumber)
+	}
+	if address := formatAddress(invoice.Participant.Address); address != "" {
+		y += invoiceLineSpacing + 1
+		doc.Text(invoiceMargin, y, address)
+	}
+	if invoice.Description != "" {
+		y += invoiceLineSpacing + 8
+		doc.SetFillColor(invoiceTextGrey)
+		for _, line := range doc.WrapText(invoice.Description, invoiceRight-invoiceMargin) {
+			doc.Text(invoiceMargin, y, line)
+			y += invoiceLineSpacing
+		}
+	}
+
+	// Line items
+	y += 16
+	drawHeader := func() {
+		doc.SetFillColor(primary)
+		doc.Rect(invoiceMargin, y, invoiceRight-invoiceMargin, invoiceRowHeight+2, true)
+		doc.SetFont(pdf.HelveticaBold, 9)
+		doc.SetFillColor(pdf.White)
+		baseline := y + 12
+		doc.Text(invoiceColDate, baseline, "Date")
+		doc.Text(invoiceColDesc, baseline, "Description")
+		doc.TextRight(invoiceColQty, baseline, "Qty")
+		doc.TextRight(invoiceColUnitPrice, baseline, "Unit price")
+		doc.Text(invoiceColGST, baseline, "GST")
+		doc.TextRight(invoiceColAmount, baseline, "Amount")
+		y += invoiceRowHeight + 2
+	}
+	drawHeader()
+
+	hasTaxable := false
+	for i, line := range invoice.Lines {
+		if line.GSTCode == "P1" {
+			hasTaxable = true
+		}
+
+		doc.SetFont(pdf.Helvetica, 9)
+		description := line.Description
+		if line.SupportItem != nil && !strings.Contains(description, line.SupportItem.ItemNumber) {
+			description = line.SupportItem.ItemNumber + " " + description
+		}
+		descLines := doc.WrapText(description, invoiceDescWidth)
+		rowHeight := invoiceRowHeight + float64(len(descLines)-1)*invoiceLineSpacing
+
+		if y+rowHeight > invoiceTableBottom {
+			doc.AddPage()
+			y = 40
+			drawHeader()
+		}
+
+		if i%2 == 1 {
+			doc.SetFillColor(primary.Lighten(0.92))
+			doc.Rect(invoiceMargin, y, invoiceRight-invoiceMargin, rowHeight, true)
+		}
+
+		doc.SetFont(pdf.Helvetica, 9)
+		doc.SetFillColor(pdf.Black)
+		baseline := y + 11
+		doc.Text(invoiceColDate, baseline, dateFormat(line.ServiceDate))
+		for j, text := range descLines {
+			doc.Text(invoiceColDesc, baseline+float64(j)*invoiceLineSpacing, text)
+		}
+		doc.TextRight(invoiceColQty, baseline, strconv.FormatFloat(line.Quantity, 'f', 2, 64)+" "+line.Unit)
+		doc.TextRight(invoiceColUnitPrice, baseline, formatMoney(line.UnitPrice))
+		doc.Text(invoiceColGST, baseline, line.GSTCode)
+		doc.TextRight(invoiceColAmount, baseline, formatMoney(line.Amount+line.GSTAmount))
+		y += rowHeight
+	}
+
+	doc.SetStrokeColor(primary)
+	doc.Line(invoiceMargin, y, invoiceRight, y, 0.75)
+
+	// Totals
+	totals := [][2]string{
+		{"Subtotal (ex GST)", formatMoney(invoice.Subtotal)},
+		{"GST", formatMoney(invoice.GSTAmount)},
+		{"Total", formatMoney(invoice.Total)},
+	}
+	if invoice.AmountPaid > 0 {
+		totals = append(totals, [2]string{"Amount paid", formatMoney(invoice.AmountPaid)})
+	}
+	if invoice.CreditedAmount > 0 {
+		totals = append(totals, [2]string{"Credited", formatMoney(invoice.CreditedAmount)})
+	}
+	totals = append(totals, [2]string{"Balance due", formatMoney(invoice.BalanceDue)})
+
+	if y+float64(len(totals)+5)*(invoiceLineSpacing+4) > invoiceTableBottom {
+		doc.AddPage()
+		y = 40
+	}
+	y += 8
+	for _, row := range totals {
+		y += invoiceLineSpacing + 4
+		font := pdf.Helvetica
+		if row[0] == "Total" || row[0] == "Balance due" {
+			font = pdf.HelveticaBold
+		}
+		doc.SetFont(font, 10)
+		doc.SetFillColor(pdf.Black)
+		doc.TextRight(invoiceColUnitPrice, y, row[0])
+		doc.TextRight(invoiceColAmount, y, row[1])
+	}
+
+	// GST treatment and payment terms
+	y += 28
+	doc.SetFont(pdf.Helvetica, 8)
+	doc.SetFillColor(invoiceTextGrey)
+	notes := []string{"GST codes: P1 taxable supply (10% GST included), P2 GST-free NDIS support, P5 out of scope."}
+	if !hasTaxable {
+		notes = append(notes, "All supports on this invoice are GST-free under section 38-38 of the GST Act.")
+	}
+	notes = append(notes, fmt.Sprintf("Payment terms: %d days. Please pay by %s quoting %s as the reference.",
+		data.TermsDays, dateFormat(invoice.DueDate), invoice.InvoiceNumber))
+	for _, note := range notes {
+		for _, line := range doc.WrapText(note, invoiceRight-invoiceMargin) {
+			doc.Text(invoiceMargin, y, line)
+			y += invoiceLineSpacing
+		}
+	}
+
+	// Footer on every page
+	footer := data.Branding.FooterText
+	if footer == "" {
+		footer = org.Name
+	}
+	pages := doc.PageCount()
+	for page := 1; page <= pages; page++ {
+		doc.SetPage(page)
+		doc.SetStrokeColor(primary)
+		doc.Line(invoiceMargin, invoiceFooterY-12, invoiceRight, invoiceFooterY-12, 0.5)
+		doc.SetFont(pdf.Helvetica, 8)
+		doc.SetFillColor(invoiceTextGrey)
+		footerLines := doc.WrapText(footer, invoiceRight-invoiceMargin-80)
+		doc.Text(invoiceMargin, invoiceFooterY, footerLines[0])
+		doc.TextRight(invoiceRight, invoiceFooterY, fmt.Sprintf("Page %d of %d", page, pages))
+	}
+
+	return doc.Bytes()
+}
+
+// invoiceDocument returns the cached PDF for an invoice, rendering and storing it on first use
+func (h *Handler) invoiceDocument(invoiceID, userID string) (*models.Document, error) {
+	var invoice models.Invoice
+	if err := h.DB.First(&invoice, "id = ?", invoiceID).Error; err != nil {
+		return nil, err
+	}
+
+	if invoice.DocumentID != nil {
+		var document models.Document
+		if err := h.DB.First(&document, "id = ?", *invoice.DocumentID).Error; err == nil {
+			if _, err := os.Stat(document.FilePath); err == nil {
+				return &document, nil
+			}
+		}
+	}
+
+	data, err := h.loadInvoicePDFData(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := renderInvoicePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadPath := "uploads"
+	if h.Config != nil && h.Config.UploadPath != "" {
+		uploadPath = h.Config.UploadPath
+	}
+	invoicesDir := filepath.Join(uploadPath, "invoices")
+	if err := os.MkdirAll(invoicesDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Name files by content so a re-render after a change never overwrites an earlier copy
+	sum := sha256.Sum256(content)
+	filename := fmt.Sprintf("%s_%s_%x.pdf", invoice.InvoiceNumber, invoice.ID, sum[:4])
+	filePath := filepath.Join(invoicesDir, filename)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		return nil, err
+	}
+
+	if userID == "" {
+		userID = invoice.CreatedBy
+	}
+	participantID := invoice.ParticipantID
+	document := models.Document{
+		ParticipantID:    &participantID,
+		UploadedBy:       userID,
+		Filename:         filename,
+		OriginalFilename: invoice.InvoiceNumber + ".pdf",
+		Title:            "Tax Invoice " + invoice.InvoiceNumber,
+		Category:         "invoice",
+		FileType:         "application/pdf",
+		FileSize:         int64(len(content)),
+		FilePath:         filePath,
+		IsActive:         true,
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		document.URL = fmt.Sprintf("/api/v1/documents/%s/download", document.ID)
+		if err := tx.Model(&document).Update("url", document.URL).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Invoice{}).Where("id = ?", invoice.ID).Update("document_id", document.ID).Error
+	})
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+
+	return &document, nil
+}
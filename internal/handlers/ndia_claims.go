@@ -0,0 +1,620 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/ndia"
+	"gorm.io/gorm"
+)
+
+// errShiftsAlreadyClaimed is returned when a shift was invoiced or claimed while the batch was being built
+var errShiftsAlreadyClaimed = errors.New("one or more shifts have already been invoiced or claimed")
+
+type GenerateNDIAClaimRequest struct {
+	StartDate     string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate       string `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	ParticipantID string `json:"participant_id"`
+}
+
+// formatClaimBatchNumber renders a sequence number as a claim batch number
+func formatClaimBatchNumber(sequence int64) string {
+	return fmt.Sprintf("CLM-%06d", sequence)
+}
+
+func (h *Handler) GetNDIAClaims(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.NDIAClaimBatch{}).Where("organization_id = ?", orgID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var batches []models.NDIAClaimBatch
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch claim batches",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"claim_batches": batches,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) GetNDIAClaim(c *gin.Context) {
+	batchID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var batch models.NDIAClaimBatch
+	if err := h.DB.Where("id = ? AND organization_id = ?", batchID, orgID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("claim_reference ASC") }).
+		First(&batch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CLAIM_BATCH_NOT_FOUND",
+					"message": "Claim batch not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch claim batch",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    batch,
+	})
+}
+
+// GenerateNDIAClaim builds a bulk payment request batch from uninvoiced completed shifts and short
+// notice cancellations of agency-managed participants
+func (h *Handler) GenerateNDIAClaim(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req GenerateNDIAClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	orgTz, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		orgTz = time.UTC
+	}
+	periodStart, startErr := time.ParseInLocation("2006-01-02", req.StartDate, orgTz)
+	periodEnd, endErr := time.ParseInLocation("2006-01-02", req.EndDate, orgTz)
+	if startErr != nil || endErr != nil || periodEnd.Before(periodStart) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": "Provide a valid start_date and end_date (YYYY-MM-DD)",
+			},
+		})
+		return
+	}
+
+	var organization models.Organization
+	if err := h.DB.Where("id = ?", orgID).First(&organization).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch organization",
+			},
+		})
+		return
+	}
+	if organization.NDISReg.RegistrationNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "MISSING_REGISTRATION_NUMBER",
+				"message": "Organization NDIS registration number is required to claim from the NDIA",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND participants.funding_management_type = ?", orgID, "agency").
+		Where("(shifts.status = ? OR (shifts.status IN ? AND shifts.cancellation_charge > 0))", "completed", []string{"cancelled", "no_show"}).
+		Where("shifts.invoice_id IS NULL AND shifts.claim_line_id IS NULL").
+		Where("shifts.start_time >= ? AND shifts.start_time < ?", periodStart, periodEnd.AddDate(0, 0, 1))
+	if req.ParticipantID != "" {
+		query = query.Where("shifts.participant_id = ?", req.ParticipantID)
+	}
+
+	var shifts []models.Shift
+	if err := query.Preload("Participant").Preload("SupportItem").Order("shifts.start_time ASC").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shifts",
+			},
+		})
+		return
+	}
+
+	skipped := []gin.H{}
+	claimable := make([]models.Shift, 0, len(shifts))
+	for _, shift := range shifts {
+		switch {
+		case shift.SupportItem == nil:
+			skipped = append(skipped, gin.H{"shift_id": shift.ID, "reason": "Shift has no support item"})
+		case shift.Participant.NDISNumber == "":
+			skipped = append(skipped, gin.H{"shift_id": shift.ID, "reason": "Participant has no NDIS number"})
+		default:
+			claimable = append(claimable, shift)
+		}
+	}
+
+	if len(claimable) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_CLAIMABLE_SHIFTS",
+				"message": "No claimable shifts found for this period",
+				"details": skipped,
+			},
+		})
+		return
+	}
+
+	batch := models.NDIAClaimBatch{
+		OrganizationID: organization.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		Status:         "generated",
+		CreatedBy:      userID,
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		sequence, err := models.NextSequenceNumber(tx, organization.ID, models.SequenceTypeNDIAClaim)
+		if err != nil {
+			return err
+		}
+		batch.BatchNumber = formatClaimBatchNumber(sequence)
+
+		total := 0.0
+		shiftLines := make([]int, 0, len(claimable)) // index of each shift's service line
+		for _, shift := range claimable {
+			shiftID := shift.ID
+			quantity := roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours())
+			amount := billableAmount(shift)
+			unitPrice := shift.HourlyRate
+			if quantity > 0 && amount != roundCurrency(quantity*unitPrice) {
+				unitPrice = roundCurrency(amount / quantity) // Average across penalty rate bands or a partial cancellation fee
+			}
+			total += amount
+
+			claimType, cancellationReason := ndia.ClaimTypeStandard, ""
+			if isChargedCancellation(shift) {
+				claimType, cancellationReason = ndia.ClaimTypeCancellation, shift.CancellationReason
+			}
+
+			shiftLines = append(shiftLines, len(batch.Lines))
+			batch.Lines = append(batch.Lines, models.NDIAClaimLine{
+				OrganizationID:        organization.ID,
+				ClaimReference:        fmt.Sprintf("%s-%04d", batch.BatchNumber, len(batch.Lines)+1),
+				ShiftID:               &shiftID,
+				ParticipantID:         shift.ParticipantID,
+				NDISNumber:            shift.Participant.NDISNumber,
+				SupportItemNumber:     shift.SupportItem.ItemNumber,
+				SupportsDeliveredFrom: shift.StartTime,
+				SupportsDeliveredTo:   shift.EndTime,
+				Quantity:              quantity,
+				UnitPrice:             unitPrice,
+				Amount:                amount,
+				GSTCode:               ndia.GSTCodeFree,
+				ClaimType:             claimType,
+				CancellationReason:    cancellationReason,
+				Status:                "pending",
+			})
+
+			// Provider travel is claimed as TRAN against the support delivered; activity based
+			// transport is a standard claim against its own support item
+			if shift.Status != "completed" {
+				continue
+			}
+			for _, charge := range h.travelCharges(tx, shift, shift.Participant.Remoteness, organization.ID) {
+				claimType := ndia.ClaimTypeTravel
+				if charge.ItemNumber == catalogue.ActivityBasedTransportItem {
+					claimType = ndia.ClaimTypeStandard
+				}
+				total += charge.Amount
+				batch.Lines = append(batch.Lines, models.NDIAClaimLine{
+					OrganizationID:        organization.ID,
+					ClaimReference:        fmt.Sprintf("%s-%04d", batch.BatchNumber, len(batch.Lines)+1),
+					ShiftID:               &shiftID,
+					ParticipantID:         shift.ParticipantID,
+					NDISNumber:            shift.Participant.NDISNumber,
+					SupportItemNumber:     charge.ItemNumber,
+					SupportsDeliveredFrom: shift.StartTime,
+					SupportsDeliveredTo:   shift.EndTime,
+					Quantity:              charge.Quantity,
+					UnitPrice:             charge.UnitPrice,
+					Amount:                charge.Amount,
+					GSTCode:               ndia.GSTCodeFree,
+					ClaimType:             claimType,
+					Status:                "pending",
+				})
+			}
+		}
+		batch.LineCount = len(batch.Lines)
+		batch.TotalAmount = roundCurrency(total)
+
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+
+		// Lock each shift against its service line so it cannot be invoiced or claimed twice
+		for _, index := range shiftLines {
+			line := batch.Lines[index]
+			result := tx.Model(&models.Shift{}).
+				Where("id = ? AND invoice_id IS NULL AND claim_line_id IS NULL", *line.ShiftID).
+				Update("claim_line_id", line.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != 1 {
+				return errShiftsAlreadyClaimed
+			}
+		}
+
+		// True up each shift's budget drawdown to the amount claimed across its lines
+		for i, index := range shiftLines {
+			claimed := 0.0
+			for _, line := range batch.Lines {
+				if *line.ShiftID == claimable[i].ID {
+					claimed += line.Amount
+				}
+			}
+			_, err := models.DrawDownShift(tx, &claimable[i], roundCurrency(claimed), models.BudgetTransactionClaim,
+				"Claimed as "+batch.Lines[index].ClaimReference, userID, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errShiftsAlreadyClaimed) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_ALREADY_CLAIMED",
+					"message": "One or more shifts were invoiced or claimed while the batch was generated",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to generate claim batch",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"batch":   batch,
+			"skipped": skipped,
+		},
+		"message": "Claim batch generated successfully",
+	})
+}
+
+// DownloadNDIAClaim returns the bulk payment request CSV for upload to the NDIA portal
+func (h *Handler) DownloadNDIAClaim(c *gin.Context) {
+	batchID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var batch models.NDIAClaimBatch
+	if err := h.DB.Where("id = ? AND organization_id = ?", batchID, orgID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("claim_reference ASC") }).
+		First(&batch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CLAIM_BATCH_NOT_FOUND",
+				"message": "Claim batch not found",
+			},
+		})
+		return
+	}
+
+	var organization models.Organization
+	h.DB.Where("id = ?", orgID).First(&organization)
+
+	orgTz, err := h.getOrganizationTimezone(organization.ID)
+	if err != nil {
+		orgTz = time.UTC
+	}
+
+	lines := make([]ndia.ClaimLine, 0, len(batch.Lines))
+	for _, line := range batch.Lines {
+		lines = append(lines, ndia.ClaimLine{
+			RegistrationNumber:    organization.NDISReg.RegistrationNumber,
+			NDISNumber:            line.NDISNumber,
+			SupportsDeliveredFrom: line.SupportsDeliveredFrom.In(orgTz),
+			SupportsDeliveredTo:   line.SupportsDeliveredTo.In(orgTz),
+			SupportNumber:         line.SupportItemNumber,
+			ClaimReference:        line.ClaimReference,
+			Quantity:              line.Quantity,
+			UnitPrice:             line.UnitPrice,
+			GSTCode:               line.GSTCode,
+			ClaimType:             line.ClaimType,
+			CancellationReason:    line.CancellationReason,
+			ProviderABN:           organization.ABN,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := ndia.WriteBulkClaim(&buf, lines); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_CLAIM",
+				"message": "Claim batch cannot be exported",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+batch.BatchNumber+".csv")
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// ImportNDIARemittance applies a bulk payment response file, marking claim lines paid or rejected.
+// Rejected lines release their shift so it can be corrected and claimed again.
+func (h *Handler) ImportNDIARemittance(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FILE_REQUIRED",
+				"message": "Remittance file upload is required",
+			},
+		})
+		return
+	}
+	defer file.Close()
+
+	remittance, err := ndia.ParseRemittance(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_REMITTANCE",
+				"message": "Failed to parse remittance file",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	paid, rejected, pending := 0, 0, 0
+	unmatched := []string{}
+	now := time.Now()
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		batchIDs := make(map[string]bool)
+
+		for _, result := range remittance {
+			var line models.NDIAClaimLine
+			lookup := tx.Where("organization_id = ? AND claim_reference = ?", orgID, result.ClaimReference).Limit(1).Find(&line)
+			if lookup.Error != nil {
+				return lookup.Error
+			}
+			if lookup.RowsAffected == 0 {
+				unmatched = append(unmatched, result.ClaimReference)
+				continue
+			}
+			batchIDs[line.BatchID] = true
+
+			switch result.Status {
+			case ndia.RemittancePaid:
+				paid++
+				if err := tx.Model(&line).Updates(map[string]interface{}{
+					"status":                 "paid",
+					"paid_amount":            roundCurrency(result.PaidAmount),
+					"payment_request_number": result.PaymentRequestNumber,
+					"rejection_code":         "",
+					"rejection_message":      "",
+					"processed_at":           now,
+				}).Error; err != nil {
+					return err
+				}
+			case ndia.RemittanceRejected:
+				rejected++
+				if err := tx.Model(&line).Updates(map[string]interface{}{
+					"status":                 "rejected",
+					"paid_amount":            0,
+					"payment_request_number": result.PaymentRequestNumber,
+					"rejection_code":         result.ReasonCode,
+					"rejection_message":      result.Message,
+					"processed_at":           now,
+				}).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Shift{}).Where("claim_line_id = ?", line.ID).
+					Update("claim_line_id", nil).Error; err != nil {
+					return err
+				}
+			default:
+				pending++
+			}
+		}
+
+		// Refresh batch totals from their lines
+		for batchID := range batchIDs {
+			var lines []models.NDIAClaimLine
+			if err := tx.Where("batch_id = ?", batchID).Find(&lines).Error; err != nil {
+				return err
+			}
+
+			paidAmount := 0.0
+			rejectedCount, pendingCount := 0, 0
+			for _, line := range lines {
+				switch line.Status {
+				case "paid":
+					paidAmount += line.PaidAmount
+				case "rejected":
+					rejectedCount++
+				default:
+					pendingCount++
+				}
+			}
+
+			updates := map[string]interface{}{
+				"paid_amount":    roundCurrency(paidAmount),
+				"rejected_count": rejectedCount,
+				"status":         "partially_reconciled",
+			}
+			if pendingCount == 0 {
+				updates["status"] = "reconciled"
+				updates["reconciled_at"] = now
+			}
+			if err := tx.Model(&models.NDIAClaimBatch{}).Where("id = ?", batchID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to apply remittance",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"paid":      paid,
+			"rejected":  rejected,
+			"pending":   pending,
+			"unmatched": unmatched,
+		},
+		"message": "Remittance imported successfully",
+	})
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// wantsEmail reports whether a user's notification preferences allow email, which they
+// do unless email has been turned off
+func (h *Handler) wantsEmail(userID string) bool {
+	var preferences models.WorkerPreferences
+	if err := h.DB.Where("user_id = ?", userID).First(&preferences).Error; err != nil {
+		return true
+	}
+	if enabled, ok := preferences.NotificationPreferences["email"].(bool); ok {
+		return enabled
+	}
+	return true
+}
+
+// notifyUser records an in-app notification and emails it when a mailer is configured
+// and the user accepts email. A failed email is logged; the notification is still kept.
+func (h *Handler) notifyUser(user models.User, kind, title, message string, data models.JSONB) (models.Notification, error) {
+	notification := models.Notification{
+		OrganizationID: user.OrganizationID,
+		UserID:         user.ID,
+		Type:           kind,
+		Title:          title,
+		Message:        message,
+		Data:           data,
+	}
+
+	if h.Mailer != nil && user.Email != "" && h.wantsEmail(user.ID) {
+		err := h.Mailer.Send(notify.Message{
+			To:      []string{user.Email},
+			Subject: title,
+			Body:    message,
+		})
+		if err != nil {
+			log.Printf("Failed to email notification to user %s: %v", user.ID, err)
+		} else {
+			now := time.Now()
+			notification.EmailedAt = &now
+		}
+	}
+
+	return notification, h.DB.Create(&notification).Error
+}
+
+// GetNotifications lists the current user's notifications, newest first
+func (h *Handler) GetNotifications(c *gin.Context) {
+	userID := h.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "User not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var unread int64
+	h.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch notifications",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"notifications": notifications,
+			"unread":        unread,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	userID := h.GetUserIDFromContext(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "User not found in context",
+			},
+		})
+		return
+	}
+
+	var notification models.Notification
+	if err := h.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "NOTIFICATION_NOT_FOUND",
+					"message": "Notification not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch notification",
+			},
+		})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := h.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update notification",
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+		"message": "Notification marked as read",
+	})
+}
@@ -0,0 +1,919 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+	"gorm.io/gorm"
+)
+
+var errShiftTaken = errors.New("shift has already been filled")
+
+// isDuplicateKey reports whether a write failed because it would break a unique index
+func (h *Handler) isDuplicateKey(err error) bool {
+	if translator, ok := h.DB.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// openShiftLine describes a shift in a notification
+func openShiftLine(shift models.Shift, loc *time.Location) string {
+	start, end := shift.StartTime.In(loc), shift.EndTime.In(loc)
+	return fmt.Sprintf("%s %s-%s with %s %s at %s", start.Format("Mon 02/01"), start.Format("15:04"), end.Format("15:04"),
+		shift.Participant.FirstName, shift.Participant.LastName, shift.Location)
+}
+
+// takeShift gives an open shift to a worker and publishes it to them. It only succeeds
+// while nobody holds the shift, so of two workers claiming at once exactly one gets it;
+// the other gets errShiftTaken.
+func takeShift(tx *gorm.DB, shift models.Shift, staffID string) error {
+	updates := staffChange(staffID)
+	updates["publish_status"] = models.ShiftPublished
+	result := tx.Model(&models.Shift{}).
+		Where("id = ? AND staff_id IS NULL AND status = ?", shift.ID, "scheduled").
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errShiftTaken
+	}
+
+	// The worker has seen the shift, so record it as published to them
+	if err := tx.Where("shift_id = ?", shift.ID).Delete(&models.PublishedShift{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.PublishedShift{
+		ShiftID:        shift.ID,
+		OrganizationID: shift.Participant.OrganizationID,
+		StaffID:        staffID,
+		ParticipantID:  shift.ParticipantID,
+		StartTime:      shift.StartTime,
+		EndTime:        shift.EndTime,
+		ServiceType:    shift.ServiceType,
+		Location:       shift.Location,
+		PublishedAt:    time.Now(),
+	}).Error
+}
+
+// fillBroadcast closes a broadcast once its shift has gone to a worker
+func fillBroadcast(tx *gorm.DB, broadcastID, staffID string) error {
+	result := tx.Model(&models.ShiftBroadcast{}).
+		Where("id = ? AND status = ?", broadcastID, models.BroadcastOpen).
+		Updates(map[string]interface{}{
+			"status":    models.BroadcastFilled,
+			"filled_by": staffID,
+			"closed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errShiftTaken
+	}
+	return nil
+}
+
+// checkClaimant re-checks that a worker can still take a broadcast shift, writing the error
+// response and returning false when they cannot
+func (h *Handler) checkClaimant(c *gin.Context, broadcast models.ShiftBroadcast, worker models.User) bool {
+	if _, ok := h.checkCredentials(c, broadcast.OrganizationID, worker.ID, broadcast.Shift.StartTime, CredentialOverride{}); !ok {
+		return false
+	}
+
+	loc, err := h.getOrganizationTimezone(broadcast.OrganizationID)
+	if err != nil {
+		loc = time.UTC
+	}
+	workers, err := h.schedulingWorkers([]models.User{worker}, broadcast.Shift.StartTime, broadcast.Shift.EndTime, loc)
+	var requirements map[string][]models.ParticipantRequirement
+	if err == nil {
+		requirements, err = h.shiftRequirements(broadcast.Shift)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load the worker's schedule",
+			},
+		})
+		return false
+	}
+	shift := schedulingShift(broadcast.Shift, broadcast.Shift.Participant, broadcast.RequiredSkills, requirements[broadcast.Shift.ID])
+	if reasons, _ := workers[0].Evaluate(shift, loc, h.fatigueRules(broadcast.OrganizationID)); len(reasons) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "WORKER_NOT_ELIGIBLE",
+				"message": "Staff member can no longer take this shift",
+				"details": reasons,
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// avoidsLocation reports whether a candidate would rather not work where the shift is
+func avoidsLocation(candidate scheduling.Candidate) bool {
+	for _, factor := range candidate.Factors {
+		if factor.Name == "location" && factor.Points < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type BroadcastShiftRequest struct {
+	Mode    string   `json:"mode" binding:"omitempty,oneof=first_come approval"` // defaults to approval
+	Skills  []string `json:"skills,omitempty"`                                   // skills the worker must hold
+	Message string   `json:"message,omitempty"`
+}
+
+// BroadcastShift offers an open shift to every care worker who could take it. Workers who
+// are unavailable, lack the skills, live further away than they will travel or avoid the
+// shift's location are left out.
+func (h *Handler) BroadcastShift(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req BroadcastShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = models.ClaimApproval
+	}
+
+	var shift models.Shift
+	if !h.fetchSchedulableShift(c, orgID.(string), &shift) {
+		return
+	}
+	if shift.StaffID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_FILLED",
+				"message": "Only open shifts can be broadcast",
+			},
+		})
+		return
+	}
+	if !shift.StartTime.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_STARTED",
+				"message": "The shift has already started",
+			},
+		})
+		return
+	}
+
+	var open int64
+	h.DB.Model(&models.ShiftBroadcast{}).Where("shift_id = ? AND status = ?", shift.ID, models.BroadcastOpen).Count(&open)
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BROADCAST_EXISTS",
+				"message": "The shift has already been broadcast",
+			},
+		})
+		return
+	}
+
+	candidates, exclusions, err := h.rankShiftWorkers(orgID.(string), shift, shift.Participant, req.Skills)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load workers",
+			},
+		})
+		return
+	}
+	recipients := []scheduling.Candidate{}
+	for _, candidate := range candidates {
+		if !avoidsLocation(candidate) {
+			recipients = append(recipients, candidate)
+		}
+	}
+	if len(recipients) == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_ELIGIBLE_WORKERS",
+				"message": "No worker is able to take this shift",
+				"details": exclusions,
+			},
+		})
+		return
+	}
+
+	broadcast := models.ShiftBroadcast{
+		OrganizationID: orgID.(string),
+		ShiftID:        shift.ID,
+		Mode:           req.Mode,
+		Status:         models.BroadcastOpen,
+		RequiredSkills: req.Skills,
+		Message:        req.Message,
+		RecipientCount: len(recipients),
+		BroadcastBy:    h.GetUserIDFromContext(c),
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&broadcast).Error; err != nil {
+			return err
+		}
+		rows := make([]models.ShiftBroadcastRecipient, 0, len(recipients))
+		for _, recipient := range recipients {
+			rows = append(rows, models.ShiftBroadcastRecipient{
+				BroadcastID: broadcast.ID,
+				UserID:      recipient.WorkerID,
+				Score:       recipient.Score,
+			})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to broadcast shift",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	action := "Claim it in the app and a manager will confirm who gets it."
+	if req.Mode == models.ClaimFirstCome {
+		action = "The first worker to claim it in the app gets the shift."
+	}
+	for _, recipient := range recipients {
+		var staff models.User
+		if err := h.DB.First(&staff, "id = ?", recipient.WorkerID).Error; err != nil {
+			continue
+		}
+		message := fmt.Sprintf("Hi %s,\n\nA shift is available: %s.\n", staff.FirstName, openShiftLine(shift, loc))
+		if req.Message != "" {
+			message += "\n" + req.Message + "\n"
+		}
+		message += "\n" + action
+		h.notifyUser(staff, models.NotificationOpenShift, "Open shift available", message, models.JSONB{
+			"broadcast_id": broadcast.ID,
+			"shift_id":     shift.ID,
+		})
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"broadcast":  broadcast,
+			"recipients": recipients,
+			"excluded":   exclusions,
+		},
+		"message": fmt.Sprintf("Shift broadcast to %d workers", len(recipients)),
+	})
+}
+
+// GetShiftBroadcasts lists the organization's shift broadcasts, newest first
+func (h *Handler) GetShiftBroadcasts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.ShiftBroadcast{}).Where("organization_id = ?", orgID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if shiftID := c.Query("shift_id"); shiftID != "" {
+		query = query.Where("shift_id = ?", shiftID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var broadcasts []models.ShiftBroadcast
+	if err := query.Preload("Shift.Participant").Preload("Claims").
+		Order("created_at DESC").Limit(limit).Offset(offset).Find(&broadcasts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift broadcasts",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"broadcasts": broadcasts,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// fetchBroadcast loads one of the organization's broadcasts with its shift, writing the
+// error response and returning false when it is missing
+func (h *Handler) fetchBroadcast(c *gin.Context, orgID, id string, broadcast *models.ShiftBroadcast) bool {
+	err := h.DB.Where("id = ? AND organization_id = ?", id, orgID).
+		Preload("Shift.Participant").First(broadcast).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "BROADCAST_NOT_FOUND",
+					"message": "Shift broadcast not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift broadcast",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetShiftBroadcast returns a broadcast with the workers it went to and their claims
+func (h *Handler) GetShiftBroadcast(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var broadcast models.ShiftBroadcast
+	if !h.fetchBroadcast(c, orgID.(string), c.Param("id"), &broadcast) {
+		return
+	}
+	h.DB.Where("broadcast_id = ?", broadcast.ID).Order("score DESC").Find(&broadcast.Recipients)
+	h.DB.Where("broadcast_id = ?", broadcast.ID).Preload("User").Order("created_at").Find(&broadcast.Claims)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    broadcast,
+	})
+}
+
+// CancelShiftBroadcast withdraws an open broadcast, turning down any claims waiting on it
+func (h *Handler) CancelShiftBroadcast(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var broadcast models.ShiftBroadcast
+	if !h.fetchBroadcast(c, orgID.(string), c.Param("id"), &broadcast) {
+		return
+	}
+	if broadcast.Status != models.BroadcastOpen {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BROADCAST_CLOSED",
+				"message": "Only open broadcasts can be cancelled",
+			},
+		})
+		return
+	}
+
+	var pending []models.ShiftClaim
+	h.DB.Where("broadcast_id = ? AND status = ?", broadcast.ID, models.ClaimPending).Preload("User").Find(&pending)
+
+	now := time.Now()
+	reason := "The shift is no longer available"
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&broadcast).Updates(map[string]interface{}{
+			"status":    models.BroadcastCancelled,
+			"closed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ShiftClaim{}).
+			Where("broadcast_id = ? AND status = ?", broadcast.ID, models.ClaimPending).
+			Updates(map[string]interface{}{
+				"status":     models.ClaimRejected,
+				"decided_by": h.GetUserIDFromContext(c),
+				"decided_at": now,
+				"reason":     reason,
+			}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to cancel shift broadcast",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	for _, claim := range pending {
+		h.notifyUser(claim.User, models.NotificationClaimResult, "Open shift withdrawn",
+			fmt.Sprintf("Hi %s,\n\nThe shift %s is no longer available.", claim.User.FirstName, openShiftLine(broadcast.Shift, loc)),
+			models.JSONB{"broadcast_id": broadcast.ID, "claim_id": claim.ID, "status": models.ClaimRejected})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Shift broadcast cancelled successfully",
+	})
+}
+
+// GetOpenShifts lists the open shifts broadcast to the current user that are still
+// available, with the user's claim on each if they have made one
+func (h *Handler) GetOpenShifts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var broadcasts []models.ShiftBroadcast
+	err := h.DB.Joins("JOIN shift_broadcast_recipients ON shift_broadcast_recipients.broadcast_id = shift_broadcasts.id").
+		Joins("JOIN shifts ON shifts.id = shift_broadcasts.shift_id").
+		Where("shift_broadcasts.organization_id = ? AND shift_broadcasts.status = ? AND shift_broadcast_recipients.user_id = ?",
+			orgID, models.BroadcastOpen, userID).
+		Where("shifts.staff_id IS NULL AND shifts.start_time > ?", time.Now()).
+		Preload("Shift.Participant").Preload("Claims", "user_id = ?", userID).
+		Order("shifts.start_time").Find(&broadcasts).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch open shifts",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    broadcasts,
+	})
+}
+
+// ClaimOpenShift lets a worker the shift was broadcast to claim it. First come broadcasts
+// give the worker the shift straight away; otherwise the claim waits for a manager.
+func (h *Handler) ClaimOpenShift(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var broadcast models.ShiftBroadcast
+	if !h.fetchBroadcast(c, orgID.(string), c.Param("id"), &broadcast) {
+		return
+	}
+
+	var recipient int64
+	h.DB.Model(&models.ShiftBroadcastRecipient{}).Where("broadcast_id = ? AND user_id = ?", broadcast.ID, userID).Count(&recipient)
+	if recipient == 0 {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_A_RECIPIENT",
+				"message": "This shift was not offered to you",
+			},
+		})
+		return
+	}
+	alreadyClaimed := func() {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ALREADY_CLAIMED",
+				"message": "You have already claimed this shift",
+			},
+		})
+	}
+	var claimed int64
+	h.DB.Model(&models.ShiftClaim{}).Where("broadcast_id = ? AND user_id = ?", broadcast.ID, userID).Count(&claimed)
+	if claimed > 0 {
+		alreadyClaimed()
+		return
+	}
+
+	claim := models.ShiftClaim{
+		BroadcastID: broadcast.ID,
+		ShiftID:     broadcast.ShiftID,
+		UserID:      userID,
+		Status:      models.ClaimPending,
+	}
+	// tooLate records a claim made after the shift was filled and tells the worker
+	tooLate := func() {
+		claim.ID = ""
+		claim.Status = models.ClaimRejected
+		claim.Reason = "The shift had already been filled"
+		h.DB.Create(&claim)
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_FILLED",
+				"message": "The shift is no longer available",
+			},
+		})
+	}
+	if broadcast.Status != models.BroadcastOpen || broadcast.Shift.StaffID != nil {
+		tooLate()
+		return
+	}
+
+	var worker models.User
+	if err := h.DB.First(&worker, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch user",
+			},
+		})
+		return
+	}
+	if !h.checkClaimant(c, broadcast, worker) {
+		return
+	}
+
+	var err error
+	if broadcast.Mode == models.ClaimFirstCome {
+		now := time.Now()
+		claim.Status = models.ClaimApproved
+		claim.DecidedAt = &now
+		err = h.DB.Transaction(func(tx *gorm.DB) error {
+			if err := takeShift(tx, broadcast.Shift, userID); err != nil {
+				return err
+			}
+			if err := fillBroadcast(tx, broadcast.ID, userID); err != nil {
+				return err
+			}
+			return tx.Create(&claim).Error
+		})
+		if err == errShiftTaken {
+			tooLate()
+			return
+		}
+	} else {
+		err = h.DB.Create(&claim).Error
+	}
+	// A second request from the worker that got past the check above is stopped by the
+	// unique index on their claims
+	if h.isDuplicateKey(err) {
+		alreadyClaimed()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to claim shift",
+			},
+		})
+		return
+	}
+
+	// Let whoever broadcast the shift know
+	var broadcaster models.User
+	if err := h.DB.First(&broadcaster, "id = ?", broadcast.BroadcastBy).Error; err == nil {
+		loc, err := h.getOrganizationTimezone(orgID.(string))
+		if err != nil {
+			loc = time.UTC
+		}
+		title, action := "Open shift claimed", "has claimed"
+		if claim.Status == models.ClaimApproved {
+			title, action = "Open shift filled", "has taken"
+		}
+		h.notifyUser(broadcaster, models.NotificationShiftClaim, title,
+			fmt.Sprintf("%s %s %s the shift %s.", worker.FirstName, worker.LastName, action, openShiftLine(broadcast.Shift, loc)),
+			models.JSONB{"broadcast_id": broadcast.ID, "claim_id": claim.ID, "shift_id": broadcast.ShiftID})
+	}
+
+	message := "Claim submitted for approval"
+	if claim.Status == models.ClaimApproved {
+		message = "Shift claimed successfully"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    claim,
+		"message": message,
+	})
+}
+
+// fetchPendingClaim loads one of the organization's claims that is waiting for a decision
+// along with its broadcast, writing the error response and returning false otherwise
+func (h *Handler) fetchPendingClaim(c *gin.Context, orgID string, claim *models.ShiftClaim, broadcast *models.ShiftBroadcast) bool {
+	err := h.DB.Joins("JOIN shift_broadcasts ON shift_broadcasts.id = shift_claims.broadcast_id").
+		Where("shift_claims.id = ? AND shift_broadcasts.organization_id = ?", c.Param("id"), orgID).
+		Preload("User").First(claim).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CLAIM_NOT_FOUND",
+					"message": "Shift claim not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift claim",
+			},
+		})
+		return false
+	}
+	if claim.Status != models.ClaimPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CLAIM_DECIDED",
+				"message": "The claim has already been " + claim.Status,
+			},
+		})
+		return false
+	}
+	return h.fetchBroadcast(c, orgID, claim.BroadcastID, broadcast)
+}
+
+// ApproveShiftClaim gives the shift to the worker who made a claim and turns down the
+// other claims on it
+func (h *Handler) ApproveShiftClaim(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var claim models.ShiftClaim
+	var broadcast models.ShiftBroadcast
+	if !h.fetchPendingClaim(c, orgID.(string), &claim, &broadcast) {
+		return
+	}
+	if broadcast.Status != models.BroadcastOpen {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BROADCAST_CLOSED",
+				"message": "The shift broadcast is " + broadcast.Status,
+			},
+		})
+		return
+	}
+	if !h.checkClaimant(c, broadcast, claim.User) {
+		return
+	}
+
+	var others []models.ShiftClaim
+	h.DB.Where("broadcast_id = ? AND status = ? AND id <> ?", broadcast.ID, models.ClaimPending, claim.ID).
+		Preload("User").Find(&others)
+
+	managerID := h.GetUserIDFromContext(c)
+	now := time.Now()
+	reason := "The shift was given to another worker"
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := takeShift(tx, broadcast.Shift, claim.UserID); err != nil {
+			return err
+		}
+		if err := fillBroadcast(tx, broadcast.ID, claim.UserID); err != nil {
+			return err
+		}
+		if err := tx.Model(&claim).Updates(map[string]interface{}{
+			"status":     models.ClaimApproved,
+			"decided_by": managerID,
+			"decided_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ShiftClaim{}).
+			Where("broadcast_id = ? AND status = ?", broadcast.ID, models.ClaimPending).
+			Updates(map[string]interface{}{
+				"status":     models.ClaimRejected,
+				"decided_by": managerID,
+				"decided_at": now,
+				"reason":     reason,
+			}).Error
+	})
+	if err == errShiftTaken {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_FILLED",
+				"message": "The shift has already been filled",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to approve shift claim",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	line := openShiftLine(broadcast.Shift, loc)
+	h.notifyUser(claim.User, models.NotificationClaimResult, "Open shift confirmed",
+		fmt.Sprintf("Hi %s,\n\nYou have been given the shift %s.", claim.User.FirstName, line),
+		models.JSONB{"broadcast_id": broadcast.ID, "claim_id": claim.ID, "shift_id": broadcast.ShiftID, "status": models.ClaimApproved})
+	for _, other := range others {
+		h.notifyUser(other.User, models.NotificationClaimResult, "Open shift filled",
+			fmt.Sprintf("Hi %s,\n\nThe shift %s has been given to another worker.", other.User.FirstName, line),
+			models.JSONB{"broadcast_id": broadcast.ID, "claim_id": other.ID, "shift_id": broadcast.ShiftID, "status": models.ClaimRejected})
+	}
+
+	h.DB.First(&claim, "id = ?", claim.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    claim,
+		"message": "Shift claim approved successfully",
+	})
+}
+
+type RejectShiftClaimRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// RejectShiftClaim turns down a worker's claim, leaving the broadcast open for others
+func (h *Handler) RejectShiftClaim(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req RejectShiftClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var claim models.ShiftClaim
+	var broadcast models.ShiftBroadcast
+	if !h.fetchPendingClaim(c, orgID.(string), &claim, &broadcast) {
+		return
+	}
+
+	result := h.DB.Model(&claim).Where("status = ?", models.ClaimPending).Updates(map[string]interface{}{
+		"status":     models.ClaimRejected,
+		"decided_by": h.GetUserIDFromContext(c),
+		"decided_at": time.Now(),
+		"reason":     req.Reason,
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to reject shift claim",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	message := fmt.Sprintf("Hi %s,\n\nYour claim for the shift %s was not approved.", claim.User.FirstName, openShiftLine(broadcast.Shift, loc))
+	if req.Reason != "" {
+		message += "\n\nReason: " + req.Reason
+	}
+	h.notifyUser(claim.User, models.NotificationClaimResult, "Open shift claim declined", message,
+		models.JSONB{"broadcast_id": broadcast.ID, "claim_id": claim.ID, "shift_id": broadcast.ShiftID, "status": models.ClaimRejected})
+
+	h.DB.First(&claim, "id = ?", claim.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    claim,
+		"message": "Shift claim rejected successfully",
+	})
+}
@@ -184,6 +184,13 @@ func (h *Handler) GetOrganizationSettings(c *gin.Context) {
 			MinShiftNotice:           30,
 			EnableSMSNotifications:   true,
 			EnableEmailNotifications: true,
+			InvoicePaymentTermsDays:  30,
+			PriceCapEnforcement:      "block",
+			CancellationNoticeHours:  48,
+			CancellationChargeRate:   100,
+			TravelKilometreRate:      0.99,
+			WorkerKilometreRate:      0.88,
+			PaymentReminderDays:      "7,14,30",
 		}
 		h.DB.Create(&settings)
 	}
@@ -195,19 +202,27 @@ func (h *Handler) GetOrganizationSettings(c *gin.Context) {
 }
 
 type UpdateSettingsRequest struct {
-	Timezone                 *string `json:"timezone,omitempty"`
-	DateFormat               *string `json:"date_format,omitempty"`
-	TimeFormat               *string `json:"time_format,omitempty"`
-	Currency                 *string `json:"currency,omitempty"`
-	Language                 *string `json:"language,omitempty"`
-	DefaultShiftDuration     *int    `json:"default_shift_duration,omitempty"`
-	MaxShiftDuration         *int    `json:"max_shift_duration,omitempty"`
-	MinShiftNotice           *int    `json:"min_shift_notice,omitempty"`
-	RequireShiftNotes        *bool   `json:"require_shift_notes,omitempty"`
-	RequirePhotoEvidence     *bool   `json:"require_photo_evidence,omitempty"`
-	AutoAssignShifts         *bool   `json:"auto_assign_shifts,omitempty"`
-	EnableSMSNotifications   *bool   `json:"enable_sms_notifications,omitempty"`
-	EnableEmailNotifications *bool   `json:"enable_email_notifications,omitempty"`
+	Timezone                 *string           `json:"timezone,omitempty"`
+	DateFormat               *string           `json:"date_format,omitempty"`
+	TimeFormat               *string           `json:"time_format,omitempty"`
+	Currency                 *string           `json:"currency,omitempty"`
+	Language                 *string           `json:"language,omitempty"`
+	DefaultShiftDuration     *int              `json:"default_shift_duration,omitempty"`
+	MaxShiftDuration         *int              `json:"max_shift_duration,omitempty"`
+	MinShiftNotice           *int              `json:"min_shift_notice,omitempty"`
+	RequireShiftNotes        *bool             `json:"require_shift_notes,omitempty"`
+	RequirePhotoEvidence     *bool             `json:"require_photo_evidence,omitempty"`
+	AutoAssignShifts         *bool             `json:"auto_assign_shifts,omitempty"`
+	EnableSMSNotifications   *bool             `json:"enable_sms_notifications,omitempty"`
+	EnableEmailNotifications *bool             `json:"enable_email_notifications,omitempty"`
+	InvoicePaymentTermsDays  *int              `json:"invoice_payment_terms_days,omitempty" binding:"omitempty,min=0"`
+	PriceCapEnforcement      *string           `json:"price_cap_enforcement,omitempty" binding:"omitempty,oneof=block warn"`
+	FatigueRules             map[string]string `json:"fatigue_rules,omitempty" binding:"omitempty,dive,keys,oneof=rest_period daily_hours weekly_hours consecutive_days,endkeys,oneof=block warn"`
+	CancellationNoticeHours  *int              `json:"cancellation_notice_hours,omitempty" binding:"omitempty,min=0"`
+	CancellationChargeRate   *float64          `json:"cancellation_charge_rate,omitempty" binding:"omitempty,min=0,max=100"`
+	TravelKilometreRate      *float64          `json:"travel_kilometre_rate,omitempty" binding:"omitempty,min=0"`
+	WorkerKilometreRate      *float64          `json:"worker_kilometre_rate,omitempty" binding:"omitempty,min=0"`
+	PaymentReminderDays      *string           `json:"payment_reminder_days,omitempty"` // e.g. "7,14,30", empty disables reminders
 }
 
 func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
@@ -248,6 +263,19 @@ func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
 		})
 		return
 	}
+	if req.PaymentReminderDays != nil {
+		if _, err := parseReminderDays(*req.PaymentReminderDays); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Invalid request parameters",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+	}
 
 	var settings models.OrganizationSettings
 	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
@@ -296,6 +324,37 @@ func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
 	if req.EnableEmailNotifications != nil {
 		updates["enable_email_notifications"] = *req.EnableEmailNotifications
 	}
+	if req.InvoicePaymentTermsDays != nil {
+		updates["invoice_payment_terms_days"] = *req.InvoicePaymentTermsDays
+	}
+	if req.PriceCapEnforcement != nil {
+		updates["price_cap_enforcement"] = *req.PriceCapEnforcement
+	}
+	if req.CancellationNoticeHours != nil {
+		updates["cancellation_notice_hours"] = *req.CancellationNoticeHours
+	}
+	if req.CancellationChargeRate != nil {
+		updates["cancellation_charge_rate"] = *req.CancellationChargeRate
+	}
+	if req.TravelKilometreRate != nil {
+		updates["travel_kilometre_rate"] = *req.TravelKilometreRate
+	}
+	if req.WorkerKilometreRate != nil {
+		updates["worker_kilometre_rate"] = *req.WorkerKilometreRate
+	}
+	if req.PaymentReminderDays != nil {
+		updates["payment_reminder_days"] = *req.PaymentReminderDays
+	}
+	if req.FatigueRules != nil {
+		rules := models.JSONB{}
+		for rule, enforcement := range settings.FatigueRules {
+			rules[rule] = enforcement
+		}
+		for rule, enforcement := range req.FatigueRules {
+			rules[rule] = enforcement
+		}
+		updates["fatigue_rules"] = rules
+	}
 
 	if err := h.DB.Model(&settings).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// errPlanOverlap is returned when a plan's dates would overlap a later plan
+var errPlanOverlap = errors.New("plan overlaps another plan")
+
+type PlanBudgetLineRequest struct {
+	ID                    string  `json:"id"` // existing line to update, empty to add a line
+	Purpose               string  `json:"purpose" binding:"required,oneof=core capacity_building capital"`
+	SupportCategoryNumber string  `json:"support_category_number"` // empty for a budget covering the whole purpose
+	Name                  string  `json:"name"`
+	Allocated             float64 `json:"allocated" binding:"gte=0"`
+}
+
+type CreateParticipantPlanRequest struct {
+	PlanNumber     string                  `json:"plan_number"`
+	StartDate      string                  `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate        string                  `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	ManagementType string                  `json:"management_type" binding:"omitempty,oneof=agency plan self"`
+	Notes          string                  `json:"notes"`
+	BudgetLines    []PlanBudgetLineRequest `json:"budget_lines" binding:"required,min=1,dive"`
+}
+
+type UpdateParticipantPlanRequest struct {
+	PlanNumber     *string                 `json:"plan_number,omitempty"`
+	EndDate        *string                 `json:"end_date,omitempty"` // YYYY-MM-DD, inclusive
+	ManagementType *string                 `json:"management_type,omitempty" binding:"omitempty,oneof=agency plan self"`
+	Notes          *string                 `json:"notes,omitempty"`
+	BudgetLines    []PlanBudgetLineRequest `json:"budget_lines,omitempty" binding:"omitempty,dive"`
+}
+
+// validateBudgetLines checks that categories belong to their purpose and no budget is listed twice
+func validateBudgetLines(lines []PlanBudgetLineRequest) string {
+	seen := make(map[string]bool)
+	for i := range lines {
+		line := &lines[i]
+		if line.SupportCategoryNumber != "" {
+			category := catalogue.NormalizeCategory(line.SupportCategoryNumber)
+			if category == "" || catalogue.PurposeFor(category) != line.Purpose {
+				return "Support category " + line.SupportCategoryNumber + " is not part of the " + line.Purpose + " purpose"
+			}
+			line.SupportCategoryNumber = category
+		}
+
+		key := line.Purpose + "/" + line.SupportCategoryNumber
+		if seen[key] {
+			return "Each purpose and support category can only have one budget line"
+		}
+		seen[key] = true
+	}
+	return ""
+}
+
+// redrawPlanShifts moves budget drawn by shifts within a plan's dates onto that plan's
+// budget lines, for shifts completed before the plan was entered or had its dates changed
+func redrawPlanShifts(tx *gorm.DB, plan *models.ParticipantPlan, userID string) error {
+	var shifts []models.Shift
+	if err := tx.Where("participant_id = ? AND start_time >= ? AND start_time < ?",
+		plan.ParticipantID, plan.StartDate, plan.EndDate.AddDate(0, 0, 1)).
+		Where("id IN (?)", tx.Model(&models.BudgetTransaction{}).Select("shift_id").Where("participant_id = ?", plan.ParticipantID)).
+		Find(&shifts).Error; err != nil {
+		return err
+	}
+
+	for i := range shifts {
+		var drawn float64
+		if err := tx.Model(&models.BudgetTransaction{}).
+			Where("shift_id = ?", shifts[i].ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&drawn).Error; err != nil {
+			return err
+		}
+		if _, err := models.DrawDownShift(tx, &shifts[i], drawn, models.BudgetTransactionAdjustment,
+			"Moved to plan "+plan.PlanNumber, userID, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) GetParticipantPlans(c *gin.Context) {
+	participantID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).First(&participant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PARTICIPANT_NOT_FOUND",
+					"message": "Participant not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant",
+			},
+		})
+		return
+	}
+
+	var plans []models.ParticipantPlan
+	if err := h.DB.Where("participant_id = ?", participant.ID).
+		Preload("BudgetLines").
+		Order("start_date DESC").
+		Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch plans",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	for i := range plans {
+		plans[i].Summarise(now)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"plans": plans,
+		},
+	})
+}
+
+func (h *Handler) GetParticipantPlan(c *gin.Context) {
+	participantID := c.Param("id")
+	planID := c.Param("planId")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var plan models.ParticipantPlan
+	if err := h.DB.Where("id = ? AND participant_id = ? AND organization_id = ?", planID, participantID, orgID).
+		Preload("BudgetLines").
+		First(&plan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PLAN_NOT_FOUND",
+					"message": "Plan not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch plan",
+			},
+		})
+		return
+	}
+
+	plan.Summarise(time.Now())
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+func (h *Handler) CreateParticipantPlan(c *gin.Context) {
+	participantID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req CreateParticipantPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	location, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		location = time.UTC
+	}
+	startDate, startErr := time.ParseInLocation("2006-01-02", req.StartDate, location)
+	endDate, endErr := time.ParseInLocation("2006-01-02", req.EndDate, location)
+	if startErr != nil || endErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format (YYYY-MM-DD expected)",
+			},
+		})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": "Plan end date must not be before its start date",
+			},
+		})
+		return
+	}
+
+	if message := validateBudgetLines(req.BudgetLines); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_BUDGET_LINE",
+				"message": message,
+			},
+		})
+		return
+	}
+
+	managementType := req.ManagementType
+	if managementType == "" {
+		managementType = models.PlanManagementAgency
+	}
+
+	plan := models.ParticipantPlan{
+		OrganizationID: orgID.(string),
+		ParticipantID:  participant.ID,
+		PlanNumber:     req.PlanNumber,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		ManagementType: managementType,
+		Notes:          req.Notes,
+		CreatedBy:      userID,
+	}
+	for _, line := range req.BudgetLines {
+		plan.BudgetLines = append(plan.BudgetLines, models.PlanBudgetLine{
+			Purpose:               line.Purpose,
+			SupportCategoryNumber: line.SupportCategoryNumber,
+			Name:                  line.Name,
+			Allocated:             roundCurrency(line.Allocated),
+		})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// A new plan replaces the one in force when it starts, which ends the day before
+		var overlapping []models.ParticipantPlan
+		if err := tx.Where("participant_id = ? AND start_date <= ? AND end_date >= ?", participant.ID, endDate, startDate).
+			Find(&overlapping).Error; err != nil {
+			return err
+		}
+		for _, previous := range overlapping {
+			if !previous.StartDate.Before(startDate) {
+				return errPlanOverlap
+			}
+			if err := tx.Model(&previous).Update("end_date", startDate.AddDate(0, 0, -1)).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&plan).Error; err != nil {
+			return err
+		}
+		if err := redrawPlanShifts(tx, &plan, userID); err != nil {
+			return err
+		}
+		return models.SyncParticipantFunding(tx, participant.ID, time.Now())
+	})
+	if err != nil {
+		if errors.Is(err, errPlanOverlap) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PLAN_OVERLAP",
+					"message": "Plan dates overlap a plan that starts on or after this one",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create plan",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("BudgetLines").First(&plan, "id = ?", plan.ID)
+	plan.Summarise(time.Now())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    plan,
+		"message": "Plan created successfully",
+	})
+}
+
+func (h *Handler) UpdateParticipantPlan(c *gin.Context) {
+	participantID := c.Param("id")
+	planID := c.Param("planId")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req UpdateParticipantPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var plan models.ParticipantPlan
+	if err := h.DB.Where("id = ? AND participant_id = ? AND organization_id = ?", planID, participantID, orgID).
+		Preload("BudgetLines").
+		First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PLAN_NOT_FOUND",
+				"message": "Plan not found",
+			},
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.PlanNumber != nil {
+		updates["plan_number"] = *req.PlanNumber
+	}
+	if req.ManagementType != nil {
+		updates["management_type"] = *req.ManagementType
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+	if req.EndDate != nil {
+		location, err := h.getOrganizationTimezone(orgID.(string))
+		if err != nil {
+			location = time.UTC
+		}
+		endDate, err := time.ParseInLocation("2006-01-02", *req.EndDate, location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format (YYYY-MM-DD expected)",
+				},
+			})
+			return
+		}
+		if endDate.Before(plan.StartDate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE_RANGE",
+					"message": "Plan end date must not be before its start date",
+				},
+			})
+			return
+		}
+		updates["end_date"] = endDate
+		plan.EndDate = endDate
+	}
+
+	// Check new lines against the existing ones as well as each other
+	existing := make(map[string]models.PlanBudgetLine)
+	combined := []PlanBudgetLineRequest{}
+	for _, line := range plan.BudgetLines {
+		existing[line.ID] = line
+	}
+	for _, line := range req.BudgetLines {
+		if line.ID != "" {
+			current, ok := existing[line.ID]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_BUDGET_LINE",
+						"message": "Budget line not found on this plan",
+						"details": line.ID,
+					},
+				})
+				return
+			}
+			delete(existing, line.ID)
+			line.Purpose, line.SupportCategoryNumber = current.Purpose, current.SupportCategoryNumber
+		}
+		combined = append(combined, line)
+	}
+	for _, line := range existing {
+		combined = append(combined, PlanBudgetLineRequest{ID: line.ID, Purpose: line.Purpose, SupportCategoryNumber: line.SupportCategoryNumber})
+	}
+	if message := validateBudgetLines(combined); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_BUDGET_LINE",
+				"message": message,
+			},
+		})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if req.EndDate != nil {
+			var overlapping int64
+			if err := tx.Model(&models.ParticipantPlan{}).
+				Where("participant_id = ? AND id != ? AND start_date <= ? AND end_date >= ?", plan.ParticipantID, plan.ID, plan.EndDate, plan.StartDate).
+				Count(&overlapping).Error; err != nil {
+				return err
+			}
+			if overlapping > 0 {
+				return errPlanOverlap
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&plan).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, line := range combined[:len(req.BudgetLines)] {
+			allocated := roundCurrency(line.Allocated)
+			if line.ID == "" {
+				if err := tx.Create(&models.PlanBudgetLine{
+					PlanID:                plan.ID,
+					Purpose:               line.Purpose,
+					SupportCategoryNumber: line.SupportCategoryNumber,
+					Name:                  line.Name,
+					Allocated:             allocated,
+				}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			lineUpdates := map[string]interface{}{
+				"allocated": allocated,
+				"remaining": gorm.Expr("? - used", allocated),
+			}
+			if line.Name != "" {
+				lineUpdates["name"] = line.Name
+			}
+			if err := tx.Model(&models.PlanBudgetLine{}).Where("id = ?", line.ID).Updates(lineUpdates).Error; err != nil {
+				return err
+			}
+		}
+
+		// New lines or dates can change which budget a shift draws from
+		if err := tx.Preload("BudgetLines").First(&plan, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+		if err := redrawPlanShifts(tx, &plan, userID); err != nil {
+			return err
+		}
+		return models.SyncParticipantFunding(tx, plan.ParticipantID, time.Now())
+	})
+	if err != nil {
+		if errors.Is(err, errPlanOverlap) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PLAN_OVERLAP",
+					"message": "Plan dates overlap another plan",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update plan",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("BudgetLines").First(&plan, "id = ?", plan.ID)
+	plan.Summarise(time.Now())
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+		"message": "Plan updated successfully",
+	})
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+	"gorm.io/gorm"
+)
+
+type ParticipantRequirementRequest struct {
+	Kind     string `json:"kind" binding:"required,oneof=skill gender language non_smoker no_pets"`
+	Value    string `json:"value"`                                                 // the skill, gender or language
+	Strength string `json:"strength" binding:"omitempty,oneof=required preferred"` // defaults to required
+	Notes    string `json:"notes"`
+}
+
+// requirement builds the requirement a request describes, or returns why it is incomplete
+func (req ParticipantRequirementRequest) requirement() (models.ParticipantRequirement, string) {
+	requirement := models.ParticipantRequirement{
+		Kind:     req.Kind,
+		Value:    strings.TrimSpace(req.Value),
+		Strength: req.Strength,
+		Notes:    req.Notes,
+	}
+	if requirement.Strength == "" {
+		requirement.Strength = models.RequirementRequired
+	}
+	if !models.RequirementNeedsValue(requirement.Kind) {
+		requirement.Value = ""
+	} else if requirement.Value == "" {
+		return requirement, "A value is required for " + requirement.Kind + " requirements"
+	}
+	return requirement, ""
+}
+
+// shiftRequirements loads what each shift's participant needs from a worker, along with
+// anything added for the shift itself, keyed by shift ID
+func (h *Handler) shiftRequirements(shifts ...models.Shift) (map[string][]models.ParticipantRequirement, error) {
+	byShift := map[string][]models.ParticipantRequirement{}
+	if len(shifts) == 0 {
+		return byShift, nil
+	}
+
+	participantIDs := make([]string, 0, len(shifts))
+	shiftIDs := make([]string, 0, len(shifts))
+	for _, shift := range shifts {
+		participantIDs = append(participantIDs, shift.ParticipantID)
+		shiftIDs = append(shiftIDs, shift.ID)
+	}
+
+	var requirements []models.ParticipantRequirement
+	if err := h.DB.Where("participant_id IN ? AND (shift_id IS NULL OR shift_id IN ?)", participantIDs, shiftIDs).
+		Order("created_at").Find(&requirements).Error; err != nil {
+		return nil, err
+	}
+
+	for _, shift := range shifts {
+		for _, requirement := range requirements {
+			if requirement.ParticipantID != shift.ParticipantID {
+				continue
+			}
+			if requirement.ShiftID == nil || (shift.ID != "" && *requirement.ShiftID == shift.ID) {
+				byShift[shift.ID] = append(byShift[shift.ID], requirement)
+			}
+		}
+	}
+	return byShift, nil
+}
+
+// schedulingRequirements describes a participant's requirements to the scheduler
+func schedulingRequirements(requirements []models.ParticipantRequirement) []scheduling.Requirement {
+	converted := make([]scheduling.Requirement, 0, len(requirements))
+	for _, requirement := range requirements {
+		converted = append(converted, scheduling.Requirement{
+			Kind:     requirement.Kind,
+			Value:    requirement.Value,
+			Required: requirement.Strength != models.RequirementPreferred,
+		})
+	}
+	return converted
+}
+
+// shiftMatch explains how a worker measures up for a shift: the participant's requirements
+// they meet, the hard constraints they fail and how they score
+func (h *Handler) shiftMatch(orgID string, staff models.User, shift models.Shift, requirements []models.ParticipantRequirement) (gin.H, error) {
+	loc, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		loc = time.UTC
+	}
+	workers, err := h.schedulingWorkers([]models.User{staff}, shift.StartTime, shift.EndTime, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	worker := workers[0]
+	target := schedulingShift(shift, shift.Participant, nil, requirements)
+	reasons, violations := worker.Evaluate(target, loc, h.fatigueRules(orgID))
+	score, factors := worker.Score(target, loc)
+	return gin.H{
+		"staff_id":     staff.ID,
+		"name":         worker.Name,
+		"eligible":     len(reasons) == 0,
+		"requirements": worker.Matches(target),
+		"reasons":      reasons,
+		"violations":   violations,
+		"score":        score,
+		"factors":      factors,
+	}, nil
+}
+
+// unmetRequirements returns the participant's required skills and preferences for a shift
+// that a worker does not meet
+func (h *Handler) unmetRequirements(staffID string, shift models.Shift, participant models.Participant) ([]scheduling.Match, error) {
+	unmet := []scheduling.Match{}
+	requirements, err := h.shiftRequirements(shift)
+	if err != nil || len(requirements[shift.ID]) == 0 {
+		return unmet, err
+	}
+
+	var staff models.User
+	if err := h.DB.First(&staff, "id = ?", staffID).Error; err != nil {
+		return nil, err
+	}
+	workers, err := h.schedulingWorkers([]models.User{staff}, shift.StartTime, shift.EndTime, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range workers[0].Matches(schedulingShift(shift, participant, nil, requirements[shift.ID])) {
+		if match.Required && !match.Met {
+			unmet = append(unmet, match)
+		}
+	}
+	return unmet, nil
+}
+
+// checkRequirements refuses to book a worker who doesn't meet the participant's required
+// skills and preferences for a shift. It writes the error response and returns false when
+// the worker can't be booked.
+func (h *Handler) checkRequirements(c *gin.Context, orgID, staffID string, shift models.Shift, participant models.Participant) bool {
+	if staffID == "" {
+		return true
+	}
+
+	unmet, err := h.unmetRequirements(staffID, shift, participant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check the participant's requirements",
+			},
+		})
+		return false
+	}
+	if len(unmet) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "REQUIREMENTS_NOT_MET",
+				"message": "Staff member does not meet the participant's requirements",
+				"details": unmet,
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetParticipantRequirements lists the skills and worker attributes a participant needs or
+// prefers. Requirements added for a single shift are included with ?include_shifts=true.
+func (h *Handler) GetParticipantRequirements(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("participant_id = ?", participant.ID)
+	if c.Query("include_shifts") != "true" {
+		query = query.Where("shift_id IS NULL")
+	}
+	var requirements []models.ParticipantRequirement
+	if err := query.Order("strength ASC, kind ASC, created_at ASC").Find(&requirements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant requirements",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"requirements": requirements,
+		},
+	})
+}
+
+// AddParticipantRequirement records a skill or worker attribute the participant needs or
+// prefers for all of their shifts
+func (h *Handler) AddParticipantRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	h.createRequirement(c, participant.ID, nil)
+}
+
+// AddShiftRequirement records a skill or worker attribute the participant needs or prefers
+// for one shift, on top of their standing requirements
+func (h *Handler) AddShiftRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var shift models.Shift
+	if err := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("shifts.id = ? AND participants.organization_id = ?", c.Param("id"), orgID).
+		First(&shift).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_NOT_FOUND",
+				"message": "Shift not found",
+			},
+		})
+		return
+	}
+
+	h.createRequirement(c, shift.ParticipantID, &shift.ID)
+}
+
+// createRequirement saves the requirement in the request body for a participant, or one of
+// their shifts
+func (h *Handler) createRequirement(c *gin.Context, participantID string, shiftID *string) {
+	var req ParticipantRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	requirement, problem := req.requirement()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": problem,
+			},
+		})
+		return
+	}
+	requirement.ParticipantID = participantID
+	requirement.ShiftID = shiftID
+	requirement.CreatedBy = h.GetUserIDFromContext(c)
+
+	if err := h.DB.Create(&requirement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to add requirement",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    requirement,
+		"message": "Requirement added successfully",
+	})
+}
+
+// UpdateParticipantRequirement changes one of a participant's requirements
+func (h *Handler) UpdateParticipantRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req ParticipantRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	changed, problem := req.requirement()
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": problem,
+			},
+		})
+		return
+	}
+
+	var requirement models.ParticipantRequirement
+	if !h.fetchRequirement(c, orgID, &requirement) {
+		return
+	}
+
+	if err := h.DB.Model(&requirement).Updates(map[string]interface{}{
+		"kind":     changed.Kind,
+		"value":    changed.Value,
+		"strength": changed.Strength,
+		"notes":    changed.Notes,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update requirement",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requirement,
+		"message": "Requirement updated successfully",
+	})
+}
+
+// RemoveParticipantRequirement deletes one of a participant's requirements, including those
+// added for a single shift
+func (h *Handler) RemoveParticipantRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var requirement models.ParticipantRequirement
+	if !h.fetchRequirement(c, orgID, &requirement) {
+		return
+	}
+
+	if err := h.DB.Delete(&requirement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to remove requirement",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Requirement removed successfully",
+	})
+}
+
+// fetchRequirement loads the requirement named by :requirementId for the participant named by
+// :id, writing the error response and returning false when there isn't one
+func (h *Handler) fetchRequirement(c *gin.Context, orgID interface{}, requirement *models.ParticipantRequirement) bool {
+	err := h.DB.Joins("JOIN participants ON participant_requirements.participant_id = participants.id").
+		Where("participant_requirements.id = ? AND participant_requirements.participant_id = ? AND participants.organization_id = ?",
+			c.Param("requirementId"), c.Param("id"), orgID).
+		First(requirement).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "REQUIREMENT_NOT_FOUND",
+					"message": "Requirement not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch requirement",
+			},
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ParticipantWorkerRequest struct {
+	Relationship string `json:"relationship" binding:"required,oneof=preferred blocked"`
+	Reason       string `json:"reason" binding:"required"`
+}
+
+// blockedWorker returns the participant's block on a worker, or nil when they have not
+// blocked them
+func (h *Handler) blockedWorker(participantID, staffID string) (*models.ParticipantWorker, error) {
+	var blocked models.ParticipantWorker
+	result := h.DB.Where("participant_id = ? AND user_id = ? AND relationship = ?", participantID, staffID, models.WorkerBlocked).
+		Limit(1).Find(&blocked)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &blocked, nil
+}
+
+// checkBlockedWorker refuses to book a worker the participant has blocked. It writes the error
+// response and returns false when the worker can't be booked.
+func (h *Handler) checkBlockedWorker(c *gin.Context, participantID, staffID string) bool {
+	if staffID == "" {
+		return true
+	}
+
+	blocked, err := h.blockedWorker(participantID, staffID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check the participant's blocked workers",
+			},
+		})
+		return false
+	}
+	if blocked == nil {
+		return true
+	}
+
+	c.JSON(http.StatusConflict, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "WORKER_BLOCKED",
+			"message": "The participant has asked not to have this staff member",
+			"details": blocked.Reason,
+		},
+	})
+	return false
+}
+
+// GetParticipantWorkers lists the workers a participant has asked for or blocked. Filter with
+// ?relationship=preferred or ?relationship=blocked.
+func (h *Handler) GetParticipantWorkers(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("participant_id = ?", participant.ID)
+	if relationship := c.Query("relationship"); relationship != "" {
+		query = query.Where("relationship = ?", relationship)
+	}
+	var workers []models.ParticipantWorker
+	if err := query.Preload("User").Order("relationship DESC, created_at ASC").Find(&workers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant workers",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"workers": workers,
+		},
+	})
+}
+
+// SetParticipantWorker records that a participant has asked for a worker, or asked never to
+// have them again, replacing anything recorded about the worker before. Blocking a worker
+// lists the upcoming shifts they are still booked on with the participant, which need to be
+// reassigned.
+func (h *Handler) SetParticipantWorker(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req ParticipantWorkerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "A reason is required",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PARTICIPANT_NOT_FOUND",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	var staff models.User
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("staffId"), orgID).First(&staff).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "STAFF_NOT_FOUND",
+				"message": "Staff member not found",
+			},
+		})
+		return
+	}
+
+	var worker models.ParticipantWorker
+	err := h.DB.Where("participant_id = ? AND user_id = ?", participant.ID, staff.ID).First(&worker).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant worker",
+			},
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if err == gorm.ErrRecordNotFound {
+		status = http.StatusCreated
+		worker = models.ParticipantWorker{ParticipantID: participant.ID, UserID: staff.ID}
+	}
+	worker.Relationship = req.Relationship
+	worker.Reason = strings.TrimSpace(req.Reason)
+	worker.RecordedBy = h.GetUserIDFromContext(c)
+	if err := h.DB.Save(&worker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save participant worker",
+			},
+		})
+		return
+	}
+	worker.User = &staff
+
+	// Shifts already booked with a worker who is now blocked aren't cancelled, but the
+	// manager needs to know about them
+	booked := []models.Shift{}
+	if worker.Relationship == models.WorkerBlocked {
+		h.DB.Where("participant_id = ? AND staff_id = ? AND status = ? AND start_time > ?",
+			participant.ID, staff.ID, "scheduled", time.Now()).Order("start_time").Find(&booked)
+	}
+
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    worker,
+		"booked":  booked,
+		"message": "Participant worker saved successfully",
+	})
+}
+
+// RemoveParticipantWorker forgets a participant's preference for, or block on, a worker
+func (h *Handler) RemoveParticipantWorker(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var worker models.ParticipantWorker
+	err := h.DB.Joins("JOIN participants ON participant_workers.participant_id = participants.id").
+		Where("participant_workers.participant_id = ? AND participant_workers.user_id = ? AND participants.organization_id = ?",
+			c.Param("id"), c.Param("staffId"), orgID).
+		First(&worker).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PARTICIPANT_WORKER_NOT_FOUND",
+					"message": "Participant worker not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant worker",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Delete(&worker).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to remove participant worker",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Participant worker removed successfully",
+	})
+}
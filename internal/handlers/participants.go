@@ -135,6 +135,7 @@ func (h *Handler) GetParticipant(c *gin.Context) {
 	var participant models.Participant
 	if err := h.DB.Where("id = ? AND organization_id = ?", participantID, orgID).
 		Preload("EmergencyContacts").
+		Preload("Contacts.ExternalContact").
 		Preload("Shifts").
 		Preload("Documents").
 		Preload("CarePlans").
@@ -159,6 +160,13 @@ func (h *Handler) GetParticipant(c *gin.Context) {
 		return
 	}
 
+	// Include the plan in force today with its budget utilisation
+	now := time.Now()
+	if plan, err := models.PlanCovering(h.DB, participant.ID, now); err == nil && plan != nil {
+		plan.Summarise(now)
+		participant.CurrentPlan = plan
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    participant,
@@ -175,6 +183,7 @@ type CreateParticipantRequest struct {
 	Address           models.Address                             `json:"address"`
 	MedicalInfo       models.MedicalInformation                  `json:"medical_information"`
 	Funding           models.FundingInformation                  `json:"funding"`
+	Remoteness        string                                     `json:"remoteness" binding:"omitempty,oneof=remote very_remote"`
 	EmergencyContacts []CreateParticipantEmergencyContactRequest `json:"emergency_contacts,omitempty"`
 }
 
@@ -227,6 +236,9 @@ func (h *Handler) CreateParticipant(c *gin.Context) {
 		}
 	}
 
+	// Place the participant's address for working out how far workers travel
+	h.geocodeAddress(&req.Address)
+
 	// Start transaction
 	tx := h.DB.Begin()
 
@@ -241,6 +253,7 @@ func (h *Handler) CreateParticipant(c *gin.Context) {
 		Address:        req.Address,
 		MedicalInfo:    req.MedicalInfo,
 		Funding:        req.Funding,
+		Remoteness:     req.Remoteness,
 		OrganizationID: orgID.(string),
 		IsActive:       true,
 	}
@@ -303,6 +316,7 @@ type UpdateParticipantRequest struct {
 	Address     *models.Address            `json:"address,omitempty"`
 	MedicalInfo *models.MedicalInformation `json:"medical_information,omitempty"`
 	Funding     *models.FundingInformation `json:"funding,omitempty"`
+	Remoteness  *string                    `json:"remoteness,omitempty" binding:"omitempty,oneof=remote very_remote"`
 	IsActive    *bool                      `json:"is_active,omitempty"`
 }
 
@@ -402,6 +416,9 @@ func (h *Handler) UpdateParticipant(c *gin.Context) {
 		updates["address_state"] = req.Address.State
 		updates["address_postcode"] = req.Address.Postcode
 		updates["address_country"] = req.Address.Country
+		h.geocodeAddress(req.Address)
+		updates["address_latitude"] = req.Address.Latitude
+		updates["address_longitude"] = req.Address.Longitude
 	}
 
 	if req.MedicalInfo != nil {
@@ -420,6 +437,15 @@ func (h *Handler) UpdateParticipant(c *gin.Context) {
 		updates["funding_budget_year"] = req.Funding.BudgetYear
 		updates["funding_plan_start_date"] = req.Funding.PlanStartDate
 		updates["funding_plan_end_date"] = req.Funding.PlanEndDate
+		updates["funding_plan_manager_name"] = req.Funding.PlanManagerName
+		updates["funding_plan_manager_email"] = req.Funding.PlanManagerEmail
+		if req.Funding.ManagementType != "" {
+			updates["funding_management_type"] = req.Funding.ManagementType
+		}
+	}
+
+	if req.Remoteness != nil {
+		updates["remoteness"] = *req.Remoteness
 	}
 
 	if err := h.DB.Model(&participant).Updates(updates).Error; err != nil {
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/rates"
+)
+
+type UpdateRateCardRequest struct {
+	EveningMultiplier       *float64 `json:"evening_multiplier,omitempty" binding:"omitempty,gt=0,lte=5"`
+	NightMultiplier         *float64 `json:"night_multiplier,omitempty" binding:"omitempty,gt=0,lte=5"`
+	SaturdayMultiplier      *float64 `json:"saturday_multiplier,omitempty" binding:"omitempty,gt=0,lte=5"`
+	SundayMultiplier        *float64 `json:"sunday_multiplier,omitempty" binding:"omitempty,gt=0,lte=5"`
+	PublicHolidayMultiplier *float64 `json:"public_holiday_multiplier,omitempty" binding:"omitempty,gt=0,lte=5"`
+}
+
+type CreatePublicHolidayRequest struct {
+	Date  string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name  string `json:"name" binding:"required"`
+	State string `json:"state"` // empty for a national holiday
+}
+
+// rateCard returns the organization's penalty rate multipliers. Organizations that have not
+// set up a rate card price every band at the base rate.
+func (h *Handler) rateCard(orgID string) rates.Card {
+	var card models.RateCard
+	if result := h.DB.Where("organization_id = ?", orgID).Limit(1).Find(&card); result.Error != nil || result.RowsAffected == 0 {
+		return rates.Card{}
+	}
+	return card.Card()
+}
+
+// holidayCalendar loads the public holidays for a state between two local dates
+func (h *Handler) holidayCalendar(orgID, state string, from, to time.Time) rates.Calendar {
+	calendar := rates.Calendar{}
+
+	var holidays []models.PublicHoliday
+	h.DB.Where("organization_id = ? AND (state = ? OR state = ?) AND date >= ? AND date <= ?",
+		orgID, "", state, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Find(&holidays)
+	for _, holiday := range holidays {
+		calendar[holiday.Date] = true
+	}
+	return calendar
+}
+
+// priceShift splits a shift into penalty rate bands in the organization's timezone and sets
+// its cost bands and total cost. Public holidays are those of the participant's state,
+// falling back to the organization's state.
+func (h *Handler) priceShift(shift *models.Shift, participant models.Participant, orgID string) {
+	location, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		location = time.UTC
+	}
+
+	state := catalogue.NormalizeState(participant.Address.State)
+	if state == "" {
+		var organization models.Organization
+		if h.DB.Select("address_state").First(&organization, "id = ?", orgID).Error == nil {
+			state = catalogue.NormalizeState(organization.Address.State)
+		}
+	}
+
+	holidays := h.holidayCalendar(orgID, state, shift.StartTime.In(location), shift.EndTime.In(location))
+	segments := rates.Split(shift.StartTime, shift.EndTime, location, holidays)
+	lines, total := h.rateCard(orgID).Price(segments, shift.HourlyRate)
+
+	shift.CostBands = make([]models.ShiftCostBand, 0, len(lines))
+	for _, line := range lines {
+		shift.CostBands = append(shift.CostBands, models.ShiftCostBand{
+			ShiftID:   shift.ID,
+			Band:      line.Band,
+			StartTime: line.Start,
+			EndTime:   line.End,
+			Hours:     line.Hours,
+			Rate:      line.Rate,
+			Amount:    line.Amount,
+		})
+	}
+	shift.TotalCost = total
+}
+
+func (h *Handler) GetRateCard(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	card := models.RateCard{
+		OrganizationID:          orgID.(string),
+		EveningMultiplier:       1,
+		NightMultiplier:         1,
+		SaturdayMultiplier:      1,
+		SundayMultiplier:        1,
+		PublicHolidayMultiplier: 1,
+	}
+	h.DB.Where("organization_id = ?", orgID).First(&card)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    card,
+	})
+}
+
+func (h *Handler) UpdateRateCard(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateRateCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var card models.RateCard
+	if err := h.DB.Where("organization_id = ?", orgID).First(&card).Error; err != nil {
+		card = models.RateCard{
+			OrganizationID:          orgID.(string),
+			EveningMultiplier:       1,
+			NightMultiplier:         1,
+			SaturdayMultiplier:      1,
+			SundayMultiplier:        1,
+			PublicHolidayMultiplier: 1,
+		}
+	}
+
+	if req.EveningMultiplier != nil {
+		card.EveningMultiplier = *req.EveningMultiplier
+	}
+	if req.NightMultiplier != nil {
+		card.NightMultiplier = *req.NightMultiplier
+	}
+	if req.SaturdayMultiplier != nil {
+		card.SaturdayMultiplier = *req.SaturdayMultiplier
+	}
+	if req.SundayMultiplier != nil {
+		card.SundayMultiplier = *req.SundayMultiplier
+	}
+	if req.PublicHolidayMultiplier != nil {
+		card.PublicHolidayMultiplier = *req.PublicHolidayMultiplier
+	}
+
+	// Existing shifts keep the price they were booked at
+	if err := h.DB.Save(&card).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update rate card",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    card,
+		"message": "Rate card updated successfully",
+	})
+}
+
+func (h *Handler) GetPublicHolidays(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("organization_id = ?", orgID)
+	if state := c.Query("state"); state != "" {
+		query = query.Where("state = ? OR state = ?", "", catalogue.NormalizeState(state))
+	}
+	if year := c.Query("year"); year != "" {
+		query = query.Where("date LIKE ?", year+"-%")
+	}
+
+	var holidays []models.PublicHoliday
+	if err := query.Order("date ASC, state ASC").Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch public holidays",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"public_holidays": holidays,
+		},
+	})
+}
+
+func (h *Handler) CreatePublicHoliday(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreatePublicHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format (YYYY-MM-DD expected)",
+			},
+		})
+		return
+	}
+
+	state := ""
+	if strings.TrimSpace(req.State) != "" {
+		state = catalogue.NormalizeState(req.State)
+		if state == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STATE",
+					"message": "Unknown state or territory",
+				},
+			})
+			return
+		}
+	}
+
+	var existing int64
+	h.DB.Model(&models.PublicHoliday{}).
+		Where("organization_id = ? AND state = ? AND date = ?", orgID, state, req.Date).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "HOLIDAY_EXISTS",
+				"message": "A public holiday already exists on this date",
+			},
+		})
+		return
+	}
+
+	holiday := models.PublicHoliday{
+		OrganizationID: orgID.(string),
+		State:          state,
+		Date:           req.Date,
+		Name:           req.Name,
+		CreatedBy:      h.GetUserIDFromContext(c),
+	}
+	if err := h.DB.Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create public holiday",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    holiday,
+		"message": "Public holiday created successfully",
+	})
+}
+
+func (h *Handler) DeletePublicHoliday(c *gin.Context) {
+	holidayID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	result := h.DB.Where("id = ? AND organization_id = ?", holidayID, orgID).Delete(&models.PublicHoliday{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete public holiday",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "HOLIDAY_NOT_FOUND",
+				"message": "Public holiday not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Public holiday deleted successfully",
+	})
+}
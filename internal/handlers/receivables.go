@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// Invoice reminder outcomes
+const (
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+	ReminderSkipped = "skipped"
+)
+
+// AgedBalance is an outstanding balance split by how long ago it was invoiced
+type AgedBalance struct {
+	Current    float64 `json:"current"`      // invoiced in the last 30 days
+	Days30     float64 `json:"days_30"`      // 31 to 60 days
+	Days60     float64 `json:"days_60"`      // 61 to 90 days
+	Days90Plus float64 `json:"days_90_plus"` // more than 90 days
+	Total      float64 `json:"total"`
+	Overdue    float64 `json:"overdue"` // past its due date, whatever its age
+}
+
+func (b *AgedBalance) add(age int, balance float64, overdue bool) {
+	switch {
+	case age <= 30:
+		b.Current += balance
+	case age <= 60:
+		b.Days30 += balance
+	case age <= 90:
+		b.Days60 += balance
+	default:
+		b.Days90Plus += balance
+	}
+	b.Total += balance
+	if overdue {
+		b.Overdue += balance
+	}
+}
+
+func (b *AgedBalance) round() {
+	b.Current = roundCurrency(b.Current)
+	b.Days30 = roundCurrency(b.Days30)
+	b.Days60 = roundCurrency(b.Days60)
+	b.Days90Plus = roundCurrency(b.Days90Plus)
+	b.Total = roundCurrency(b.Total)
+	b.Overdue = roundCurrency(b.Overdue)
+}
+
+type ParticipantReceivable struct {
+	ParticipantID   string `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+	NDISNumber      string `json:"ndis_number"`
+	ManagementType  string `json:"management_type"`
+	PlanManagerName string `json:"plan_manager_name,omitempty"`
+	InvoiceCount    int    `json:"invoice_count"`
+	AgedBalance
+}
+
+type PlanManagerReceivable struct {
+	ExternalContactID *string `json:"external_contact_id,omitempty"`
+	PlanManagerName   string  `json:"plan_manager_name"`
+	PlanManagerEmail  string  `json:"plan_manager_email"`
+	ParticipantCount  int     `json:"participant_count"`
+	InvoiceCount      int     `json:"invoice_count"`
+	AgedBalance
+}
+
+// receivablesRun summarises one pass of the overdue and reminder job
+type receivablesRun struct {
+	MarkedOverdue   int `json:"marked_overdue"`
+	RemindersSent   int `json:"reminders_sent"`
+	RemindersFailed int `json:"reminders_failed"`
+}
+
+// parseReminderDays parses a reminder sequence such as "7,14,30": the number of days past
+// the due date at which each reminder is sent. An empty sequence disables reminders.
+func parseReminderDays(value string) ([]int, error) {
+	days := []int{}
+	if strings.TrimSpace(value) == "" {
+		return days, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || day < 1 {
+			return nil, fmt.Errorf("payment_reminder_days must be a comma separated list of positive days, got %q", part)
+		}
+		if len(days) > 0 && day <= days[len(days)-1] {
+			return nil, errors.New("payment_reminder_days must be in increasing order")
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// paymentReminderDays returns the organization's reminder sequence, or none when email notifications are off
+func (h *Handler) paymentReminderDays(orgID string) []int {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return []int{7, 14, 30}
+	}
+	if !settings.EnableEmailNotifications {
+		return nil
+	}
+	days, err := parseReminderDays(settings.PaymentReminderDays)
+	if err != nil {
+		return nil
+	}
+	return days
+}
+
+// daysBetween counts calendar days from one time to another in a location
+func daysBetween(from, to time.Time, loc *time.Location) int {
+	from, to = from.In(loc), to.In(loc)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	// Round rather than truncate so a daylight saving change does not lose a day
+	return int(end.Sub(start).Hours()/24 + 0.5)
+}
+
+// invoiceSettled updates an invoice's balance after a payment or credit and marks it paid once nothing is owed
+func invoiceSettled(invoice models.Invoice, amountPaid, credited float64, settledAt time.Time) map[string]interface{} {
+	balance := roundCurrency(invoice.Total - amountPaid - credited)
+	updates := map[string]interface{}{
+		"amount_paid":     amountPaid,
+		"credited_amount": credited,
+		"balance_due":     balance,
+		"document_id":     nil, // The cached PDF shows the old balance
+	}
+	if balance <= 0 {
+		updates["status"] = "paid"
+		updates["paid_date"] = settledAt
+	}
+	return updates
+}
+
+// runReceivables marks an organization's unpaid invoices overdue once their due date has
+// passed and sends the next reminder in the organization's sequence for each of them. Drafts
+// have never been sent to the payer, so are left alone.
+func (h *Handler) runReceivables(orgID string, now time.Time) (receivablesRun, error) {
+	run := receivablesRun{}
+
+	orgTz, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		orgTz = time.UTC
+	}
+	local := now.In(orgTz)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, orgTz)
+
+	result := h.DB.Model(&models.Invoice{}).
+		Where("organization_id = ? AND status = ? AND balance_due > 0 AND due_date < ?", orgID, "sent", today).
+		Update("status", "overdue")
+	if result.Error != nil {
+		return run, result.Error
+	}
+	run.MarkedOverdue = int(result.RowsAffected)
+
+	reminderDays := h.paymentReminderDays(orgID)
+	if h.Mailer == nil || len(reminderDays) == 0 {
+		return run, nil
+	}
+
+	var organization models.Organization
+	h.DB.Where("id = ?", orgID).First(&organization)
+
+	var invoices []models.Invoice
+	if err := h.DB.Where("organization_id = ? AND status = ? AND balance_due > 0", orgID, "overdue").
+		Preload("Participant").Order("due_date ASC").Find(&invoices).Error; err != nil {
+		return run, err
+	}
+
+	for _, invoice := range invoices {
+		daysOverdue := daysBetween(invoice.DueDate, now, orgTz)
+
+		// Only the latest step that is due is sent, so a job that has not run for a while
+		// does not send a burst of reminders
+		step := 0
+		for i, days := range reminderDays {
+			if daysOverdue >= days {
+				step = i + 1
+			}
+		}
+		if step == 0 {
+			continue
+		}
+
+		var previous models.InvoiceReminder
+		err := h.DB.Where("invoice_id = ? AND step >= ?", invoice.ID, step).Order("step DESC").First(&previous).Error
+		if err == nil && (previous.Step > step || previous.Status != ReminderFailed) {
+			continue
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return run, err
+		}
+
+		payer := h.invoicePayer(h.DB, invoice.Participant)
+		name, email := payer.Name, payer.Email
+		reminder := previous
+		if err != nil {
+			// Claim the step before sending so a concurrent run cannot send it too
+			reminder = models.InvoiceReminder{OrganizationID: orgID, InvoiceID: invoice.ID, Step: step, Status: ReminderFailed}
+			if err := h.DB.Create(&reminder).Error; err != nil {
+				continue
+			}
+		}
+
+		updates := map[string]interface{}{"days_overdue": daysOverdue, "sent_to": email, "status": ReminderSent, "error": ""}
+		if email == "" {
+			updates["status"] = ReminderSkipped
+			updates["error"] = "Billing contact has no email address"
+		} else if err := h.Mailer.Send(paymentReminder(organization, invoice, name, email, daysOverdue, orgTz)); err != nil {
+			updates["status"] = ReminderFailed
+			updates["error"] = err.Error()
+		}
+		h.DB.Model(&reminder).Updates(updates)
+
+		switch updates["status"] {
+		case ReminderSent:
+			run.RemindersSent++
+		case ReminderFailed:
+			run.RemindersFailed++
+		}
+	}
+
+	return run, nil
+}
+
+// paymentReminder writes the reminder email for an overdue invoice
+func paymentReminder(organization models.Organization, invoice models.Invoice, name, email string, daysOverdue int, loc *time.Location) notify.Message {
+	participant := invoice.Participant.FirstName + " " + invoice.Participant.LastName
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Dear %s,\n\n", name)
+	fmt.Fprintf(&body, "Invoice %s issued on %s for supports provided to %s was due on %s and is now %d days overdue.\n\n",
+		invoice.InvoiceNumber, invoice.IssueDate.In(loc).Format("02/01/2006"), participant, invoice.DueDate.In(loc).Format("02/01/2006"), daysOverdue)
+	fmt.Fprintf(&body, "The outstanding balance is $%.2f.\n\n", invoice.BalanceDue)
+	body.WriteString("Please arrange payment at your earliest convenience. If you have already paid, please disregard this reminder.\n\n")
+	fmt.Fprintf(&body, "Kind regards,\n%s\n", organization.Name)
+
+	return notify.Message{
+		To:      []string{email},
+		Subject: fmt.Sprintf("Payment reminder: invoice %s is overdue", invoice.InvoiceNumber),
+		Body:    body.String(),
+	}
+}
+
+// StartReceivablesJob runs the overdue and reminder job for every organization at the given interval
+func (h *Handler) StartReceivablesJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			var orgIDs []string
+			if err := h.DB.Model(&models.Organization{}).Pluck("id", &orgIDs).Error; err != nil {
+				log.Printf("Receivables job failed to list organizations: %v", err)
+				continue
+			}
+			for _, orgID := range orgIDs {
+				run, err := h.runReceivables(orgID, time.Now())
+				if err != nil {
+					log.Printf("Receivables job failed for organization %s: %v", orgID, err)
+					continue
+				}
+				if run.MarkedOverdue > 0 || run.RemindersSent > 0 || run.RemindersFailed > 0 {
+					log.Printf("Receivables job for organization %s: %d overdue, %d reminders sent, %d failed",
+						orgID, run.MarkedOverdue, run.RemindersSent, run.RemindersFailed)
+				}
+			}
+		}
+	}()
+}
+
+// RunReceivables runs the overdue and reminder job for the caller's organization now
+func (h *Handler) RunReceivables(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	run, err := h.runReceivables(orgID.(string), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to process receivables",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+		"message": "Receivables processed successfully",
+	})
+}
+
+// GetAgedReceivables reports outstanding invoice balances by age, per participant and per plan manager
+func (h *Handler) GetAgedReceivables(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	orgTz, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		orgTz = time.UTC
+	}
+
+	asAt := time.Now().In(orgTz)
+	if value := c.Query("as_at"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, orgTz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "as_at must be a date (YYYY-MM-DD)",
+				},
+			})
+			return
+		}
+		asAt = parsed
+	}
+	endOfDay := time.Date(asAt.Year(), asAt.Month(), asAt.Day(), 0, 0, 0, 0, orgTz).AddDate(0, 0, 1)
+
+	var invoices []models.Invoice
+	if err := h.DB.Where("organization_id = ? AND balance_due > 0 AND issue_date < ?", orgID, endOfDay).
+		Preload("Participant").Order("issue_date ASC").Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoices",
+			},
+		})
+		return
+	}
+
+	totals := AgedBalance{}
+	participants := []*ParticipantReceivable{}
+	byParticipant := map[string]*ParticipantReceivable{}
+	planManagers := []*PlanManagerReceivable{}
+	byPlanManager := map[string]*PlanManagerReceivable{}
+	planManagerParticipants := map[string]map[string]bool{}
+
+	payers := map[string]InvoicePayer{}
+	for _, invoice := range invoices {
+		age := daysBetween(invoice.IssueDate, asAt, orgTz)
+		overdue := daysBetween(invoice.DueDate, asAt, orgTz) > 0
+		totals.add(age, invoice.BalanceDue, overdue)
+
+		participant := invoice.Participant
+		payer, ok := payers[participant.ID]
+		if !ok {
+			payer = h.invoicePayer(h.DB, participant)
+			payers[participant.ID] = payer
+		}
+
+		row, ok := byParticipant[participant.ID]
+		if !ok {
+			row = &ParticipantReceivable{
+				ParticipantID:   participant.ID,
+				ParticipantName: participant.FirstName + " " + participant.LastName,
+				NDISNumber:      participant.NDISNumber,
+				ManagementType:  participant.Funding.ManagementType,
+			}
+			if payer.IsPlanManager {
+				row.PlanManagerName = payer.Name
+			}
+			byParticipant[participant.ID] = row
+			participants = append(participants, row)
+		}
+		row.InvoiceCount++
+		row.add(age, invoice.BalanceDue, overdue)
+
+		if !payer.IsPlanManager {
+			continue
+		}
+		// Plan managers without a contact record are matched by email, as the same firm is
+		// entered against many participants
+		key := strings.ToLower(strings.TrimSpace(payer.Email))
+		if payer.ExternalContactID != nil {
+			key = *payer.ExternalContactID
+		} else if key == "" {
+			key = strings.ToLower(strings.TrimSpace(payer.Name))
+		}
+		manager, ok := byPlanManager[key]
+		if !ok {
+			manager = &PlanManagerReceivable{
+				ExternalContactID: payer.ExternalContactID,
+				PlanManagerName:   payer.Name,
+				PlanManagerEmail:  payer.Email,
+			}
+			byPlanManager[key] = manager
+			planManagers = append(planManagers, manager)
+			planManagerParticipants[key] = map[string]bool{}
+		}
+		if !planManagerParticipants[key][participant.ID] {
+			planManagerParticipants[key][participant.ID] = true
+			manager.ParticipantCount++
+		}
+		manager.InvoiceCount++
+		manager.add(age, invoice.BalanceDue, overdue)
+	}
+
+	totals.round()
+	for _, row := range participants {
+		row.round()
+	}
+	for _, manager := range planManagers {
+		manager.round()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"as_at":         asAt.Format("2006-01-02"),
+			"totals":        totals,
+			"participants":  participants,
+			"plan_managers": planManagers,
+		},
+	})
+}
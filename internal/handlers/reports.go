@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -389,17 +391,101 @@ func (h *Handler) GetParticipantReport(c *gin.Context) {
 		}
 	}
 
+	// Continuity of care covers the current month and the ones before it, 6 by default
+	months := 6
+	if value, err := strconv.Atoi(c.DefaultQuery("months", "6")); err == nil && value >= 1 && value <= 24 {
+		months = value
+	}
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, -(months - 1), 0)
+
+	var shifts []models.Shift
+	h.DB.Select("shifts.participant_id, shifts.staff_id, shifts.start_time").
+		Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.staff_id IS NOT NULL AND shifts.status != ? AND shifts.start_time >= ? AND shifts.start_time < ?",
+			orgID, "cancelled", from, from.AddDate(0, months, 0)).
+		Find(&shifts)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
 			"total_participants":    len(participants),
 			"active_participants":   activeCount,
 			"inactive_participants": inactiveCount,
+			"continuity_of_care":    continuityOfCare(participants, shifts, from, months, loc),
 			"report_generated":      time.Now(),
 		},
 	})
 }
 
+// continuityOfCare counts the distinct workers each participant saw in each month from the
+// month starting at from. Fewer workers a month means better continuity of care.
+func continuityOfCare(participants []models.Participant, shifts []models.Shift, from time.Time, months int, loc *time.Location) gin.H {
+	labels := make([]string, months)
+	for i := range labels {
+		labels[i] = from.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	workers := map[string]map[string]map[string]bool{} // participant, month, staff
+	shiftCounts := map[string]map[string]int{}
+	for _, shift := range shifts {
+		month := shift.StartTime.In(loc).Format("2006-01")
+		if workers[shift.ParticipantID] == nil {
+			workers[shift.ParticipantID] = map[string]map[string]bool{}
+			shiftCounts[shift.ParticipantID] = map[string]int{}
+		}
+		if workers[shift.ParticipantID][month] == nil {
+			workers[shift.ParticipantID][month] = map[string]bool{}
+		}
+		workers[shift.ParticipantID][month][*shift.StaffID] = true
+		shiftCounts[shift.ParticipantID][month]++
+	}
+
+	rows := []gin.H{}
+	totalWorkers, servedMonths := 0, 0
+	for _, participant := range participants {
+		if workers[participant.ID] == nil {
+			continue
+		}
+		monthly := []gin.H{}
+		participantWorkers, participantMonths := 0, 0
+		for _, month := range labels {
+			count := len(workers[participant.ID][month])
+			monthly = append(monthly, gin.H{
+				"month":   month,
+				"workers": count,
+				"shifts":  shiftCounts[participant.ID][month],
+			})
+			if count > 0 {
+				participantWorkers += count
+				participantMonths++
+			}
+		}
+		totalWorkers += participantWorkers
+		servedMonths += participantMonths
+		rows = append(rows, gin.H{
+			"participant_id":            participant.ID,
+			"participant_name":          participant.FirstName + " " + participant.LastName,
+			"months":                    monthly,
+			"average_workers_per_month": math.Round(float64(participantWorkers)/float64(participantMonths)*100) / 100,
+		})
+	}
+
+	average := 0.0
+	if servedMonths > 0 {
+		average = math.Round(float64(totalWorkers)/float64(servedMonths)*100) / 100
+	}
+	return gin.H{
+		"months":                    labels,
+		"participants":              rows,
+		"average_workers_per_month": average,
+	}
+}
+
 func (h *Handler) GetStaffPerformance(c *gin.Context) {
 	orgID, exists := c.Get("org_id")
 	if !exists {
@@ -0,0 +1,1025 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxRosterDays bounds how many days a template can be stamped onto or a roster published for at once
+const maxRosterDays = 12 * 7
+
+type RosterTemplateShiftRequest struct {
+	DayOffset     int     `json:"day_offset" binding:"min=0"`
+	StartTime     string  `json:"start_time" binding:"required"` // HH:MM
+	EndTime       string  `json:"end_time" binding:"required"`   // HH:MM, earlier than the start for overnight shifts
+	ParticipantID string  `json:"participant_id" binding:"required"`
+	StaffID       string  `json:"staff_id" binding:"required"`
+	ServiceType   string  `json:"service_type" binding:"required"`
+	SupportItemID *string `json:"support_item_id,omitempty"`
+	Location      string  `json:"location" binding:"required"`
+	HourlyRate    float64 `json:"hourly_rate" binding:"required,gt=0"`
+	Notes         string  `json:"notes"`
+}
+
+type RosterTemplateRequest struct {
+	Name        string                       `json:"name" binding:"required"`
+	Description string                       `json:"description"`
+	PeriodDays  int                          `json:"period_days" binding:"omitempty,min=1,max=84"` // defaults to a fortnight
+	Shifts      []RosterTemplateShiftRequest `json:"shifts" binding:"dive"`
+}
+
+type ApplyRosterTemplateRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // first day of the template period, YYYY-MM-DD
+	EndDate   string `json:"end_date,omitempty"`            // last day, inclusive; defaults to one period
+}
+
+type PublishRosterRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+}
+
+// TemplateConflict is a template shift that was not stamped because its staff member
+// already has a shift at that time, or can't be booked on it
+type TemplateConflict struct {
+	TemplateShiftID string      `json:"template_shift_id"`
+	StaffID         string      `json:"staff_id"`
+	StartTime       time.Time   `json:"start_time"`
+	EndTime         time.Time   `json:"end_time"`
+	Code            string      `json:"code"`
+	Message         string      `json:"message"`
+	Details         interface{} `json:"details,omitempty"`
+}
+
+// RosterShiftChange describes a shift in a worker's roster diff
+type RosterShiftChange struct {
+	ShiftID         string             `json:"shift_id"`
+	ParticipantID   string             `json:"participant_id"`
+	ParticipantName string             `json:"participant_name"`
+	StartTime       time.Time          `json:"start_time"`
+	EndTime         time.Time          `json:"end_time"`
+	ServiceType     string             `json:"service_type"`
+	Location        string             `json:"location"`
+	Previous        *RosterShiftChange `json:"previous,omitempty"` // as last published, for changed shifts
+}
+
+// WorkerRosterChanges is what publishing a roster changes for one staff member
+type WorkerRosterChanges struct {
+	StaffID   string              `json:"staff_id"`
+	StaffName string              `json:"staff_name"`
+	New       []RosterShiftChange `json:"new"`
+	Changed   []RosterShiftChange `json:"changed"`
+	Removed   []RosterShiftChange `json:"removed"`
+}
+
+// parseClock reads a local time of day written as HH:MM
+func parseClock(value string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: use HH:MM", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// templateShiftTimes normalises a template shift's start and returns its length in
+// minutes. An end at or before the start runs overnight.
+func templateShiftTimes(start, end string) (string, int, error) {
+	startHour, startMinute, err := parseClock(start)
+	if err != nil {
+		return "", 0, err
+	}
+	endHour, endMinute, err := parseClock(end)
+	if err != nil {
+		return "", 0, err
+	}
+	minutes := (endHour*60 + endMinute) - (startHour*60 + startMinute)
+	if minutes <= 0 {
+		minutes += 24 * 60
+	}
+	return fmt.Sprintf("%02d:%02d", startHour, startMinute), minutes, nil
+}
+
+// buildTemplateShifts validates the shifts of a template request, writing the error
+// response and returning false when one is invalid
+func (h *Handler) buildTemplateShifts(c *gin.Context, orgID string, periodDays int, reqs []RosterTemplateShiftRequest) ([]models.RosterTemplateShift, bool) {
+	shifts := make([]models.RosterTemplateShift, 0, len(reqs))
+	for i, req := range reqs {
+		if req.DayOffset >= periodDays {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DAY_OFFSET",
+					"message": fmt.Sprintf("Shift %d falls on day %d of a %d day period", i+1, req.DayOffset, periodDays),
+				},
+			})
+			return nil, false
+		}
+
+		startTime, minutes, err := templateShiftTimes(req.StartTime, req.EndTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_TIME",
+					"message": fmt.Sprintf("Shift %d has an invalid time", i+1),
+					"details": err.Error(),
+				},
+			})
+			return nil, false
+		}
+		shift := models.RosterTemplateShift{
+			DayOffset:       req.DayOffset,
+			StartTime:       startTime,
+			DurationMinutes: minutes,
+			ParticipantID:   req.ParticipantID,
+			StaffID:         req.StaffID,
+			ServiceType:     req.ServiceType,
+			Location:        req.Location,
+			HourlyRate:      req.HourlyRate,
+			Notes:           req.Notes,
+		}
+
+		var count int64
+		h.DB.Model(&models.Participant{}).Where("id = ? AND organization_id = ? AND is_active = ?", req.ParticipantID, orgID, true).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_PARTICIPANT",
+					"message": fmt.Sprintf("Participant of shift %d not found or inactive", i+1),
+				},
+			})
+			return nil, false
+		}
+
+		h.DB.Model(&models.User{}).Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": fmt.Sprintf("Staff member of shift %d not found or inactive", i+1),
+				},
+			})
+			return nil, false
+		}
+
+		if req.SupportItemID != nil && *req.SupportItemID != "" {
+			supportItem, err := h.loadSupportItem(*req.SupportItemID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_SUPPORT_ITEM",
+						"message": fmt.Sprintf("Support item of shift %d not found or inactive", i+1),
+					},
+				})
+				return nil, false
+			}
+			shift.SupportItemID = &supportItem.ID
+		}
+		shifts = append(shifts, shift)
+	}
+	return shifts, true
+}
+
+// fetchRosterTemplate loads one of the organization's templates with its shifts, writing
+// the error response and returning false when it is missing
+func (h *Handler) fetchRosterTemplate(c *gin.Context, orgID string, template *models.RosterTemplate) bool {
+	err := h.DB.Preload("Shifts", func(db *gorm.DB) *gorm.DB { return db.Order("day_offset, start_time") }).
+		Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		First(template).Error
+	if err == nil {
+		return true
+	}
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ROSTER_TEMPLATE_NOT_FOUND",
+				"message": "Roster template not found",
+			},
+		})
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "DATABASE_ERROR",
+			"message": "Failed to fetch roster template",
+		},
+	})
+	return false
+}
+
+// GetRosterTemplates lists the organization's roster templates
+func (h *Handler) GetRosterTemplates(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var templates []models.RosterTemplate
+	if err := h.DB.Preload("Shifts", func(db *gorm.DB) *gorm.DB { return db.Order("day_offset, start_time") }).
+		Where("organization_id = ?", orgID).Order("name").Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch roster templates",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"templates": templates,
+		},
+	})
+}
+
+// GetRosterTemplate returns a roster template with its shifts
+func (h *Handler) GetRosterTemplate(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var template models.RosterTemplate
+	if !h.fetchRosterTemplate(c, orgID.(string), &template) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// CreateRosterTemplate creates a named roster template
+func (h *Handler) CreateRosterTemplate(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req RosterTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.PeriodDays == 0 {
+		req.PeriodDays = 14
+	}
+
+	shifts, ok := h.buildTemplateShifts(c, orgID.(string), req.PeriodDays, req.Shifts)
+	if !ok {
+		return
+	}
+
+	template := models.RosterTemplate{
+		OrganizationID: orgID.(string),
+		Name:           req.Name,
+		Description:    req.Description,
+		PeriodDays:     req.PeriodDays,
+		CreatedBy:      h.GetUserIDFromContext(c),
+		Shifts:         shifts,
+	}
+	if err := h.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create roster template",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+		"message": "Roster template created successfully",
+	})
+}
+
+// UpdateRosterTemplate replaces a roster template's details and shifts
+func (h *Handler) UpdateRosterTemplate(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var template models.RosterTemplate
+	if !h.fetchRosterTemplate(c, orgID.(string), &template) {
+		return
+	}
+
+	var req RosterTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.PeriodDays == 0 {
+		req.PeriodDays = template.PeriodDays
+	}
+
+	shifts, ok := h.buildTemplateShifts(c, orgID.(string), req.PeriodDays, req.Shifts)
+	if !ok {
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&template).Updates(map[string]interface{}{
+			"name":        req.Name,
+			"description": req.Description,
+			"period_days": req.PeriodDays,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.RosterTemplateShift{}).Error; err != nil {
+			return err
+		}
+		for i := range shifts {
+			shifts[i].TemplateID = template.ID
+		}
+		if len(shifts) > 0 {
+			return tx.Create(&shifts).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update roster template",
+			},
+		})
+		return
+	}
+
+	h.fetchRosterTemplate(c, orgID.(string), &template)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+		"message": "Roster template updated successfully",
+	})
+}
+
+// DeleteRosterTemplate deletes a roster template. Shifts already stamped from it are kept.
+func (h *Handler) DeleteRosterTemplate(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var template models.RosterTemplate
+	if !h.fetchRosterTemplate(c, orgID.(string), &template) {
+		return
+	}
+
+	if err := h.DB.Delete(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete roster template",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Roster template deleted successfully",
+	})
+}
+
+// rosterDates reads an inclusive range of dates in the organization's timezone and returns
+// it as a half open range of times, writing the error response and returning false when
+// the range is invalid
+func (h *Handler) rosterDates(c *gin.Context, orgID, startDate, endDate string) (time.Time, time.Time, bool) {
+	loc, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", startDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid start date format. Use YYYY-MM-DD.",
+			},
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	last, err := time.ParseInLocation("2006-01-02", endDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid end date format. Use YYYY-MM-DD.",
+			},
+		})
+		return time.Time{}, time.Time{}, false
+	}
+
+	to := last.AddDate(0, 0, 1)
+	if !to.After(from) || to.After(from.AddDate(0, 0, maxRosterDays)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": fmt.Sprintf("End date must be on or after the start date and within %d days of it", maxRosterDays),
+			},
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// ApplyRosterTemplate stamps a template onto a date range as draft shifts, repeating it
+// for ranges longer than its period. Shifts that clash with one their staff member already
+// has, or that the staff member couldn't be booked on by hand, are skipped and reported as
+// conflicts. The shifts are created together or not at all.
+func (h *Handler) ApplyRosterTemplate(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var template models.RosterTemplate
+	if !h.fetchRosterTemplate(c, orgID.(string), &template) {
+		return
+	}
+
+	var req ApplyRosterTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.EndDate == "" {
+		if start, err := time.Parse("2006-01-02", req.StartDate); err == nil {
+			req.EndDate = start.AddDate(0, 0, template.PeriodDays-1).Format("2006-01-02")
+		}
+	}
+
+	from, to, ok := h.rosterDates(c, orgID.(string), req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	participants := map[string]models.Participant{}
+	shifts := []models.Shift{}
+	conflicts := []TemplateConflict{}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// The checks read through the transaction, so each shift is checked against the
+		// ones stamped before it
+		stamp := *h
+		stamp.DB = tx
+
+		for day, date := 0, from; date.Before(to); day, date = day+1, from.AddDate(0, 0, day+1) {
+			for _, templateShift := range template.Shifts {
+				if templateShift.DayOffset != day%template.PeriodDays {
+					continue
+				}
+
+				hour, minute, _ := parseClock(templateShift.StartTime)
+				startTime := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+				endTime := startTime.Add(time.Duration(templateShift.DurationMinutes) * time.Minute)
+				if hasScheduleConflict(tx, templateShift.StaffID, "", startTime, endTime) {
+					conflicts = append(conflicts, TemplateConflict{
+						TemplateShiftID: templateShift.ID,
+						StaffID:         templateShift.StaffID,
+						StartTime:       startTime,
+						EndTime:         endTime,
+						Code:            "SCHEDULE_CONFLICT",
+						Message:         "Staff member already has a shift scheduled during this time",
+					})
+					continue
+				}
+
+				participant, loaded := participants[templateShift.ParticipantID]
+				if !loaded {
+					tx.First(&participant, "id = ?", templateShift.ParticipantID)
+					participants[templateShift.ParticipantID] = participant
+				}
+
+				shift := models.Shift{
+					ParticipantID: templateShift.ParticipantID,
+					StaffID:       &templateShift.StaffID,
+					StartTime:     startTime,
+					EndTime:       endTime,
+					ServiceType:   templateShift.ServiceType,
+					SupportItemID: templateShift.SupportItemID,
+					Location:      templateShift.Location,
+					Status:        "scheduled",
+					PublishStatus: models.ShiftDraft,
+					HourlyRate:    templateShift.HourlyRate,
+					Notes:         templateShift.Notes,
+				}
+				stamp.geocodeShift(&shift)
+
+				// The same checks as booking the shift by hand, without the credential override
+				conflict, err := stamp.generatedShiftConflict(orgID.(string), templateShift.StaffID, shift, participant)
+				if err == nil && conflict == nil {
+					conflict, err = stamp.fatigueConflict(orgID.(string), templateShift.StaffID, shift, participant)
+				}
+				if err != nil {
+					return err
+				}
+				if conflict != nil {
+					conflicts = append(conflicts, TemplateConflict{
+						TemplateShiftID: templateShift.ID,
+						StaffID:         templateShift.StaffID,
+						StartTime:       startTime,
+						EndTime:         endTime,
+						Code:            conflict.Code,
+						Message:         conflict.Message,
+						Details:         conflict.Details,
+					})
+					continue
+				}
+
+				stamp.priceShift(&shift, participant, orgID.(string))
+				if err := tx.Create(&shift).Error; err != nil {
+					return err
+				}
+				shifts = append(shifts, shift)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create shifts from the template",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shifts":    shifts,
+			"conflicts": conflicts,
+		},
+		"message": fmt.Sprintf("Created %d draft shifts with %d conflicts", len(shifts), len(conflicts)),
+	})
+}
+
+// rosterChanges works out what publishing the roster between from and to would change for
+// each staff member, comparing the shifts in the period with what was last published. It
+// returns the changes by worker, the shifts to publish and the published shifts that have
+// since been cancelled or deleted.
+func (h *Handler) rosterChanges(orgID string, from, to time.Time) ([]WorkerRosterChanges, []models.Shift, []models.PublishedShift, error) {
+	var snapshots []models.PublishedShift
+	if err := h.DB.Where("organization_id = ? AND start_time >= ? AND start_time < ?", orgID, from, to).
+		Find(&snapshots).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	snapshotIDs := []string{}
+	for _, snapshot := range snapshots {
+		snapshotIDs = append(snapshotIDs, snapshot.ShiftID)
+	}
+
+	// Assigned shifts in the period, and published ones that have been moved out of it. Open
+	// shifts have nobody to publish to, so a published shift that is unassigned counts as removed.
+	var shifts []models.Shift
+	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.status != ? AND shifts.staff_id IS NOT NULL", orgID, "cancelled")
+	if len(snapshotIDs) > 0 {
+		query = query.Where("((shifts.start_time >= ? AND shifts.start_time < ?) OR shifts.id IN ?)", from, to, snapshotIDs)
+	} else {
+		query = query.Where("shifts.start_time >= ? AND shifts.start_time < ?", from, to)
+	}
+	if err := query.Preload("Participant").Order("shifts.start_time").Find(&shifts).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Published shifts moved into the period from outside it
+	published := map[string]models.PublishedShift{}
+	for _, snapshot := range snapshots {
+		published[snapshot.ShiftID] = snapshot
+	}
+	missing := []string{}
+	for _, shift := range shifts {
+		if _, ok := published[shift.ID]; !ok {
+			missing = append(missing, shift.ID)
+		}
+	}
+	if len(missing) > 0 {
+		var moved []models.PublishedShift
+		if err := h.DB.Where("shift_id IN ?", missing).Find(&moved).Error; err != nil {
+			return nil, nil, nil, err
+		}
+		for _, snapshot := range moved {
+			published[snapshot.ShiftID] = snapshot
+		}
+	}
+
+	// Names for the diff
+	participantNames := map[string]string{}
+	for _, shift := range shifts {
+		participantNames[shift.ParticipantID] = shift.Participant.FirstName + " " + shift.Participant.LastName
+	}
+	missingParticipants := []string{}
+	staffIDs := []string{}
+	for _, shift := range shifts {
+		staffIDs = append(staffIDs, *shift.StaffID)
+	}
+	for _, snapshot := range published {
+		staffIDs = append(staffIDs, snapshot.StaffID)
+		if _, ok := participantNames[snapshot.ParticipantID]; !ok {
+			missingParticipants = append(missingParticipants, snapshot.ParticipantID)
+		}
+	}
+	if len(missingParticipants) > 0 {
+		var participants []models.Participant
+		h.DB.Unscoped().Where("id IN ?", missingParticipants).Find(&participants)
+		for _, participant := range participants {
+			participantNames[participant.ID] = participant.FirstName + " " + participant.LastName
+		}
+	}
+	staffNames := map[string]string{}
+	if len(staffIDs) > 0 {
+		var staff []models.User
+		h.DB.Unscoped().Where("id IN ?", staffIDs).Find(&staff)
+		for _, user := range staff {
+			staffNames[user.ID] = user.FirstName + " " + user.LastName
+		}
+	}
+
+	workers := map[string]*WorkerRosterChanges{}
+	worker := func(staffID string) *WorkerRosterChanges {
+		if workers[staffID] == nil {
+			workers[staffID] = &WorkerRosterChanges{
+				StaffID:   staffID,
+				StaffName: staffNames[staffID],
+				New:       []RosterShiftChange{},
+				Changed:   []RosterShiftChange{},
+				Removed:   []RosterShiftChange{},
+			}
+		}
+		return workers[staffID]
+	}
+	fromShift := func(shift models.Shift) RosterShiftChange {
+		return RosterShiftChange{
+			ShiftID:         shift.ID,
+			ParticipantID:   shift.ParticipantID,
+			ParticipantName: participantNames[shift.ParticipantID],
+			StartTime:       shift.StartTime,
+			EndTime:         shift.EndTime,
+			ServiceType:     shift.ServiceType,
+			Location:        shift.Location,
+		}
+	}
+	fromSnapshot := func(snapshot models.PublishedShift) RosterShiftChange {
+		return RosterShiftChange{
+			ShiftID:         snapshot.ShiftID,
+			ParticipantID:   snapshot.ParticipantID,
+			ParticipantName: participantNames[snapshot.ParticipantID],
+			StartTime:       snapshot.StartTime,
+			EndTime:         snapshot.EndTime,
+			ServiceType:     snapshot.ServiceType,
+			Location:        snapshot.Location,
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, shift := range shifts {
+		snapshot, ok := published[shift.ID]
+		seen[shift.ID] = true
+		switch {
+		case !ok:
+			w := worker(*shift.StaffID)
+			w.New = append(w.New, fromShift(shift))
+		case snapshot.StaffID != *shift.StaffID:
+			// Reassigned: gone from one roster, new on the other
+			previous := worker(snapshot.StaffID)
+			previous.Removed = append(previous.Removed, fromSnapshot(snapshot))
+			w := worker(*shift.StaffID)
+			w.New = append(w.New, fromShift(shift))
+		case !snapshot.StartTime.Equal(shift.StartTime) || !snapshot.EndTime.Equal(shift.EndTime) ||
+			snapshot.ParticipantID != shift.ParticipantID || snapshot.Location != shift.Location || snapshot.ServiceType != shift.ServiceType:
+			change := fromShift(shift)
+			previous := fromSnapshot(snapshot)
+			change.Previous = &previous
+			w := worker(*shift.StaffID)
+			w.Changed = append(w.Changed, change)
+		}
+	}
+
+	removed := []models.PublishedShift{}
+	for _, snapshot := range snapshots {
+		if seen[snapshot.ShiftID] {
+			continue
+		}
+		removed = append(removed, snapshot)
+		w := worker(snapshot.StaffID)
+		w.Removed = append(w.Removed, fromSnapshot(snapshot))
+	}
+
+	changes := make([]WorkerRosterChanges, 0, len(workers))
+	for _, w := range workers {
+		sort.Slice(w.Removed, func(i, j int) bool { return w.Removed[i].StartTime.Before(w.Removed[j].StartTime) })
+		changes = append(changes, *w)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].StaffName != changes[j].StaffName {
+			return changes[i].StaffName < changes[j].StaffName
+		}
+		return changes[i].StaffID < changes[j].StaffID
+	})
+	return changes, shifts, removed, nil
+}
+
+// rosterMessage is the notification telling a worker what changed in their roster
+func rosterMessage(changes WorkerRosterChanges, from, to time.Time, loc *time.Location) (string, string) {
+	period := fmt.Sprintf("%s to %s", from.Format("02/01/2006"), to.AddDate(0, 0, -1).Format("02/01/2006"))
+	line := func(change RosterShiftChange) string {
+		start, end := change.StartTime.In(loc), change.EndTime.In(loc)
+		return fmt.Sprintf("%s %s-%s with %s at %s", start.Format("Mon 02/01"), start.Format("15:04"), end.Format("15:04"),
+			change.ParticipantName, change.Location)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nYour roster for %s has been published.\n", changes.StaffName, period)
+	if len(changes.New) > 0 {
+		body.WriteString("\nNew shifts:\n")
+		for _, change := range changes.New {
+			fmt.Fprintf(&body, "- %s\n", line(change))
+		}
+	}
+	if len(changes.Changed) > 0 {
+		body.WriteString("\nChanged shifts:\n")
+		for _, change := range changes.Changed {
+			fmt.Fprintf(&body, "- %s (was %s)\n", line(change), line(*change.Previous))
+		}
+	}
+	if len(changes.Removed) > 0 {
+		body.WriteString("\nRemoved shifts:\n")
+		for _, change := range changes.Removed {
+			fmt.Fprintf(&body, "- %s\n", line(change))
+		}
+	}
+	return "Roster published for " + period, body.String()
+}
+
+// GetRosterChanges previews what publishing the roster for a date range would change for each worker
+func (h *Handler) GetRosterChanges(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	from, to, ok := h.rosterDates(c, orgID.(string), c.Query("start_date"), c.Query("end_date"))
+	if !ok {
+		return
+	}
+
+	changes, shifts, _, err := h.rosterChanges(orgID.(string), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to compare the roster",
+			},
+		})
+		return
+	}
+
+	drafts := 0
+	for _, shift := range shifts {
+		if shift.PublishStatus == models.ShiftDraft {
+			drafts++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"period_start": from,
+			"period_end":   to,
+			"draft_shifts": drafts,
+			"workers":      changes,
+		},
+	})
+}
+
+// PublishRoster releases the shifts in a date range to staff and notifies each worker of
+// their new, changed and removed shifts
+func (h *Handler) PublishRoster(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req PublishRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	from, to, ok := h.rosterDates(c, orgID.(string), req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	changes, shifts, removed, err := h.rosterChanges(orgID.(string), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to compare the roster",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	publication := models.RosterPublication{
+		OrganizationID:  orgID.(string),
+		PeriodStart:     from,
+		PeriodEnd:       to,
+		ShiftsPublished: len(shifts),
+		StaffNotified:   len(changes),
+		PublishedBy:     h.GetUserIDFromContext(c),
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		shiftIDs := make([]string, 0, len(shifts))
+		snapshots := make([]models.PublishedShift, 0, len(shifts))
+		for _, shift := range shifts {
+			shiftIDs = append(shiftIDs, shift.ID)
+			snapshots = append(snapshots, models.PublishedShift{
+				ShiftID:        shift.ID,
+				OrganizationID: orgID.(string),
+				StaffID:        *shift.StaffID,
+				ParticipantID:  shift.ParticipantID,
+				StartTime:      shift.StartTime,
+				EndTime:        shift.EndTime,
+				ServiceType:    shift.ServiceType,
+				Location:       shift.Location,
+				PublishedAt:    now,
+			})
+		}
+		for _, snapshot := range removed {
+			shiftIDs = append(shiftIDs, snapshot.ShiftID)
+		}
+
+		if len(shiftIDs) > 0 {
+			if err := tx.Where("shift_id IN ?", shiftIDs).Delete(&models.PublishedShift{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(snapshots) > 0 {
+			if err := tx.Create(&snapshots).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Shift{}).Where("id IN ?", shiftIDs[:len(snapshots)]).
+				Update("publish_status", models.ShiftPublished).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&publication).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to publish roster",
+			},
+		})
+		return
+	}
+
+	// Notify each worker whose roster changed
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	for _, workerChanges := range changes {
+		var staff models.User
+		if err := h.DB.First(&staff, "id = ?", workerChanges.StaffID).Error; err != nil {
+			continue
+		}
+		title, message := rosterMessage(workerChanges, from, to, loc)
+		h.notifyUser(staff, models.NotificationRosterPublished, title, message, models.JSONB{
+			"publication_id": publication.ID,
+			"new":            len(workerChanges.New),
+			"changed":        len(workerChanges.Changed),
+			"removed":        len(workerChanges.Removed),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"publication": publication,
+			"workers":     changes,
+		},
+		"message": fmt.Sprintf("Published %d shifts and notified %d staff", len(shifts), len(changes)),
+	})
+}
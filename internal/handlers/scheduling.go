@@ -0,0 +1,686 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+	"gorm.io/gorm"
+)
+
+// schedulingHistoryDays is how far back completed shifts count towards continuity of care
+const schedulingHistoryDays = 90
+
+// schedulingMargin is how far either side of the shifts being filled existing bookings are
+// loaded, enough to cover their weeks and any run of consecutive days
+const schedulingMargin = 14 * 24 * time.Hour
+
+// timeOfDay scans a time column as minutes from midnight. Drivers return these as either
+// a time on some date or text such as "08:30:00".
+type timeOfDay struct {
+	Minutes int
+	Valid   bool
+}
+
+// Scan implements the sql.Scanner interface for timeOfDay
+func (t *timeOfDay) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*t = timeOfDay{}
+		return nil
+	case time.Time:
+		*t = timeOfDay{Minutes: v.Hour()*60 + v.Minute(), Valid: true}
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("cannot scan %T into a time of day", value)
+	}
+
+	// Drop any date in front of the time
+	if len(text) > 10 && (text[10] == ' ' || text[10] == 'T') {
+		text = text[11:]
+	}
+	if len(text) < 5 {
+		return fmt.Errorf("invalid time of day %q", text)
+	}
+	hour, minute, err := parseClock(text[:5])
+	if err != nil {
+		return err
+	}
+	*t = timeOfDay{Minutes: hour*60 + minute, Valid: true}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for timeOfDay
+func (t timeOfDay) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return fmt.Sprintf("%02d:%02d:00", t.Minutes/60, t.Minutes%60), nil
+}
+
+// careWorkers returns the organization's active care workers
+func (h *Handler) careWorkers(orgID string) ([]models.User, error) {
+	var staff []models.User
+	err := h.DB.Where("organization_id = ? AND role = ? AND is_active = ?", orgID, "care_worker", true).
+		Order("first_name, last_name").Find(&staff).Error
+	return staff, err
+}
+
+// schedulingWorkers loads what the scheduler needs to know about each staff member to fill
+// shifts between from and to: their availability, approved leave, skills, preferences,
+// existing bookings, recent shifts with each participant and the participants who have asked
+// for them or blocked them
+func (h *Handler) schedulingWorkers(staff []models.User, from, to time.Time, loc *time.Location) ([]*scheduling.Worker, error) {
+	workers := make([]*scheduling.Worker, 0, len(staff))
+	byID := map[string]*scheduling.Worker{}
+	ids := make([]string, 0, len(staff))
+	for _, user := range staff {
+		worker := &scheduling.Worker{ID: user.ID, Name: user.FirstName + " " + user.LastName, History: map[string]int{}, Relations: map[string]string{}}
+		workers = append(workers, worker)
+		byID[user.ID] = worker
+		ids = append(ids, user.ID)
+	}
+	if len(ids) == 0 {
+		return workers, nil
+	}
+
+	var availability []struct {
+		UserID         string
+		DayOfWeek      int
+		StartTime      timeOfDay
+		EndTime        timeOfDay
+		IsAvailable    bool
+		MaxHoursPerDay float64
+	}
+	if err := h.DB.Model(&models.WorkerAvailability{}).
+		Select("user_id, day_of_week, start_time, end_time, is_available, max_hours_per_day").
+		Where("user_id IN ? AND is_active = ?", ids, true).Scan(&availability).Error; err != nil {
+		return nil, err
+	}
+	for _, window := range availability {
+		worker := byID[window.UserID]
+		worker.Availability = append(worker.Availability, scheduling.Window{
+			Weekday:        time.Weekday(window.DayOfWeek),
+			Start:          window.StartTime.Minutes,
+			End:            window.EndTime.Minutes,
+			Available:      window.IsAvailable,
+			MaxHoursPerDay: window.MaxHoursPerDay,
+		})
+	}
+
+	var exceptions []timeOff
+	if err := h.DB.Model(&models.WorkerAvailabilityException{}).
+		Select("user_id, exception_date, start_time, end_time").
+		Where("user_id IN ? AND is_approved = ? AND exception_type = ?", ids, true, "unavailable").
+		Scan(&exceptions).Error; err != nil {
+		return nil, err
+	}
+	for _, exception := range exceptions {
+		period := exception.period(loc)
+		if period.End.After(from.Add(-schedulingMargin)) && period.Start.Before(to.Add(schedulingMargin)) {
+			byID[exception.UserID].TimeOff = append(byID[exception.UserID].TimeOff, period)
+		}
+	}
+
+	var skills []models.WorkerSkill
+	if err := h.DB.Where("user_id IN ? AND is_active = ?", ids, true).Find(&skills).Error; err != nil {
+		return nil, err
+	}
+	skillsByWorker := map[string][]models.WorkerSkill{}
+	for _, skill := range skills {
+		worker := byID[skill.UserID]
+		worker.Skills = append(worker.Skills, scheduling.Skill{Name: skill.SkillName, Category: skill.SkillCategory, ExpiresAt: skill.ExpiryDate})
+		skillsByWorker[skill.UserID] = append(skillsByWorker[skill.UserID], skill)
+	}
+
+	// Workers without the organization's mandatory credentials can't be given shifts
+	credentials, err := h.mandatoryCredentials(staff[0].OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) > 0 {
+		for _, worker := range workers {
+			worker.Lapse = credentialLapse(credentials, skillsByWorker[worker.ID], from, to)
+		}
+	}
+
+	var preferences []models.WorkerPreferences
+	if err := h.DB.Where("user_id IN ? AND is_active = ?", ids, true).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range preferences {
+		byID[p.UserID].Home = addressPoint(p.HomeAddress)
+		byID[p.UserID].Profile = &scheduling.Profile{
+			Gender:    p.Gender,
+			Languages: p.Languages,
+			Smoker:    p.Smoker,
+			HasPets:   p.HasPets,
+		}
+		byID[p.UserID].Preferences = &scheduling.Preferences{
+			MaxHoursPerWeek:       p.MaxHoursPerWeek,
+			PreferredHoursPerWeek: p.PreferredHoursPerWeek,
+			MaxConsecutiveDays:    p.MaxConsecutiveDays,
+			MinHoursBetweenShifts: float64(p.MinHoursBetweenShifts),
+			MaxTravelKm:           float64(p.MaxTravelDistanceKm),
+			AvoidWeekends:         !p.WillingWeekendWork,
+			AvoidEvenings:         !p.WillingEveningWork,
+			AvoidEarlyMornings:    !p.WillingEarlyMorningWork,
+			ShiftTypes:            p.PreferredShiftTypes,
+		}
+	}
+
+	// Suburbs and postcodes can be matched directly; regions and addresses are not used yet
+	var locations []models.WorkerLocationPreference
+	if err := h.DB.Where("user_id IN ? AND is_active = ? AND location_type IN ?", ids, true, []string{"suburb", "postcode"}).
+		Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		worker := byID[location.UserID]
+		worker.Locations = append(worker.Locations, scheduling.Location{Value: location.LocationValue, Level: location.PreferenceLevel})
+	}
+
+	var bookings []models.Shift
+	if err := h.DB.Where("staff_id IN ? AND status != ? AND start_time >= ? AND start_time < ?",
+		ids, "cancelled", from.Add(-schedulingMargin), to.Add(schedulingMargin)).Preload("Participant").Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		worker := byID[*booking.StaffID]
+		worker.Bookings = append(worker.Bookings, scheduling.Booking{
+			ShiftID:       booking.ID,
+			ParticipantID: booking.ParticipantID,
+			Start:         booking.StartTime,
+			End:           booking.EndTime,
+			Point:         shiftPoint(booking, booking.Participant),
+		})
+	}
+
+	var history []struct {
+		StaffID       string
+		ParticipantID string
+		Shifts        int
+	}
+	if err := h.DB.Model(&models.Shift{}).Select("staff_id, participant_id, COUNT(*) AS shifts").
+		Where("staff_id IN ? AND status = ? AND start_time >= ?", ids, "completed", time.Now().In(loc).AddDate(0, 0, -schedulingHistoryDays)).
+		Group("staff_id, participant_id").Scan(&history).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range history {
+		byID[row.StaffID].History[row.ParticipantID] = row.Shifts
+	}
+
+	var relations []models.ParticipantWorker
+	if err := h.DB.Where("user_id IN ?", ids).Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	for _, relation := range relations {
+		byID[relation.UserID].Relations[relation.ParticipantID] = relation.Relationship
+	}
+
+	return workers, nil
+}
+
+// timeOff is an approved unavailable exception
+type timeOff struct {
+	UserID        string
+	ExceptionDate time.Time
+	StartTime     timeOfDay
+	EndTime       timeOfDay
+}
+
+// period returns when the exception applies: the whole local day, or between its start and
+// end times on that day
+func (t timeOff) period(loc *time.Location) scheduling.Period {
+	date := t.ExceptionDate
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	period := scheduling.Period{Start: day, End: day.AddDate(0, 0, 1)}
+	if t.StartTime.Valid && t.EndTime.Valid && t.EndTime.Minutes > t.StartTime.Minutes {
+		period.Start = day.Add(time.Duration(t.StartTime.Minutes) * time.Minute)
+		period.End = day.Add(time.Duration(t.EndTime.Minutes) * time.Minute)
+	}
+	return period
+}
+
+// schedulingShift describes a shift to the scheduler. It takes place at its own location
+// when that names a suburb or postcode, otherwise at the participant's address. The worker
+// must hold the given skills and the participant's requirements are checked.
+func schedulingShift(shift models.Shift, participant models.Participant, skills []string, requirements []models.ParticipantRequirement) scheduling.Shift {
+	target := scheduling.Shift{
+		ID:             shift.ID,
+		ParticipantID:  shift.ParticipantID,
+		ServiceType:    shift.ServiceType,
+		Start:          shift.StartTime,
+		End:            shift.EndTime,
+		Suburb:         participant.Address.Suburb,
+		Postcode:       participant.Address.Postcode,
+		Point:          shiftPoint(shift, participant),
+		RequiredSkills: skills,
+		Requirements:   schedulingRequirements(requirements),
+	}
+	if located := geo.ParseAddress(shift.Location); located.Suburb != "" || located.Postcode != "" {
+		target.Suburb, target.Postcode = located.Suburb, located.Postcode
+	}
+	return target
+}
+
+// splitSkills reads a comma separated list of skills
+func splitSkills(value string) []string {
+	skills := []string{}
+	for _, skill := range strings.Split(value, ",") {
+		if skill = strings.TrimSpace(skill); skill != "" {
+			skills = append(skills, skill)
+		}
+	}
+	return skills
+}
+
+// rankShiftWorkers ranks the organization's care workers for a shift, checking them against
+// the participant's requirements as well as the given skills
+func (h *Handler) rankShiftWorkers(orgID string, shift models.Shift, participant models.Participant, skills []string) ([]scheduling.Candidate, []scheduling.Exclusion, error) {
+	loc, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		loc = time.UTC
+	}
+	staff, err := h.careWorkers(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	workers, err := h.schedulingWorkers(staff, shift.StartTime, shift.EndTime, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	requirements, err := h.shiftRequirements(shift)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates, exclusions := scheduling.Rank(schedulingShift(shift, participant, skills, requirements[shift.ID]), workers, loc, h.fatigueRules(orgID))
+	return candidates, exclusions, nil
+}
+
+// autoAssignShifts reports whether the organization wants open shifts given to the best
+// available worker as they are created
+func (h *Handler) autoAssignShifts(orgID string) bool {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return false
+	}
+	return settings.AutoAssignShifts
+}
+
+// fetchSchedulableShift loads one of the organization's scheduled shifts with its
+// participant, writing the error response and returning false when it is missing or no
+// longer scheduled
+func (h *Handler) fetchSchedulableShift(c *gin.Context, orgID string, shift *models.Shift) bool {
+	err := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("shifts.id = ? AND participants.organization_id = ?", c.Param("id"), orgID).
+		Preload("Participant").First(shift).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_FOUND",
+					"message": "Shift not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift",
+			},
+		})
+		return false
+	}
+	if shift.Status != "scheduled" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SHIFT_STATUS",
+				"message": "Only scheduled shifts can be assigned",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetShiftSuggestions ranks the care workers who could take a shift and explains why the
+// others cannot. ?skills= lists skills the worker must hold, comma separated.
+func (h *Handler) GetShiftSuggestions(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var shift models.Shift
+	if !h.fetchSchedulableShift(c, orgID.(string), &shift) {
+		return
+	}
+
+	candidates, exclusions, err := h.rankShiftWorkers(orgID.(string), shift, shift.Participant, splitSkills(c.Query("skills")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load workers",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shift_id":   shift.ID,
+			"candidates": candidates,
+			"excluded":   exclusions,
+		},
+	})
+}
+
+type AssignShiftRequest struct {
+	StaffID  string   `json:"staff_id" binding:"required"`
+	Skills   []string `json:"skills,omitempty"` // skills the worker must hold
+	Reassign bool     `json:"reassign"`         // take the shift off the worker who has it
+
+	CredentialOverride
+}
+
+// AssignShift gives a shift to a care worker after checking them against the same
+// constraints the suggestions use
+func (h *Handler) AssignShift(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req AssignShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var shift models.Shift
+	if !h.fetchSchedulableShift(c, orgID.(string), &shift) {
+		return
+	}
+	if shift.StaffID != nil && *shift.StaffID != req.StaffID && !req.Reassign {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_ASSIGNED",
+				"message": "The shift is already assigned, set reassign to give it to someone else",
+			},
+		})
+		return
+	}
+
+	var staff models.User
+	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_STAFF",
+				"message": "Staff member not found or inactive",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	workers, err := h.schedulingWorkers([]models.User{staff}, shift.StartTime, shift.EndTime, loc)
+	var requirements map[string][]models.ParticipantRequirement
+	if err == nil {
+		requirements, err = h.shiftRequirements(shift)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load the worker's schedule",
+			},
+		})
+		return
+	}
+	workers[0].Lapse = nil // checked below, where a manager can override it
+	target := schedulingShift(shift, shift.Participant, req.Skills, requirements[shift.ID])
+	reasons, violations := workers[0].Evaluate(target, loc, h.fatigueRules(orgID.(string)))
+	if len(reasons) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "WORKER_NOT_ELIGIBLE",
+				"message": "Staff member cannot take this shift",
+				"details": reasons,
+			},
+			"violations": violations,
+		})
+		return
+	}
+
+	overridden, ok := h.checkCredentials(c, orgID.(string), staff.ID, shift.StartTime, req.CredentialOverride)
+	if !ok {
+		return
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// Only assign the shift if nobody else has assigned or cancelled it since it was checked
+		query := tx.Model(&models.Shift{}).Where("id = ? AND status = ?", shift.ID, "scheduled")
+		if shift.StaffID == nil {
+			query = query.Where("staff_id IS NULL")
+		} else {
+			query = query.Where("staff_id = ?", *shift.StaffID)
+		}
+		result := query.Updates(staffChange(staff.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errShiftTaken
+		}
+		return recordCredentialOverride(tx, c, orgID.(string), shift.ID, staff.ID, req.CredentialOverride, overridden)
+	})
+	if err == errShiftTaken {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_ASSIGNED",
+				"message": "The shift was assigned or cancelled in the meantime",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to assign shift",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").Preload("SupportItem").Preload("CostBands").First(&shift, "id = ?", shift.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       shift,
+		"violations": violations, // fatigue rules the organization only warns about
+		"message":    "Shift assigned successfully",
+	})
+}
+
+type SolveRosterRequest struct {
+	StartDate     string   `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate       string   `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	ParticipantID string   `json:"participant_id,omitempty"`
+	Skills        []string `json:"skills,omitempty"` // skills the worker must hold for every shift
+	DryRun        bool     `json:"dry_run"`          // work out the assignments without saving them
+}
+
+// SolveRoster fills the open shifts in a date range, giving each to the best worker who
+// can take it. Shifts nobody can take are returned with the reasons each worker was
+// excluded.
+func (h *Handler) SolveRoster(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req SolveRosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	from, to, ok := h.rosterDates(c, orgID.(string), req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+	loc := from.Location()
+
+	var shifts []models.Shift
+	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.staff_id IS NULL AND shifts.status = ?", orgID, "scheduled").
+		Where("shifts.start_time >= ? AND shifts.start_time < ?", from, to)
+	if req.ParticipantID != "" {
+		query = query.Where("shifts.participant_id = ?", req.ParticipantID)
+	}
+	if err := query.Preload("Participant").Order("shifts.start_time").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch open shifts",
+			},
+		})
+		return
+	}
+
+	staff, err := h.careWorkers(orgID.(string))
+	var workers []*scheduling.Worker
+	var requirements map[string][]models.ParticipantRequirement
+	if err == nil {
+		workers, err = h.schedulingWorkers(staff, from, to, loc)
+	}
+	if err == nil {
+		requirements, err = h.shiftRequirements(shifts...)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load workers",
+			},
+		})
+		return
+	}
+
+	open := make([]scheduling.Shift, 0, len(shifts))
+	for _, shift := range shifts {
+		open = append(open, schedulingShift(shift, shift.Participant, req.Skills, requirements[shift.ID]))
+	}
+	assignments, unfilled := scheduling.Solve(open, workers, loc, h.fatigueRules(orgID.(string)))
+
+	if !req.DryRun && len(assignments) > 0 {
+		// Shifts filled or cancelled while the roster was being solved are left as they are
+		// and dropped from the assignments
+		saved := []scheduling.Assignment{}
+		err := h.DB.Transaction(func(tx *gorm.DB) error {
+			for _, assignment := range assignments {
+				result := tx.Model(&models.Shift{}).Where("id = ? AND staff_id IS NULL AND status = ?", assignment.ShiftID, "scheduled").
+					Updates(staffChange(assignment.WorkerID))
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected > 0 {
+					saved = append(saved, assignment)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to save assignments",
+				},
+			})
+			return
+		}
+		assignments = saved
+	}
+
+	message := "Roster solved"
+	if req.DryRun {
+		message = "Roster solved, nothing was saved"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"open_shifts": len(shifts),
+			"assignments": assignments,
+			"unfilled":    unfilled,
+			"dry_run":     req.DryRun,
+		},
+		"message": message,
+	})
+}
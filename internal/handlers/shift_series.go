@@ -0,0 +1,1147 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/rrule"
+	"gorm.io/gorm"
+)
+
+// shiftSeriesHorizon is how far ahead the occurrences of a series are created as shifts
+const shiftSeriesHorizon = 8 * 7 * 24 * time.Hour
+
+// OccurrenceConflict is an occurrence of a series that could not be booked or changed
+// because its staff member already has a shift at that time, or can't be booked on it
+type OccurrenceConflict struct {
+	OccurrenceStart time.Time   `json:"occurrence_start"`
+	ShiftID         string      `json:"shift_id,omitempty"` // set when an existing occurrence could not be changed
+	StartTime       time.Time   `json:"start_time"`
+	EndTime         time.Time   `json:"end_time"`
+	Code            string      `json:"code"`
+	Message         string      `json:"message"`
+	Details         interface{} `json:"details,omitempty"`
+}
+
+func scheduleConflict(occurrence, start, end time.Time, shiftID string) OccurrenceConflict {
+	return OccurrenceConflict{
+		OccurrenceStart: occurrence,
+		ShiftID:         shiftID,
+		StartTime:       start,
+		EndTime:         end,
+		Code:            "SCHEDULE_CONFLICT",
+		Message:         "Staff member already has a shift scheduled during this time",
+	}
+}
+
+func occurrenceConflict(occurrence time.Time, shift models.Shift, conflict *bookingConflict) OccurrenceConflict {
+	return OccurrenceConflict{
+		OccurrenceStart: occurrence,
+		ShiftID:         shift.ID,
+		StartTime:       shift.StartTime,
+		EndTime:         shift.EndTime,
+		Code:            conflict.Code,
+		Message:         conflict.Message,
+		Details:         conflict.Details,
+	}
+}
+
+// seriesRule parses a series' rule and returns it with the first occurrence in the
+// organization's timezone, which fixes the local time of day of every occurrence
+func (h *Handler) seriesRule(series models.ShiftSeries) (*rrule.Rule, time.Time, error) {
+	loc, err := h.getOrganizationTimezone(series.OrganizationID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	rule, err := rrule.Parse(series.RRule, loc)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return rule, series.StartTime.In(loc), nil
+}
+
+// seriesHorizon is the end of the window a series starting at start is created up to
+func seriesHorizon(start, now time.Time) time.Time {
+	if start.After(now) {
+		return start.Add(shiftSeriesHorizon)
+	}
+	return now.Add(shiftSeriesHorizon)
+}
+
+// extendShiftSeries creates shifts for the occurrences of a series starting before until
+// that have not been created yet. Occurrences that clash with another shift of the staff
+// member, that they can't be booked on or that would break the fatigue rules are skipped
+// and returned as conflicts. The series ends once its rule has no occurrences left.
+func (h *Handler) extendShiftSeries(series *models.ShiftSeries, until time.Time) ([]models.Shift, []OccurrenceConflict, error) {
+	created := []models.Shift{}
+	conflicts := []OccurrenceConflict{}
+	if series.Status != models.ShiftSeriesActive || !until.After(series.GeneratedUntil) {
+		return created, conflicts, nil
+	}
+
+	rule, dtstart, err := h.seriesRule(*series)
+	if err != nil {
+		return created, conflicts, err
+	}
+
+	var participant models.Participant
+	if err := h.DB.First(&participant, "id = ?", series.ParticipantID).Error; err != nil {
+		return created, conflicts, err
+	}
+
+	// Occurrences already created are never recreated, including ones deleted on their own
+	var existing []time.Time
+	if err := h.DB.Unscoped().Model(&models.Shift{}).
+		Where("series_id = ? AND occurrence_start IS NOT NULL", series.ID).
+		Pluck("occurrence_start", &existing).Error; err != nil {
+		return created, conflicts, err
+	}
+	taken := map[int64]bool{}
+	for _, t := range existing {
+		taken[t.Unix()] = true
+	}
+
+	from := series.GeneratedUntil
+	if from.Before(dtstart) {
+		from = dtstart
+	}
+	for _, occurrence := range rule.Between(dtstart, from, until) {
+		if taken[occurrence.Unix()] {
+			continue
+		}
+		occurrenceStart := occurrence
+		endTime := occurrence.Add(series.Duration())
+		if hasScheduleConflict(h.DB, series.StaffID, "", occurrence, endTime) {
+			conflicts = append(conflicts, scheduleConflict(occurrence, occurrence, endTime, ""))
+			continue
+		}
+
+		shift := models.Shift{
+			ParticipantID:   series.ParticipantID,
+			StaffID:         &series.StaffID,
+			StartTime:       occurrence,
+			EndTime:         endTime,
+			ServiceType:     series.ServiceType,
+			SupportItemID:   series.SupportItemID,
+			Location:        series.Location,
+			Status:          "scheduled",
+			PublishStatus:   models.ShiftDraft,
+			HourlyRate:      series.HourlyRate,
+			Notes:           series.Notes,
+			SeriesID:        &series.ID,
+			OccurrenceStart: &occurrenceStart,
+		}
+		conflict, err := h.generatedShiftConflict(series.OrganizationID, series.StaffID, shift, participant)
+		if err != nil {
+			return created, conflicts, err
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, occurrenceConflict(occurrence, shift, conflict))
+			continue
+		}
+
+		// Rest periods allow for the drive from the worker's other shifts, and count the
+		// occurrences already booked
+		h.geocodeShift(&shift)
+		if conflict, err = h.fatigueConflict(series.OrganizationID, series.StaffID, shift, participant); err != nil {
+			return created, conflicts, err
+		}
+		if conflict != nil {
+			conflicts = append(conflicts, occurrenceConflict(occurrence, shift, conflict))
+			continue
+		}
+		h.priceShift(&shift, participant, series.OrganizationID)
+		if err := h.DB.Create(&shift).Error; err != nil {
+			return created, conflicts, err
+		}
+		created = append(created, shift)
+	}
+
+	updates := map[string]interface{}{"generated_until": until}
+	series.GeneratedUntil = until
+	if _, more := rule.Next(dtstart, until); !more {
+		updates["status"] = models.ShiftSeriesEnded
+		series.Status = models.ShiftSeriesEnded
+	}
+	if err := h.DB.Model(&models.ShiftSeries{}).Where("id = ?", series.ID).Updates(updates).Error; err != nil {
+		return created, conflicts, err
+	}
+	return created, conflicts, nil
+}
+
+// endSeriesBefore stops a series so its last occurrence starts before cutoff, and returns
+// how many occurrences a COUNT limited rule had left from cutoff on
+func (h *Handler) endSeriesBefore(series *models.ShiftSeries, rule rrule.Rule, dtstart, cutoff time.Time) (int, error) {
+	remaining := 0
+	if rule.Count > 0 {
+		remaining = rule.Count - rule.Index(dtstart, cutoff)
+		rule.Count = 0
+	}
+	until := cutoff.Add(-time.Second)
+	rule.Until = &until
+
+	series.RRule = rule.String()
+	updates := map[string]interface{}{"rrule": series.RRule}
+	if _, more := rule.Next(dtstart, series.GeneratedUntil); !more {
+		series.Status = models.ShiftSeriesEnded
+		updates["status"] = series.Status
+	}
+	return remaining, h.DB.Model(&models.ShiftSeries{}).Where("id = ?", series.ID).Updates(updates).Error
+}
+
+// upcomingOccurrences lists the scheduled shifts of a series from the occurrence at cutoff
+// on that have not started yet
+func (h *Handler) upcomingOccurrences(seriesID string, cutoff, now time.Time, includeOverrides bool) ([]models.Shift, error) {
+	var shifts []models.Shift
+	query := h.DB.Where("series_id = ? AND status = ?", seriesID, "scheduled")
+	if !includeOverrides {
+		query = query.Where("series_override = ?", false)
+	}
+	if err := query.Order("start_time").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+
+	upcoming := []models.Shift{}
+	for _, shift := range shifts {
+		if shift.OccurrenceStart != nil && !shift.OccurrenceStart.Before(cutoff) && shift.StartTime.After(now) {
+			upcoming = append(upcoming, shift)
+		}
+	}
+	return upcoming, nil
+}
+
+// staffChange is the update that gives a shift to a worker, or leaves it open for a nil
+// staffID. An occurrence handed to someone other than its series' worker no longer follows
+// edits to the series, so they don't move it back or regenerate it away.
+func staffChange(staffID interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"staff_id":        staffID,
+		"series_override": gorm.Expr("series_id IS NOT NULL"),
+	}
+}
+
+// StartShiftSeriesJob creates the shifts of every active series up to the rolling horizon at the given interval
+func (h *Handler) StartShiftSeriesJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for ; true; <-ticker.C {
+			var series []models.ShiftSeries
+			if err := h.DB.Where("status = ?", models.ShiftSeriesActive).Find(&series).Error; err != nil {
+				log.Printf("Shift series job failed to list series: %v", err)
+				continue
+			}
+			for i := range series {
+				created, conflicts, err := h.extendShiftSeries(&series[i], seriesHorizon(series[i].StartTime, time.Now()))
+				if err != nil {
+					log.Printf("Shift series job failed for series %s: %v", series[i].ID, err)
+					continue
+				}
+				if len(created) > 0 || len(conflicts) > 0 {
+					log.Printf("Shift series job for series %s: %d shifts created, %d conflicts", series[i].ID, len(created), len(conflicts))
+				}
+			}
+		}
+	}()
+}
+
+type CreateShiftSeriesRequest struct {
+	CreateShiftRequest        // StartTime and EndTime are the first occurrence
+	RRule              string `json:"rrule" binding:"required"` // e.g. FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20251231
+}
+
+// CreateShiftSeries creates a repeating shift and books its occurrences up to the rolling horizon
+func (h *Handler) CreateShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateShiftSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if req.StaffID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "A shift series needs a staff member",
+			},
+		})
+		return
+	}
+
+	startTime, err := h.parseTimeInOrganizationTimezone(req.StartTime, orgID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_START_TIME",
+				"message": "Invalid start time format. Use ISO format or local datetime.",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	endTime, err := h.parseTimeInOrganizationTimezone(req.EndTime, orgID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_END_TIME",
+				"message": "Invalid end time format. Use ISO format or local datetime.",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME_RANGE",
+				"message": "End time must be after start time",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	rule, err := rrule.Parse(req.RRule, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RRULE",
+				"message": "Invalid recurrence rule",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	participant, supportItemID, warnings, ok := h.checkShiftBooking(c, orgID.(string), req.CreateShiftRequest)
+	if !ok {
+		return
+	}
+
+	// The participant's blocked workers and requirements apply to every occurrence
+	if !h.checkBlockedWorker(c, req.ParticipantID, req.StaffID) {
+		return
+	}
+	first := models.Shift{ParticipantID: req.ParticipantID, StartTime: startTime, EndTime: endTime}
+	if !h.checkRequirements(c, orgID.(string), req.StaffID, first, participant) {
+		return
+	}
+
+	series := models.ShiftSeries{
+		OrganizationID:  orgID.(string),
+		ParticipantID:   req.ParticipantID,
+		StaffID:         req.StaffID,
+		RRule:           rule.String(),
+		StartTime:       startTime,
+		DurationMinutes: int(endTime.Sub(startTime).Minutes()),
+		ServiceType:     req.ServiceType,
+		SupportItemID:   supportItemID,
+		Location:        req.Location,
+		HourlyRate:      req.HourlyRate,
+		Notes:           req.Notes,
+		Status:          models.ShiftSeriesActive,
+		GeneratedUntil:  startTime,
+		CreatedBy:       h.GetUserIDFromContext(c),
+	}
+	if err := h.DB.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create shift series",
+			},
+		})
+		return
+	}
+
+	shifts, conflicts, err := h.extendShiftSeries(&series, seriesHorizon(startTime, time.Now()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create shifts for the series",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series":    series,
+			"shifts":    shifts,
+			"conflicts": conflicts,
+		},
+		"warnings": warnings,
+		"message":  fmt.Sprintf("Shift series created with %d shifts and %d conflicts", len(shifts), len(conflicts)),
+	})
+}
+
+// GetShiftSeriesList lists the organization's shift series
+func (h *Handler) GetShiftSeriesList(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.ShiftSeries{}).Where("organization_id = ?", orgID)
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+	if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var series []models.ShiftSeries
+	if err := query.Preload("Participant").Preload("Staff").
+		Limit(limit).Offset(offset).Order("created_at DESC").
+		Find(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift series",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series": series,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// GetShiftSeries returns a shift series with the shifts created for it
+func (h *Handler) GetShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var series models.ShiftSeries
+	if err := h.DB.Preload("Participant").Preload("Staff").
+		Preload("Shifts", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).
+		Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		First(&series).Error; err != nil {
+		h.shiftSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// shiftSeriesError writes the response for a failed series lookup
+func (h *Handler) shiftSeriesError(c *gin.Context, err error) {
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_SERIES_NOT_FOUND",
+				"message": "Shift series not found",
+			},
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "DATABASE_ERROR",
+			"message": "Failed to fetch shift series",
+		},
+	})
+}
+
+// fetchSeriesOccurrence loads a series and one of its shifts, writing the error response
+// and returning false when either is missing
+func (h *Handler) fetchSeriesOccurrence(c *gin.Context, orgID string, series *models.ShiftSeries, shift *models.Shift) bool {
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(series).Error; err != nil {
+		h.shiftSeriesError(c, err)
+		return false
+	}
+	if err := h.DB.Where("id = ? AND series_id = ?", c.Param("shiftId"), series.ID).First(shift).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_FOUND",
+					"message": "Shift not found in this series",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift",
+			},
+		})
+		return false
+	}
+	if shift.Status != "scheduled" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OCCURRENCE_NOT_EDITABLE",
+				"message": "Only scheduled occurrences can be changed",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// Scopes of a change to a series occurrence
+const (
+	seriesScopeThis      = "this"
+	seriesScopeFollowing = "following"
+	seriesScopeAll       = "all"
+)
+
+type UpdateSeriesOccurrenceRequest struct {
+	Scope       string   `json:"scope" binding:"required,oneof=this following all"`
+	StartTime   *string  `json:"start_time,omitempty"` // new start of this occurrence; later ones move by the same local time
+	EndTime     *string  `json:"end_time,omitempty"`
+	StaffID     *string  `json:"staff_id,omitempty"`
+	ServiceType *string  `json:"service_type,omitempty"`
+	Location    *string  `json:"location,omitempty"`
+	HourlyRate  *float64 `json:"hourly_rate,omitempty" binding:"omitempty,gt=0"`
+	Notes       *string  `json:"notes,omitempty"`
+	RRule       *string  `json:"rrule,omitempty"` // following and all only
+}
+
+// applyTo copies the requested field changes onto a shift or series
+func (req UpdateSeriesOccurrenceRequest) applyTo(staffID, serviceType, location, notes *string, hourlyRate *float64) {
+	if req.StaffID != nil {
+		*staffID = *req.StaffID
+	}
+	if req.ServiceType != nil {
+		*serviceType = *req.ServiceType
+	}
+	if req.Location != nil {
+		*location = *req.Location
+	}
+	if req.Notes != nil {
+		*notes = *req.Notes
+	}
+	if req.HourlyRate != nil {
+		*hourlyRate = *req.HourlyRate
+	}
+}
+
+// saveOccurrence writes a changed occurrence back with its cost repriced. Call it on a
+// handler whose DB is a transaction.
+func (h *Handler) saveOccurrence(shift *models.Shift, participant models.Participant, orgID string) error {
+	h.geocodeShift(shift)
+	h.priceShift(shift, participant, orgID)
+	if err := h.DB.Model(&models.Shift{}).Where("id = ?", shift.ID).Updates(map[string]interface{}{
+		"series_id":       shift.SeriesID,
+		"staff_id":        shift.StaffID,
+		"start_time":      shift.StartTime,
+		"end_time":        shift.EndTime,
+		"service_type":    shift.ServiceType,
+		"location":        shift.Location,
+		"latitude":        shift.Latitude,
+		"longitude":       shift.Longitude,
+		"hourly_rate":     shift.HourlyRate,
+		"notes":           shift.Notes,
+		"total_cost":      shift.TotalCost,
+		"series_override": shift.SeriesOverride,
+	}).Error; err != nil {
+		return err
+	}
+	if err := h.DB.Where("shift_id = ?", shift.ID).Delete(&models.ShiftCostBand{}).Error; err != nil {
+		return err
+	}
+	if len(shift.CostBands) > 0 {
+		return h.DB.Create(&shift.CostBands).Error
+	}
+	return nil
+}
+
+// wallClockOffset is how far the local time of day and date moved between two times,
+// so moving other occurrences by it keeps them at the same local time across daylight saving
+func wallClockOffset(from, to time.Time, loc *time.Location) time.Duration {
+	naive := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	}
+	return naive(to).Sub(naive(from))
+}
+
+func moveWallClock(t time.Time, offset time.Duration, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+int(offset/time.Second), 0, loc)
+}
+
+// UpdateSeriesOccurrence changes one occurrence of a series, that occurrence and the ones
+// after it, or the whole series. Past and completed occurrences, and occurrences that were
+// edited on their own, are left as they are. Occurrences that a change would make clash
+// with another shift are left unchanged and reported as conflicts.
+func (h *Handler) UpdateSeriesOccurrence(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateSeriesOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var series models.ShiftSeries
+	var shift models.Shift
+	if !h.fetchSeriesOccurrence(c, orgID.(string), &series, &shift) {
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+
+	startTime, endTime := shift.StartTime, shift.EndTime
+	if req.StartTime != nil {
+		if startTime, err = h.parseTimeInOrganizationTimezone(*req.StartTime, orgID.(string)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_START_TIME",
+					"message": "Invalid start time format",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+	}
+	if req.EndTime != nil {
+		if endTime, err = h.parseTimeInOrganizationTimezone(*req.EndTime, orgID.(string)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_END_TIME",
+					"message": "Invalid end time format",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+	}
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME_RANGE",
+				"message": "End time must be after start time",
+			},
+		})
+		return
+	}
+
+	rule, dtstart, err := h.seriesRule(series)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RRULE",
+				"message": "Series has an invalid recurrence rule",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	newRule := *rule
+	if req.RRule != nil {
+		if req.Scope == seriesScopeThis {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "The recurrence rule can only be changed for following occurrences or the whole series",
+				},
+			})
+			return
+		}
+		parsed, err := rrule.Parse(*req.RRule, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_RRULE",
+					"message": "Invalid recurrence rule",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+		newRule = *parsed
+	}
+
+	// Only a single occurrence can be left open, as a series always has a worker
+	if req.StaffID != nil && *req.StaffID == "" && req.Scope != seriesScopeThis {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Only this occurrence can be left without a staff member",
+			},
+		})
+		return
+	}
+	if req.StaffID != nil && *req.StaffID != "" {
+		var staff models.User
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", *req.StaffID, orgID, true).First(&staff).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return
+		}
+	}
+
+	var participant models.Participant
+	h.DB.Where("id = ?", series.ParticipantID).First(&participant)
+
+	if req.StaffID != nil {
+		if !h.checkBlockedWorker(c, series.ParticipantID, *req.StaffID) {
+			return
+		}
+		occurrence := models.Shift{ID: shift.ID, ParticipantID: series.ParticipantID, StartTime: startTime, EndTime: endTime}
+		if !h.checkRequirements(c, orgID.(string), *req.StaffID, occurrence, participant) {
+			return
+		}
+	}
+
+	// Check the new rate against the support item's price limit
+	warnings := []string{}
+	if req.HourlyRate != nil && series.SupportItemID != nil {
+		if supportItem, err := h.loadSupportItem(*series.SupportItemID); err == nil {
+			if message, exceeded := checkPriceCap(supportItem, participant, *req.HourlyRate); message != "" {
+				if exceeded && h.priceCapEnforcement(orgID.(string)) == "block" {
+					c.JSON(http.StatusBadRequest, gin.H{
+						"success": false,
+						"error": gin.H{
+							"code":    "PRICE_CAP_EXCEEDED",
+							"message": message,
+						},
+					})
+					return
+				}
+				warnings = append(warnings, message)
+			}
+		}
+	}
+
+	if req.Scope == seriesScopeThis {
+		shift.StartTime, shift.EndTime = startTime, endTime
+		staffID := shiftStaffID(shift)
+		req.applyTo(&staffID, &shift.ServiceType, &shift.Location, &shift.Notes, &shift.HourlyRate)
+		shift.StaffID = nil
+		if staffID != "" {
+			shift.StaffID = &staffID
+		}
+		if hasScheduleConflict(h.DB, staffID, shift.ID, shift.StartTime, shift.EndTime) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SCHEDULE_CONFLICT",
+					"message": "Staff member already has a shift scheduled during this time",
+				},
+			})
+			return
+		}
+		if req.StaffID != nil || req.StartTime != nil {
+			if _, ok := h.checkCredentials(c, orgID.(string), staffID, shift.StartTime, CredentialOverride{}); !ok {
+				return
+			}
+		}
+		if req.Location != nil {
+			h.geocodeShift(&shift)
+		}
+		violations, ok := h.checkFatigue(c, orgID.(string), staffID, shift, participant)
+		if !ok {
+			return
+		}
+		shift.SeriesOverride = true
+		err := h.DB.Transaction(func(tx *gorm.DB) error {
+			edit := *h
+			edit.DB = tx
+			return edit.saveOccurrence(&shift, participant, orgID.(string))
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update shift",
+				},
+			})
+			return
+		}
+
+		h.DB.Preload("Participant").Preload("Staff").Preload("CostBands").First(&shift, "id = ?", shift.ID)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"series":    series,
+				"shifts":    []models.Shift{shift},
+				"conflicts": []OccurrenceConflict{},
+			},
+			"warnings":   warnings,
+			"violations": violations,
+			"message":    "Occurrence updated successfully",
+		})
+		return
+	}
+
+	// The change applies from this occurrence on, or from the first for the whole series
+	cutoff := dtstart
+	if req.Scope == seriesScopeFollowing && shift.OccurrenceStart != nil {
+		cutoff = shift.OccurrenceStart.In(loc)
+	}
+	now := time.Now()
+	offset := wallClockOffset(shift.StartTime, startTime, loc)
+	duration := endTime.Sub(startTime)
+	regenerate := req.RRule != nil || offset != 0 || duration != series.Duration()
+
+	var target models.ShiftSeries
+	shifts := []models.Shift{}
+	conflicts := []OccurrenceConflict{}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// The checks read through the transaction, so each occurrence is checked against the
+		// ones changed before it
+		edit := *h
+		edit.DB = tx
+
+		affected, err := edit.upcomingOccurrences(series.ID, cutoff, now, false)
+		if err != nil {
+			return err
+		}
+
+		// The whole series is changed in place. Following occurrences are split off into a new
+		// series, so the ones before keep their original definition.
+		target = series
+		if cutoff.After(dtstart) {
+			remaining, err := edit.endSeriesBefore(&series, *rule, dtstart, cutoff)
+			if err != nil {
+				return err
+			}
+			if req.RRule == nil && remaining > 0 {
+				newRule.Count = remaining
+			}
+			target = models.ShiftSeries{
+				OrganizationID: series.OrganizationID,
+				ParticipantID:  series.ParticipantID,
+				StaffID:        series.StaffID,
+				StartTime:      cutoff,
+				ServiceType:    series.ServiceType,
+				SupportItemID:  series.SupportItemID,
+				Location:       series.Location,
+				HourlyRate:     series.HourlyRate,
+				Notes:          series.Notes,
+				Status:         models.ShiftSeriesActive,
+				GeneratedUntil: series.GeneratedUntil,
+				PreviousID:     &series.ID,
+				CreatedBy:      h.GetUserIDFromContext(c),
+			}
+		}
+		target.StartTime = moveWallClock(target.StartTime, offset, loc)
+		target.DurationMinutes = int(duration.Minutes())
+		target.RRule = newRule.String()
+		req.applyTo(&target.StaffID, &target.ServiceType, &target.Location, &target.Notes, &target.HourlyRate)
+		if regenerate {
+			// Occurrences are recreated from the new definition from now on
+			target.GeneratedUntil = now
+			if target.StartTime.After(now) {
+				target.GeneratedUntil = target.StartTime
+			}
+		}
+		if _, more := newRule.Next(target.StartTime.In(loc), target.GeneratedUntil); more {
+			target.Status = models.ShiftSeriesActive
+		}
+
+		if target.ID == "" {
+			err = tx.Create(&target).Error
+		} else {
+			err = tx.Model(&models.ShiftSeries{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+				"staff_id":         target.StaffID,
+				"rrule":            target.RRule,
+				"start_time":       target.StartTime,
+				"duration_minutes": target.DurationMinutes,
+				"service_type":     target.ServiceType,
+				"location":         target.Location,
+				"hourly_rate":      target.HourlyRate,
+				"notes":            target.Notes,
+				"status":           target.Status,
+				"generated_until":  target.GeneratedUntil,
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if regenerate {
+			// Upcoming occurrences are deleted so the new definition can book their slots. They
+			// are only soft deleted, so published rosters and claims that refer to them still
+			// find them, and their occurrence start is cleared so the slots count as free.
+			ids := make([]string, 0, len(affected))
+			for _, s := range affected {
+				ids = append(ids, s.ID)
+			}
+			if len(ids) > 0 {
+				if err := tx.Model(&models.Shift{}).Where("id IN ?", ids).Update("occurrence_start", nil).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("id IN ?", ids).Delete(&models.Shift{}).Error; err != nil {
+					return err
+				}
+			}
+			shifts, conflicts, err = edit.extendShiftSeries(&target, seriesHorizon(target.StartTime, now))
+			return err
+		}
+
+		for i := range affected {
+			occurrence := affected[i]
+			staffID := shiftStaffID(occurrence)
+			req.applyTo(&staffID, &occurrence.ServiceType, &occurrence.Location, &occurrence.Notes, &occurrence.HourlyRate)
+			if staffID != "" {
+				occurrence.StaffID = &staffID
+			}
+			if staffID != shiftStaffID(affected[i]) {
+				if hasScheduleConflict(tx, staffID, occurrence.ID, occurrence.StartTime, occurrence.EndTime) {
+					conflicts = append(conflicts, scheduleConflict(*occurrence.OccurrenceStart, occurrence.StartTime, occurrence.EndTime, occurrence.ID))
+					continue
+				}
+				conflict, err := edit.generatedShiftConflict(orgID.(string), staffID, occurrence, participant)
+				if err != nil {
+					return err
+				}
+				if conflict != nil {
+					conflicts = append(conflicts, occurrenceConflict(*occurrence.OccurrenceStart, occurrence, conflict))
+					continue
+				}
+			}
+			if staffID != shiftStaffID(affected[i]) || req.Location != nil {
+				if req.Location != nil {
+					edit.geocodeShift(&occurrence)
+				}
+				conflict, err := edit.fatigueConflict(orgID.(string), staffID, occurrence, participant)
+				if err != nil {
+					return err
+				}
+				if conflict != nil {
+					conflicts = append(conflicts, occurrenceConflict(*occurrence.OccurrenceStart, occurrence, conflict))
+					continue
+				}
+			}
+			occurrence.SeriesID = &target.ID
+			if err := edit.saveOccurrence(&occurrence, participant, orgID.(string)); err != nil {
+				return err
+			}
+			shifts = append(shifts, occurrence)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift series",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(&target, "id = ?", target.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series":    target,
+			"shifts":    shifts,
+			"conflicts": conflicts,
+		},
+		"warnings": warnings,
+		"message":  fmt.Sprintf("Updated %d occurrences with %d conflicts", len(shifts), len(conflicts)),
+	})
+}
+
+// DeleteSeriesOccurrence removes one occurrence of a series, that occurrence and the ones
+// after it, or every upcoming occurrence. The scope is given by the scope query parameter
+// and defaults to this occurrence.
+func (h *Handler) DeleteSeriesOccurrence(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	scope := c.DefaultQuery("scope", seriesScopeThis)
+	if scope != seriesScopeThis && scope != seriesScopeFollowing && scope != seriesScopeAll {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "scope must be this, following or all",
+			},
+		})
+		return
+	}
+
+	var series models.ShiftSeries
+	var shift models.Shift
+	if !h.fetchSeriesOccurrence(c, orgID.(string), &series, &shift) {
+		return
+	}
+
+	deleted := []models.Shift{shift}
+	if scope != seriesScopeThis {
+		rule, dtstart, err := h.seriesRule(series)
+		if err == nil {
+			cutoff := dtstart
+			if scope == seriesScopeFollowing && shift.OccurrenceStart != nil {
+				cutoff = shift.OccurrenceStart.In(dtstart.Location())
+			}
+			if cutoff.After(dtstart) {
+				_, err = h.endSeriesBefore(&series, *rule, dtstart, cutoff)
+			} else {
+				err = h.DB.Model(&models.ShiftSeries{}).Where("id = ?", series.ID).Update("status", models.ShiftSeriesEnded).Error
+			}
+			if err == nil {
+				deleted, err = h.upcomingOccurrences(series.ID, cutoff, time.Now(), true)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to end shift series",
+				},
+			})
+			return
+		}
+	}
+
+	if len(deleted) > 0 {
+		if err := h.DB.Delete(&deleted).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to delete shifts",
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"deleted": len(deleted),
+		},
+		"message": fmt.Sprintf("Deleted %d occurrences", len(deleted)),
+	})
+}
@@ -0,0 +1,912 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
+	"gorm.io/gorm"
+)
+
+var errSwapStale = errors.New("shift has changed hands since the request was made")
+
+// isManagerRole reports whether a role can approve requests on behalf of the organization
+func isManagerRole(role string) bool {
+	switch role {
+	case "super_admin", "admin", "manager":
+		return true
+	}
+	return false
+}
+
+// organizationManagers returns the active users who look after the organization's roster
+func (h *Handler) organizationManagers(orgID string) []models.User {
+	var managers []models.User
+	h.DB.Where("organization_id = ? AND role IN ? AND is_active = ?", orgID, []string{"admin", "manager"}, true).Find(&managers)
+	return managers
+}
+
+// heldBy reports whether a shift is still scheduled for the given worker
+func heldBy(shift models.Shift, userID string) bool {
+	return shift.Status == "scheduled" && shift.StaffID != nil && *shift.StaffID == userID
+}
+
+// swapReasons checks whether a worker can take a shift once they have given up the shift
+// with ID givingUp, if any
+func (h *Handler) swapReasons(orgID string, worker models.User, shift models.Shift, givingUp string) ([]scheduling.Reason, error) {
+	loc, err := h.getOrganizationTimezone(orgID)
+	if err != nil {
+		loc = time.UTC
+	}
+	workers, err := h.schedulingWorkers([]models.User{worker}, shift.StartTime, shift.EndTime, loc)
+	if err != nil {
+		return nil, err
+	}
+	bookings := workers[0].Bookings[:0]
+	for _, booking := range workers[0].Bookings {
+		if booking.ShiftID != givingUp {
+			bookings = append(bookings, booking)
+		}
+	}
+	workers[0].Bookings = bookings
+	requirements, err := h.shiftRequirements(shift)
+	if err != nil {
+		return nil, err
+	}
+	reasons, _ := workers[0].Evaluate(schedulingShift(shift, shift.Participant, nil, requirements[shift.ID]), loc, h.fatigueRules(orgID))
+	return reasons, nil
+}
+
+// checkSwapWorkers re-runs the credential, overlap and fatigue checks for everyone a swap
+// request moves: the worker taking the shift and, for a swap, the requester taking theirs.
+// It writes the error response and returns false when either cannot.
+func (h *Handler) checkSwapWorkers(c *gin.Context, swap models.ShiftSwapRequest, acceptor models.User) bool {
+	if _, ok := h.checkCredentials(c, swap.OrganizationID, acceptor.ID, swap.Shift.StartTime, CredentialOverride{}); !ok {
+		return false
+	}
+	if swap.SwapShift != nil {
+		if _, ok := h.checkCredentials(c, swap.OrganizationID, swap.Requester.ID, swap.SwapShift.StartTime, CredentialOverride{}); !ok {
+			return false
+		}
+	}
+
+	var exclusions []scheduling.Exclusion
+	givingUp := ""
+	if swap.SwapShift != nil {
+		givingUp = swap.SwapShift.ID
+	}
+	reasons, err := h.swapReasons(swap.OrganizationID, acceptor, swap.Shift, givingUp)
+	if err == nil && len(reasons) > 0 {
+		exclusions = append(exclusions, scheduling.Exclusion{WorkerID: acceptor.ID, Name: acceptor.FirstName + " " + acceptor.LastName, Reasons: reasons})
+	}
+	if err == nil && swap.SwapShift != nil {
+		reasons, err = h.swapReasons(swap.OrganizationID, swap.Requester, *swap.SwapShift, swap.ShiftID)
+		if err == nil && len(reasons) > 0 {
+			exclusions = append(exclusions, scheduling.Exclusion{WorkerID: swap.Requester.ID, Name: swap.Requester.FirstName + " " + swap.Requester.LastName, Reasons: reasons})
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to load the workers' schedules",
+			},
+		})
+		return false
+	}
+	if len(exclusions) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "WORKER_NOT_ELIGIBLE",
+				"message": "The shifts cannot be exchanged without breaking scheduling rules",
+				"details": exclusions,
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// checkSwapShifts makes sure the shifts in a request are still held by the workers giving
+// them up, writing the error response and returning false otherwise
+func checkSwapShifts(c *gin.Context, swap models.ShiftSwapRequest) bool {
+	if heldBy(swap.Shift, swap.RequestedBy) && (swap.SwapShift == nil || heldBy(*swap.SwapShift, *swap.TargetUserID)) {
+		return true
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "SHIFT_CHANGED",
+			"message": "The shift has been changed or reassigned since the request was made",
+		},
+	})
+	return false
+}
+
+// swapSummary describes a swap request in a notification
+func swapSummary(swap models.ShiftSwapRequest, loc *time.Location) string {
+	summary := openShiftLine(swap.Shift, loc)
+	if swap.SwapShift != nil {
+		summary += " in exchange for " + openShiftLine(*swap.SwapShift, loc)
+	}
+	return summary
+}
+
+// notifySwap tells each of the given users about a step in a swap request
+func (h *Handler) notifySwap(users []models.User, swap models.ShiftSwapRequest, title, message string) {
+	for _, user := range users {
+		h.notifyUser(user, models.NotificationShiftSwap, title, message, models.JSONB{
+			"swap_request_id": swap.ID,
+			"shift_id":        swap.ShiftID,
+			"status":          swap.Status,
+		})
+	}
+}
+
+// fetchSwapRequest loads one of the organization's swap requests with its shifts and
+// workers, writing the error response and returning false when it is missing
+func (h *Handler) fetchSwapRequest(c *gin.Context, orgID string, swap *models.ShiftSwapRequest) bool {
+	err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Preload("Shift.Participant").Preload("SwapShift.Participant").
+		Preload("Requester").Preload("Acceptor").First(swap).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SWAP_REQUEST_NOT_FOUND",
+					"message": "Shift swap request not found",
+				},
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift swap request",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// fetchOrganizationShift loads one of the organization's shifts with its participant
+func (h *Handler) fetchOrganizationShift(orgID, shiftID string, shift *models.Shift) error {
+	return h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("shifts.id = ? AND participants.organization_id = ?", shiftID, orgID).
+		Preload("Participant").First(shift).Error
+}
+
+type CreateShiftSwapRequest struct {
+	ShiftID      string `json:"shift_id" binding:"required"`
+	Type         string `json:"type" binding:"required,oneof=drop swap"`
+	SwapShiftID  string `json:"swap_shift_id,omitempty"`  // required for a swap
+	TargetUserID string `json:"target_user_id,omitempty"` // offer a drop to one worker only
+	Note         string `json:"note,omitempty"`
+}
+
+// CreateShiftSwap lets a worker offer one of their upcoming shifts to another worker,
+// either outright or in exchange for one of that worker's shifts
+func (h *Handler) CreateShiftSwap(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req CreateShiftSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.Type == models.SwapTypeSwap && req.SwapShiftID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "A swap needs the shift to take in return",
+			},
+		})
+		return
+	}
+
+	var shift models.Shift
+	if err := h.fetchOrganizationShift(orgID.(string), req.ShiftID, &shift); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_NOT_FOUND",
+				"message": "Shift not found",
+			},
+		})
+		return
+	}
+	if !heldBy(shift, userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_YOUR_SHIFT",
+				"message": "You can only offer scheduled shifts assigned to you",
+			},
+		})
+		return
+	}
+	if !shift.StartTime.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_STARTED",
+				"message": "The shift has already started",
+			},
+		})
+		return
+	}
+
+	var active int64
+	h.DB.Model(&models.ShiftSwapRequest{}).
+		Where("status IN ? AND (shift_id IN ? OR swap_shift_id IN ?)", []string{models.SwapOpen, models.SwapAccepted},
+			[]string{req.ShiftID, req.SwapShiftID}, []string{req.ShiftID, req.SwapShiftID}).
+		Count(&active)
+	if active > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_EXISTS",
+				"message": "The shift is already part of a pending swap request",
+			},
+		})
+		return
+	}
+
+	swap := models.ShiftSwapRequest{
+		OrganizationID: orgID.(string),
+		Type:           req.Type,
+		ShiftID:        shift.ID,
+		RequestedBy:    userID,
+		Status:         models.SwapOpen,
+		Note:           req.Note,
+		Shift:          shift,
+	}
+
+	if req.Type == models.SwapTypeSwap {
+		var swapShift models.Shift
+		if err := h.fetchOrganizationShift(orgID.(string), req.SwapShiftID, &swapShift); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_FOUND",
+					"message": "Shift to swap for not found",
+				},
+			})
+			return
+		}
+		if swapShift.Status != "scheduled" || swapShift.StaffID == nil || *swapShift.StaffID == userID ||
+			!swapShift.StartTime.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SWAP_SHIFT",
+					"message": "You can only swap for another worker's upcoming scheduled shift",
+				},
+			})
+			return
+		}
+		if req.TargetUserID != "" && req.TargetUserID != *swapShift.StaffID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "A swap is offered to the worker holding the other shift",
+				},
+			})
+			return
+		}
+		swap.SwapShiftID = &swapShift.ID
+		swap.SwapShift = &swapShift
+		req.TargetUserID = *swapShift.StaffID
+	}
+
+	var recipients []models.User
+	if req.TargetUserID != "" {
+		var target models.User
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.TargetUserID, orgID, true).First(&target).Error; err != nil || target.ID == userID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return
+		}
+		swap.TargetUserID = &target.ID
+		recipients = append(recipients, target)
+	} else {
+		// Tell the workers who could take the shift
+		candidates, _, err := h.rankShiftWorkers(orgID.(string), shift, shift.Participant, nil)
+		if err == nil {
+			for _, candidate := range candidates {
+				var worker models.User
+				if candidate.WorkerID != userID && h.DB.First(&worker, "id = ?", candidate.WorkerID).Error == nil {
+					recipients = append(recipients, worker)
+				}
+			}
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Shift", "SwapShift", "Requester", "Acceptor").Create(&swap).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, orgID.(string), "create_shift_swap", "shift_swap_request", swap.ID, nil, gin.H{
+			"type":           swap.Type,
+			"shift_id":       swap.ShiftID,
+			"swap_shift_id":  swap.SwapShiftID,
+			"target_user_id": swap.TargetUserID,
+			"status":         swap.Status,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create shift swap request",
+			},
+		})
+		return
+	}
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	var requester models.User
+	h.DB.First(&requester, "id = ?", userID)
+	title := "Shift available"
+	if swap.Type == models.SwapTypeSwap {
+		title = "Shift swap offered"
+	}
+	message := fmt.Sprintf("%s %s is offering the shift %s.", requester.FirstName, requester.LastName, swapSummary(swap, loc))
+	if swap.Note != "" {
+		message += "\n\n" + swap.Note
+	}
+	h.notifySwap(recipients, swap, title, message+"\n\nAccept it in the app; a manager will confirm the change.")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    swap,
+		"message": "Shift swap request created successfully",
+	})
+}
+
+// swapVisibleTo reports whether a worker is involved in a swap request or could take it up
+func swapVisibleTo(swap models.ShiftSwapRequest, userID string) bool {
+	switch {
+	case swap.RequestedBy == userID:
+		return true
+	case swap.AcceptedBy != nil && *swap.AcceptedBy == userID:
+		return true
+	case swap.TargetUserID != nil:
+		return *swap.TargetUserID == userID
+	}
+	return swap.Status == models.SwapOpen
+}
+
+// GetShiftSwaps lists swap requests, newest first. Managers see all of them; workers see
+// the ones they made, the ones offered to them and drops open to anyone.
+func (h *Handler) GetShiftSwaps(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.ShiftSwapRequest{}).Where("organization_id = ?", orgID)
+	if !isManagerRole(h.GetUserRoleFromContext(c)) {
+		userID := h.GetUserIDFromContext(c)
+		query = query.Where("(requested_by = ? OR accepted_by = ? OR target_user_id = ? OR (target_user_id IS NULL AND status = ?))",
+			userID, userID, userID, models.SwapOpen)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var swaps []models.ShiftSwapRequest
+	if err := query.Preload("Shift.Participant").Preload("SwapShift.Participant").
+		Preload("Requester").Preload("Acceptor").
+		Order("created_at DESC").Limit(limit).Offset(offset).Find(&swaps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift swap requests",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"swap_requests": swaps,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// GetShiftSwap returns a swap request with its audit trail
+func (h *Handler) GetShiftSwap(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var swap models.ShiftSwapRequest
+	if !h.fetchSwapRequest(c, orgID.(string), &swap) {
+		return
+	}
+	if !isManagerRole(h.GetUserRoleFromContext(c)) && !swapVisibleTo(swap, h.GetUserIDFromContext(c)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_NOT_FOUND",
+				"message": "Shift swap request not found",
+			},
+		})
+		return
+	}
+
+	var history []models.AuditLog
+	h.DB.Where("entity_type = ? AND entity_id = ?", "shift_swap_request", swap.ID).Order("created_at").Find(&history)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"swap_request": swap,
+			"history":      history,
+		},
+	})
+}
+
+// AcceptShiftSwap lets a worker take up a swap request once the overlap and fatigue checks
+// pass. The request then waits for a manager.
+func (h *Handler) AcceptShiftSwap(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var swap models.ShiftSwapRequest
+	if !h.fetchSwapRequest(c, orgID.(string), &swap) {
+		return
+	}
+	if swap.Status != models.SwapOpen {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_CLOSED",
+				"message": "The swap request is " + swap.Status,
+			},
+		})
+		return
+	}
+	if swap.RequestedBy == userID || (swap.TargetUserID != nil && *swap.TargetUserID != userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_OFFERED_TO_YOU",
+				"message": "This swap request was not offered to you",
+			},
+		})
+		return
+	}
+	if !checkSwapShifts(c, swap) {
+		return
+	}
+
+	var acceptor models.User
+	if err := h.DB.First(&acceptor, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch user",
+			},
+		})
+		return
+	}
+	if !h.checkSwapWorkers(c, swap, acceptor) {
+		return
+	}
+
+	now := time.Now()
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ShiftSwapRequest{}).Where("id = ? AND status = ?", swap.ID, models.SwapOpen).
+			Updates(map[string]interface{}{
+				"status":      models.SwapAccepted,
+				"accepted_by": userID,
+				"accepted_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSwapStale
+		}
+		return recordAudit(tx, c, orgID.(string), "accept_shift_swap", "shift_swap_request", swap.ID,
+			gin.H{"status": models.SwapOpen}, gin.H{"status": models.SwapAccepted, "accepted_by": userID})
+	})
+	if err == errSwapStale {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_CLOSED",
+				"message": "The swap request has already been taken up",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to accept shift swap request",
+			},
+		})
+		return
+	}
+	swap.Status = models.SwapAccepted
+	swap.AcceptedBy = &userID
+	swap.AcceptedAt = &now
+	swap.Acceptor = &acceptor
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	summary := swapSummary(swap, loc)
+	h.notifySwap([]models.User{swap.Requester}, swap, "Shift swap accepted",
+		fmt.Sprintf("Hi %s,\n\n%s %s has accepted your offer of the shift %s. A manager will confirm the change.",
+			swap.Requester.FirstName, acceptor.FirstName, acceptor.LastName, summary))
+	h.notifySwap(h.organizationManagers(orgID.(string)), swap, "Shift swap awaiting approval",
+		fmt.Sprintf("%s %s and %s %s have agreed to exchange the shift %s. Please approve or reject the change.",
+			swap.Requester.FirstName, swap.Requester.LastName, acceptor.FirstName, acceptor.LastName, summary))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    swap,
+		"message": "Shift swap request accepted, awaiting manager approval",
+	})
+}
+
+// ApproveShiftSwap moves the shifts in an accepted swap request. The checks are run again
+// and the shifts only change hands if nobody else has changed them in the meantime.
+func (h *Handler) ApproveShiftSwap(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var swap models.ShiftSwapRequest
+	if !h.fetchSwapRequest(c, orgID.(string), &swap) {
+		return
+	}
+	if swap.Status != models.SwapAccepted {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SWAP_STATUS",
+				"message": "Only accepted swap requests can be approved",
+			},
+		})
+		return
+	}
+	if !checkSwapShifts(c, swap) || !h.checkSwapWorkers(c, swap, *swap.Acceptor) {
+		return
+	}
+
+	managerID := h.GetUserIDFromContext(c)
+	now := time.Now()
+	acceptorID := *swap.AcceptedBy
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := moveShift(tx, swap.ShiftID, swap.RequestedBy, acceptorID); err != nil {
+			return err
+		}
+		if err := recordAudit(tx, c, orgID.(string), "reassign_shift", "shift", swap.ShiftID,
+			gin.H{"staff_id": swap.RequestedBy}, gin.H{"staff_id": acceptorID, "swap_request_id": swap.ID}); err != nil {
+			return err
+		}
+		if swap.SwapShiftID != nil {
+			if err := moveShift(tx, *swap.SwapShiftID, acceptorID, swap.RequestedBy); err != nil {
+				return err
+			}
+			if err := recordAudit(tx, c, orgID.(string), "reassign_shift", "shift", *swap.SwapShiftID,
+				gin.H{"staff_id": acceptorID}, gin.H{"staff_id": swap.RequestedBy, "swap_request_id": swap.ID}); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.ShiftSwapRequest{}).Where("id = ? AND status = ?", swap.ID, models.SwapAccepted).
+			Updates(map[string]interface{}{
+				"status":     models.SwapApproved,
+				"decided_by": managerID,
+				"decided_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSwapStale
+		}
+		return recordAudit(tx, c, orgID.(string), "approve_shift_swap", "shift_swap_request", swap.ID,
+			gin.H{"status": models.SwapAccepted}, gin.H{"status": models.SwapApproved})
+	})
+	if err == errSwapStale {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_CHANGED",
+				"message": "The shift has been changed or reassigned since the request was made",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to approve shift swap request",
+			},
+		})
+		return
+	}
+	swap.Status = models.SwapApproved
+	swap.DecidedBy = &managerID
+	swap.DecidedAt = &now
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	summary := swapSummary(swap, loc)
+	h.notifySwap([]models.User{swap.Requester, *swap.Acceptor}, swap, "Shift swap approved",
+		fmt.Sprintf("The exchange of the shift %s between %s %s and %s %s has been approved. Your roster has been updated.",
+			summary, swap.Requester.FirstName, swap.Requester.LastName, swap.Acceptor.FirstName, swap.Acceptor.LastName))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    swap,
+		"message": "Shift swap request approved successfully",
+	})
+}
+
+// moveShift hands a shift from one worker to another, failing with errSwapStale if the
+// first worker no longer holds it. A published copy of the shift moves with it so the
+// next roster publication does not report the change again.
+func moveShift(tx *gorm.DB, shiftID, from, to string) error {
+	result := tx.Model(&models.Shift{}).Where("id = ? AND staff_id = ? AND status = ?", shiftID, from, "scheduled").
+		Updates(staffChange(to))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errSwapStale
+	}
+	return tx.Model(&models.PublishedShift{}).Where("shift_id = ?", shiftID).Update("staff_id", to).Error
+}
+
+type DecideShiftSwapRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// RejectShiftSwap turns down an open or accepted swap request, leaving the shifts as they are
+func (h *Handler) RejectShiftSwap(c *gin.Context) {
+	h.closeShiftSwap(c, models.SwapRejected)
+}
+
+// CancelShiftSwap lets the worker who made a swap request withdraw it before it is approved
+func (h *Handler) CancelShiftSwap(c *gin.Context) {
+	h.closeShiftSwap(c, models.SwapCancelled)
+}
+
+// closeShiftSwap rejects or cancels a swap request that has not been approved
+func (h *Handler) closeShiftSwap(c *gin.Context, status string) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := h.GetUserIDFromContext(c)
+
+	var req DecideShiftSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var swap models.ShiftSwapRequest
+	if !h.fetchSwapRequest(c, orgID.(string), &swap) {
+		return
+	}
+	if status == models.SwapCancelled && swap.RequestedBy != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "Only the worker who made the request can cancel it",
+			},
+		})
+		return
+	}
+	if swap.Status != models.SwapOpen && swap.Status != models.SwapAccepted {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_CLOSED",
+				"message": "The swap request is " + swap.Status,
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	previous := swap.Status
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ShiftSwapRequest{}).Where("id = ? AND status = ?", swap.ID, previous).
+			Updates(map[string]interface{}{
+				"status":     status,
+				"decided_by": userID,
+				"decided_at": now,
+				"reason":     req.Reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSwapStale
+		}
+		action := "reject_shift_swap"
+		if status == models.SwapCancelled {
+			action = "cancel_shift_swap"
+		}
+		return recordAudit(tx, c, orgID.(string), action, "shift_swap_request", swap.ID,
+			gin.H{"status": previous}, gin.H{"status": status, "reason": req.Reason})
+	})
+	if err == errSwapStale {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SWAP_REQUEST_CLOSED",
+				"message": "The swap request has changed, please reload it",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift swap request",
+			},
+		})
+		return
+	}
+	swap.Status = status
+	swap.DecidedBy = &userID
+	swap.DecidedAt = &now
+	swap.Reason = req.Reason
+
+	loc, err := h.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	summary := swapSummary(swap, loc)
+	if status == models.SwapRejected {
+		recipients := []models.User{swap.Requester}
+		if swap.Acceptor != nil {
+			recipients = append(recipients, *swap.Acceptor)
+		}
+		message := fmt.Sprintf("The request to exchange the shift %s was not approved. The shifts stay as they were.", summary)
+		if req.Reason != "" {
+			message += "\n\nReason: " + req.Reason
+		}
+		h.notifySwap(recipients, swap, "Shift swap rejected", message)
+	} else if swap.Acceptor != nil {
+		h.notifySwap([]models.User{*swap.Acceptor}, swap, "Shift swap withdrawn",
+			fmt.Sprintf("Hi %s,\n\n%s %s has withdrawn the offer of the shift %s.",
+				swap.Acceptor.FirstName, swap.Requester.FirstName, swap.Requester.LastName, summary))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    swap,
+		"message": "Shift swap request " + status,
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
 	"gorm.io/gorm"
 )
 
@@ -123,6 +124,7 @@ func (h *Handler) GetShifts(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	participantID := c.Query("participant_id")
 	staffID := c.Query("staff_id")
+	open := c.Query("open") == "true" // shifts with nobody assigned
 	status := c.Query("status")
 	serviceType := c.Query("service_type")
 	startDate := c.Query("start_date")
@@ -153,6 +155,11 @@ func (h *Handler) GetShifts(c *gin.Context) {
 			Where("participants.organization_id = ?", orgID)
 	}
 
+	// Workers only see shifts once their roster has been published
+	if userRole == "care_worker" {
+		query = query.Where("shifts.publish_status = ?", models.ShiftPublished)
+	}
+
 	if participantID != "" {
 		query = query.Where("shifts.participant_id = ?", participantID)
 	}
@@ -161,6 +168,10 @@ func (h *Handler) GetShifts(c *gin.Context) {
 		query = query.Where("shifts.staff_id = ?", staffID)
 	}
 
+	if open {
+		query = query.Where("shifts.staff_id IS NULL")
+	}
+
 	if status != "" {
 		query = query.Where("shifts.status = ?", status)
 	}
@@ -232,9 +243,15 @@ func (h *Handler) GetShift(c *gin.Context) {
 
 	// Find shift with access control through participant
 	var shift models.Shift
-	if err := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
-		Where("shifts.id = ? AND participants.organization_id = ?", shiftID, orgID).
-		Preload("Participant").Preload("Staff").
+	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("shifts.id = ? AND participants.organization_id = ?", shiftID, orgID)
+
+	// Workers only see shifts once their roster has been published
+	if h.GetUserRoleFromContext(c) == "care_worker" {
+		query = query.Where("shifts.publish_status = ?", models.ShiftPublished)
+	}
+
+	if err := query.Preload("Participant").Preload("Staff").Preload("SupportItem").Preload("CostBands").
 		First(&shift).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -256,21 +273,49 @@ func (h *Handler) GetShift(c *gin.Context) {
 		return
 	}
 
+	// Explain how the assigned worker, or the one given by ?staff_id=, matches the shift
+	requirements, err := h.shiftRequirements(shift)
+	var match gin.H
+	if staffID := c.DefaultQuery("staff_id", shiftStaffID(shift)); err == nil && staffID != "" {
+		var staff models.User
+		if h.DB.Where("id = ? AND organization_id = ?", staffID, orgID).First(&staff).Error == nil {
+			match, err = h.shiftMatch(orgID.(string), staff, shift, requirements[shift.ID])
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check the shift's requirements",
+			},
+		})
+		return
+	}
+	if requirements[shift.ID] == nil {
+		requirements[shift.ID] = []models.ParticipantRequirement{}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    shift,
+		"success":      true,
+		"data":         shift,
+		"requirements": requirements[shift.ID],
+		"match":        match,
 	})
 }
 
 type CreateShiftRequest struct {
 	ParticipantID string  `json:"participant_id" binding:"required"`
-	StaffID       string  `json:"staff_id" binding:"required"`
+	StaffID       string  `json:"staff_id"`                      // empty for an open shift
 	StartTime     string  `json:"start_time" binding:"required"` // Accept ISO string or local datetime
 	EndTime       string  `json:"end_time" binding:"required"`   // Accept ISO string or local datetime
 	ServiceType   string  `json:"service_type" binding:"required"`
+	SupportItemID *string `json:"support_item_id,omitempty"`
 	Location      string  `json:"location" binding:"required"`
 	HourlyRate    float64 `json:"hourly_rate" binding:"required,gt=0"`
 	Notes         string  `json:"notes"`
+
+	CredentialOverride
 }
 
 func (h *Handler) CreateShift(c *gin.Context) {
@@ -338,40 +383,18 @@ func (h *Handler) CreateShift(c *gin.Context) {
 		return
 	}
 
-	// Verify participant belongs to organization
-	var participant models.Participant
-	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.ParticipantID, orgID, true).First(&participant).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    "INVALID_PARTICIPANT",
-				"message": "Participant not found or inactive",
-			},
-		})
+	participant, supportItemID, warnings, ok := h.checkShiftBooking(c, orgID.(string), req)
+	if !ok {
 		return
 	}
 
-	// Verify staff belongs to organization
-	var staff models.User
-	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    "INVALID_STAFF",
-				"message": "Staff member not found or inactive",
-			},
-		})
+	// The participant may have asked never to have this worker
+	if !h.checkBlockedWorker(c, req.ParticipantID, req.StaffID) {
 		return
 	}
 
 	// Check for overlapping shifts for the staff member
-	var overlappingShifts int64
-	h.DB.Model(&models.Shift{}).
-		Where("staff_id = ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-			req.StaffID, "cancelled", "completed", startTime, startTime, endTime, endTime).
-		Count(&overlappingShifts)
-
-	if overlappingShifts > 0 {
+	if hasScheduleConflict(h.DB, req.StaffID, "", startTime, endTime) {
 		c.JSON(http.StatusConflict, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -382,20 +405,72 @@ func (h *Handler) CreateShift(c *gin.Context) {
 		return
 	}
 
-	// Create shift
+	// The worker must hold the organization's mandatory credentials when the shift starts
+	overridden, ok := h.checkCredentials(c, orgID.(string), req.StaffID, startTime, req.CredentialOverride)
+	if !ok {
+		return
+	}
+
+	// The worker must also meet the participant's requirements
+	booking := models.Shift{ParticipantID: req.ParticipantID, StartTime: startTime, EndTime: endTime}
+	if !h.checkRequirements(c, orgID.(string), req.StaffID, booking, participant) {
+		return
+	}
+
+	// Create shift, priced by penalty rate band
 	shift := models.Shift{
 		ParticipantID: req.ParticipantID,
-		StaffID:       req.StaffID,
 		StartTime:     startTime,
 		EndTime:       endTime,
 		ServiceType:   req.ServiceType,
+		SupportItemID: supportItemID,
 		Location:      req.Location,
 		Status:        "scheduled",
+		PublishStatus: models.ShiftDraft,
 		HourlyRate:    req.HourlyRate,
 		Notes:         req.Notes,
 	}
+	h.geocodeShift(&shift)
+	h.priceShift(&shift, participant, orgID.(string))
+
+	// Rest periods allow for the drive from the worker's other shifts, so are checked once
+	// the shift has been placed
+	violations, ok := h.checkFatigue(c, orgID.(string), req.StaffID, shift, participant)
+	if !ok {
+		return
+	}
+
+	// Open shifts go to the best available worker when the organization auto-assigns them
+	if req.StaffID != "" {
+		shift.StaffID = &req.StaffID
+	} else if h.autoAssignShifts(orgID.(string)) {
+		candidates, _, err := h.rankShiftWorkers(orgID.(string), shift, participant, nil)
+		if err == nil {
+			for i := range candidates {
+				if gaps, err := h.credentialGaps(orgID.(string), candidates[i].WorkerID, startTime); err == nil && len(gaps) == 0 {
+					shift.StaffID = &candidates[i].WorkerID
+					violations = append(violations, candidates[i].Warnings...)
+					break
+				}
+			}
+		}
+		if shift.StaffID == nil {
+			warnings = append(warnings, "No available worker could be found, the shift has been left open")
+		}
+	}
+
+	// Warn when the booking would take projected spend past the remaining budget
+	if message := h.budgetWarning(participant, startTime, shiftCost(shift)); message != "" {
+		warnings = append(warnings, message)
+	}
 
-	if err := h.DB.Create(&shift).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&shift).Error; err != nil {
+			return err
+		}
+		return recordCredentialOverride(tx, c, orgID.(string), shift.ID, req.StaffID, req.CredentialOverride, overridden)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -407,25 +482,178 @@ func (h *Handler) CreateShift(c *gin.Context) {
 	}
 
 	// Fetch shift with related data
-	h.DB.Preload("Participant").Preload("Staff").First(&shift, "id = ?", shift.ID)
+	h.DB.Preload("Participant").Preload("Staff").Preload("SupportItem").Preload("CostBands").First(&shift, "id = ?", shift.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"data":    shift,
-		"message": "Shift created successfully",
+		"success":    true,
+		"data":       shift,
+		"warnings":   warnings,
+		"violations": violations, // fatigue rules the organization only warns about
+		"message":    "Shift created successfully",
 	})
 }
 
+// checkShiftBooking verifies the participant, staff member and support item of a new booking
+// and checks the rate against the item's price limit. It writes the error response and
+// returns false when the booking is invalid.
+func (h *Handler) checkShiftBooking(c *gin.Context, orgID string, req CreateShiftRequest) (models.Participant, *string, []string, bool) {
+	// Verify participant belongs to organization
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.ParticipantID, orgID, true).First(&participant).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PARTICIPANT",
+				"message": "Participant not found or inactive",
+			},
+		})
+		return participant, nil, nil, false
+	}
+
+	// Verify staff belongs to organization, unless the shift is open
+	var staff models.User
+	if req.StaffID != "" {
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return participant, nil, nil, false
+		}
+	}
+
+	// Verify support item and check the rate against its price limit
+	warnings := []string{}
+	var supportItemID *string
+	if req.SupportItemID != nil && *req.SupportItemID != "" {
+		supportItem, err := h.loadSupportItem(*req.SupportItemID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SUPPORT_ITEM",
+					"message": "Support item not found or inactive",
+				},
+			})
+			return participant, nil, nil, false
+		}
+		supportItemID = &supportItem.ID
+
+		if message, exceeded := checkPriceCap(supportItem, participant, req.HourlyRate); message != "" {
+			if exceeded && h.priceCapEnforcement(orgID) == "block" {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "PRICE_CAP_EXCEEDED",
+						"message": message,
+					},
+				})
+				return participant, nil, nil, false
+			}
+			warnings = append(warnings, message)
+		}
+	}
+
+	return participant, supportItemID, warnings, true
+}
+
+// shiftStaffID returns the staff member assigned to a shift, or "" while the shift is open
+func shiftStaffID(shift models.Shift) string {
+	if shift.StaffID == nil {
+		return ""
+	}
+	return *shift.StaffID
+}
+
+// hasScheduleConflict reports whether a staff member already has an active shift overlapping
+// the given times, ignoring the shift with excludeID. Open shifts never conflict.
+func hasScheduleConflict(db *gorm.DB, staffID, excludeID string, startTime, endTime time.Time) bool {
+	if staffID == "" {
+		return false
+	}
+	query := db.Model(&models.Shift{}).
+		Where("staff_id = ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
+			staffID, "cancelled", "completed", startTime, startTime, endTime, endTime)
+	if excludeID != "" {
+		query = query.Where("id != ?", excludeID)
+	}
+
+	var overlappingShifts int64
+	query.Count(&overlappingShifts)
+	return overlappingShifts > 0
+}
+
+// bookingConflict is why a staff member can't be booked on a shift
+type bookingConflict struct {
+	Code    string
+	Message string
+	Details interface{}
+}
+
+// generatedShiftConflict runs the checks made when a shift is booked by hand on a shift
+// generated for a staff member, such as an occurrence of a series. Nobody is there to
+// override a gap in their credentials, so every check has to pass. It returns nil when the
+// staff member can be booked.
+func (h *Handler) generatedShiftConflict(orgID, staffID string, shift models.Shift, participant models.Participant) (*bookingConflict, error) {
+	if staffID == "" {
+		return nil, nil
+	}
+
+	blocked, err := h.blockedWorker(shift.ParticipantID, staffID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked != nil {
+		return &bookingConflict{
+			Code:    "WORKER_BLOCKED",
+			Message: "The participant has asked not to have this staff member",
+			Details: blocked.Reason,
+		}, nil
+	}
+
+	gaps, err := h.credentialGaps(orgID, staffID, shift.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(gaps) > 0 {
+		return &bookingConflict{
+			Code:    "CREDENTIALS_NOT_MET",
+			Message: "Staff member does not meet the organization's mandatory credentials",
+			Details: gaps,
+		}, nil
+	}
+
+	unmet, err := h.unmetRequirements(staffID, shift, participant)
+	if err != nil {
+		return nil, err
+	}
+	if len(unmet) > 0 {
+		return &bookingConflict{
+			Code:    "REQUIREMENTS_NOT_MET",
+			Message: "Staff member does not meet the participant's requirements",
+			Details: unmet,
+		}, nil
+	}
+	return nil, nil
+}
+
 type UpdateShiftRequest struct {
+	StaffID         *string  `json:"staff_id,omitempty"`          // empty string leaves the shift open
 	StartTime       *string  `json:"start_time,omitempty"`        // Accept string for easier frontend integration
 	EndTime         *string  `json:"end_time,omitempty"`          // Accept string for easier frontend integration
 	ActualStartTime *string  `json:"actual_start_time,omitempty"` // Accept string for easier frontend integration
 	ActualEndTime   *string  `json:"actual_end_time,omitempty"`   // Accept string for easier frontend integration
 	ServiceType     *string  `json:"service_type,omitempty"`
+	SupportItemID   *string  `json:"support_item_id,omitempty"` // empty string clears the support item
 	Location        *string  `json:"location,omitempty"`
 	HourlyRate      *float64 `json:"hourly_rate,omitempty" binding:"omitempty,gt=0"`
 	Notes           *string  `json:"notes,omitempty"`
 	CompletionNotes *string  `json:"completion_notes,omitempty"`
+
+	CredentialOverride
 }
 
 func (h *Handler) UpdateShift(c *gin.Context) {
@@ -546,15 +774,32 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		return
 	}
 
-	// Check for overlapping shifts if time is being changed
-	if (req.StartTime != nil || req.EndTime != nil) && shift.Status != "cancelled" && shift.Status != "completed" {
-		var overlappingShifts int64
-		h.DB.Model(&models.Shift{}).
-			Where("staff_id = ? AND id != ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-				shift.StaffID, shiftID, "cancelled", "completed", startTime, startTime, endTime, endTime).
-			Count(&overlappingShifts)
+	// Verify a new staff member belongs to organization
+	staffID := shiftStaffID(shift)
+	staffChanged := req.StaffID != nil && *req.StaffID != staffID
+	if staffChanged {
+		staffID = *req.StaffID
+		if staffID != "" {
+			var staff models.User
+			if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", staffID, orgID, true).First(&staff).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_STAFF",
+						"message": "Staff member not found or inactive",
+					},
+				})
+				return
+			}
+		}
+		if !h.checkBlockedWorker(c, shift.ParticipantID, staffID) {
+			return
+		}
+	}
 
-		if overlappingShifts > 0 {
+	// Check for overlapping shifts if time or staff is being changed
+	if (req.StartTime != nil || req.EndTime != nil || staffChanged) && shift.Status != "cancelled" && shift.Status != "completed" {
+		if hasScheduleConflict(h.DB, staffID, shiftID, startTime, endTime) {
 			c.JSON(http.StatusConflict, gin.H{
 				"success": false,
 				"error": gin.H{
@@ -566,11 +811,103 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		}
 	}
 
+	// Re-check the fatigue rules when the worker, times or location change
+	violations := []scheduling.Violation{}
+	if (req.StartTime != nil || req.EndTime != nil || req.Location != nil || staffChanged) && shift.Status != "cancelled" && shift.Status != "completed" {
+		var participant models.Participant
+		h.DB.Where("id = ?", shift.ParticipantID).First(&participant)
+
+		booking := shift
+		booking.StartTime, booking.EndTime = startTime, endTime
+		if req.Location != nil {
+			booking.Location = *req.Location
+			h.geocodeShift(&booking)
+		}
+		var ok bool
+		if violations, ok = h.checkFatigue(c, orgID.(string), staffID, booking, participant); !ok {
+			return
+		}
+	}
+
+	// Re-check the mandatory credentials and participant's requirements when the worker or
+	// start time changes
+	var overridden []credentialGap
+	if (staffChanged || req.StartTime != nil) && shift.Status != "cancelled" && shift.Status != "completed" {
+		var ok bool
+		if overridden, ok = h.checkCredentials(c, orgID.(string), staffID, startTime, req.CredentialOverride); !ok {
+			return
+		}
+
+		var participant models.Participant
+		h.DB.Where("id = ?", shift.ParticipantID).First(&participant)
+
+		booking := models.Shift{ID: shift.ID, ParticipantID: shift.ParticipantID, StartTime: startTime, EndTime: endTime}
+		if !h.checkRequirements(c, orgID.(string), staffID, booking, participant) {
+			return
+		}
+	}
+
+	// Re-check the price limit when the rate or support item changes
+	warnings := []string{}
+	if req.SupportItemID != nil || req.HourlyRate != nil {
+		supportItemID := shift.SupportItemID
+		if req.SupportItemID != nil {
+			supportItemID = req.SupportItemID
+		}
+		rate := shift.HourlyRate
+		if req.HourlyRate != nil {
+			rate = *req.HourlyRate
+		}
+
+		if supportItemID != nil && *supportItemID != "" {
+			supportItem, err := h.loadSupportItem(*supportItemID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_SUPPORT_ITEM",
+						"message": "Support item not found or inactive",
+					},
+				})
+				return
+			}
+
+			var participant models.Participant
+			h.DB.Where("id = ?", shift.ParticipantID).First(&participant)
+
+			if message, exceeded := checkPriceCap(supportItem, participant, rate); message != "" {
+				if exceeded && h.priceCapEnforcement(participant.OrganizationID) == "block" {
+					c.JSON(http.StatusBadRequest, gin.H{
+						"success": false,
+						"error": gin.H{
+							"code":    "PRICE_CAP_EXCEEDED",
+							"message": message,
+						},
+					})
+					return
+				}
+				warnings = append(warnings, message)
+			}
+		}
+	}
+
 	// Update fields
 	updates := make(map[string]interface{})
 	hourlyRate := shift.HourlyRate // Default to current rate
 	timeChanged := false
 
+	if staffChanged {
+		if staffID == "" {
+			updates["staff_id"] = nil
+		} else {
+			updates["staff_id"] = staffID
+		}
+		// A reassigned shift waits for the roster to be published again, which tells both
+		// workers about the change
+		if shift.PublishStatus == models.ShiftPublished {
+			updates["publish_status"] = models.ShiftDraft
+		}
+	}
 	if req.StartTime != nil {
 		updates["start_time"] = startTime
 		timeChanged = true
@@ -592,8 +929,18 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 	if req.ServiceType != nil {
 		updates["service_type"] = *req.ServiceType
 	}
+	if req.SupportItemID != nil {
+		if *req.SupportItemID == "" {
+			updates["support_item_id"] = nil
+		} else {
+			updates["support_item_id"] = *req.SupportItemID
+		}
+	}
 	if req.Location != nil {
 		updates["location"] = *req.Location
+		located := models.Shift{Location: *req.Location}
+		h.geocodeShift(&located)
+		updates["latitude"], updates["longitude"] = located.Latitude, located.Longitude
 	}
 	if req.HourlyRate != nil {
 		updates["hourly_rate"] = *req.HourlyRate
@@ -607,14 +954,59 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		updates["completion_notes"] = *req.CompletionNotes
 	}
 
-	// CRITICAL FIX: Recalculate total cost when time or rate changes
-	if timeChanged && endTime.After(startTime) {
-		duration := endTime.Sub(startTime).Hours()
-		totalCost := duration * hourlyRate
-		updates["total_cost"] = totalCost
+	// An occurrence edited on its own is no longer changed by edits to its series
+	if shift.SeriesID != nil && len(updates) > 0 {
+		updates["series_override"] = true
 	}
 
-	if err := h.DB.Model(&shift).Updates(updates).Error; err != nil {
+	// Reprice by penalty rate band when time or rate changes
+	priced := shift
+	if timeChanged {
+		var participant models.Participant
+		h.DB.Where("id = ?", shift.ParticipantID).First(&participant)
+
+		priced.StartTime, priced.EndTime, priced.HourlyRate = startTime, endTime, hourlyRate
+		h.priceShift(&priced, participant, orgID.(string))
+		updates["total_cost"] = priced.TotalCost
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&shift).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := recordCredentialOverride(tx, c, orgID.(string), shift.ID, staffID, req.CredentialOverride, overridden); err != nil {
+			return err
+		}
+		if timeChanged {
+			if err := tx.Where("shift_id = ?", shift.ID).Delete(&models.ShiftCostBand{}).Error; err != nil {
+				return err
+			}
+			if len(priced.CostBands) > 0 {
+				if err := tx.Create(&priced.CostBands).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// A completed shift that has not been billed yet redraws its budget at the new cost and category
+		if shift.Status == "completed" && (timeChanged || req.SupportItemID != nil) && shift.InvoiceID == nil && shift.ClaimLineID == nil {
+			shift.StartTime, shift.EndTime, shift.HourlyRate = startTime, endTime, hourlyRate
+			if timeChanged {
+				shift.TotalCost = priced.TotalCost
+			}
+			if req.SupportItemID != nil {
+				shift.SupportItemID = nil
+				if *req.SupportItemID != "" {
+					shift.SupportItemID = req.SupportItemID
+				}
+			}
+			_, err := models.DrawDownShift(tx, &shift, h.completedShiftAmount(tx, shift, orgID.(string)), models.BudgetTransactionAdjustment,
+				"Completed shift updated", h.GetUserIDFromContext(c), nil)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -626,12 +1018,14 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 	}
 
 	// Fetch updated shift
-	h.DB.Preload("Participant").Preload("Staff").First(&shift, "id = ?", shiftID)
+	h.DB.Preload("Participant").Preload("Staff").Preload("SupportItem").Preload("CostBands").First(&shift, "id = ?", shiftID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    shift,
-		"message": "Shift updated successfully",
+		"success":    true,
+		"data":       shift,
+		"warnings":   warnings,
+		"violations": violations, // fatigue rules the organization only warns about
+		"message":    "Shift updated successfully",
 	})
 }
 
@@ -640,6 +1034,17 @@ type UpdateShiftStatusRequest struct {
 	CompletionNotes *string `json:"completion_notes,omitempty"`
 	ActualStartTime *string `json:"actual_start_time,omitempty"` // Accept string for easier frontend integration
 	ActualEndTime   *string `json:"actual_end_time,omitempty"`   // Accept string for easier frontend integration
+
+	// Cancellation details, required when cancelling or marking a no show
+	CancelledBy        *string `json:"cancelled_by,omitempty" binding:"omitempty,oneof=participant provider"`
+	CancellationReason *string `json:"cancellation_reason,omitempty" binding:"omitempty,oneof=NSDH NSDF NSDT NSDO"`
+	CancellationNotes  *string `json:"cancellation_notes,omitempty"`
+	NoticeGivenAt      *string `json:"notice_given_at,omitempty"` // When the participant gave notice, defaults to now
+
+	// Travel, recorded when completing a shift
+	TravelTimeMinutes *int     `json:"travel_time_minutes,omitempty" binding:"omitempty,min=0"`
+	TravelKm          *float64 `json:"travel_km,omitempty" binding:"omitempty,min=0"`
+	ActivityKm        *float64 `json:"activity_km,omitempty" binding:"omitempty,min=0"`
 }
 
 func (h *Handler) UpdateShiftStatus(c *gin.Context) {
@@ -724,7 +1129,7 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 
 	// Only admin and manager can edit shifts, staff can only start/complete their own shifts
 	canEdit := role == "admin" || role == "manager"
-	isOwnShift := shift.StaffID == currentUserID
+	isOwnShift := shiftStaffID(shift) == currentUserID
 
 	// For status changes, staff can only modify their own shifts and only for start/complete actions
 	if !canEdit && !isOwnShift {
@@ -791,6 +1196,69 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 		return
 	}
 
+	cancelling := (req.Status == "cancelled" || req.Status == "no_show") && req.Status != shift.Status
+	cancelledBy := "participant" // A no show is always the participant's
+	if cancelling {
+		if req.Status == "cancelled" {
+			if req.CancelledBy == nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "CANCELLED_BY_REQUIRED",
+						"message": "Record whether the participant or the provider cancelled the shift",
+					},
+				})
+				return
+			}
+			cancelledBy = *req.CancelledBy
+		}
+		if req.CancellationReason == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CANCELLATION_REASON_REQUIRED",
+					"message": "A cancellation reason is required",
+				},
+			})
+			return
+		}
+	}
+
+	recordingTravel := req.TravelTimeMinutes != nil || req.TravelKm != nil || req.ActivityKm != nil
+	if recordingTravel && req.Status != "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "TRAVEL_REQUIRES_COMPLETION",
+				"message": "Travel can only be recorded when completing a shift",
+			},
+		})
+		return
+	}
+	if recordingTravel && (shift.InvoiceID != nil || shift.ClaimLineID != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_BILLED",
+				"message": "Travel cannot be changed after the shift has been invoiced or claimed",
+			},
+		})
+		return
+	}
+
+	// Billed cancellations must be credited before the shift can go ahead
+	rescheduling := req.Status == "scheduled" && (shift.Status == "cancelled" || shift.Status == "no_show")
+	if rescheduling && (shift.InvoiceID != nil || shift.ClaimLineID != nil) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_BILLED",
+				"message": "The cancellation fee for this shift has already been invoiced or claimed",
+			},
+		})
+		return
+	}
+
 	// Special validation for starting shifts (30-minute rule) - using organization timezone
 	if req.Status == "in_progress" && shift.Status == "scheduled" {
 		orgTz, err := h.getOrganizationTimezone(orgID.(string))
@@ -847,7 +1315,97 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 		updates["actual_end_time"] = now
 	}
 
-	if err := h.DB.Model(&shift).Updates(updates).Error; err != nil {
+	if recordingTravel {
+		if req.TravelKm != nil {
+			shift.TravelKm = roundCurrency(*req.TravelKm)
+		}
+		if req.ActivityKm != nil {
+			shift.ActivityKm = roundCurrency(*req.ActivityKm)
+		}
+		if req.TravelTimeMinutes != nil {
+			shift.TravelTimeMinutes = *req.TravelTimeMinutes
+		} else if shift.TravelTimeMinutes == 0 && shift.TravelKm > 0 {
+			shift.TravelTimeMinutes = h.defaultTravelTime(shiftStaffID(shift), shift.StartTime, orgTz)
+		}
+		updates["travel_time_minutes"] = shift.TravelTimeMinutes
+		updates["travel_km"] = shift.TravelKm
+		updates["activity_km"] = shift.ActivityKm
+	}
+
+	charge := 0.0
+	if cancelling {
+		noticeAt := now
+		if req.NoticeGivenAt != nil {
+			parsed, err := h.parseTimeInOrganizationTimezone(*req.NoticeGivenAt, orgID.(string))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_TIME_FORMAT",
+						"message": "Invalid notice_given_at format",
+					},
+				})
+				return
+			}
+			noticeAt = parsed
+		}
+		notice := noticeHours(shift, noticeAt)
+		if req.Status == "no_show" {
+			notice = 0
+		}
+
+		updates["cancelled_at"] = noticeAt
+		updates["cancelled_by"] = cancelledBy
+		updates["cancellation_recorded_by"] = currentUserID
+		updates["cancellation_reason"] = *req.CancellationReason
+		updates["cancellation_notice_hours"] = notice
+		charge = h.cancellationCharge(shift, cancelledBy, notice, orgID.(string))
+		updates["cancellation_charge"] = charge
+		if req.CancellationNotes != nil {
+			updates["cancellation_notes"] = *req.CancellationNotes
+		}
+	}
+	if rescheduling {
+		updates["cancelled_at"] = nil
+		updates["cancelled_by"] = ""
+		updates["cancellation_recorded_by"] = nil
+		updates["cancellation_reason"] = ""
+		updates["cancellation_notes"] = ""
+		updates["cancellation_notice_hours"] = 0
+		updates["cancellation_charge"] = 0
+	}
+
+	previousStatus := shift.Status
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&shift).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// Completed shifts and short notice cancellations draw down the participant's budget
+		switch {
+		case req.Status == "completed":
+			transactionType, description := models.BudgetTransactionShift, "Shift completed "
+			if previousStatus == "completed" {
+				transactionType, description = models.BudgetTransactionAdjustment, "Travel recorded for shift "
+			}
+			_, err := models.DrawDownShift(tx, &shift, h.completedShiftAmount(tx, shift, orgID.(string)), transactionType,
+				description+shift.StartTime.In(orgTz).Format("02/01/2006 15:04"), h.GetUserIDFromContext(c), nil)
+			if err != nil {
+				return err
+			}
+			return h.syncWorkerReimbursement(tx, shift, orgID.(string))
+		case charge > 0:
+			_, err := models.DrawDownShift(tx, &shift, charge, models.BudgetTransactionCancellation,
+				"Short notice cancellation "+shift.StartTime.In(orgTz).Format("02/01/2006 15:04"), h.GetUserIDFromContext(c), nil)
+			return err
+		case rescheduling:
+			_, err := models.DrawDownShift(tx, &shift, 0, models.BudgetTransactionCancellation,
+				"Cancellation reversed "+shift.StartTime.In(orgTz).Format("02/01/2006 15:04"), h.GetUserIDFromContext(c), nil)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -12,6 +12,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// testStaffID is the staff member the test shifts are assigned to
+var testStaffID = "shift-staff"
+
 func TestCreateShift(t *testing.T) {
 	handler, router := setupTestHandler()
 
@@ -175,7 +178,7 @@ func TestGetShift(t *testing.T) {
 	testShift := models.Shift{
 		ID:            "test-shift",
 		ParticipantID: "shift-participant",
-		StaffID:       "shift-staff",
+		StaffID:       &testStaffID,
 		StartTime:     futureTime,
 		EndTime:       futureTime.Add(8 * time.Hour),
 		ServiceType:   "Personal Care",
@@ -222,7 +225,7 @@ func TestUpdateShift(t *testing.T) {
 	testShift := models.Shift{
 		ID:            "update-shift",
 		ParticipantID: "shift-participant",
-		StaffID:       "shift-staff",
+		StaffID:       &testStaffID,
 		StartTime:     futureTime,
 		EndTime:       futureTime.Add(8 * time.Hour),
 		ServiceType:   "Personal Care",
@@ -279,7 +282,7 @@ func TestUpdateShiftStatus(t *testing.T) {
 	testShift := models.Shift{
 		ID:            "status-shift",
 		ParticipantID: "shift-participant",
-		StaffID:       "shift-staff",
+		StaffID:       &testStaffID,
 		StartTime:     futureTime,
 		EndTime:       futureTime.Add(8 * time.Hour),
 		ServiceType:   "Personal Care",
@@ -315,7 +318,7 @@ func TestUpdateShiftStatus(t *testing.T) {
 		testShift2 := models.Shift{
 			ID:            "status-shift-2",
 			ParticipantID: "shift-participant",
-			StaffID:       "shift-staff",
+			StaffID:       &testStaffID,
 			StartTime:     futureTime,
 			EndTime:       futureTime.Add(8 * time.Hour),
 			Status:        "scheduled",
@@ -347,7 +350,7 @@ func TestDeleteShift(t *testing.T) {
 	testShift := models.Shift{
 		ID:            "delete-shift",
 		ParticipantID: "shift-participant",
-		StaffID:       "shift-staff",
+		StaffID:       &testStaffID,
 		StartTime:     futureTime,
 		EndTime:       futureTime.Add(8 * time.Hour),
 		ServiceType:   "Personal Care",
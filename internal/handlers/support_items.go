@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetSupportItems(c *gin.Context) {
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	search := c.Query("search")
+	registrationGroup := c.Query("registration_group")
+	category := c.Query("category")
+	active := c.DefaultQuery("active", "true")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.SupportItem{})
+
+	if search != "" {
+		searchPattern := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(item_number) LIKE ? OR LOWER(name) LIKE ?", searchPattern, searchPattern)
+	}
+	if registrationGroup != "" {
+		query = query.Where("registration_group_number = ?", registrationGroup)
+	}
+	if category != "" {
+		query = query.Where("support_category_number = ?", category)
+	}
+	if active != "all" {
+		query = query.Where("is_active = ?", active == "true")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.SupportItem
+	if err := query.Preload("Prices").Limit(limit).Offset(offset).Order("item_number ASC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch support items",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"support_items": items,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) GetSupportItem(c *gin.Context) {
+	itemID := c.Param("id")
+
+	var item models.SupportItem
+	if err := h.DB.Preload("Prices").Where("id = ? OR item_number = ?", itemID, itemID).First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SUPPORT_ITEM_NOT_FOUND",
+					"message": "Support item not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch support item",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    item,
+	})
+}
+
+// ImportSupportCatalogue loads the NDIS Pricing Arrangements CSV or XLSX and upserts every item by item number.
+// Every organization prices against the same catalogue, so only super admins can import it.
+func (h *Handler) ImportSupportCatalogue(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FILE_REQUIRED",
+				"message": "Catalogue file upload is required",
+			},
+		})
+		return
+	}
+	defer file.Close()
+
+	// Validate file size (20MB limit)
+	if header.Size > 20<<20 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FILE_TOO_LARGE",
+				"message": "File size cannot exceed 20MB",
+			},
+		})
+		return
+	}
+
+	var items []catalogue.Item
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		items, err = catalogue.ParseCSV(file)
+	case ".xlsx":
+		items, err = catalogue.ParseXLSX(file, header.Size)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_FILE_TYPE",
+				"message": "Catalogue must be a .csv or .xlsx file",
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_CATALOGUE",
+				"message": "Failed to parse support catalogue",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	created, updated := 0, 0
+	now := time.Now()
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, parsed := range items {
+			var item models.SupportItem
+			result := tx.Where("item_number = ?", parsed.Number).Limit(1).Find(&item)
+			if result.Error != nil {
+				return result.Error
+			}
+			isNew := result.RowsAffected == 0
+
+			item.ItemNumber = parsed.Number
+			item.Name = parsed.Name
+			item.RegistrationGroupNumber = parsed.RegistrationGroupNumber
+			item.RegistrationGroupName = parsed.RegistrationGroupName
+			item.SupportCategoryNumber = parsed.SupportCategoryNumber
+			item.SupportCategoryName = parsed.SupportCategoryName
+			item.Unit = parsed.Unit
+			item.Quote = parsed.Quote
+			item.ItemType = parsed.Type
+			item.StartDate = parsed.StartDate
+			item.EndDate = parsed.EndDate
+			item.NonFaceToFace = parsed.NonFaceToFace
+			item.ProviderTravel = parsed.ProviderTravel
+			item.ShortNoticeCancellation = parsed.ShortNoticeCancellation
+			item.NDIARequestedReports = parsed.NDIARequestedReports
+			item.IrregularSIL = parsed.IrregularSIL
+			item.IsActive = parsed.EndDate == nil || parsed.EndDate.After(now)
+
+			if isNew {
+				if err := tx.Create(&item).Error; err != nil {
+					return fmt.Errorf("item %s: %w", parsed.Number, err)
+				}
+				created++
+			} else {
+				if err := tx.Save(&item).Error; err != nil {
+					return fmt.Errorf("item %s: %w", parsed.Number, err)
+				}
+				if err := tx.Where("support_item_id = ?", item.ID).Delete(&models.SupportItemPrice{}).Error; err != nil {
+					return fmt.Errorf("item %s: %w", parsed.Number, err)
+				}
+				updated++
+			}
+
+			for _, region := range catalogue.Regions {
+				limit, ok := parsed.Prices[region]
+				if !ok {
+					continue
+				}
+				price := models.SupportItemPrice{SupportItemID: item.ID, Region: region, PriceLimit: limit}
+				if err := tx.Create(&price).Error; err != nil {
+					return fmt.Errorf("item %s: %w", parsed.Number, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to import support catalogue",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"total":   len(items),
+			"created": created,
+			"updated": updated,
+		},
+		"message": "Support catalogue imported successfully",
+	})
+}
+
+// loadSupportItem fetches an active support item with its regional price limits
+func (h *Handler) loadSupportItem(itemID string) (*models.SupportItem, error) {
+	var item models.SupportItem
+	if err := h.DB.Preload("Prices").Where("id = ? AND is_active = ?", itemID, true).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// priceCapEnforcement returns the organization's price cap mode, defaulting to block
+func (h *Handler) priceCapEnforcement(orgID string) string {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil || settings.PriceCapEnforcement == "" {
+		return "block"
+	}
+	return settings.PriceCapEnforcement
+}
+
+// checkPriceCap compares a rate against the support item's price limit for the participant's region.
+// It returns a message when the limit is exceeded (exceeded=true) or cannot be determined (exceeded=false).
+func checkPriceCap(item *models.SupportItem, participant models.Participant, rate float64) (message string, exceeded bool) {
+	if item == nil || item.Quote {
+		return "", false
+	}
+
+	region := catalogue.RegionFor(participant.Address.State, participant.Remoteness)
+	if region == "" {
+		return fmt.Sprintf("Participant state is not set, price limit for %s could not be checked", item.ItemNumber), false
+	}
+
+	limit, ok := item.PriceLimitFor(region)
+	if !ok {
+		return "", false
+	}
+	if roundCurrency(rate) > limit {
+		return fmt.Sprintf("Rate %.2f exceeds the %s price limit of %.2f for %s", rate, region, limit, item.ItemNumber), true
+	}
+	return "", false
+}
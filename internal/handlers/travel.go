@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type UpdateReimbursementStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending approved rejected paid"`
+}
+
+// travelCharge is a billable travel part of a completed shift, invoiced or claimed as its own line
+type travelCharge struct {
+	LineType      string // travel, transport
+	Description   string
+	SupportItemID *string
+	ItemNumber    string
+	Quantity      float64
+	Unit          string
+	UnitPrice     float64
+	Amount        float64
+}
+
+// kilometreRates returns the per km rate billed to participants, capped by the catalogue,
+// and the rate reimbursed to workers
+func (h *Handler) kilometreRates(db *gorm.DB, orgID string) (float64, float64) {
+	var settings models.OrganizationSettings
+	if err := db.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return catalogue.KilometreRateCap, 0.88
+	}
+	return catalogue.KilometreRate(settings.TravelKilometreRate), settings.WorkerKilometreRate
+}
+
+// travelCharges prices the travel recorded on a shift. Travel time is billed at the shift's
+// base rate up to the catalogue cap for the participant's remoteness, and provider travel km
+// at the organization's rate; both only when the support item allows provider travel.
+// Activity based transport is billed against its own support item.
+func (h *Handler) travelCharges(db *gorm.DB, shift models.Shift, remoteness, orgID string) []travelCharge {
+	charges := []travelCharge{}
+	if shift.TravelTimeMinutes <= 0 && shift.TravelKm <= 0 && shift.ActivityKm <= 0 {
+		return charges
+	}
+
+	service, itemNumber := shift.ServiceType, ""
+	providerTravel := true
+	if shift.SupportItem != nil {
+		service = shift.SupportItem.ItemNumber + " " + shift.SupportItem.Name
+		itemNumber = shift.SupportItem.ItemNumber
+		providerTravel = shift.SupportItem.ProviderTravel
+	}
+	date := shift.StartTime.Format("02/01/2006")
+	kmRate, _ := h.kilometreRates(db, orgID)
+
+	if providerTravel && shift.TravelTimeMinutes > 0 {
+		minutes := min(shift.TravelTimeMinutes, catalogue.TravelTimeCap(remoteness))
+		hours := roundCurrency(float64(minutes) / 60)
+		charges = append(charges, travelCharge{
+			LineType:      "travel",
+			Description:   fmt.Sprintf("Provider travel (%d min): %s - %s", minutes, service, date),
+			SupportItemID: shift.SupportItemID,
+			ItemNumber:    itemNumber,
+			Quantity:      hours,
+			Unit:          "H",
+			UnitPrice:     shift.HourlyRate,
+			Amount:        roundCurrency(hours * shift.HourlyRate),
+		})
+	}
+	if providerTravel && shift.TravelKm > 0 {
+		charges = append(charges, travelCharge{
+			LineType:      "transport",
+			Description:   fmt.Sprintf("Provider travel (%.1f km): %s - %s", shift.TravelKm, service, date),
+			SupportItemID: shift.SupportItemID,
+			ItemNumber:    itemNumber,
+			Quantity:      shift.TravelKm,
+			Unit:          "KM",
+			UnitPrice:     kmRate,
+			Amount:        roundCurrency(shift.TravelKm * kmRate),
+		})
+	}
+	if shift.ActivityKm > 0 {
+		var supportItemID *string
+		var item models.SupportItem
+		if db.Where("item_number = ?", catalogue.ActivityBasedTransportItem).First(&item).Error == nil {
+			supportItemID = &item.ID
+		}
+		charges = append(charges, travelCharge{
+			LineType:      "transport",
+			Description:   fmt.Sprintf("Activity based transport (%.1f km) - %s", shift.ActivityKm, date),
+			SupportItemID: supportItemID,
+			ItemNumber:    catalogue.ActivityBasedTransportItem,
+			Quantity:      shift.ActivityKm,
+			Unit:          "KM",
+			UnitPrice:     kmRate,
+			Amount:        roundCurrency(shift.ActivityKm * kmRate),
+		})
+	}
+	return charges
+}
+
+// invoiceLine bills a travel charge as its own line on the shift's invoice
+func (t travelCharge) invoiceLine(shift models.Shift) models.InvoiceLine {
+	shiftID := shift.ID
+	return models.InvoiceLine{
+		ShiftID:       &shiftID,
+		SupportItemID: t.SupportItemID,
+		LineType:      t.LineType,
+		Description:   t.Description,
+		ServiceDate:   shift.StartTime,
+		Quantity:      t.Quantity,
+		Unit:          t.Unit,
+		UnitPrice:     t.UnitPrice,
+		GSTCode:       "P2",
+		Amount:        t.Amount,
+	}
+}
+
+// travelTotal is the billable amount of a shift's travel
+func travelTotal(charges []travelCharge) float64 {
+	total := 0.0
+	for _, charge := range charges {
+		total += charge.Amount
+	}
+	return roundCurrency(total)
+}
+
+// completedShiftAmount is what a completed shift draws from its participant's budget: its
+// cost plus its travel
+func (h *Handler) completedShiftAmount(db *gorm.DB, shift models.Shift, orgID string) float64 {
+	if shift.SupportItemID == nil {
+		shift.SupportItem = nil
+	} else if shift.SupportItem == nil || shift.SupportItem.ID != *shift.SupportItemID {
+		var item models.SupportItem
+		if db.First(&item, "id = ?", *shift.SupportItemID).Error == nil {
+			shift.SupportItem = &item
+		}
+	}
+	var participant models.Participant
+	db.Select("id", "remoteness").First(&participant, "id = ?", shift.ParticipantID)
+	return roundCurrency(shiftCost(shift) + travelTotal(h.travelCharges(db, shift, participant.Remoteness, orgID)))
+}
+
+// defaultTravelTime is the travel time set on the worker's availability for the day a shift
+// starts, used when a worker records kilometres but not how long they took
+func (h *Handler) defaultTravelTime(staffID string, start time.Time, location *time.Location) int {
+	var availability models.WorkerAvailability
+	if err := h.DB.Select("travel_time_minutes").
+		Where("user_id = ? AND day_of_week = ? AND is_active = ?", staffID, int(start.In(location).Weekday()), true).
+		First(&availability).Error; err != nil {
+		return 0
+	}
+	return availability.TravelTimeMinutes
+}
+
+// syncWorkerReimbursement brings the pending reimbursement for a shift's kilometres into
+// line with the travel recorded on it. Reimbursements already reviewed are left alone.
+func (h *Handler) syncWorkerReimbursement(tx *gorm.DB, shift models.Shift, orgID string) error {
+	var reimbursement models.WorkerReimbursement
+	lookup := tx.Where("shift_id = ?", shift.ID).Limit(1).Find(&reimbursement)
+	if lookup.Error != nil {
+		return lookup.Error
+	}
+	if lookup.RowsAffected > 0 && reimbursement.Status != "pending" {
+		return nil
+	}
+
+	kilometres := shift.TravelKm + shift.ActivityKm
+	if kilometres <= 0 && shift.TravelTimeMinutes <= 0 {
+		if lookup.RowsAffected > 0 {
+			return tx.Delete(&reimbursement).Error
+		}
+		return nil
+	}
+
+	_, rate := h.kilometreRates(tx, orgID)
+	reimbursement.OrganizationID = orgID
+	reimbursement.UserID = shiftStaffID(shift)
+	reimbursement.ShiftID = shift.ID
+	reimbursement.TravelTimeMinutes = shift.TravelTimeMinutes
+	reimbursement.TravelKm = shift.TravelKm
+	reimbursement.ActivityKm = shift.ActivityKm
+	reimbursement.KilometreRate = rate
+	reimbursement.Amount = roundCurrency(kilometres * rate)
+	reimbursement.Status = "pending"
+	return tx.Save(&reimbursement).Error
+}
+
+func (h *Handler) GetReimbursements(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	// Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	status := c.Query("status")
+	userID := c.Query("user_id")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	// Workers only see their own reimbursements
+	userRole, _ := c.Get("user_role")
+	if userRole != "admin" && userRole != "manager" {
+		userID = h.GetUserIDFromContext(c)
+	}
+
+	query := h.DB.Model(&models.WorkerReimbursement{}).Where("organization_id = ?", orgID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var totalAmount float64
+	query.Session(&gorm.Session{}).Select("COALESCE(SUM(amount), 0)").Scan(&totalAmount)
+
+	var reimbursements []models.WorkerReimbursement
+	if err := query.Preload("User").Preload("Shift").
+		Limit(limit).Offset(offset).Order("created_at DESC").Find(&reimbursements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch reimbursements",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"reimbursements": reimbursements,
+			"total_amount":   roundCurrency(totalAmount),
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) UpdateReimbursementStatus(c *gin.Context) {
+	reimbursementID := c.Param("id")
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateReimbursementStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var reimbursement models.WorkerReimbursement
+	if err := h.DB.Where("id = ? AND organization_id = ?", reimbursementID, orgID).First(&reimbursement).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "REIMBURSEMENT_NOT_FOUND",
+					"message": "Reimbursement not found",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch reimbursement",
+			},
+		})
+		return
+	}
+
+	validTransitions := map[string][]string{
+		"pending":  {"approved", "rejected"},
+		"approved": {"paid", "pending"},
+		"rejected": {"pending"},
+		"paid":     {}, // Final state
+	}
+	isValidTransition := false
+	for _, allowedStatus := range validTransitions[reimbursement.Status] {
+		isValidTransition = isValidTransition || req.Status == allowedStatus
+	}
+	if !isValidTransition {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TRANSITION",
+				"message": "Invalid status transition from " + reimbursement.Status + " to " + req.Status,
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": req.Status}
+	switch req.Status {
+	case "approved", "rejected":
+		updates["reviewed_by"] = h.GetUserIDFromContext(c)
+		updates["reviewed_at"] = now
+	case "paid":
+		updates["paid_at"] = now
+	case "pending":
+		updates["reviewed_by"] = nil
+		updates["reviewed_at"] = nil
+	}
+
+	if err := h.DB.Model(&reimbursement).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update reimbursement",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    reimbursement,
+		"message": "Reimbursement updated successfully",
+	})
+}
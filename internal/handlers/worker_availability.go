@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/scheduling"
 	"gorm.io/gorm"
 )
 
@@ -52,6 +53,9 @@ func (wah *WorkerAvailabilityHandler) RegisterRoutes(router *gin.RouterGroup) {
 		availabilityRoutes.PUT("/exceptions/:id", wah.UpdateAvailabilityException)
 		availabilityRoutes.DELETE("/exceptions/:id", wah.DeleteAvailabilityException)
 		availabilityRoutes.POST("/exceptions/:id/approve", wah.ApproveAvailabilityException)
+		availabilityRoutes.POST("/exceptions/:id/reject", wah.RejectAvailabilityException)
+		availabilityRoutes.GET("/exceptions/:id/conflicts", wah.GetExceptionConflicts)
+		availabilityRoutes.POST("/exceptions/:id/release-shifts", wah.ReleaseConflictingShifts)
 	}
 	
 	// Worker preferences routes
@@ -72,6 +76,7 @@ func (wah *WorkerAvailabilityHandler) RegisterRoutes(router *gin.RouterGroup) {
 		skillsRoutes.DELETE("/:id", wah.DeleteWorkerSkill)
 		skillsRoutes.GET("/categories", wah.GetSkillCategories)
 		skillsRoutes.GET("/expiring", wah.GetExpiringSkills)
+		skillsRoutes.POST("/:id/verify", wah.VerifyWorkerSkill)
 	}
 	
 	// Location preferences routes
@@ -425,6 +430,7 @@ func (wah *WorkerAvailabilityHandler) CreateWorkerPreferences(c *gin.Context) {
 		targetUserID = req.UserID
 	}
 	req.UserID = targetUserID
+	wah.handler.geocodeAddress(&req.HomeAddress)
 	
 	// Try to update existing preferences first
 	var existingPrefs models.WorkerPreferences
@@ -434,6 +440,7 @@ func (wah *WorkerAvailabilityHandler) CreateWorkerPreferences(c *gin.Context) {
 		// Update existing preferences
 		req.ID = existingPrefs.ID
 		req.CreatedAt = existingPrefs.CreatedAt
+		req.IsActive = existingPrefs.IsActive
 		
 		if err := wah.handler.DB.Save(&req).Error; err != nil {
 			wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update preferences", err)
@@ -726,26 +733,6 @@ func (wah *WorkerAvailabilityHandler) GetAvailabilityTemplate(c *gin.Context) {
 }
 
 // Placeholder methods for remaining endpoints
-func (wah *WorkerAvailabilityHandler) GetAvailabilityExceptions(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) CreateAvailabilityException(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) UpdateAvailabilityException(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) DeleteAvailabilityException(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) ApproveAvailabilityException(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
 func (wah *WorkerAvailabilityHandler) UpdateWorkerPreferences(c *gin.Context) {
 	// This would be similar to CreateWorkerPreferences but with ID param
 	wah.CreateWorkerPreferences(c) // For now, reuse create logic
@@ -755,22 +742,6 @@ func (wah *WorkerAvailabilityHandler) DeleteWorkerPreferences(c *gin.Context) {
 	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
 }
 
-func (wah *WorkerAvailabilityHandler) GetWorkerSkills(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) CreateWorkerSkill(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) UpdateWorkerSkill(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) DeleteWorkerSkill(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
 func (wah *WorkerAvailabilityHandler) GetSkillCategories(c *gin.Context) {
 	categories := []string{
 		"Safety", "Healthcare", "Physical", "Communication", "Transport", 
@@ -782,30 +753,148 @@ func (wah *WorkerAvailabilityHandler) GetSkillCategories(c *gin.Context) {
 	})
 }
 
-func (wah *WorkerAvailabilityHandler) GetExpiringSkills(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
-
-func (wah *WorkerAvailabilityHandler) GetLocationPreferences(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
+// capacityWorkers works out whose capacity a request covers and over which dates. Managers
+// see the whole team unless they ask for one worker with ?user_id=; everyone else sees only
+// themselves. Dates come from ?start_date= and ?end_date= (inclusive), defaulting to the
+// given number of days from today in the organization's timezone.
+func (wah *WorkerAvailabilityHandler) capacityWorkers(c *gin.Context, defaultDays int) ([]models.User, time.Time, time.Time, *time.Location, bool) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return nil, time.Time{}, time.Time{}, nil, false
+	}
 
-func (wah *WorkerAvailabilityHandler) CreateLocationPreference(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
+	targetUserID := c.Query("user_id")
+	if targetUserID != "" && !wah.handler.CanUserAccessResource(c, "view_capacity", targetUserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return nil, time.Time{}, time.Time{}, nil, false
+	}
+	if targetUserID == "" && !isManagerRole(wah.handler.GetUserRoleFromContext(c)) {
+		targetUserID = userID
+	}
 
-func (wah *WorkerAvailabilityHandler) UpdateLocationPreference(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
-}
+	loc, err := wah.handler.getOrganizationTimezone(orgID.(string))
+	if err != nil {
+		loc = time.UTC
+	}
+	today := time.Now().In(loc)
+	startDate := c.DefaultQuery("start_date", today.Format("2006-01-02"))
+	endDate := c.DefaultQuery("end_date", today.AddDate(0, 0, defaultDays-1).Format("2006-01-02"))
+	from, to, ok := wah.handler.rosterDates(c, orgID.(string), startDate, endDate)
+	if !ok {
+		return nil, time.Time{}, time.Time{}, nil, false
+	}
 
-func (wah *WorkerAvailabilityHandler) DeleteLocationPreference(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
+	var staff []models.User
+	if targetUserID != "" {
+		if err := wah.handler.DB.Where("id = ? AND organization_id = ?", targetUserID, orgID).Find(&staff).Error; err != nil || len(staff) == 0 {
+			wah.handler.SendErrorResponse(c, http.StatusNotFound, "Worker not found", err)
+			return nil, time.Time{}, time.Time{}, nil, false
+		}
+	} else if staff, err = wah.handler.careWorkers(orgID.(string)); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load workers", err)
+		return nil, time.Time{}, time.Time{}, nil, false
+	}
+	return staff, from, to, loc, true
 }
 
+// GetWeeklyCapacity compares each worker's available, booked and preferred hours week by
+// week, with totals for the team. Weeks run Monday to Sunday in the organization's timezone
+// and cover the requested dates, four weeks from today by default.
 func (wah *WorkerAvailabilityHandler) GetWeeklyCapacity(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
+	staff, from, to, loc, ok := wah.capacityWorkers(c, 28)
+	if !ok {
+		return
+	}
+
+	// Whole weeks, Monday to Monday
+	from = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	if offset := (int(to.Weekday()) + 6) % 7; offset > 0 {
+		to = to.AddDate(0, 0, 7-offset)
+	}
+
+	workers, err := wah.handler.schedulingWorkers(staff, from, to, loc)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load worker schedules", err)
+		return
+	}
+
+	team := []*scheduling.Capacity{}
+	for week := from; week.Before(to); week = week.AddDate(0, 0, 7) {
+		team = append(team, &scheduling.Capacity{WeekStart: week.Format("2006-01-02")})
+	}
+	results := make([]gin.H, 0, len(workers))
+	for _, worker := range workers {
+		weeks := make([]scheduling.Capacity, 0, len(team))
+		total := scheduling.Capacity{}
+		for i, week := 0, from; week.Before(to); i, week = i+1, week.AddDate(0, 0, 7) {
+			capacity := worker.WeekCapacity(week)
+			weeks = append(weeks, capacity)
+			total.Add(capacity)
+			team[i].Add(capacity)
+		}
+		results = append(results, gin.H{
+			"user_id":                 worker.ID,
+			"name":                    worker.Name,
+			"availability_configured": len(worker.Availability) > 0,
+			"weeks":                   weeks,
+			"total":                   total,
+		})
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"start_date": from.Format("2006-01-02"),
+		"end_date":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone":   loc.String(),
+		"workers":    results,
+		"team":       team,
+	})
 }
 
+// GetAvailabilityConflicts lists the shifts booked against each worker that fall outside
+// their availability or approved leave, clash with another shift, or break their weekly
+// hours, consecutive days or rest limits. It covers the requested dates, two weeks from
+// today by default.
 func (wah *WorkerAvailabilityHandler) GetAvailabilityConflicts(c *gin.Context) {
-	wah.handler.SendErrorResponse(c, http.StatusNotImplemented, "Not implemented yet", nil)
+	staff, from, to, loc, ok := wah.capacityWorkers(c, 14)
+	if !ok {
+		return
+	}
+
+	workers, err := wah.handler.schedulingWorkers(staff, from, to, loc)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to load worker schedules", err)
+		return
+	}
+
+	results := []gin.H{}
+	byReason := map[string]int{}
+	total := 0
+	for _, worker := range workers {
+		conflicts := worker.Conflicts(from, to, loc)
+		if len(conflicts) == 0 {
+			continue
+		}
+		for _, conflict := range conflicts {
+			for _, reason := range conflict.Reasons {
+				byReason[reason.Code]++
+			}
+		}
+		total += len(conflicts)
+		results = append(results, gin.H{
+			"user_id":   worker.ID,
+			"name":      worker.Name,
+			"conflicts": conflicts,
+		})
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"start_date":      from.Format("2006-01-02"),
+		"end_date":        to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone":        loc.String(),
+		"workers":         results,
+		"total_conflicts": total,
+		"by_reason":       byReason,
+	})
 }
\ No newline at end of file
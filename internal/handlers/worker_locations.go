@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// locationTypes are the kinds of place a worker can record a preference about. Only suburbs
+// and postcodes are matched against shifts.
+var locationTypes = map[string]bool{
+	"suburb":   true,
+	"postcode": true,
+	"region":   true,
+	"address":  true,
+}
+
+// locationPreferenceLevels are how a worker feels about working somewhere
+var locationPreferenceLevels = map[string]bool{
+	"preferred":  true,
+	"acceptable": true,
+	"avoid":      true,
+}
+
+var postcodePattern = regexp.MustCompile(`^\d{4}$`)
+
+// validateLocation checks a location preference's type and value, returning the value as it
+// should be stored
+func validateLocation(locationType, value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if !locationTypes[locationType] {
+		return "", "Invalid location type"
+	}
+	if value == "" {
+		return "", "Location value is required"
+	}
+	if locationType == "postcode" && !postcodePattern.MatchString(value) {
+		return "", "Postcode must be four digits"
+	}
+	return value, ""
+}
+
+// fetchLocationPreference loads a location preference belonging to someone in the current
+// user's organization that they are allowed to manage, writing the error response if there
+// isn't one
+func (wah *WorkerAvailabilityHandler) fetchLocationPreference(c *gin.Context) (*models.WorkerLocationPreference, bool) {
+	orgID, exists := c.Get("org_id")
+	if wah.handler.GetUserIDFromContext(c) == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return nil, false
+	}
+
+	var location models.WorkerLocationPreference
+	err := wah.handler.DB.Where("id = ? AND user_id IN (?)", c.Param("id"), wah.organizationUsers(orgID)).
+		First(&location).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			wah.handler.SendErrorResponse(c, http.StatusNotFound, "Location preference not found", nil)
+			return nil, false
+		}
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return nil, false
+	}
+
+	if !wah.handler.CanUserAccessResource(c, "manage_preferences", location.UserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return nil, false
+	}
+	return &location, true
+}
+
+// GetLocationPreferences lists the suburbs, postcodes and other places a worker prefers or
+// avoids working in. Managers can ask for another worker with ?user_id=. Filter with
+// ?preference_level=.
+func (wah *WorkerAvailabilityHandler) GetLocationPreferences(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	targetUserID := c.Query("user_id")
+	if targetUserID != "" && !wah.handler.CanUserAccessResource(c, "view_preferences", targetUserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	if targetUserID == "" {
+		targetUserID = userID
+	}
+
+	var locations []models.WorkerLocationPreference
+	query := wah.handler.DB.Where("user_id = ? AND user_id IN (?) AND is_active = ?", targetUserID, wah.organizationUsers(orgID), true)
+	if level := c.Query("preference_level"); level != "" {
+		query = query.Where("preference_level = ?", level)
+	}
+	if err := query.Order("location_type ASC, location_value ASC").Find(&locations).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch location preferences", err)
+		return
+	}
+
+	dtos := make([]models.WorkerLocationPreferenceDTO, 0, len(locations))
+	for _, location := range locations {
+		dtos = append(dtos, location.ToDTO())
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"locations": dtos,
+		"user_id":   targetUserID,
+	})
+}
+
+// CreateLocationPreference records a place a worker prefers or avoids working in. Shifts in a
+// preferred suburb or postcode rank the worker higher, and shifts in an avoided one lower.
+func (wah *WorkerAvailabilityHandler) CreateLocationPreference(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		UserID               string   `json:"user_id"`
+		LocationType         string   `json:"location_type" binding:"required"`
+		LocationValue        string   `json:"location_value" binding:"required"`
+		PreferenceLevel      string   `json:"preference_level"`
+		MaxTravelTimeMinutes *int     `json:"max_travel_time_minutes"`
+		AdditionalTravelRate *float64 `json:"additional_travel_rate"`
+		Notes                string   `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	// Use current user if no user_id specified or check permissions
+	targetUserID := userID
+	if req.UserID != "" && req.UserID != userID {
+		if !wah.handler.CanUserAccessResource(c, "manage_preferences", req.UserID) {
+			wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+			return
+		}
+		targetUserID = req.UserID
+	}
+
+	var worker int64
+	wah.handler.DB.Model(&models.User{}).Where("id = ? AND organization_id = ?", targetUserID, orgID).Count(&worker)
+	if worker == 0 {
+		wah.handler.SendErrorResponse(c, http.StatusNotFound, "Worker not found", nil)
+		return
+	}
+
+	value, problem := validateLocation(req.LocationType, req.LocationValue)
+	if problem != "" {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, problem, nil)
+		return
+	}
+	if req.PreferenceLevel == "" {
+		req.PreferenceLevel = "preferred"
+	}
+	if !locationPreferenceLevels[req.PreferenceLevel] {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid preference level", nil)
+		return
+	}
+
+	var duplicate int64
+	wah.handler.DB.Model(&models.WorkerLocationPreference{}).
+		Where("user_id = ? AND location_type = ? AND LOWER(location_value) = ? AND is_active = ?", targetUserID, req.LocationType, strings.ToLower(value), true).
+		Count(&duplicate)
+	if duplicate > 0 {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "A preference for this location already exists", nil)
+		return
+	}
+
+	location := models.WorkerLocationPreference{
+		UserID:               targetUserID,
+		LocationType:         req.LocationType,
+		LocationValue:        value,
+		PreferenceLevel:      req.PreferenceLevel,
+		MaxTravelTimeMinutes: 45,
+		Notes:                req.Notes,
+		IsActive:             true,
+	}
+	if req.MaxTravelTimeMinutes != nil {
+		location.MaxTravelTimeMinutes = *req.MaxTravelTimeMinutes
+	}
+	if req.AdditionalTravelRate != nil {
+		location.AdditionalTravelRate = *req.AdditionalTravelRate
+	}
+
+	if err := wah.handler.DB.Create(&location).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create location preference", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"location": location.ToDTO(),
+		"message":  "Location preference created successfully",
+	})
+}
+
+// UpdateLocationPreference changes a location preference
+func (wah *WorkerAvailabilityHandler) UpdateLocationPreference(c *gin.Context) {
+	location, ok := wah.fetchLocationPreference(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		LocationType         *string  `json:"location_type"`
+		LocationValue        *string  `json:"location_value"`
+		PreferenceLevel      *string  `json:"preference_level"`
+		MaxTravelTimeMinutes *int     `json:"max_travel_time_minutes"`
+		AdditionalTravelRate *float64 `json:"additional_travel_rate"`
+		Notes                *string  `json:"notes"`
+		IsActive             *bool    `json:"is_active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	updates := map[string]interface{}{}
+
+	if req.LocationType != nil || req.LocationValue != nil {
+		locationType, value := location.LocationType, location.LocationValue
+		if req.LocationType != nil {
+			locationType = *req.LocationType
+		}
+		if req.LocationValue != nil {
+			value = *req.LocationValue
+		}
+		value, problem := validateLocation(locationType, value)
+		if problem != "" {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, problem, nil)
+			return
+		}
+		updates["location_type"] = locationType
+		updates["location_value"] = value
+	}
+	if req.PreferenceLevel != nil {
+		if !locationPreferenceLevels[*req.PreferenceLevel] {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid preference level", nil)
+			return
+		}
+		updates["preference_level"] = *req.PreferenceLevel
+	}
+	if req.MaxTravelTimeMinutes != nil {
+		updates["max_travel_time_minutes"] = *req.MaxTravelTimeMinutes
+	}
+	if req.AdditionalTravelRate != nil {
+		updates["additional_travel_rate"] = *req.AdditionalTravelRate
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if len(updates) > 0 {
+		if err := wah.handler.DB.Model(&models.WorkerLocationPreference{}).Where("id = ?", location.ID).Updates(updates).Error; err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update location preference", err)
+			return
+		}
+	}
+	var updated models.WorkerLocationPreference
+	if err := wah.handler.DB.Where("id = ?", location.ID).First(&updated).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"location": updated.ToDTO(),
+		"message":  "Location preference updated successfully",
+	})
+}
+
+// DeleteLocationPreference soft deletes a location preference
+func (wah *WorkerAvailabilityHandler) DeleteLocationPreference(c *gin.Context) {
+	location, ok := wah.fetchLocationPreference(c)
+	if !ok {
+		return
+	}
+
+	if err := wah.handler.DB.Delete(&models.WorkerLocationPreference{}, "id = ?", location.ID).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete location preference", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"message": "Location preference deleted successfully",
+	})
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// proficiencyLevels are the levels a skill can be held at
+var proficiencyLevels = map[string]bool{
+	"beginner":     true,
+	"intermediate": true,
+	"advanced":     true,
+	"expert":       true,
+}
+
+// parseExpiryDate reads an optional YYYY-MM-DD expiry date
+func parseExpiryDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	expiry, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &expiry, nil
+}
+
+// fetchWorkerSkill loads a skill belonging to someone in the current user's organization
+// that they are allowed to manage, writing the error response if there isn't one
+func (wah *WorkerAvailabilityHandler) fetchWorkerSkill(c *gin.Context) (*models.WorkerSkill, bool) {
+	orgID, exists := c.Get("org_id")
+	if wah.handler.GetUserIDFromContext(c) == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return nil, false
+	}
+
+	var skill models.WorkerSkill
+	err := wah.handler.DB.Where("id = ? AND user_id IN (?)", c.Param("id"), wah.organizationUsers(orgID)).
+		First(&skill).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			wah.handler.SendErrorResponse(c, http.StatusNotFound, "Skill not found", nil)
+			return nil, false
+		}
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return nil, false
+	}
+
+	if !wah.handler.CanUserAccessResource(c, "manage_skills", skill.UserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return nil, false
+	}
+	return &skill, true
+}
+
+// GetWorkerSkills lists a worker's skills and certifications, with how they stand against the
+// organization's mandatory credentials. Managers can ask for another worker with ?user_id=.
+// Filter with ?category=.
+func (wah *WorkerAvailabilityHandler) GetWorkerSkills(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	targetUserID := c.Query("user_id")
+	if targetUserID != "" && !wah.handler.CanUserAccessResource(c, "view_skills", targetUserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	if targetUserID == "" {
+		targetUserID = userID
+	}
+
+	var skills []models.WorkerSkill
+	query := wah.handler.DB.Where("user_id = ? AND user_id IN (?) AND is_active = ?", targetUserID, wah.organizationUsers(orgID), true)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("skill_category = ?", category)
+	}
+	if err := query.Order("skill_category ASC, skill_name ASC").Find(&skills).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch skills", err)
+		return
+	}
+
+	dtos := make([]models.WorkerSkillDTO, 0, len(skills))
+	for _, skill := range skills {
+		dtos = append(dtos, skill.ToDTO())
+	}
+
+	gaps, err := wah.handler.credentialGaps(orgID.(string), targetUserID, time.Now())
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to check mandatory credentials", err)
+		return
+	}
+	if gaps == nil {
+		gaps = []credentialGap{}
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"skills":  dtos,
+		"user_id": targetUserID,
+		"compliance": gin.H{
+			"compliant": len(gaps) == 0,
+			"gaps":      gaps,
+		},
+	})
+}
+
+// CreateWorkerSkill records a skill or certification. It counts towards the organization's
+// mandatory credentials once a manager has verified it.
+func (wah *WorkerAvailabilityHandler) CreateWorkerSkill(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req struct {
+		UserID              string `json:"user_id"`
+		SkillCategory       string `json:"skill_category" binding:"required"`
+		SkillName           string `json:"skill_name" binding:"required"`
+		ProficiencyLevel    string `json:"proficiency_level"`
+		CertificationNumber string `json:"certification_number"`
+		ExpiryDate          string `json:"expiry_date"`
+		Notes               string `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	// Use current user if no user_id specified or check permissions
+	targetUserID := userID
+	if req.UserID != "" && req.UserID != userID {
+		if !wah.handler.CanUserAccessResource(c, "manage_skills", req.UserID) {
+			wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+			return
+		}
+		targetUserID = req.UserID
+	}
+
+	var worker int64
+	wah.handler.DB.Model(&models.User{}).Where("id = ? AND organization_id = ?", targetUserID, orgID).Count(&worker)
+	if worker == 0 {
+		wah.handler.SendErrorResponse(c, http.StatusNotFound, "Worker not found", nil)
+		return
+	}
+
+	if req.ProficiencyLevel == "" {
+		req.ProficiencyLevel = "intermediate"
+	}
+	if !proficiencyLevels[req.ProficiencyLevel] {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid proficiency level", nil)
+		return
+	}
+	expiryDate, err := parseExpiryDate(req.ExpiryDate)
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid expiry date format", err)
+		return
+	}
+
+	skill := models.WorkerSkill{
+		UserID:              targetUserID,
+		SkillCategory:       strings.TrimSpace(req.SkillCategory),
+		SkillName:           strings.TrimSpace(req.SkillName),
+		ProficiencyLevel:    req.ProficiencyLevel,
+		CertificationNumber: req.CertificationNumber,
+		ExpiryDate:          expiryDate,
+		Notes:               req.Notes,
+		IsActive:            true,
+	}
+
+	if err := wah.handler.DB.Create(&skill).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create skill", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"skill":   skill.ToDTO(),
+		"message": "Skill created successfully",
+	})
+}
+
+// UpdateWorkerSkill changes a skill. Changing what the certificate says - its name, number
+// or expiry - means a manager has to verify it again.
+func (wah *WorkerAvailabilityHandler) UpdateWorkerSkill(c *gin.Context) {
+	skill, ok := wah.fetchWorkerSkill(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SkillCategory       *string `json:"skill_category"`
+		SkillName           *string `json:"skill_name"`
+		ProficiencyLevel    *string `json:"proficiency_level"`
+		CertificationNumber *string `json:"certification_number"`
+		ExpiryDate          *string `json:"expiry_date"` // empty string clears the expiry
+		Notes               *string `json:"notes"`
+		IsActive            *bool   `json:"is_active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+
+	updates := map[string]interface{}{}
+	reverify := false
+
+	if req.SkillCategory != nil {
+		updates["skill_category"] = strings.TrimSpace(*req.SkillCategory)
+	}
+	if req.SkillName != nil && strings.TrimSpace(*req.SkillName) != skill.SkillName {
+		if strings.TrimSpace(*req.SkillName) == "" {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Skill name is required", nil)
+			return
+		}
+		updates["skill_name"] = strings.TrimSpace(*req.SkillName)
+		reverify = true
+	}
+	if req.ProficiencyLevel != nil {
+		if !proficiencyLevels[*req.ProficiencyLevel] {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid proficiency level", nil)
+			return
+		}
+		updates["proficiency_level"] = *req.ProficiencyLevel
+	}
+	if req.CertificationNumber != nil && *req.CertificationNumber != skill.CertificationNumber {
+		updates["certification_number"] = *req.CertificationNumber
+		reverify = true
+	}
+	if req.ExpiryDate != nil {
+		expiryDate, err := parseExpiryDate(*req.ExpiryDate)
+		if err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Invalid expiry date format", err)
+			return
+		}
+		updates["expiry_date"] = expiryDate
+		reverify = true
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if reverify {
+		updates["verified_by"] = nil
+		updates["verified_at"] = nil
+	}
+
+	if len(updates) > 0 {
+		if err := wah.handler.DB.Model(&models.WorkerSkill{}).Where("id = ?", skill.ID).Updates(updates).Error; err != nil {
+			wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update skill", err)
+			return
+		}
+	}
+	var updated models.WorkerSkill
+	if err := wah.handler.DB.Where("id = ?", skill.ID).First(&updated).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"skill":   updated.ToDTO(),
+		"message": "Skill updated successfully",
+	})
+}
+
+// DeleteWorkerSkill soft deletes a skill
+func (wah *WorkerAvailabilityHandler) DeleteWorkerSkill(c *gin.Context) {
+	skill, ok := wah.fetchWorkerSkill(c)
+	if !ok {
+		return
+	}
+
+	if err := wah.handler.DB.Delete(&models.WorkerSkill{}, "id = ?", skill.ID).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to delete skill", err)
+		return
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"message": "Skill deleted successfully",
+	})
+}
+
+// VerifyWorkerSkill records that a manager has sighted a worker's certificate
+func (wah *WorkerAvailabilityHandler) VerifyWorkerSkill(c *gin.Context) {
+	if !isManagerRole(wah.handler.GetUserRoleFromContext(c)) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Only managers can verify skills", nil)
+		return
+	}
+	skill, ok := wah.fetchWorkerSkill(c)
+	if !ok {
+		return
+	}
+
+	if skill.IsSkillExpired() {
+		wah.handler.SendErrorResponse(c, http.StatusConflict, "Expired skills cannot be verified", nil)
+		return
+	}
+
+	userID := wah.handler.GetUserIDFromContext(c)
+	now := time.Now()
+	err := wah.handler.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WorkerSkill{}).Where("id = ?", skill.ID).
+			Updates(map[string]interface{}{"verified_by": userID, "verified_at": now}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, c.GetString("org_id"), "verify_skill", "worker_skill", skill.ID, nil, gin.H{
+			"user_id":              skill.UserID,
+			"skill_name":           skill.SkillName,
+			"certification_number": skill.CertificationNumber,
+			"expiry_date":          skill.ExpiryDate,
+		})
+	})
+	if err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to verify skill", err)
+		return
+	}
+	skill.VerifiedBy, skill.VerifiedAt = &userID, &now
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"skill":   skill.ToDTO(),
+		"message": "Skill verified successfully",
+	})
+}
+
+// GetExpiringSkills lists skills that have expired or will within ?days= (30 by default).
+// Managers see the whole organization unless they ask for one worker with ?user_id=;
+// everyone else sees only their own.
+func (wah *WorkerAvailabilityHandler) GetExpiringSkills(c *gin.Context) {
+	userID := wah.handler.GetUserIDFromContext(c)
+	orgID, exists := c.Get("org_id")
+	if userID == "" || !exists {
+		wah.handler.SendErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	days := 30
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 365 {
+			wah.handler.SendErrorResponse(c, http.StatusBadRequest, "Days must be between 1 and 365", err)
+			return
+		}
+		days = parsed
+	}
+
+	targetUserID := c.Query("user_id")
+	if targetUserID != "" && !wah.handler.CanUserAccessResource(c, "view_skills", targetUserID) {
+		wah.handler.SendErrorResponse(c, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	if targetUserID == "" && !isManagerRole(wah.handler.GetUserRoleFromContext(c)) {
+		targetUserID = userID
+	}
+
+	query := wah.handler.DB.Where("user_id IN (?) AND is_active = ? AND expiry_date IS NOT NULL AND expiry_date < ?",
+		wah.organizationUsers(orgID), true, time.Now().AddDate(0, 0, days))
+	if targetUserID != "" {
+		query = query.Where("user_id = ?", targetUserID)
+	}
+
+	var skills []models.WorkerSkill
+	if err := query.Preload("User").Order("expiry_date ASC").Find(&skills).Error; err != nil {
+		wah.handler.SendErrorResponse(c, http.StatusInternalServerError, "Failed to fetch expiring skills", err)
+		return
+	}
+
+	expiring := make([]gin.H, 0, len(skills))
+	expired := 0
+	for _, skill := range skills {
+		if skill.IsSkillExpired() {
+			expired++
+		}
+		expiring = append(expiring, gin.H{
+			"skill":          skill.ToDTO(),
+			"worker_name":    skill.User.FirstName + " " + skill.User.LastName,
+			"days_remaining": int(time.Until(*skill.ExpiryDate).Hours() / 24),
+		})
+	}
+
+	wah.handler.SendSuccessResponse(c, gin.H{
+		"skills":  expiring,
+		"days":    days,
+		"expired": expired,
+		"total":   len(expiring),
+	})
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccountingExport represents one run of invoices and payments sent to an accounting system
+type AccountingExport struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Format         string    `json:"format" gorm:"type:varchar(20);not null;index"` // xero, myob, http
+	Status         string    `json:"status" gorm:"type:varchar(20);not null"`       // completed, partial, failed
+	InvoiceCount   int       `json:"invoice_count" gorm:"default:0"`
+	PaymentCount   int       `json:"payment_count" gorm:"default:0"`
+	Filename       string    `json:"filename,omitempty" gorm:"type:varchar(255)"`
+	ContentType    string    `json:"content_type,omitempty" gorm:"type:varchar(100)"`
+	Data           []byte    `json:"-"` // the generated import file, empty for connector exports
+	Error          string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedBy      string    `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Relationships
+	Records []AccountingExportRecord `json:"records,omitempty" gorm:"foreignKey:ExportID"`
+}
+
+// AccountingExportRecord marks an invoice or payment as already sent in a format so it is
+// not sent again
+type AccountingExportRecord struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_accounting_records_unique"`
+	ExportID       string    `json:"export_id" gorm:"type:varchar(36);not null;index"`
+	Format         string    `json:"format" gorm:"type:varchar(20);not null;uniqueIndex:idx_accounting_records_unique"`
+	RecordType     string    `json:"record_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_accounting_records_unique"` // invoice, payment
+	RecordID       string    `json:"record_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_accounting_records_unique"`
+	ExternalID     string    `json:"external_id,omitempty" gorm:"type:varchar(100)"` // ID assigned by the accounting system
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (e *AccountingExport) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *AccountingExportRecord) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
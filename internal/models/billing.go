@@ -0,0 +1,226 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sequence types used with NextSequenceNumber
+const (
+	SequenceTypeInvoice    = "invoice"
+	SequenceTypeNDIAClaim  = "ndia_claim"
+	SequenceTypeCreditNote = "credit_note"
+)
+
+// Invoice represents a tax invoice issued to a participant for delivered supports
+type Invoice struct {
+	ID                  string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID      string         `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_invoices_org_number"`
+	ParticipantID       string         `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	InvoiceNumber       string         `json:"invoice_number" gorm:"type:varchar(50);not null;uniqueIndex:idx_invoices_org_number"`
+	SequenceNumber      int64          `json:"sequence_number" gorm:"not null"`
+	Status              string         `json:"status" gorm:"type:varchar(50);default:'draft';index"` // draft, sent, paid, overdue, void
+	IssueDate           time.Time      `json:"issue_date" gorm:"not null;index"`
+	DueDate             time.Time      `json:"due_date" gorm:"not null;index"`
+	PaidDate            *time.Time     `json:"paid_date,omitempty"`
+	Description         string         `json:"description" gorm:"type:text"`
+	Subtotal            float64        `json:"subtotal" gorm:"type:decimal(12,2);default:0"`
+	GSTAmount           float64        `json:"gst_amount" gorm:"type:decimal(12,2);default:0"`
+	Total               float64        `json:"total" gorm:"type:decimal(12,2);default:0"`
+	AmountPaid          float64        `json:"amount_paid" gorm:"type:decimal(12,2);default:0"`
+	CreditedAmount      float64        `json:"credited_amount" gorm:"type:decimal(12,2);default:0"`
+	BalanceDue          float64        `json:"balance_due" gorm:"type:decimal(12,2);default:0"`
+	DocumentID          *string        `json:"document_id,omitempty" gorm:"type:varchar(36)"` // Cached PDF, cleared when the invoice changes
+	VoidedAt            *time.Time     `json:"voided_at,omitempty"`
+	VoidedBy            *string        `json:"voided_by,omitempty" gorm:"type:varchar(36)"`
+	VoidReason          string         `json:"void_reason,omitempty" gorm:"type:text"`
+	ReplacesInvoiceID   *string        `json:"replaces_invoice_id,omitempty" gorm:"type:varchar(36);index"`
+	ReplacedByInvoiceID *string        `json:"replaced_by_invoice_id,omitempty" gorm:"type:varchar(36);index"`
+	CreatedBy           string         `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Organization Organization      `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	Participant  Participant       `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Lines        []InvoiceLine     `json:"lines,omitempty" gorm:"foreignKey:InvoiceID"`
+	Payments     []Payment         `json:"payments,omitempty" gorm:"foreignKey:InvoiceID"`
+	CreditNotes  []CreditNote      `json:"credit_notes,omitempty" gorm:"foreignKey:InvoiceID"`
+	Deliveries   []InvoiceDelivery `json:"deliveries,omitempty" gorm:"foreignKey:InvoiceID"`
+
+	// Chain lists every invoice in a void and re-issue chain, oldest first
+	Chain []InvoiceChainLink `json:"chain,omitempty" gorm:"-"`
+}
+
+// InvoiceChainLink summarises one invoice in a re-issue chain
+type InvoiceChainLink struct {
+	ID            string    `json:"id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	Status        string    `json:"status"`
+	IssueDate     time.Time `json:"issue_date"`
+	Total         float64   `json:"total"`
+}
+
+// InvoiceLine represents a single priced item on an invoice
+type InvoiceLine struct {
+	ID            string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	InvoiceID     string    `json:"invoice_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID       *string   `json:"shift_id,omitempty" gorm:"type:varchar(36);index"`
+	SupportItemID *string   `json:"support_item_id,omitempty" gorm:"type:varchar(36);index"`
+	LineType      string    `json:"line_type" gorm:"type:varchar(50);default:'service'"` // service, cancellation, travel, transport
+	Description   string    `json:"description" gorm:"type:text"`
+	ServiceDate   time.Time `json:"service_date" gorm:"not null"`
+	Quantity      float64   `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	Unit          string    `json:"unit" gorm:"type:varchar(10);default:'H'"` // H = hours, E = each
+	UnitPrice     float64   `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+	GSTCode       string    `json:"gst_code" gorm:"type:varchar(5);default:'P2'"` // P1 = taxable, P2 = GST free, P5 = out of scope
+	GSTAmount     float64   `json:"gst_amount" gorm:"type:decimal(12,2);default:0"`
+	Amount        float64   `json:"amount" gorm:"type:decimal(12,2);not null"` // excluding GST
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relationships
+	Shift       *Shift       `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	SupportItem *SupportItem `json:"support_item,omitempty" gorm:"foreignKey:SupportItemID"`
+}
+
+// Payment represents money received against an invoice
+type Payment struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	InvoiceID      string    `json:"invoice_id" gorm:"type:varchar(36);not null;index"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Amount         float64   `json:"amount" gorm:"type:decimal(12,2);not null"`
+	PaymentDate    time.Time `json:"payment_date" gorm:"not null;index"`
+	Method         string    `json:"method" gorm:"type:varchar(50)"` // bank_transfer, card, cash, ndia, plan_manager
+	Reference      string    `json:"reference" gorm:"type:varchar(100)"`
+	RecordedBy     string    `json:"recorded_by" gorm:"type:varchar(36);not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreditNote reduces what is owed on an invoice without money changing hands
+type CreditNote struct {
+	ID               string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID   string    `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_credit_notes_org_number"`
+	InvoiceID        string    `json:"invoice_id" gorm:"type:varchar(36);not null;index"`
+	CreditNoteNumber string    `json:"credit_note_number" gorm:"type:varchar(50);not null;uniqueIndex:idx_credit_notes_org_number"`
+	SequenceNumber   int64     `json:"sequence_number" gorm:"not null"`
+	IssueDate        time.Time `json:"issue_date" gorm:"not null;index"`
+	Reason           string    `json:"reason" gorm:"type:text"`
+	Amount           float64   `json:"amount" gorm:"type:decimal(12,2);not null"` // including GST
+	CreatedBy        string    `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	// Relationships
+	Lines []CreditNoteLine `json:"lines,omitempty" gorm:"foreignKey:CreditNoteID"`
+}
+
+// CreditNoteLine is the part of a credit note that credits one invoice line
+type CreditNoteLine struct {
+	ID            string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	CreditNoteID  string    `json:"credit_note_id" gorm:"type:varchar(36);not null;index"`
+	InvoiceLineID string    `json:"invoice_line_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID       *string   `json:"shift_id,omitempty" gorm:"type:varchar(36);index"`
+	Description   string    `json:"description" gorm:"type:text"`
+	Amount        float64   `json:"amount" gorm:"type:decimal(12,2);not null"` // excluding GST
+	GSTAmount     float64   `json:"gst_amount" gorm:"type:decimal(12,2);default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// InvoiceReminder records a payment reminder sent for an overdue invoice. Step is the
+// position in the organization's reminder sequence, so each step is sent only once.
+type InvoiceReminder struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	InvoiceID      string    `json:"invoice_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_invoice_reminders_step"`
+	Step           int       `json:"step" gorm:"not null;uniqueIndex:idx_invoice_reminders_step"`
+	DaysOverdue    int       `json:"days_overdue" gorm:"not null"`
+	SentTo         string    `json:"sent_to" gorm:"type:varchar(255)"`
+	Status         string    `json:"status" gorm:"type:varchar(20);not null"` // sent, failed, skipped
+	Error          string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// DocumentSequence holds the last number issued for a gap-free per organization sequence
+type DocumentSequence struct {
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);primaryKey"`
+	SequenceType   string    `json:"sequence_type" gorm:"type:varchar(50);primaryKey"` // invoice, ndia_claim, credit_note
+	LastNumber     int64     `json:"last_number" gorm:"not null;default:0"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (i *Invoice) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *InvoiceLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+func (n *CreditNote) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *CreditNoteLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *InvoiceReminder) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// NextSequenceNumber allocates the next number in an organization's sequence.
+// It must be called inside the transaction that persists the numbered record so
+// that a rollback also releases the number and the sequence stays gap-free.
+func NextSequenceNumber(tx *gorm.DB, orgID, sequenceType string) (int64, error) {
+	// Make sure the counter exists first. Concurrent first numbers both try the insert and
+	// the loser does nothing, where a plain insert would fail on the primary key.
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&DocumentSequence{OrganizationID: orgID, SequenceType: sequenceType}).Error; err != nil {
+		return 0, err
+	}
+
+	var seq DocumentSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND sequence_type = ?", orgID, sequenceType).
+		First(&seq).Error; err != nil {
+		return 0, err
+	}
+
+	next := seq.LastNumber + 1
+	if err := tx.Model(&DocumentSequence{}).
+		Where("organization_id = ? AND sequence_type = ?", orgID, sequenceType).
+		Updates(map[string]interface{}{"last_number": next, "updated_at": time.Now()}).Error; err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
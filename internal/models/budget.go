@@ -0,0 +1,183 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Budget transaction types
+const (
+	BudgetTransactionShift        = "shift"        // drawdown when a shift is completed
+	BudgetTransactionAdjustment   = "adjustment"   // a completed shift's cost changed
+	BudgetTransactionInvoice      = "invoice"      // true-up to the invoiced amount
+	BudgetTransactionClaim        = "claim"        // true-up to the amount claimed from the NDIA
+	BudgetTransactionCancellation = "cancellation" // short notice cancellation charge, or its reversal
+	BudgetTransactionCredit       = "credit"       // funds returned by a credit note
+	BudgetTransactionVoid         = "void"         // back to the unbilled amount when an invoice is voided
+)
+
+// BudgetTransaction records one movement of a participant's funding budget.
+// Positive amounts draw the budget down, negative amounts return funds.
+type BudgetTransaction struct {
+	ID              string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID  string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	ParticipantID   string    `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID         *string   `json:"shift_id,omitempty" gorm:"type:varchar(36);index"`
+	InvoiceID       *string   `json:"invoice_id,omitempty" gorm:"type:varchar(36);index"`
+	PlanID          *string   `json:"plan_id,omitempty" gorm:"type:varchar(36);index"`
+	BudgetLineID    *string   `json:"budget_line_id,omitempty" gorm:"type:varchar(36);index"`
+	TransactionType string    `json:"transaction_type" gorm:"type:varchar(30);not null;index"`
+	Amount          float64   `json:"amount" gorm:"type:decimal(12,2);not null"`
+	UsedBudget      float64   `json:"used_budget" gorm:"type:decimal(12,2)"`      // budget line, or flat funding, used after this movement
+	RemainingBudget float64   `json:"remaining_budget" gorm:"type:decimal(12,2)"` // budget line, or flat funding, remaining after this movement
+	Description     string    `json:"description" gorm:"type:varchar(255)"`
+	CreatedBy       string    `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt       time.Time `json:"created_at" gorm:"index"`
+
+	// Relationships
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Shift       *Shift      `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+}
+
+func (b *BudgetTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+// DrawDownShift brings the amount a shift has drawn from its participant's budget to
+// amount, recording the difference in the ledger. The shift draws from the matching budget
+// line of the plan in force on the day it started, or from the participant's flat funding
+// when they have no plans. Anything the shift drew from another budget line, say before
+// its support item changed, is returned first. It returns nil when the shift has already
+// drawn exactly amount from the right budget. Call it inside the transaction that changes
+// the shift so the ledger and the shift cannot disagree.
+func DrawDownShift(tx *gorm.DB, shift *Shift, amount float64, transactionType, description, createdBy string, invoiceID *string) (*BudgetTransaction, error) {
+	plan, err := PlanCovering(tx, shift.ParticipantID, shift.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	var budgetLineID *string
+	if plan != nil {
+		if line := plan.BudgetLineFor(shiftBudgetCategory(tx, shift)); line != nil {
+			budgetLineID = &line.ID
+		}
+	}
+
+	var drawn []struct {
+		BudgetLineID *string
+		Amount       float64
+	}
+	if err := tx.Model(&BudgetTransaction{}).
+		Select("budget_line_id, SUM(amount) AS amount").
+		Where("shift_id = ?", shift.ID).
+		Group("budget_line_id").
+		Scan(&drawn).Error; err != nil {
+		return nil, err
+	}
+
+	current := 0.0
+	for _, previous := range drawn {
+		if sameBudgetLine(previous.BudgetLineID, budgetLineID) {
+			current += previous.Amount
+			continue
+		}
+		if roundCents(previous.Amount) != 0 {
+			if _, err := recordDrawdown(tx, shift, previous.BudgetLineID, -previous.Amount, BudgetTransactionAdjustment,
+				"Moved to another budget", createdBy, invoiceID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	delta := roundCents(amount - current)
+	if delta == 0 {
+		return nil, nil
+	}
+	return recordDrawdown(tx, shift, budgetLineID, delta, transactionType, description, createdBy, invoiceID)
+}
+
+// recordDrawdown applies one ledger movement to a budget line or the participant's flat funding
+func recordDrawdown(tx *gorm.DB, shift *Shift, budgetLineID *string, delta float64, transactionType, description, createdBy string, invoiceID *string) (*BudgetTransaction, error) {
+	delta = roundCents(delta)
+
+	var participant Participant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&participant, "id = ?", shift.ParticipantID).Error; err != nil {
+		return nil, err
+	}
+
+	shiftID := shift.ID
+	entry := BudgetTransaction{
+		OrganizationID:  participant.OrganizationID,
+		ParticipantID:   participant.ID,
+		ShiftID:         &shiftID,
+		InvoiceID:       invoiceID,
+		BudgetLineID:    budgetLineID,
+		TransactionType: transactionType,
+		Amount:          delta,
+		Description:     description,
+		CreatedBy:       createdBy,
+	}
+
+	if budgetLineID != nil {
+		var line PlanBudgetLine
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&line, "id = ?", *budgetLineID).Error; err != nil {
+			return nil, err
+		}
+		entry.PlanID = &line.PlanID
+		entry.UsedBudget = roundCents(line.Used + delta)
+		entry.RemainingBudget = roundCents(line.Allocated - entry.UsedBudget)
+		if err := tx.Model(&PlanBudgetLine{}).Where("id = ?", line.ID).UpdateColumns(map[string]interface{}{
+			"used":      entry.UsedBudget,
+			"remaining": entry.RemainingBudget,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := SyncParticipantFunding(tx, participant.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	} else {
+		var plans int64
+		if err := tx.Model(&ParticipantPlan{}).Where("participant_id = ?", participant.ID).Count(&plans).Error; err != nil {
+			return nil, err
+		}
+		entry.UsedBudget = participant.Funding.UsedBudget
+		entry.RemainingBudget = participant.Funding.RemainingBudget
+
+		// Flat funding only tracks spend for participants who are not on plans yet
+		if plans == 0 {
+			entry.UsedBudget = roundCents(participant.Funding.UsedBudget + delta)
+			entry.RemainingBudget = roundCents(participant.Funding.TotalBudget - entry.UsedBudget)
+			if err := tx.Model(&Participant{}).Where("id = ?", participant.ID).UpdateColumns(map[string]interface{}{
+				"funding_used_budget":      entry.UsedBudget,
+				"funding_remaining_budget": entry.RemainingBudget,
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// sameBudgetLine compares optional budget line IDs, where nil means flat funding
+func sameBudgetLine(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
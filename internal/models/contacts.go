@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// External contact types and the roles they play for a participant
+const (
+	ContactRolePlanManager        = "plan_manager"
+	ContactRoleSupportCoordinator = "support_coordinator"
+	ContactRoleGP                 = "gp"
+	ContactRoleOther              = "other"
+)
+
+// Invoice delivery channels
+const (
+	DeliveryChannelEmail  = "email"
+	DeliveryChannelPortal = "portal" // uploaded by staff to the payer's online portal
+)
+
+// Invoice delivery outcomes
+const (
+	DeliveryStatusSent     = "sent"
+	DeliveryStatusFailed   = "failed"
+	DeliveryStatusQueued   = "queued" // waiting to be exported for portal upload
+	DeliveryStatusExported = "exported"
+)
+
+// ExternalContact is a person at another organisation who works with participants, such as
+// a plan manager, support coordinator or GP. One contact can be linked to many participants.
+type ExternalContact struct {
+	ID               string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID   string         `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Type             string         `json:"type" gorm:"type:varchar(30);not null;index"` // plan_manager, support_coordinator, gp, other
+	CompanyName      string         `json:"company_name" gorm:"type:varchar(255)"`
+	ContactName      string         `json:"contact_name" gorm:"type:varchar(200)"`
+	Email            string         `json:"email" gorm:"type:varchar(255)"`
+	Phone            string         `json:"phone" gorm:"type:varchar(20)"`
+	ABN              string         `json:"abn" gorm:"type:varchar(20)"`
+	Address          Address        `json:"address" gorm:"embedded;embeddedPrefix:address_"`
+	PreferredChannel string         `json:"preferred_channel" gorm:"type:varchar(20);default:'email'"` // email, portal
+	PortalName       string         `json:"portal_name" gorm:"type:varchar(100)"`                      // the portal invoices are uploaded to
+	Notes            string         `json:"notes" gorm:"type:text"`
+	IsActive         bool           `json:"is_active" gorm:"default:true;index"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// DisplayName is the contact's company, falling back to their own name
+func (c ExternalContact) DisplayName() string {
+	if c.CompanyName != "" {
+		return c.CompanyName
+	}
+	return c.ContactName
+}
+
+// ParticipantContact links an external contact to a participant in a role. The payer link,
+// if any, decides who the participant's invoices are sent to.
+type ParticipantContact struct {
+	ID                string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ParticipantID     string    `json:"participant_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_participant_contacts_role"`
+	ExternalContactID string    `json:"external_contact_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_participant_contacts_role;index"`
+	Role              string    `json:"role" gorm:"type:varchar(30);not null;uniqueIndex:idx_participant_contacts_role"`
+	IsPayer           bool      `json:"is_payer" gorm:"default:false"`
+	Notes             string    `json:"notes" gorm:"type:text"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Relationships
+	ExternalContact ExternalContact `json:"external_contact,omitempty" gorm:"foreignKey:ExternalContactID"`
+}
+
+// InvoiceDelivery records each time an invoice was sent, or queued for portal upload, to its payer
+type InvoiceDelivery struct {
+	ID                string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID    string     `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	InvoiceID         string     `json:"invoice_id" gorm:"type:varchar(36);not null;index"`
+	ExternalContactID *string    `json:"external_contact_id,omitempty" gorm:"type:varchar(36);index"` // nil when sent to the participant
+	Channel           string     `json:"channel" gorm:"type:varchar(20);not null"`                    // email, portal
+	Recipient         string     `json:"recipient" gorm:"type:varchar(255)"`                          // email address or portal name
+	Status            string     `json:"status" gorm:"type:varchar(20);not null;index"`               // sent, failed, queued, exported
+	Error             string     `json:"error,omitempty" gorm:"type:text"`
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+	CreatedBy         string     `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func (c *ExternalContact) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *ParticipantContact) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+func (d *InvoiceDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MandatoryCredential is a credential every care worker in the organization must hold,
+// verified by a manager and in date, before they can be assigned to a shift. Workers hold
+// it as a WorkerSkill with the same name.
+type MandatoryCredential struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_mandatory_credentials_org_name"`
+	Name           string    `json:"name" gorm:"type:varchar(200);not null;uniqueIndex:idx_mandatory_credentials_org_name"`
+	Category       string    `json:"category" gorm:"type:varchar(100)"` // skill category workers record it under
+	Description    string    `json:"description" gorm:"type:text"`
+	RequiresExpiry bool      `json:"requires_expiry"` // the worker's record must have an expiry date
+	IsActive       bool      `json:"is_active" gorm:"index"`
+	CreatedBy      string    `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DefaultMandatoryCredentials are the checks most NDIS providers require of support workers
+var DefaultMandatoryCredentials = []MandatoryCredential{
+	{Name: "NDIS Worker Screening Check", Category: "Safety", Description: "NDIS Worker Screening clearance", RequiresExpiry: true},
+	{Name: "Working With Children Check", Category: "Safety", Description: "State or territory Working With Children Check", RequiresExpiry: true},
+	{Name: "First Aid", Category: "Emergency Response", Description: "HLTAID011 Provide First Aid", RequiresExpiry: true},
+	{Name: "CPR", Category: "Emergency Response", Description: "HLTAID009 Provide cardiopulmonary resuscitation, renewed yearly", RequiresExpiry: true},
+}
+
+// Credential statuses for a worker against a mandatory credential
+const (
+	CredentialCompliant  = "compliant"
+	CredentialMissing    = "missing"
+	CredentialExpired    = "expired"
+	CredentialUnverified = "unverified"
+	CredentialNoExpiry   = "no_expiry" // held without the expiry date the credential needs
+)
+
+func (m *MandatoryCredential) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return
+}
@@ -65,18 +65,22 @@ type Participant struct {
 	Address        Address            `json:"address" gorm:"embedded;embeddedPrefix:address_"`
 	MedicalInfo    MedicalInformation `json:"medical_information" gorm:"embedded;embeddedPrefix:medical_"`
 	Funding        FundingInformation `json:"funding" gorm:"embedded;embeddedPrefix:funding_"`
+	Remoteness     string             `json:"remoteness" gorm:"type:varchar(20)"` // empty for metro/regional, remote, very_remote (MMM 6/7)
 	OrganizationID string             `json:"organization_id" gorm:"type:varchar(36);not null;index"`
 	IsActive       bool               `json:"is_active" gorm:"default:true;index"`
 	CreatedAt      time.Time          `json:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt     `json:"-" gorm:"index"`
 
+	CurrentPlan *ParticipantPlan `json:"current_plan,omitempty" gorm:"-"` // Loaded by GetParticipant
+
 	// Relationships
-	Organization      Organization       `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
-	EmergencyContacts []EmergencyContact `json:"emergency_contacts,omitempty" gorm:"foreignKey:ParticipantID"`
-	Shifts            []Shift            `json:"shifts,omitempty" gorm:"foreignKey:ParticipantID"`
-	Documents         []Document         `json:"documents,omitempty" gorm:"foreignKey:ParticipantID"`
-	CarePlans         []CarePlan         `json:"care_plans,omitempty" gorm:"foreignKey:ParticipantID"`
+	Organization      Organization         `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	EmergencyContacts []EmergencyContact   `json:"emergency_contacts,omitempty" gorm:"foreignKey:ParticipantID"`
+	Contacts          []ParticipantContact `json:"contacts,omitempty" gorm:"foreignKey:ParticipantID"`
+	Shifts            []Shift              `json:"shifts,omitempty" gorm:"foreignKey:ParticipantID"`
+	Documents         []Document           `json:"documents,omitempty" gorm:"foreignKey:ParticipantID"`
+	CarePlans         []CarePlan           `json:"care_plans,omitempty" gorm:"foreignKey:ParticipantID"`
 }
 
 // EmergencyContact represents participant emergency contacts
@@ -100,25 +104,54 @@ type EmergencyContact struct {
 type Shift struct {
 	ID              string         `json:"id" gorm:"type:varchar(36);primaryKey"`
 	ParticipantID   string         `json:"participant_id" gorm:"type:varchar(36);not null;index"`
-	StaffID         string         `json:"staff_id" gorm:"type:varchar(36);not null;index"`
+	StaffID         *string        `json:"staff_id" gorm:"type:varchar(36);index"` // nil while the shift is open
 	StartTime       time.Time      `json:"start_time" gorm:"not null;index"`
 	EndTime         time.Time      `json:"end_time" gorm:"not null;index"`
 	ActualStartTime *time.Time     `json:"actual_start_time,omitempty"`
 	ActualEndTime   *time.Time     `json:"actual_end_time,omitempty"`
 	ServiceType     string         `json:"service_type" gorm:"type:varchar(100);not null;index"`
+	SupportItemID   *string        `json:"support_item_id,omitempty" gorm:"type:varchar(36);index"`
 	Location        string         `json:"location" gorm:"type:varchar(100);not null"`
+	Latitude        *float64       `json:"latitude,omitempty" gorm:"type:decimal(9,6)"` // geocoded from the location; nil when it is the participant's address
+	Longitude       *float64       `json:"longitude,omitempty" gorm:"type:decimal(9,6)"`
 	Status          string         `json:"status" gorm:"type:varchar(50);default:'scheduled';index"` // scheduled, in_progress, completed, cancelled, no_show
 	HourlyRate      float64        `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
 	TotalCost       float64        `json:"total_cost" gorm:"type:decimal(10,2)"`
 	Notes           string         `json:"notes" gorm:"type:text"`
 	CompletionNotes string         `json:"completion_notes" gorm:"type:text"`
+	InvoiceID       *string        `json:"invoice_id,omitempty" gorm:"type:varchar(36);index"`    // Set once the shift is billed, prevents double billing
+	ClaimLineID     *string        `json:"claim_line_id,omitempty" gorm:"type:varchar(36);index"` // Set while the shift is claimed from the NDIA, cleared if the claim is rejected
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Cancellation, recorded when the shift is cancelled or marked as a no show
+	CancelledAt             *time.Time `json:"cancelled_at,omitempty"`                                     // when notice was given
+	CancelledBy             string     `json:"cancelled_by,omitempty" gorm:"type:varchar(20)"`             // participant, provider
+	CancellationRecordedBy  *string    `json:"cancellation_recorded_by,omitempty" gorm:"type:varchar(36)"` // user who recorded it
+	CancellationReason      string     `json:"cancellation_reason,omitempty" gorm:"type:varchar(10)"`      // NSDH, NSDF, NSDT, NSDO
+	CancellationNotes       string     `json:"cancellation_notes,omitempty" gorm:"type:text"`
+	CancellationNoticeHours float64    `json:"cancellation_notice_hours,omitempty" gorm:"type:decimal(8,2)"`
+	CancellationCharge      float64    `json:"cancellation_charge,omitempty" gorm:"type:decimal(10,2)"` // Billable short notice cancellation fee
+
+	// Travel, recorded when the shift is completed
+	TravelTimeMinutes int     `json:"travel_time_minutes,omitempty"`                  // provider travel time to the participant
+	TravelKm          float64 `json:"travel_km,omitempty" gorm:"type:decimal(8,2)"`   // non-labour provider travel
+	ActivityKm        float64 `json:"activity_km,omitempty" gorm:"type:decimal(8,2)"` // activity based transport with the participant
+
+	// Rostering
+	PublishStatus string `json:"publish_status" gorm:"type:varchar(20);default:'published';index"` // draft, published; staff only see published shifts
+
+	// Recurrence, set on shifts generated from a shift series
+	SeriesID        *string    `json:"series_id,omitempty" gorm:"type:varchar(36);uniqueIndex:idx_shift_series_occurrence"`
+	OccurrenceStart *time.Time `json:"occurrence_start,omitempty" gorm:"uniqueIndex:idx_shift_series_occurrence"` // start the series rule gave this occurrence
+	SeriesOverride  bool       `json:"series_override,omitempty" gorm:"default:false"`                            // edited on its own, so series edits leave it alone
+
 	// Relationships
-	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
-	Staff       User        `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+	Participant Participant     `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Staff       *User           `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+	SupportItem *SupportItem    `json:"support_item,omitempty" gorm:"foreignKey:SupportItemID"`
+	CostBands   []ShiftCostBand `json:"cost_bands,omitempty" gorm:"foreignKey:ShiftID"`
 }
 
 // Document represents uploaded files and documents
@@ -267,11 +300,13 @@ type UserPermission struct {
 
 // Address represents physical addresses
 type Address struct {
-	Street   string `json:"street" gorm:"type:varchar(255)"`
-	Suburb   string `json:"suburb" gorm:"type:varchar(100)"`
-	State    string `json:"state" gorm:"type:varchar(50)"`
-	Postcode string `json:"postcode" gorm:"type:varchar(10)"`
-	Country  string `json:"country" gorm:"type:varchar(100);default:'Australia'"`
+	Street    string   `json:"street" gorm:"type:varchar(255)"`
+	Suburb    string   `json:"suburb" gorm:"type:varchar(100)"`
+	State     string   `json:"state" gorm:"type:varchar(50)"`
+	Postcode  string   `json:"postcode" gorm:"type:varchar(10)"`
+	Country   string   `json:"country" gorm:"type:varchar(100);default:'Australia'"`
+	Latitude  *float64 `json:"latitude,omitempty" gorm:"type:decimal(9,6)"` // geocoded from the address unless placed by hand
+	Longitude *float64 `json:"longitude,omitempty" gorm:"type:decimal(9,6)"`
 }
 
 // NDISReg represents NDIS registration information
@@ -293,12 +328,15 @@ type MedicalInformation struct {
 
 // FundingInformation represents NDIS funding details
 type FundingInformation struct {
-	TotalBudget     float64    `json:"total_budget" gorm:"type:decimal(12,2);default:0"`
-	UsedBudget      float64    `json:"used_budget" gorm:"type:decimal(12,2);default:0"`
-	RemainingBudget float64    `json:"remaining_budget" gorm:"type:decimal(12,2);default:0"`
-	BudgetYear      string     `json:"budget_year" gorm:"type:varchar(20)"` // e.g., "2025-2026"
-	PlanStartDate   *time.Time `json:"plan_start_date,omitempty"`
-	PlanEndDate     *time.Time `json:"plan_end_date,omitempty"`
+	TotalBudget      float64    `json:"total_budget" gorm:"type:decimal(12,2);default:0"`
+	UsedBudget       float64    `json:"used_budget" gorm:"type:decimal(12,2);default:0"`
+	RemainingBudget  float64    `json:"remaining_budget" gorm:"type:decimal(12,2);default:0"`
+	BudgetYear       string     `json:"budget_year" gorm:"type:varchar(20)"` // e.g., "2025-2026"
+	PlanStartDate    *time.Time `json:"plan_start_date,omitempty"`
+	PlanEndDate      *time.Time `json:"plan_end_date,omitempty"`
+	ManagementType   string     `json:"management_type" gorm:"type:varchar(20);default:'agency'"` // agency, plan, self
+	PlanManagerName  string     `json:"plan_manager_name" gorm:"type:varchar(255)"`               // who pays invoices for plan managed participants
+	PlanManagerEmail string     `json:"plan_manager_email" gorm:"type:varchar(255)"`
 }
 
 // BeforeCreate hooks for generating UUIDs
@@ -334,9 +372,11 @@ func (s *Shift) BeforeCreate(tx *gorm.DB) (err error) {
 	if s.ID == "" {
 		s.ID = uuid.New().String()
 	}
-	// Calculate total cost based on duration and hourly rate
-	duration := s.EndTime.Sub(s.StartTime).Hours()
-	s.TotalCost = duration * s.HourlyRate
+	// Flat price from duration and hourly rate unless the shift was priced by band
+	if s.TotalCost == 0 {
+		duration := s.EndTime.Sub(s.StartTime).Hours()
+		s.TotalCost = duration * s.HourlyRate
+	}
 	return
 }
 
@@ -370,8 +410,8 @@ func (p *UserPermission) BeforeCreate(tx *gorm.DB) (err error) {
 
 // BeforeUpdate hooks for maintaining data consistency
 func (s *Shift) BeforeUpdate(tx *gorm.DB) (err error) {
-	// Recalculate total cost if times have changed
-	if s.EndTime.After(s.StartTime) {
+	// Recalculate total cost if times have changed, unless the shift was priced by band
+	if len(s.CostBands) == 0 && s.EndTime.After(s.StartTime) {
 		duration := s.EndTime.Sub(s.StartTime).Hours()
 		s.TotalCost = duration * s.HourlyRate
 	}
@@ -702,7 +742,12 @@ func MigrateDB(db *gorm.DB) error {
 		&User{},
 		&Participant{},
 		&EmergencyContact{},
+		&SupportItem{},
+		&SupportItemPrice{},
 		&Shift{},
+		&ShiftCostBand{},
+		&RateCard{},
+		&PublicHoliday{},
 		&Document{},
 		&CarePlan{},
 		&CareNote{},
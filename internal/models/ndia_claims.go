@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NDIAClaimBatch represents a bulk payment request file submitted to the NDIA portal
+type NDIAClaimBatch struct {
+	ID             string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_ndia_batches_org_number"`
+	BatchNumber    string     `json:"batch_number" gorm:"type:varchar(50);not null;uniqueIndex:idx_ndia_batches_org_number"`
+	PeriodStart    time.Time  `json:"period_start" gorm:"not null"`
+	PeriodEnd      time.Time  `json:"period_end" gorm:"not null"`
+	Status         string     `json:"status" gorm:"type:varchar(50);default:'generated';index"` // generated, partially_reconciled, reconciled
+	LineCount      int        `json:"line_count" gorm:"default:0"`
+	TotalAmount    float64    `json:"total_amount" gorm:"type:decimal(12,2);default:0"`
+	PaidAmount     float64    `json:"paid_amount" gorm:"type:decimal(12,2);default:0"`
+	RejectedCount  int        `json:"rejected_count" gorm:"default:0"`
+	ReconciledAt   *time.Time `json:"reconciled_at,omitempty"`
+	CreatedBy      string     `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Lines []NDIAClaimLine `json:"lines,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+// NDIAClaimLine represents a single claim row and its remittance outcome
+type NDIAClaimLine struct {
+	ID                    string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	BatchID               string     `json:"batch_id" gorm:"type:varchar(36);not null;index"`
+	OrganizationID        string     `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_ndia_lines_org_reference"`
+	ClaimReference        string     `json:"claim_reference" gorm:"type:varchar(50);not null;uniqueIndex:idx_ndia_lines_org_reference"`
+	ShiftID               *string    `json:"shift_id,omitempty" gorm:"type:varchar(36);index"`
+	ParticipantID         string     `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	NDISNumber            string     `json:"ndis_number" gorm:"type:varchar(10);not null"`
+	SupportItemNumber     string     `json:"support_item_number" gorm:"type:varchar(50);not null"`
+	SupportsDeliveredFrom time.Time  `json:"supports_delivered_from" gorm:"not null"`
+	SupportsDeliveredTo   time.Time  `json:"supports_delivered_to" gorm:"not null"`
+	Quantity              float64    `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	UnitPrice             float64    `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+	Amount                float64    `json:"amount" gorm:"type:decimal(12,2);not null"`
+	GSTCode               string     `json:"gst_code" gorm:"type:varchar(5);default:'P2'"`
+	ClaimType             string     `json:"claim_type" gorm:"type:varchar(10)"` // empty for standard, CANC, REPW, TRAN, NF2F, IRSS
+	CancellationReason    string     `json:"cancellation_reason" gorm:"type:varchar(10)"`
+	Status                string     `json:"status" gorm:"type:varchar(50);default:'pending';index"` // pending, paid, rejected
+	PaidAmount            float64    `json:"paid_amount" gorm:"type:decimal(12,2);default:0"`
+	PaymentRequestNumber  string     `json:"payment_request_number" gorm:"type:varchar(50)"`
+	RejectionCode         string     `json:"rejection_code" gorm:"type:varchar(20)"`
+	RejectionMessage      string     `json:"rejection_message" gorm:"type:text"`
+	ProcessedAt           *time.Time `json:"processed_at,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+
+	// Relationships
+	Participant *Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (b *NDIAClaimBatch) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *NDIAClaimLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Open shift claim modes
+const (
+	ClaimFirstCome = "first_come" // the first eligible worker to claim gets the shift
+	ClaimApproval  = "approval"   // claims wait for a manager to pick one
+)
+
+// Shift broadcast states
+const (
+	BroadcastOpen      = "open"
+	BroadcastFilled    = "filled"
+	BroadcastCancelled = "cancelled"
+)
+
+// Shift claim states
+const (
+	ClaimPending  = "pending"
+	ClaimApproved = "approved"
+	ClaimRejected = "rejected"
+)
+
+// Notification types for open shifts
+const (
+	NotificationOpenShift   = "open_shift"
+	NotificationShiftClaim  = "shift_claim"
+	NotificationClaimResult = "shift_claim_result"
+)
+
+// ShiftBroadcast offers an open shift to the workers eligible to take it
+type ShiftBroadcast struct {
+	ID             string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID        string         `json:"shift_id" gorm:"type:varchar(36);not null;index"`
+	Mode           string         `json:"mode" gorm:"type:varchar(20);not null;default:'approval'"`     // first_come, approval
+	Status         string         `json:"status" gorm:"type:varchar(20);not null;default:'open';index"` // open, filled, cancelled
+	RequiredSkills pq.StringArray `json:"required_skills" gorm:"type:text[]"`
+	Message        string         `json:"message" gorm:"type:text"`
+	RecipientCount int            `json:"recipient_count"`
+	BroadcastBy    string         `json:"broadcast_by" gorm:"type:varchar(36);not null"`
+	FilledBy       *string        `json:"filled_by,omitempty" gorm:"type:varchar(36)"` // worker who got the shift
+	ClosedAt       *time.Time     `json:"closed_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Relationships
+	Shift      Shift                     `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	Recipients []ShiftBroadcastRecipient `json:"recipients,omitempty" gorm:"foreignKey:BroadcastID"`
+	Claims     []ShiftClaim              `json:"claims,omitempty" gorm:"foreignKey:BroadcastID"`
+}
+
+// ShiftBroadcastRecipient is a worker a broadcast was sent to. Only recipients can claim.
+type ShiftBroadcastRecipient struct {
+	BroadcastID string    `json:"broadcast_id" gorm:"type:varchar(36);primaryKey"`
+	UserID      string    `json:"user_id" gorm:"type:varchar(36);primaryKey;index"`
+	Score       float64   `json:"score"` // the worker's ranking for the shift when it was broadcast
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ShiftClaim is a worker asking for a broadcast shift
+type ShiftClaim struct {
+	ID          string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	BroadcastID string     `json:"broadcast_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_shift_claim_worker"`
+	ShiftID     string     `json:"shift_id" gorm:"type:varchar(36);not null;index"`
+	UserID      string     `json:"user_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_shift_claim_worker"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"` // pending, approved, rejected
+	DecidedBy   *string    `json:"decided_by,omitempty" gorm:"type:varchar(36)"`                    // manager, or nil when granted first come
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	Reason      string     `json:"reason,omitempty" gorm:"type:text"` // why a claim was rejected
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (b *ShiftBroadcast) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+func (c *ShiftClaim) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return
+}
@@ -44,6 +44,14 @@ type OrganizationSettings struct {
 	AutoAssignShifts         bool      `json:"auto_assign_shifts" gorm:"default:false"`
 	EnableSMSNotifications   bool      `json:"enable_sms_notifications" gorm:"default:true"`
 	EnableEmailNotifications bool      `json:"enable_email_notifications" gorm:"default:true"`
+	InvoicePaymentTermsDays  int       `json:"invoice_payment_terms_days" gorm:"default:30"`
+	PriceCapEnforcement      string    `json:"price_cap_enforcement" gorm:"type:varchar(10);default:'block'"`   // block, warn
+	FatigueRules             JSONB     `json:"fatigue_rules" gorm:"type:jsonb"`                                 // rule name to block or warn, rules not set block
+	CancellationNoticeHours  int       `json:"cancellation_notice_hours" gorm:"default:48"`                     // cancellations with less notice are charged
+	CancellationChargeRate   float64   `json:"cancellation_charge_rate" gorm:"type:decimal(5,2);default:100"`   // percent of the booked fee, 0 disables charging
+	TravelKilometreRate      float64   `json:"travel_kilometre_rate" gorm:"type:decimal(6,2);default:0.99"`     // billed per km, capped by the catalogue
+	WorkerKilometreRate      float64   `json:"worker_kilometre_rate" gorm:"type:decimal(6,2);default:0.88"`     // paid to workers per km driven
+	PaymentReminderDays      string    `json:"payment_reminder_days" gorm:"type:varchar(50);default:'7,14,30'"` // days past due to send each reminder
 	CreatedAt                time.Time `json:"created_at"`
 	UpdatedAt                time.Time `json:"updated_at"`
 
@@ -222,6 +230,37 @@ func MigrateExtendedDB(db *gorm.DB) error {
 		&WorkerSkill{},
 		&WorkerLocationPreference{},
 		&IncidentReport{},
+		&Invoice{},
+		&InvoiceLine{},
+		&Payment{},
+		&CreditNote{},
+		&CreditNoteLine{},
+		&InvoiceReminder{},
+		&InvoiceDelivery{},
+		&DocumentSequence{},
+		&NDIAClaimBatch{},
+		&NDIAClaimLine{},
+		&BudgetTransaction{},
+		&ParticipantPlan{},
+		&PlanBudgetLine{},
+		&WorkerReimbursement{},
+		&AccountingExport{},
+		&AccountingExportRecord{},
+		&ExternalContact{},
+		&ParticipantContact{},
+		&ShiftSeries{},
+		&RosterTemplate{},
+		&RosterTemplateShift{},
+		&PublishedShift{},
+		&RosterPublication{},
+		&Notification{},
+		&ShiftBroadcast{},
+		&ShiftBroadcastRecipient{},
+		&ShiftClaim{},
+		&ShiftSwapRequest{},
+		&MandatoryCredential{},
+		&ParticipantRequirement{},
+		&ParticipantWorker{},
 	)
 }
 
@@ -250,6 +289,13 @@ func SetupOrganizationDefaults(db *gorm.DB, orgID string) error {
 		MinShiftNotice:           30,
 		EnableSMSNotifications:   true,
 		EnableEmailNotifications: true,
+		InvoicePaymentTermsDays:  30,
+		PriceCapEnforcement:      "block",
+		CancellationNoticeHours:  48,
+		CancellationChargeRate:   100,
+		TravelKilometreRate:      0.99,
+		WorkerKilometreRate:      0.88,
+		PaymentReminderDays:      "7,14,30",
 	}
 	db.FirstOrCreate(&settings, "organization_id = ?", orgID)
 
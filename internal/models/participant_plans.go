@@ -0,0 +1,194 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/catalogue"
+	"gorm.io/gorm"
+)
+
+// Plan management types
+const (
+	PlanManagementAgency = "agency"
+	PlanManagementPlan   = "plan"
+	PlanManagementSelf   = "self"
+)
+
+// ParticipantPlan is one NDIS plan with its budgets per support purpose or category.
+// Plans are replaced at review rather than edited, so past plans are kept for reporting.
+type ParticipantPlan struct {
+	ID             string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	ParticipantID  string         `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	PlanNumber     string         `json:"plan_number" gorm:"type:varchar(50)"`
+	StartDate      time.Time      `json:"start_date" gorm:"not null;index"`
+	EndDate        time.Time      `json:"end_date" gorm:"not null;index"`                           // last day of the plan, inclusive
+	ManagementType string         `json:"management_type" gorm:"type:varchar(20);default:'agency'"` // agency, plan, self
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedBy      string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Calculated by Summarise
+	Status          string  `json:"status,omitempty" gorm:"-"` // upcoming, current, ended
+	TotalBudget     float64 `json:"total_budget" gorm:"-"`
+	UsedBudget      float64 `json:"used_budget" gorm:"-"`
+	RemainingBudget float64 `json:"remaining_budget" gorm:"-"`
+	Utilisation     float64 `json:"utilisation" gorm:"-"` // percent of the total budget used
+
+	// Relationships
+	BudgetLines []PlanBudgetLine `json:"budget_lines,omitempty" gorm:"foreignKey:PlanID"`
+}
+
+// PlanBudgetLine is the funding for one support purpose, or for a single support
+// category within it when SupportCategoryNumber is set
+type PlanBudgetLine struct {
+	ID                    string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	PlanID                string    `json:"plan_id" gorm:"type:varchar(36);not null;index"`
+	Purpose               string    `json:"purpose" gorm:"type:varchar(30);not null"`        // core, capacity_building, capital
+	SupportCategoryNumber string    `json:"support_category_number" gorm:"type:varchar(20)"` // e.g. 07, empty for the whole purpose
+	Name                  string    `json:"name" gorm:"type:varchar(255)"`
+	Allocated             float64   `json:"allocated" gorm:"type:decimal(12,2);default:0"`
+	Used                  float64   `json:"used" gorm:"type:decimal(12,2);default:0"`
+	Remaining             float64   `json:"remaining" gorm:"type:decimal(12,2);default:0"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+
+	Utilisation float64 `json:"utilisation" gorm:"-"` // percent of the allocation used
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (p *ParticipantPlan) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *PlanBudgetLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	l.Remaining = roundCents(l.Allocated - l.Used)
+	return
+}
+
+// Covers reports whether a time falls within the plan, including all of its last day
+func (p *ParticipantPlan) Covers(at time.Time) bool {
+	return !at.Before(p.StartDate) && at.Before(p.EndDate.AddDate(0, 0, 1))
+}
+
+// Summarise fills in the plan's status and budget totals from its lines
+func (p *ParticipantPlan) Summarise(at time.Time) {
+	switch {
+	case at.Before(p.StartDate):
+		p.Status = "upcoming"
+	case p.Covers(at):
+		p.Status = "current"
+	default:
+		p.Status = "ended"
+	}
+
+	p.TotalBudget, p.UsedBudget = 0, 0
+	for i := range p.BudgetLines {
+		line := &p.BudgetLines[i]
+		line.Utilisation = utilisation(line.Used, line.Allocated)
+		p.TotalBudget += line.Allocated
+		p.UsedBudget += line.Used
+	}
+	p.TotalBudget = roundCents(p.TotalBudget)
+	p.UsedBudget = roundCents(p.UsedBudget)
+	p.RemainingBudget = roundCents(p.TotalBudget - p.UsedBudget)
+	p.Utilisation = utilisation(p.UsedBudget, p.TotalBudget)
+}
+
+// BudgetLineFor picks the line a support draws from: the line for its exact support
+// category if there is one, otherwise the line for its whole purpose
+func (p *ParticipantPlan) BudgetLineFor(purpose, category string) *PlanBudgetLine {
+	var fallback *PlanBudgetLine
+	for i := range p.BudgetLines {
+		line := &p.BudgetLines[i]
+		if line.Purpose != purpose {
+			continue
+		}
+		if category != "" && line.SupportCategoryNumber == category {
+			return line
+		}
+		if line.SupportCategoryNumber == "" && fallback == nil {
+			fallback = line
+		}
+	}
+	return fallback
+}
+
+// PlanCovering returns the participant's plan in force at a time, or nil if there is none
+func PlanCovering(tx *gorm.DB, participantID string, at time.Time) (*ParticipantPlan, error) {
+	var plan ParticipantPlan
+	err := tx.Preload("BudgetLines").
+		Where("participant_id = ? AND start_date <= ? AND end_date > ?", participantID, at, at.AddDate(0, 0, -1)).
+		Order("start_date DESC").
+		First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// SyncParticipantFunding copies the participant's current plan into their flat funding
+// details, which older screens and reports still read. It does nothing when no plan is
+// in force.
+func SyncParticipantFunding(tx *gorm.DB, participantID string, at time.Time) error {
+	plan, err := PlanCovering(tx, participantID, at)
+	if err != nil || plan == nil {
+		return err
+	}
+	plan.Summarise(at)
+
+	return tx.Model(&Participant{}).Where("id = ?", participantID).UpdateColumns(map[string]interface{}{
+		"funding_total_budget":     plan.TotalBudget,
+		"funding_used_budget":      plan.UsedBudget,
+		"funding_remaining_budget": plan.RemainingBudget,
+		"funding_plan_start_date":  plan.StartDate,
+		"funding_plan_end_date":    plan.EndDate,
+		"funding_management_type":  plan.ManagementType,
+	}).Error
+}
+
+// shiftBudgetCategory resolves the support purpose and category a shift draws from.
+// Shifts without a support item draw from the flexible Core budget.
+func shiftBudgetCategory(tx *gorm.DB, shift *Shift) (string, string) {
+	if shift.SupportItemID == nil {
+		return catalogue.PurposeCore, ""
+	}
+
+	item := shift.SupportItem
+	if item == nil || item.ID != *shift.SupportItemID {
+		item = &SupportItem{}
+		if err := tx.First(item, "id = ?", *shift.SupportItemID).Error; err != nil {
+			return catalogue.PurposeCore, ""
+		}
+	}
+
+	category := catalogue.NormalizeCategory(item.SupportCategoryNumber)
+	if category == "" {
+		category = catalogue.NormalizeCategory(item.ItemNumber)
+	}
+	purpose := catalogue.PurposeFor(category)
+	if purpose == "" {
+		purpose = catalogue.PurposeCore
+	}
+	return purpose, category
+}
+
+func utilisation(used, allocated float64) float64 {
+	if allocated <= 0 {
+		return 0
+	}
+	return roundCents(used / allocated * 100)
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of requirement a participant can have of their worker
+const (
+	RequirementSkill     = "skill"      // holds a skill, by name or category, such as PEG feeding
+	RequirementGender    = "gender"     // the worker's gender
+	RequirementLanguage  = "language"   // speaks a language
+	RequirementNonSmoker = "non_smoker" // does not smoke
+	RequirementNoPets    = "no_pets"    // has no pets, for participants with allergies
+)
+
+// Requirement strengths
+const (
+	RequirementRequired  = "required"  // workers who don't meet it can't be assigned
+	RequirementPreferred = "preferred" // favours workers who meet it
+)
+
+// ParticipantRequirement is a skill or worker attribute a participant needs or prefers. It
+// applies to all of the participant's shifts, or only to one shift when ShiftID is set.
+type ParticipantRequirement struct {
+	ID            string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ParticipantID string    `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID       *string   `json:"shift_id,omitempty" gorm:"type:varchar(36);index"`
+	Kind          string    `json:"kind" gorm:"type:varchar(20);not null"`                        // skill, gender, language, non_smoker, no_pets
+	Value         string    `json:"value" gorm:"type:varchar(200)"`                               // the skill, gender or language
+	Strength      string    `json:"strength" gorm:"type:varchar(20);not null;default:'required'"` // required, preferred
+	Notes         string    `json:"notes" gorm:"type:text"`
+	CreatedBy     string    `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RequirementNeedsValue reports whether a kind of requirement names a skill, gender or
+// language
+func RequirementNeedsValue(kind string) bool {
+	return kind == RequirementSkill || kind == RequirementGender || kind == RequirementLanguage
+}
+
+func (pr *ParticipantRequirement) BeforeCreate(tx *gorm.DB) (err error) {
+	if pr.ID == "" {
+		pr.ID = uuid.New().String()
+	}
+	return
+}
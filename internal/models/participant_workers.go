@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Relationships a participant can have with a worker
+const (
+	WorkerPreferred = "preferred" // the participant has asked for this worker
+	WorkerBlocked   = "blocked"   // the participant never wants this worker again
+)
+
+// ParticipantWorker records a participant or their family asking for a particular worker, or
+// asking never to see them again. Blocked workers can't be booked with the participant.
+type ParticipantWorker struct {
+	ID            string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ParticipantID string    `json:"participant_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_participant_worker"`
+	UserID        string    `json:"user_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_participant_worker;index"`
+	Relationship  string    `json:"relationship" gorm:"type:varchar(20);not null"` // preferred, blocked
+	Reason        string    `json:"reason" gorm:"type:text;not null"`
+	RecordedBy    string    `json:"recorded_by" gorm:"type:varchar(36)"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (pw *ParticipantWorker) BeforeCreate(tx *gorm.DB) (err error) {
+	if pw.ID == "" {
+		pw.ID = uuid.New().String()
+	}
+	return
+}
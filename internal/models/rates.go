@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/rates"
+	"gorm.io/gorm"
+)
+
+// RateCard holds an organization's penalty rate multipliers. Each band's hourly rate is
+// the shift's base rate times the band's multiplier; weekday daytime is always 1.
+type RateCard struct {
+	ID                      string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID          string    `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex"`
+	EveningMultiplier       float64   `json:"evening_multiplier" gorm:"type:decimal(6,4);default:1"`
+	NightMultiplier         float64   `json:"night_multiplier" gorm:"type:decimal(6,4);default:1"`
+	SaturdayMultiplier      float64   `json:"saturday_multiplier" gorm:"type:decimal(6,4);default:1"`
+	SundayMultiplier        float64   `json:"sunday_multiplier" gorm:"type:decimal(6,4);default:1"`
+	PublicHolidayMultiplier float64   `json:"public_holiday_multiplier" gorm:"type:decimal(6,4);default:1"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// Card returns the multipliers in the form the rate engine uses
+func (r *RateCard) Card() rates.Card {
+	return rates.Card{
+		rates.BandWeekday:       1,
+		rates.BandEvening:       r.EveningMultiplier,
+		rates.BandNight:         r.NightMultiplier,
+		rates.BandSaturday:      r.SaturdayMultiplier,
+		rates.BandSunday:        r.SundayMultiplier,
+		rates.BandPublicHoliday: r.PublicHolidayMultiplier,
+	}
+}
+
+// PublicHoliday is a public holiday in one state, or in every state when State is empty
+type PublicHoliday struct {
+	ID             string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_public_holidays_org_state_date"`
+	State          string    `json:"state" gorm:"type:varchar(10);uniqueIndex:idx_public_holidays_org_state_date"`         // ACT, NSW, NT, QLD, SA, TAS, VIC, WA or empty for national
+	Date           string    `json:"date" gorm:"type:varchar(10);not null;uniqueIndex:idx_public_holidays_org_state_date"` // YYYY-MM-DD
+	Name           string    `json:"name" gorm:"type:varchar(255);not null"`
+	CreatedBy      string    `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ShiftCostBand is the part of a shift priced in one penalty rate band
+type ShiftCostBand struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ShiftID   string    `json:"shift_id" gorm:"type:varchar(36);not null;index"`
+	Band      string    `json:"band" gorm:"type:varchar(30);not null"` // weekday, evening, night, saturday, sunday, public_holiday
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Hours     float64   `json:"hours" gorm:"type:decimal(8,2)"`
+	Rate      float64   `json:"rate" gorm:"type:decimal(10,2)"`
+	Amount    float64   `json:"amount" gorm:"type:decimal(12,2)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (r *RateCard) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *PublicHoliday) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+func (b *ShiftCostBand) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
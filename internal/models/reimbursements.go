@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WorkerReimbursement is what a worker is owed for driving their own vehicle on a shift.
+// It is kept in step with the travel recorded on the shift until it is approved.
+type WorkerReimbursement struct {
+	ID                string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID    string     `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	UserID            string     `json:"user_id" gorm:"type:varchar(36);not null;index"`
+	ShiftID           string     `json:"shift_id" gorm:"type:varchar(36);not null;uniqueIndex"`
+	TravelTimeMinutes int        `json:"travel_time_minutes"`
+	TravelKm          float64    `json:"travel_km" gorm:"type:decimal(8,2)"`
+	ActivityKm        float64    `json:"activity_km" gorm:"type:decimal(8,2)"`
+	KilometreRate     float64    `json:"kilometre_rate" gorm:"type:decimal(6,2)"`
+	Amount            float64    `json:"amount" gorm:"type:decimal(10,2)"`
+	Status            string     `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, approved, rejected, paid
+	ReviewedBy        *string    `json:"reviewed_by,omitempty" gorm:"type:varchar(36)"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	PaidAt            *time.Time `json:"paid_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Relationships
+	User  User   `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Shift *Shift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+}
+
+func (r *WorkerReimbursement) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shift publish states
+const (
+	ShiftDraft     = "draft"     // visible to managers only
+	ShiftPublished = "published" // released to the assigned staff member
+)
+
+// Notification types
+const (
+	NotificationRosterPublished = "roster_published"
+)
+
+// RosterTemplate is a named pattern of shifts over a period, usually a fortnight, that
+// can be stamped onto a date range as draft shifts
+type RosterTemplate struct {
+	ID             string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Name           string         `json:"name" gorm:"type:varchar(200);not null"`
+	Description    string         `json:"description" gorm:"type:text"`
+	PeriodDays     int            `json:"period_days" gorm:"not null;default:14"` // length of the pattern, repeated across longer ranges
+	CreatedBy      string         `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Shifts []RosterTemplateShift `json:"shifts,omitempty" gorm:"foreignKey:TemplateID"`
+}
+
+// RosterTemplateShift is one shift in a roster template
+type RosterTemplateShift struct {
+	ID              string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TemplateID      string    `json:"template_id" gorm:"type:varchar(36);not null;index"`
+	DayOffset       int       `json:"day_offset" gorm:"not null"`                 // days from the start of the period
+	StartTime       string    `json:"start_time" gorm:"type:varchar(5);not null"` // local time of day, HH:MM
+	DurationMinutes int       `json:"duration_minutes" gorm:"not null"`
+	ParticipantID   string    `json:"participant_id" gorm:"type:varchar(36);not null"`
+	StaffID         string    `json:"staff_id" gorm:"type:varchar(36);not null"`
+	ServiceType     string    `json:"service_type" gorm:"type:varchar(100);not null"`
+	SupportItemID   *string   `json:"support_item_id,omitempty" gorm:"type:varchar(36)"`
+	Location        string    `json:"location" gorm:"type:varchar(100);not null"`
+	HourlyRate      float64   `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
+	Notes           string    `json:"notes" gorm:"type:text"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relationships
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Staff       User        `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// PublishedShift is a shift as its staff member last saw it, kept so the next publish can
+// tell them which of their shifts are new, changed or removed
+type PublishedShift struct {
+	ShiftID        string    `json:"shift_id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	StaffID        string    `json:"staff_id" gorm:"type:varchar(36);not null;index"`
+	ParticipantID  string    `json:"participant_id" gorm:"type:varchar(36);not null"`
+	StartTime      time.Time `json:"start_time" gorm:"not null;index"`
+	EndTime        time.Time `json:"end_time" gorm:"not null"`
+	ServiceType    string    `json:"service_type" gorm:"type:varchar(100)"`
+	Location       string    `json:"location" gorm:"type:varchar(100)"`
+	PublishedAt    time.Time `json:"published_at"`
+}
+
+// RosterPublication records a roster period being released to staff
+type RosterPublication struct {
+	ID              string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID  string    `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	PeriodStart     time.Time `json:"period_start" gorm:"not null"`
+	PeriodEnd       time.Time `json:"period_end" gorm:"not null"` // exclusive
+	ShiftsPublished int       `json:"shifts_published"`
+	StaffNotified   int       `json:"staff_notified"`
+	PublishedBy     string    `json:"published_by" gorm:"type:varchar(36);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Notification is an in-app message to a user
+type Notification struct {
+	ID             string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	UserID         string     `json:"user_id" gorm:"type:varchar(36);not null;index"`
+	Type           string     `json:"type" gorm:"type:varchar(50);not null;index"`
+	Title          string     `json:"title" gorm:"type:varchar(255);not null"`
+	Message        string     `json:"message" gorm:"type:text"`
+	Data           JSONB      `json:"data,omitempty" gorm:"type:jsonb"`
+	EmailedAt      *time.Time `json:"emailed_at,omitempty"`
+	ReadAt         *time.Time `json:"read_at,omitempty" gorm:"index"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (t *RosterTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return
+}
+
+func (s *RosterTemplateShift) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *RosterPublication) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shift series statuses
+const (
+	ShiftSeriesActive = "active"
+	ShiftSeriesEnded  = "ended" // split, cancelled or past its last occurrence
+)
+
+// ShiftSeries is a repeating shift defined by an iCalendar RRULE. Its occurrences are
+// created as ordinary shifts a rolling horizon ahead, so they can be edited, completed
+// and billed one by one.
+type ShiftSeries struct {
+	ID              string         `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID  string         `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	ParticipantID   string         `json:"participant_id" gorm:"type:varchar(36);not null;index"`
+	StaffID         string         `json:"staff_id" gorm:"type:varchar(36);not null;index"`
+	RRule           string         `json:"rrule" gorm:"column:rrule;type:varchar(255);not null"`
+	StartTime       time.Time      `json:"start_time" gorm:"not null"` // first occurrence, whose local time of day every occurrence keeps
+	DurationMinutes int            `json:"duration_minutes" gorm:"not null"`
+	ServiceType     string         `json:"service_type" gorm:"type:varchar(100);not null"`
+	SupportItemID   *string        `json:"support_item_id,omitempty" gorm:"type:varchar(36)"`
+	Location        string         `json:"location" gorm:"type:varchar(100);not null"`
+	HourlyRate      float64        `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
+	Notes           string         `json:"notes" gorm:"type:text"`
+	Status          string         `json:"status" gorm:"type:varchar(20);default:'active';index"` // active, ended
+	GeneratedUntil  time.Time      `json:"generated_until"`                                       // occurrences starting before this have been created
+	PreviousID      *string        `json:"previous_id,omitempty" gorm:"type:varchar(36);index"`   // series this one was split from
+	CreatedBy       string         `json:"created_by" gorm:"type:varchar(36);not null"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Staff       User        `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+	Shifts      []Shift     `json:"shifts,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// Duration is how long each occurrence lasts
+func (s ShiftSeries) Duration() time.Duration {
+	return time.Duration(s.DurationMinutes) * time.Minute
+}
+
+func (s *ShiftSeries) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shift swap request types
+const (
+	SwapTypeDrop = "drop" // hand the shift to another worker
+	SwapTypeSwap = "swap" // trade the shift for one of another worker's shifts
+)
+
+// Shift swap request states
+const (
+	SwapOpen      = "open"     // waiting for a worker to accept
+	SwapAccepted  = "accepted" // waiting for a manager
+	SwapApproved  = "approved"
+	SwapRejected  = "rejected"
+	SwapCancelled = "cancelled"
+)
+
+// NotificationShiftSwap is sent at each step of a shift swap request
+const NotificationShiftSwap = "shift_swap"
+
+// ShiftSwapRequest is a worker offering one of their shifts to another worker. Once a
+// worker accepts, a manager approves the change before the shifts move.
+type ShiftSwapRequest struct {
+	ID             string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Type           string     `json:"type" gorm:"type:varchar(20);not null"` // drop, swap
+	ShiftID        string     `json:"shift_id" gorm:"type:varchar(36);not null;index"`
+	SwapShiftID    *string    `json:"swap_shift_id,omitempty" gorm:"type:varchar(36);index"`  // the shift taken in return for a swap
+	RequestedBy    string     `json:"requested_by" gorm:"type:varchar(36);not null;index"`    // worker giving up the shift
+	TargetUserID   *string    `json:"target_user_id,omitempty" gorm:"type:varchar(36);index"` // only this worker may accept; nil offers a drop to anyone
+	AcceptedBy     *string    `json:"accepted_by,omitempty" gorm:"type:varchar(36);index"`    // worker taking the shift
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'open';index"` // open, accepted, approved, rejected, cancelled
+	Note           string     `json:"note" gorm:"type:text"`
+	DecidedBy      *string    `json:"decided_by,omitempty" gorm:"type:varchar(36)"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	Reason         string     `json:"reason,omitempty" gorm:"type:text"` // why the request was rejected
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Shift     Shift  `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	SwapShift *Shift `json:"swap_shift,omitempty" gorm:"foreignKey:SwapShiftID"`
+	Requester User   `json:"requester,omitempty" gorm:"foreignKey:RequestedBy"`
+	Acceptor  *User  `json:"acceptor,omitempty" gorm:"foreignKey:AcceptedBy"`
+}
+
+func (s *ShiftSwapRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SupportItem represents a line item in the NDIS Support Catalogue
+type SupportItem struct {
+	ID                      string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ItemNumber              string     `json:"item_number" gorm:"type:varchar(50);not null;uniqueIndex"` // e.g. 01_011_0107_1_1
+	Name                    string     `json:"name" gorm:"type:varchar(500);not null"`
+	RegistrationGroupNumber string     `json:"registration_group_number" gorm:"type:varchar(20);index"`
+	RegistrationGroupName   string     `json:"registration_group_name" gorm:"type:varchar(255)"`
+	SupportCategoryNumber   string     `json:"support_category_number" gorm:"type:varchar(20);index"`
+	SupportCategoryName     string     `json:"support_category_name" gorm:"type:varchar(255)"`
+	Unit                    string     `json:"unit" gorm:"type:varchar(10);not null"` // H, E, D, WK, MON, YR
+	Quote                   bool       `json:"quote" gorm:"default:false"`
+	ItemType                string     `json:"item_type" gorm:"type:varchar(100)"`
+	StartDate               *time.Time `json:"start_date,omitempty"`
+	EndDate                 *time.Time `json:"end_date,omitempty"`
+	NonFaceToFace           bool       `json:"non_face_to_face" gorm:"default:false"`
+	ProviderTravel          bool       `json:"provider_travel" gorm:"default:false"`
+	ShortNoticeCancellation bool       `json:"short_notice_cancellation" gorm:"default:false"`
+	NDIARequestedReports    bool       `json:"ndia_requested_reports" gorm:"default:false"`
+	IrregularSIL            bool       `json:"irregular_sil" gorm:"default:false"`
+	IsActive                bool       `json:"is_active" gorm:"default:true;index"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+
+	// Relationships
+	Prices []SupportItemPrice `json:"prices,omitempty" gorm:"foreignKey:SupportItemID"`
+}
+
+// SupportItemPrice holds the price limit for a support item in one region
+type SupportItemPrice struct {
+	ID            string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	SupportItemID string    `json:"support_item_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_support_item_region"`
+	Region        string    `json:"region" gorm:"type:varchar(20);not null;uniqueIndex:idx_support_item_region"` // ACT, NSW, NT, QLD, SA, TAS, VIC, WA, REMOTE, VERY_REMOTE
+	PriceLimit    float64   `json:"price_limit" gorm:"type:decimal(10,2);not null"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PriceLimitFor returns the price limit for a region; false when the item is unlimited there
+func (s *SupportItem) PriceLimitFor(region string) (float64, bool) {
+	for _, price := range s.Prices {
+		if price.Region == region {
+			return price.PriceLimit, true
+		}
+	}
+	return 0, false
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (s *SupportItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *SupportItemPrice) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
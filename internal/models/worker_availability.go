@@ -82,7 +82,10 @@ type WorkerAvailabilityException struct {
 	Notes         string         `json:"notes" gorm:"type:text"`
 	IsApproved    bool           `json:"is_approved" gorm:"type:boolean;default:false;index"`
 	ApprovedBy    *string        `json:"approved_by" gorm:"type:varchar(36)"`
-	ApprovedAt    *time.Time     `json:"approved_at" gorm:"type:timestamptz"`
+	ApprovedAt    *time.Time     `json:"approved_at"`
+	RejectedBy    *string        `json:"rejected_by" gorm:"type:varchar(36)"`
+	RejectedAt    *time.Time     `json:"rejected_at"`
+	ReviewNotes   string         `json:"review_notes" gorm:"type:text"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
@@ -97,6 +100,16 @@ func (WorkerAvailabilityException) TableName() string {
 	return "worker_availability_exceptions"
 }
 
+// Exception statuses, derived from the approval and rejection fields
+const (
+	ExceptionPending  = "pending"
+	ExceptionApproved = "approved"
+	ExceptionRejected = "rejected"
+)
+
+// NotificationAvailabilityException is sent when an exception is requested or reviewed
+const NotificationAvailabilityException = "availability_exception"
+
 // WorkerPreferences represents worker capacity and general preferences
 type WorkerPreferences struct {
 	ID                       string          `json:"id" gorm:"type:varchar(36);primaryKey"`
@@ -106,6 +119,7 @@ type WorkerPreferences struct {
 	MaxConsecutiveDays       int             `json:"max_consecutive_days" gorm:"type:integer;default:5"`
 	MinHoursBetweenShifts    int             `json:"min_hours_between_shifts" gorm:"type:integer;default:10"`
 	MaxTravelDistanceKm      int             `json:"max_travel_distance_km" gorm:"type:integer;default:50"`
+	HomeAddress              Address         `json:"home_address" gorm:"embedded;embeddedPrefix:home_"` // where the worker travels from
 	PreferredShiftTypes      pq.StringArray  `json:"preferred_shift_types" gorm:"type:text[]"`
 	WillingWeekendWork       bool            `json:"willing_weekend_work" gorm:"type:boolean;default:true"`
 	WillingEveningWork       bool            `json:"willing_evening_work" gorm:"type:boolean;default:true"`
@@ -114,6 +128,10 @@ type WorkerPreferences struct {
 	HasOwnVehicle            bool            `json:"has_own_vehicle" gorm:"type:boolean;default:true"`
 	SpecialSkills            pq.StringArray  `json:"special_skills" gorm:"type:text[]"`
 	ClientPreferences        string          `json:"client_preferences" gorm:"type:text"`
+	Gender                   string          `json:"gender" gorm:"type:varchar(20)"`
+	Languages                pq.StringArray  `json:"languages" gorm:"type:text[]"` // languages spoken
+	Smoker                   bool            `json:"smoker" gorm:"type:boolean;default:false"`
+	HasPets                  bool            `json:"has_pets" gorm:"type:boolean;default:false"`
 	NotificationPreferences  JSONB           `json:"notification_preferences" gorm:"type:jsonb;default:'{\"email\": true, \"sms\": true, \"app\": true}'"`
 	IsActive                 bool            `json:"is_active" gorm:"type:boolean;default:true;index"`
 	CreatedAt                time.Time       `json:"created_at"`
@@ -138,7 +156,7 @@ type WorkerSkill struct {
 	CertificationNumber string         `json:"certification_number" gorm:"type:varchar(100)"`
 	ExpiryDate          *time.Time     `json:"expiry_date" gorm:"type:date;index"`
 	VerifiedBy          *string        `json:"verified_by" gorm:"type:varchar(36)"`
-	VerifiedAt          *time.Time     `json:"verified_at" gorm:"type:timestamptz"`
+	VerifiedAt          *time.Time     `json:"verified_at"`
 	Notes               string         `json:"notes" gorm:"type:text"`
 	IsActive            bool           `json:"is_active" gorm:"type:boolean;default:true;index"`
 	CreatedAt           time.Time      `json:"created_at"`
@@ -275,6 +293,18 @@ func (wp *WorkerPreferences) GetWorkCapacityPercentage() float64 {
 	return (wp.PreferredHoursPerWeek / wp.MaxHoursPerWeek) * 100
 }
 
+// Status reports whether the exception is waiting for a manager, approved or rejected
+func (wae *WorkerAvailabilityException) Status() string {
+	switch {
+	case wae.IsApproved:
+		return ExceptionApproved
+	case wae.RejectedAt != nil:
+		return ExceptionRejected
+	default:
+		return ExceptionPending
+	}
+}
+
 // IsSkillExpiringSoon checks if a skill expires within the next 30 days
 func (ws *WorkerSkill) IsSkillExpiringSoon() bool {
 	if ws.ExpiryDate == nil {
@@ -314,6 +344,28 @@ type WorkerAvailabilityDTO struct {
 	UpdatedAt            string  `json:"updated_at"`
 }
 
+type WorkerAvailabilityExceptionDTO struct {
+	ID            string   `json:"id"`
+	UserID        string   `json:"user_id"`
+	ExceptionDate string   `json:"exception_date"`
+	ExceptionType string   `json:"exception_type"`
+	StartTime     string   `json:"start_time,omitempty"`
+	EndTime       string   `json:"end_time,omitempty"`
+	AllDay        bool     `json:"all_day"`
+	MaxHours      *float64 `json:"max_hours"`
+	HourlyRate    *float64 `json:"hourly_rate"`
+	Reason        string   `json:"reason"`
+	Notes         string   `json:"notes"`
+	Status        string   `json:"status"`
+	ApprovedBy    string   `json:"approved_by,omitempty"`
+	ApprovedAt    string   `json:"approved_at,omitempty"`
+	RejectedBy    string   `json:"rejected_by,omitempty"`
+	RejectedAt    string   `json:"rejected_at,omitempty"`
+	ReviewNotes   string   `json:"review_notes"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
 type WorkerPreferencesDTO struct {
 	ID                      string   `json:"id"`
 	UserID                  string   `json:"user_id"`
@@ -322,6 +374,7 @@ type WorkerPreferencesDTO struct {
 	MaxConsecutiveDays      int      `json:"max_consecutive_days"`
 	MinHoursBetweenShifts   int      `json:"min_hours_between_shifts"`
 	MaxTravelDistanceKm     int      `json:"max_travel_distance_km"`
+	HomeAddress             Address  `json:"home_address"`
 	PreferredShiftTypes     []string `json:"preferred_shift_types"`
 	WillingWeekendWork      bool     `json:"willing_weekend_work"`
 	WillingEveningWork      bool     `json:"willing_evening_work"`
@@ -330,6 +383,10 @@ type WorkerPreferencesDTO struct {
 	HasOwnVehicle           bool     `json:"has_own_vehicle"`
 	SpecialSkills           []string `json:"special_skills"`
 	ClientPreferences       string   `json:"client_preferences"`
+	Gender                  string   `json:"gender"`
+	Languages               []string `json:"languages"`
+	Smoker                  bool     `json:"smoker"`
+	HasPets                 bool     `json:"has_pets"`
 	NotificationPreferences JSONB    `json:"notification_preferences"`
 	WorkCapacityPercentage  float64  `json:"work_capacity_percentage"`
 	IsActive                bool     `json:"is_active"`
@@ -347,6 +404,7 @@ type WorkerSkillDTO struct {
 	ExpiryDate          string `json:"expiry_date"`
 	IsExpired           bool   `json:"is_expired"`
 	IsExpiringSoon      bool   `json:"is_expiring_soon"`
+	IsVerified          bool   `json:"is_verified"`
 	VerifiedBy          string `json:"verified_by"`
 	VerifiedAt          string `json:"verified_at"`
 	Notes               string `json:"notes"`
@@ -355,6 +413,20 @@ type WorkerSkillDTO struct {
 	UpdatedAt           string `json:"updated_at"`
 }
 
+type WorkerLocationPreferenceDTO struct {
+	ID                   string  `json:"id"`
+	UserID               string  `json:"user_id"`
+	LocationType         string  `json:"location_type"`
+	LocationValue        string  `json:"location_value"`
+	PreferenceLevel      string  `json:"preference_level"`
+	MaxTravelTimeMinutes int     `json:"max_travel_time_minutes"`
+	AdditionalTravelRate float64 `json:"additional_travel_rate"`
+	Notes                string  `json:"notes"`
+	IsActive             bool    `json:"is_active"`
+	CreatedAt            string  `json:"created_at"`
+	UpdatedAt            string  `json:"updated_at"`
+}
+
 // Convert model to DTO
 func (wa *WorkerAvailability) ToDTO() WorkerAvailabilityDTO {
 	return WorkerAvailabilityDTO{
@@ -377,6 +449,41 @@ func (wa *WorkerAvailability) ToDTO() WorkerAvailabilityDTO {
 	}
 }
 
+func (wae *WorkerAvailabilityException) ToDTO() WorkerAvailabilityExceptionDTO {
+	dto := WorkerAvailabilityExceptionDTO{
+		ID:            wae.ID,
+		UserID:        wae.UserID,
+		ExceptionDate: wae.ExceptionDate.Format("2006-01-02"),
+		ExceptionType: wae.ExceptionType,
+		AllDay:        wae.StartTime == nil || wae.EndTime == nil,
+		MaxHours:      wae.MaxHours,
+		HourlyRate:    wae.HourlyRate,
+		Reason:        wae.Reason,
+		Notes:         wae.Notes,
+		Status:        wae.Status(),
+		ReviewNotes:   wae.ReviewNotes,
+		CreatedAt:     wae.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     wae.UpdatedAt.Format(time.RFC3339),
+	}
+	if !dto.AllDay {
+		dto.StartTime = wae.StartTime.Format("15:04")
+		dto.EndTime = wae.EndTime.Format("15:04")
+	}
+	if wae.ApprovedBy != nil {
+		dto.ApprovedBy = *wae.ApprovedBy
+	}
+	if wae.ApprovedAt != nil {
+		dto.ApprovedAt = wae.ApprovedAt.Format(time.RFC3339)
+	}
+	if wae.RejectedBy != nil {
+		dto.RejectedBy = *wae.RejectedBy
+	}
+	if wae.RejectedAt != nil {
+		dto.RejectedAt = wae.RejectedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
 func (wp *WorkerPreferences) ToDTO() WorkerPreferencesDTO {
 	return WorkerPreferencesDTO{
 		ID:                      wp.ID,
@@ -386,6 +493,7 @@ func (wp *WorkerPreferences) ToDTO() WorkerPreferencesDTO {
 		MaxConsecutiveDays:      wp.MaxConsecutiveDays,
 		MinHoursBetweenShifts:   wp.MinHoursBetweenShifts,
 		MaxTravelDistanceKm:     wp.MaxTravelDistanceKm,
+		HomeAddress:             wp.HomeAddress,
 		PreferredShiftTypes:     wp.PreferredShiftTypes,
 		WillingWeekendWork:      wp.WillingWeekendWork,
 		WillingEveningWork:      wp.WillingEveningWork,
@@ -394,6 +502,10 @@ func (wp *WorkerPreferences) ToDTO() WorkerPreferencesDTO {
 		HasOwnVehicle:           wp.HasOwnVehicle,
 		SpecialSkills:           wp.SpecialSkills,
 		ClientPreferences:       wp.ClientPreferences,
+		Gender:                  wp.Gender,
+		Languages:               wp.Languages,
+		Smoker:                  wp.Smoker,
+		HasPets:                 wp.HasPets,
 		NotificationPreferences: wp.NotificationPreferences,
 		WorkCapacityPercentage:  wp.GetWorkCapacityPercentage(),
 		IsActive:                wp.IsActive,
@@ -428,6 +540,7 @@ func (ws *WorkerSkill) ToDTO() WorkerSkillDTO {
 		ExpiryDate:          expiryDate,
 		IsExpired:           ws.IsSkillExpired(),
 		IsExpiringSoon:      ws.IsSkillExpiringSoon(),
+		IsVerified:          ws.VerifiedAt != nil,
 		VerifiedBy:          verifiedBy,
 		VerifiedAt:          verifiedAt,
 		Notes:               ws.Notes,
@@ -435,4 +548,20 @@ func (ws *WorkerSkill) ToDTO() WorkerSkillDTO {
 		CreatedAt:           ws.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:           ws.UpdatedAt.Format(time.RFC3339),
 	}
-}
\ No newline at end of file
+}
+
+func (wlp *WorkerLocationPreference) ToDTO() WorkerLocationPreferenceDTO {
+	return WorkerLocationPreferenceDTO{
+		ID:                   wlp.ID,
+		UserID:               wlp.UserID,
+		LocationType:         wlp.LocationType,
+		LocationValue:        wlp.LocationValue,
+		PreferenceLevel:      wlp.PreferenceLevel,
+		MaxTravelTimeMinutes: wlp.MaxTravelTimeMinutes,
+		AdditionalTravelRate: wlp.AdditionalTravelRate,
+		Notes:                wlp.Notes,
+		IsActive:             wlp.IsActive,
+		CreatedAt:            wlp.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:            wlp.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,139 @@
+// Package ndia reads and writes the NDIA myplace portal bulk payment request files.
+package ndia
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Claim types accepted in the ClaimType column; an empty value is a standard service booking claim
+const (
+	ClaimTypeStandard      = ""
+	ClaimTypeCancellation  = "CANC"
+	ClaimTypeReport        = "REPW"
+	ClaimTypeTravel        = "TRAN"
+	ClaimTypeNonFaceToFace = "NF2F"
+	ClaimTypeIrregularSIL  = "IRSS"
+)
+
+// Cancellation reasons accepted in the CancellationReason column of CANC claims
+const (
+	CancellationNoShowHealth    = "NSDH" // No show due to health reasons
+	CancellationNoShowFamily    = "NSDF" // No show due to family issues
+	CancellationNoShowTransport = "NSDT" // No show due to unavailability of transport
+	CancellationOther           = "NSDO" // Other
+)
+
+// GST codes used by the NDIA
+const (
+	GSTCodeTaxable    = "P1"
+	GSTCodeFree       = "P2"
+	GSTCodeOutOfScope = "P5"
+)
+
+// DateLayout is the date format used by the bulk payment request file
+const DateLayout = "2006-01-02"
+
+// BulkClaimHeader is the column order of the bulk payment request CSV template
+var BulkClaimHeader = []string{
+	"RegistrationNumber",
+	"NDISNumber",
+	"SupportsDeliveredFrom",
+	"SupportsDeliveredTo",
+	"SupportNumber",
+	"ClaimReference",
+	"Quantity",
+	"Hours",
+	"UnitPrice",
+	"GSTCode",
+	"AuthorisedBy",
+	"ParticipantApproved",
+	"InKindFundingProgram",
+	"ClaimType",
+	"CancellationReason",
+	"ABN of Support Provider",
+}
+
+// ClaimLine is a single row of a bulk payment request
+type ClaimLine struct {
+	RegistrationNumber    string
+	NDISNumber            string
+	SupportsDeliveredFrom time.Time
+	SupportsDeliveredTo   time.Time
+	SupportNumber         string
+	ClaimReference        string // unique per provider, max 50 characters
+	Quantity              float64
+	UnitPrice             float64
+	GSTCode               string
+	ClaimType             string
+	CancellationReason    string
+	ProviderABN           string
+}
+
+// Validate checks the fields the portal rejects a file for
+func (l ClaimLine) Validate() error {
+	switch {
+	case l.RegistrationNumber == "":
+		return fmt.Errorf("claim %s: registration number is required", l.ClaimReference)
+	case l.NDISNumber == "":
+		return fmt.Errorf("claim %s: NDIS number is required", l.ClaimReference)
+	case l.SupportNumber == "":
+		return fmt.Errorf("claim %s: support number is required", l.ClaimReference)
+	case l.ClaimReference == "" || len(l.ClaimReference) > 50:
+		return fmt.Errorf("claim reference %q must be 1-50 characters", l.ClaimReference)
+	case l.Quantity <= 0:
+		return fmt.Errorf("claim %s: quantity must be positive", l.ClaimReference)
+	case l.SupportsDeliveredTo.Before(l.SupportsDeliveredFrom):
+		return fmt.Errorf("claim %s: delivery end date is before start date", l.ClaimReference)
+	case l.ClaimType == ClaimTypeCancellation && l.CancellationReason == "":
+		return fmt.Errorf("claim %s: cancellation reason is required", l.ClaimReference)
+	}
+	return nil
+}
+
+// WriteBulkClaim writes claim lines as a bulk payment request CSV
+func WriteBulkClaim(w io.Writer, lines []ClaimLine) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(BulkClaimHeader); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if err := line.Validate(); err != nil {
+			return err
+		}
+
+		gstCode := line.GSTCode
+		if gstCode == "" {
+			gstCode = GSTCodeFree
+		}
+
+		record := []string{
+			line.RegistrationNumber,
+			line.NDISNumber,
+			line.SupportsDeliveredFrom.Format(DateLayout),
+			line.SupportsDeliveredTo.Format(DateLayout),
+			line.SupportNumber,
+			line.ClaimReference,
+			strconv.FormatFloat(line.Quantity, 'f', 2, 64),
+			"",
+			strconv.FormatFloat(line.UnitPrice, 'f', 2, 64),
+			gstCode,
+			"",
+			"",
+			"",
+			line.ClaimType,
+			line.CancellationReason,
+			line.ProviderABN,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,87 @@
+package ndia
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBulkClaim(t *testing.T) {
+	day := time.Date(2024, 8, 5, 9, 0, 0, 0, time.UTC)
+	lines := []ClaimLine{{
+		RegistrationNumber:    "4050000000",
+		NDISNumber:            "430000001",
+		SupportsDeliveredFrom: day,
+		SupportsDeliveredTo:   day,
+		SupportNumber:         "01_011_0107_1_1",
+		ClaimReference:        "CLM-000001-0001",
+		Quantity:              2,
+		UnitPrice:             67.56,
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBulkClaim(&buf, lines))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, BulkClaimHeader, records[0])
+	assert.Equal(t, []string{
+		"4050000000", "430000001", "2024-08-05", "2024-08-05", "01_011_0107_1_1", "CLM-000001-0001",
+		"2.00", "", "67.56", "P2", "", "", "", "", "", "",
+	}, records[1])
+}
+
+func TestWriteBulkClaimValidates(t *testing.T) {
+	err := WriteBulkClaim(&bytes.Buffer{}, []ClaimLine{{ClaimReference: "X", RegistrationNumber: "1", SupportNumber: "01", Quantity: 1}})
+	assert.ErrorContains(t, err, "NDIS number")
+}
+
+func TestCancellationClaimNeedsReason(t *testing.T) {
+	day := time.Date(2024, 8, 5, 9, 0, 0, 0, time.UTC)
+	line := ClaimLine{
+		RegistrationNumber:    "4050000000",
+		NDISNumber:            "430000001",
+		SupportsDeliveredFrom: day,
+		SupportsDeliveredTo:   day,
+		SupportNumber:         "01_011_0107_1_1",
+		ClaimReference:        "CLM-000001-0001",
+		Quantity:              2,
+		UnitPrice:             67.56,
+		ClaimType:             ClaimTypeCancellation,
+	}
+	assert.ErrorContains(t, line.Validate(), "cancellation reason")
+
+	line.CancellationReason = CancellationNoShowHealth
+	assert.NoError(t, line.Validate())
+}
+
+func TestParseRemittance(t *testing.T) {
+	file := `ClaimReference,RegistrationNumber,NDISNumber,SupportNumber,PaidTotalAmount,Payment Request Number,Payment Request Status,Error Message
+CLM-000001-0001,4050000000,430000001,01_011_0107_1_1,135.12,PR100,SUCCESSFUL,
+CLM-000001-0002,4050000000,430000001,01_011_0107_1_1,0,PR101,ERROR,SBNF - No service booking found
+CLM-000001-0003,4050000000,430000001,01_011_0107_1_1,,,INCOMPLETE,
+`
+	lines, err := ParseRemittance(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+
+	assert.Equal(t, RemittancePaid, lines[0].Status)
+	assert.Equal(t, 135.12, lines[0].PaidAmount)
+	assert.Equal(t, "PR100", lines[0].PaymentRequestNumber)
+
+	assert.Equal(t, RemittanceRejected, lines[1].Status)
+	assert.Equal(t, "SBNF", lines[1].ReasonCode)
+
+	assert.Equal(t, RemittancePending, lines[2].Status)
+}
+
+func TestParseRemittanceRequiresReference(t *testing.T) {
+	_, err := ParseRemittance(strings.NewReader("Status\nPAID\n"))
+	assert.ErrorIs(t, err, ErrMissingClaimReference)
+}
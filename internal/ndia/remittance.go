@@ -0,0 +1,143 @@
+package ndia
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Remittance outcomes for a claim line
+const (
+	RemittancePaid     = "paid"
+	RemittanceRejected = "rejected"
+	RemittancePending  = "pending"
+)
+
+// ErrMissingClaimReference is returned when the remittance file has no ClaimReference column
+var ErrMissingClaimReference = errors.New("remittance file is missing the ClaimReference column")
+
+// RemittanceLine is the portal's response for a single claim
+type RemittanceLine struct {
+	ClaimReference       string
+	PaymentRequestNumber string
+	Status               string // paid, rejected, pending
+	RawStatus            string
+	PaidAmount           float64
+	ReasonCode           string
+	Message              string
+}
+
+var remittanceAliases = map[string][]string{
+	"reference":      {"claimreference", "claimref"},
+	"request_number": {"paymentrequestnumber", "paymentrequestno"},
+	"status":         {"paymentrequeststatus", "status", "claimstatus"},
+	"paid":           {"paidtotalamount", "amountpaid", "paidamount"},
+	"reason":         {"reasoncode", "errorcode", "rejectionreasoncode"},
+	"message":        {"errormessage", "rejectionreason", "message"},
+}
+
+// reasonCodePattern extracts a leading NDIA error code such as "E0014" or "SBNF" from a message
+var reasonCodePattern = regexp.MustCompile(`^\s*\[?([A-Z]{1,5}[0-9]{0,5})\]?\s*[-:]`)
+
+// ParseRemittance parses a bulk payment response (remittance) CSV
+func ParseRemittance(r io.Reader) ([]RemittanceLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remittance csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrMissingClaimReference
+	}
+
+	index := make(map[string]int)
+	for i, header := range rows[0] {
+		index[normalizeHeader(header)] = i
+	}
+	columns := make(map[string]int)
+	for field, aliases := range remittanceAliases {
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if _, ok := columns["reference"]; !ok {
+		return nil, ErrMissingClaimReference
+	}
+
+	lines := make([]RemittanceLine, 0, len(rows)-1)
+	for lineNo, row := range rows[1:] {
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		reference := get("reference")
+		if reference == "" {
+			continue
+		}
+
+		line := RemittanceLine{
+			ClaimReference:       reference,
+			PaymentRequestNumber: get("request_number"),
+			RawStatus:            get("status"),
+			ReasonCode:           get("reason"),
+			Message:              get("message"),
+		}
+
+		if paid := strings.NewReplacer("$", "", ",", "").Replace(get("paid")); paid != "" {
+			amount, err := strconv.ParseFloat(paid, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid paid amount %q", lineNo+2, paid)
+			}
+			line.PaidAmount = amount
+		}
+
+		line.Status = classifyStatus(line.RawStatus, line.Message)
+		if line.Status == RemittanceRejected && line.ReasonCode == "" {
+			if match := reasonCodePattern.FindStringSubmatch(line.Message); match != nil {
+				line.ReasonCode = match[1]
+			}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func classifyStatus(status, message string) string {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(status), " ", "_")) {
+	case "SUCCESSFUL", "SUCCESS", "PAID", "PAID_IN_FULL", "PAYMENT_MADE":
+		return RemittancePaid
+	case "ERROR", "REJECTED", "FAILED", "CANCELLED", "INVALID":
+		return RemittanceRejected
+	case "":
+		if message != "" {
+			return RemittanceRejected
+		}
+	}
+	return RemittancePending
+}
+
+func normalizeHeader(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{
+		To:      []string{"accounts@planmanager.test", "jane@example.test"},
+		Subject: "Invoice INV-000001 – payment reminder",
+		Body:    "Dear Jane,\nYour invoice is overdue.",
+	}
+	data, err := msg.Bytes("billing@provider.test", time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	text := string(data)
+	assert.Contains(t, text, "From: billing@provider.test\r\n")
+	assert.Contains(t, text, "To: accounts@planmanager.test, jane@example.test\r\n")
+	assert.Contains(t, text, "Subject: =?utf-8?q?Invoice_INV-000001_=E2=80=93_payment_reminder?=\r\n")
+	assert.Contains(t, text, "Date: Mon, 01 Jul 2024 09:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(text, "\r\n\r\nDear Jane,\r\nYour invoice is overdue."))
+}
+
+func TestMessageBytesWithAttachment(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.4 "), 20)
+	msg := Message{
+		To:          []string{"accounts@planmanager.test"},
+		Subject:     "Invoice INV-000001",
+		Body:        "Please find attached our invoice.",
+		Attachments: []Attachment{{Filename: "INV-000001.pdf", ContentType: "application/pdf", Data: pdf}},
+	}
+	data, err := msg.Bytes("billing@provider.test", time.Now())
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	text, err := reader.NextPart()
+	require.NoError(t, err)
+	// The reader decodes quoted-printable parts itself
+	body, _ := io.ReadAll(text)
+	assert.Equal(t, "Please find attached our invoice.", string(body))
+
+	attachment, err := reader.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "INV-000001.pdf", attachment.FileName())
+	assert.Equal(t, "application/pdf", attachment.Header.Get("Content-Type"))
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	require.NoError(t, err)
+	assert.Equal(t, pdf, decoded)
+
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestMessageRejectsHeaderInjection(t *testing.T) {
+	msg := Message{To: []string{"jane@example.test\r\nBcc: everyone@example.test"}, Subject: "Hello"}
+	_, err := msg.Bytes("billing@provider.test", time.Now())
+	assert.Error(t, err)
+}
+
+func TestSMTPMailerRequiresRecipients(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", 25, "billing@provider.test", "", "")
+	assert.Equal(t, "billing@provider.test", mailer.From)
+	assert.ErrorIs(t, mailer.Send(Message{Subject: "Hello"}), ErrNoRecipients)
+}
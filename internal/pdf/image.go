@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+)
+
+// Image is an image prepared for embedding
+type Image struct {
+	width      int
+	height     int
+	colorSpace string
+	filter     string
+	decode     string
+	data       []byte
+	mask       []byte
+}
+
+// Size returns the image dimensions in pixels
+func (img *Image) Size() (int, int) {
+	return img.width, img.height
+}
+
+// LoadImage reads a JPEG or PNG image from disk
+func LoadImage(path string) (*Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(data)
+}
+
+// NewImage prepares JPEG or PNG data for embedding
+func NewImage(data []byte) (*Image, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return newJPEG(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return newPNG(data)
+	}
+	return nil, fmt.Errorf("unsupported image format, expected JPEG or PNG")
+}
+
+// newJPEG embeds JPEG data as-is, the PDF reader decodes it
+func newJPEG(data []byte) (*Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JPEG: %w", err)
+	}
+
+	img := &Image{width: cfg.Width, height: cfg.Height, filter: "DCTDecode", data: data}
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "DeviceGray"
+	case color.CMYKModel:
+		// Adobe writes inverted CMYK JPEGs
+		img.colorSpace = "DeviceCMYK"
+		img.decode = "[1 0 1 0 1 0 1 0]"
+	default:
+		img.colorSpace = "DeviceRGB"
+	}
+	return img, nil
+}
+
+// newPNG decodes the PNG and re-encodes its pixels as RGB with an optional alpha mask
+func newPNG(data []byte) (*Image, error) {
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PNG: %w", err)
+	}
+
+	bounds := decoded.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	rgbData := make([]byte, 0, width*height*3)
+	alpha := make([]byte, 0, width*height)
+	opaque := true
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			rgbData = append(rgbData, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	compressed, err := deflate(rgbData)
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{width: width, height: height, colorSpace: "DeviceRGB", filter: "FlateDecode", data: compressed}
+
+	if !opaque {
+		if img.mask, err = deflate(alpha); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// FitImage scales an image to fit within maxW x maxH points, keeping its aspect ratio
+func FitImage(img *Image, maxW, maxH float64) (float64, float64) {
+	w, h := float64(img.width), float64(img.height)
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	scale := maxW / w
+	if h*scale > maxH {
+		scale = maxH / h
+	}
+	return w * scale, h * scale
+}
@@ -0,0 +1,423 @@
+// Package pdf is a small, dependency free PDF writer for generated business documents.
+//
+// It supports the standard Helvetica fonts, filled and stroked shapes, and JPEG or PNG
+// images. Output is deterministic: the same calls always produce byte-identical files.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font selects one of the built-in fonts
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// Color is an RGB colour with components in the range 0-1
+type Color struct {
+	R, G, B float64
+}
+
+// Common colours
+var (
+	Black = Color{0, 0, 0}
+	White = Color{1, 1, 1}
+)
+
+// ParseHexColor parses colours in #rrggbb or #rgb form
+func ParseHexColor(s string) (Color, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return Color{}, fmt.Errorf("invalid colour %q", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid colour %q", s)
+	}
+	return Color{
+		R: float64(v>>16&0xff) / 255,
+		G: float64(v>>8&0xff) / 255,
+		B: float64(v&0xff) / 255,
+	}, nil
+}
+
+// Lighten mixes the colour with white; amount 0 keeps the colour, 1 gives white
+func (c Color) Lighten(amount float64) Color {
+	return Color{
+		R: c.R + (1-c.R)*amount,
+		G: c.G + (1-c.G)*amount,
+		B: c.B + (1-c.B)*amount,
+	}
+}
+
+// Document is a PDF under construction. Coordinates are in points from the top-left corner.
+type Document struct {
+	pages    []*bytes.Buffer
+	current  *bytes.Buffer
+	images   []*Image
+	font     Font
+	fontSize float64
+	fill     Color
+	stroke   Color
+	title    string
+	author   string
+	created  time.Time
+}
+
+// New creates an empty A4 portrait document
+func New() *Document {
+	return &Document{font: Helvetica, fontSize: 10, fill: Black, stroke: Black}
+}
+
+// SetInfo sets the document metadata. The creation date is stored as given so that
+// re-rendering the same document yields the same bytes.
+func (d *Document) SetInfo(title, author string, created time.Time) {
+	d.title = title
+	d.author = author
+	d.created = created
+}
+
+// AddPage starts a new page and makes it current
+func (d *Document) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+}
+
+// PageCount returns the number of pages added so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage makes an existing page current, numbered from 1
+func (d *Document) SetPage(n int) {
+	if n >= 1 && n <= len(d.pages) {
+		d.current = d.pages[n-1]
+	}
+}
+
+// SetFont sets the font used by subsequent text
+func (d *Document) SetFont(font Font, size float64) {
+	d.font = font
+	d.fontSize = size
+}
+
+// SetFillColor sets the colour used for text and filled shapes
+func (d *Document) SetFillColor(c Color) {
+	d.fill = c
+}
+
+// SetStrokeColor sets the colour used for lines and outlines
+func (d *Document) SetStrokeColor(c Color) {
+	d.stroke = c
+}
+
+// TextWidth returns the width of s in the current font and size
+func (d *Document) TextWidth(s string) float64 {
+	widths := fontWidths[d.font]
+	total := 0
+	for _, b := range encodeWinAnsi(s) {
+		if b >= 32 && b <= 126 {
+			total += widths[b-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * d.fontSize / 1000
+}
+
+// Text draws s with its baseline at (x, y)
+func (d *Document) Text(x, y float64, s string) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.current, "BT /F%d %s Tf %s rg %s %s Td (%s) Tj ET\n",
+		int(d.font)+1, num(d.fontSize), rgb(d.fill), num(x), num(PageHeight-y), escapeText(encodeWinAnsi(s)))
+}
+
+// TextRight draws s so that it ends at x
+func (d *Document) TextRight(x, y float64, s string) {
+	d.Text(x-d.TextWidth(s), y, s)
+}
+
+// WrapText splits s into lines that fit within width in the current font
+func (d *Document) WrapText(s string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			if d.TextWidth(line+" "+word) <= width {
+				line += " " + word
+				continue
+			}
+			lines = append(lines, line)
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Rect draws a rectangle with its top-left corner at (x, y), filled or stroked
+func (d *Document) Rect(x, y, w, h float64, fill bool) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	if fill {
+		fmt.Fprintf(d.current, "%s rg %s %s %s %s re f\n", rgb(d.fill), num(x), num(PageHeight-y-h), num(w), num(h))
+		return
+	}
+	fmt.Fprintf(d.current, "%s RG %s %s %s %s re S\n", rgb(d.stroke), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// Line draws a straight line
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.current, "%s RG %s w %s %s m %s %s l S\n",
+		rgb(d.stroke), num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Image draws img into the box with top-left corner (x, y)
+func (d *Document) Image(img *Image, x, y, w, h float64) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	index := -1
+	for i, existing := range d.images {
+		if existing == img {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		d.images = append(d.images, img)
+		index = len(d.images) - 1
+	}
+	fmt.Fprintf(d.current, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(PageHeight-y-h), index+1)
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo renders the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := &writer{}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbering: 1 catalog, 2 page tree, 3-4 fonts, then images, pages and contents, then info
+	const firstFont = 3
+	firstImage := firstFont + len(fontNames)
+	nextObject := firstImage
+	imageObjects := make([]int, len(d.images))
+	for i, img := range d.images {
+		imageObjects[i] = nextObject
+		nextObject++
+		if img.mask != nil {
+			nextObject++
+		}
+	}
+	firstPage := nextObject
+	infoObject := firstPage + 2*len(d.pages)
+
+	out.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	out.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	for i, name := range fontNames {
+		out.object(firstFont+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	for i, img := range d.images {
+		obj := imageObjects[i]
+		dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+			img.width, img.height, img.colorSpace, img.filter)
+		if img.decode != "" {
+			dict += " /Decode " + img.decode
+		}
+		if img.mask != nil {
+			dict += fmt.Sprintf(" /SMask %d 0 R", obj+1)
+		}
+		out.stream(obj, dict, img.data)
+		if img.mask != nil {
+			out.stream(obj+1, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+				img.width, img.height), img.mask)
+		}
+	}
+
+	var xobjects strings.Builder
+	for i := range d.images {
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i+1, imageObjects[i])
+	}
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >>", firstFont, firstFont+1)
+	if len(d.images) > 0 {
+		resources += " /XObject <<" + xobjects.String() + " >>"
+	}
+	resources += " >>"
+
+	for i, content := range d.pages {
+		pageObj := firstPage + 2*i
+		out.object(pageObj, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			num(PageWidth), num(PageHeight), resources, pageObj+1))
+
+		compressed, err := deflate(content.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		out.stream(pageObj+1, "/Filter /FlateDecode", compressed)
+	}
+
+	info := "<< /Producer (gofiber-ago-crm)"
+	if d.title != "" {
+		info += " /Title (" + escapeText(encodeWinAnsi(d.title)) + ")"
+	}
+	if d.author != "" {
+		info += " /Author (" + escapeText(encodeWinAnsi(d.author)) + ")"
+	}
+	if !d.created.IsZero() {
+		info += " /CreationDate (D:" + d.created.UTC().Format("20060102150405") + "Z)"
+	}
+	info += " >>"
+	out.object(infoObject, info)
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", infoObject+1)
+	for i := 1; i <= infoObject; i++ {
+		fmt.Fprintf(out, "%010d 00000 n \n", out.offsets[i])
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", infoObject+1, infoObject, xrefOffset)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+// writer tracks object offsets for the cross-reference table
+type writer struct {
+	bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(n int, body string) {
+	w.mark(n)
+	fmt.Fprintf(w, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+func (w *writer) stream(n int, dict string, data []byte) {
+	w.mark(n)
+	fmt.Fprintf(w, "%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data))
+	w.Write(data)
+	w.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *writer) mark(n int) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[n] = w.Len()
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	v = math.Round(v*100) / 100
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func rgb(c Color) string {
+	return num3(c.R) + " " + num3(c.G) + " " + num3(c.B)
+}
+
+func num3(v float64) string {
+	v = math.Round(v*1000) / 1000
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// encodeWinAnsi converts text to the single byte encoding used by the standard fonts
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if b, ok := winAnsiExtras[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+func escapeText(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, logo *Image) []byte {
+	doc := New()
+	doc.SetInfo("Invoice INV-000001", "Test Organization", time.Date(2024, 8, 5, 0, 0, 0, 0, time.UTC))
+	doc.AddPage()
+	doc.SetFont(HelveticaBold, 20)
+	doc.SetFillColor(Color{0.4, 0.5, 0.9})
+	doc.Text(40, 60, "TAX INVOICE (copy)")
+	doc.SetFont(Helvetica, 10)
+	doc.TextRight(555, 60, "Total $1,234.50")
+	doc.Rect(40, 80, 515, 20, true)
+	doc.Line(40, 110, 555, 110, 0.5)
+	if logo != nil {
+		doc.Image(logo, 40, 10, 40, 40)
+	}
+	doc.AddPage()
+	doc.Text(40, 60, "Page two – café")
+
+	out, err := doc.Bytes()
+	require.NoError(t, err)
+	return out
+}
+
+func testPNG(t *testing.T) *Image {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	src.Set(1, 1, color.NRGBA{0, 0, 255, 128})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	img, err := NewImage(buf.Bytes())
+	require.NoError(t, err)
+	return img
+}
+
+func TestOutputIsDeterministic(t *testing.T) {
+	first := render(t, testPNG(t))
+	second := render(t, testPNG(t))
+	assert.Equal(t, first, second)
+}
+
+func TestStructure(t *testing.T) {
+	out := render(t, testPNG(t))
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), "/BaseFont /Helvetica-Bold")
+	assert.Contains(t, string(out), "/SMask")
+	assert.Contains(t, string(out), "/CreationDate (D:20240805000000Z)")
+
+	// Every cross-reference entry must point at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	require.True(t, bytes.HasPrefix(out[offset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[offset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		pos, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(out[pos:], []byte(strconv.Itoa(i+1)+" 0 obj")), "object %d", i+1)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	doc := New()
+	doc.SetFont(Helvetica, 10)
+	assert.InDelta(t, 5.56*3, doc.TextWidth("123"), 0.001)
+
+	doc.SetFont(HelveticaBold, 10)
+	assert.Greater(t, doc.TextWidth("Total"), 0.0)
+
+	lines := doc.WrapText("one two three four five six seven", 60)
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.LessOrEqual(t, doc.TextWidth(line), 60.0)
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	c, err := ParseHexColor("#667eea")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.4, c.R, 0.001)
+
+	c, err = ParseHexColor("fff")
+	require.NoError(t, err)
+	assert.Equal(t, White, c)
+
+	_, err = ParseHexColor("#12345")
+	assert.Error(t, err)
+}
+
+func TestNewImageRejectsUnknownFormats(t *testing.T) {
+	_, err := NewImage([]byte("GIF89a"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+// Package rates splits shifts into penalty rate bands and prices each band.
+//
+// Bands follow the NDIS Pricing Arrangements: weekday daytime runs from 6am to 8pm,
+// evening from 8pm to midnight and night from midnight to 6am. Saturdays, Sundays and
+// public holidays are a single band for the whole day, with public holidays taking
+// precedence over weekends. All boundaries are in the organization's local time.
+package rates
+
+import (
+	"math"
+	"time"
+)
+
+// Penalty rate bands
+const (
+	BandWeekday       = "weekday"
+	BandEvening       = "evening"
+	BandNight         = "night"
+	BandSaturday      = "saturday"
+	BandSunday        = "sunday"
+	BandPublicHoliday = "public_holiday"
+)
+
+// Bands lists every band in display order
+var Bands = []string{BandWeekday, BandEvening, BandNight, BandSaturday, BandSunday, BandPublicHoliday}
+
+// bandNames are the labels printed on invoices
+var bandNames = map[string]string{
+	BandWeekday:       "Weekday",
+	BandEvening:       "Evening",
+	BandNight:         "Night",
+	BandSaturday:      "Saturday",
+	BandSunday:        "Sunday",
+	BandPublicHoliday: "Public holiday",
+}
+
+// BandName returns a band's display label
+func BandName(band string) string {
+	if name, ok := bandNames[band]; ok {
+		return name
+	}
+	return band
+}
+
+// Local hours at which the weekday bands change
+const (
+	dayStartHour     = 6
+	eveningStartHour = 20
+)
+
+// Calendar is a set of public holiday dates keyed as YYYY-MM-DD
+type Calendar map[string]bool
+
+// Add marks a date as a public holiday
+func (c Calendar) Add(date time.Time) {
+	c[date.Format("2006-01-02")] = true
+}
+
+// Contains reports whether the local date of t is a public holiday
+func (c Calendar) Contains(t time.Time) bool {
+	return c[t.Format("2006-01-02")]
+}
+
+// Segment is a continuous part of a shift within one band
+type Segment struct {
+	Band  string
+	Start time.Time
+	End   time.Time
+}
+
+// Hours returns the length of the segment in hours
+func (s Segment) Hours() float64 {
+	return s.End.Sub(s.Start).Hours()
+}
+
+// BandAt returns the band a local time falls in
+func BandAt(t time.Time, holidays Calendar) string {
+	if holidays.Contains(t) {
+		return BandPublicHoliday
+	}
+	switch t.Weekday() {
+	case time.Saturday:
+		return BandSaturday
+	case time.Sunday:
+		return BandSunday
+	}
+	switch hour := t.Hour(); {
+	case hour < dayStartHour:
+		return BandNight
+	case hour < eveningStartHour:
+		return BandWeekday
+	}
+	return BandEvening
+}
+
+// Split divides the time from start to end into band segments in loc. Adjacent parts in
+// the same band are merged, so a Saturday overnight shift into Sunday gives two segments.
+func Split(start, end time.Time, loc *time.Location, holidays Calendar) []Segment {
+	if loc == nil {
+		loc = time.UTC
+	}
+	segments := []Segment{}
+	current := start.In(loc)
+	end = end.In(loc)
+
+	for current.Before(end) {
+		next := nextBoundary(current)
+		if next.After(end) {
+			next = end
+		}
+
+		band := BandAt(current, holidays)
+		if n := len(segments); n > 0 && segments[n-1].Band == band {
+			segments[n-1].End = next
+		} else {
+			segments = append(segments, Segment{Band: band, Start: current, End: next})
+		}
+		current = next
+	}
+	return segments
+}
+
+// nextBoundary returns the next local time after t at which the band could change
+func nextBoundary(t time.Time) time.Time {
+	year, month, day := t.Date()
+	for _, hour := range []int{dayStartHour, eveningStartHour} {
+		boundary := time.Date(year, month, day, hour, 0, 0, 0, t.Location())
+		if boundary.After(t) {
+			return boundary
+		}
+	}
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+// Card holds the multiplier applied to the base hourly rate in each band.
+// Bands without an entry price at the base rate.
+type Card map[string]float64
+
+// Rate returns the hourly rate for a band, rounded to cents
+func (c Card) Rate(band string, baseRate float64) float64 {
+	multiplier, ok := c[band]
+	if !ok || multiplier <= 0 {
+		multiplier = 1
+	}
+	return roundCents(baseRate * multiplier)
+}
+
+// Line is a priced segment
+type Line struct {
+	Segment
+	Hours  float64
+	Rate   float64
+	Amount float64
+}
+
+// Price prices each segment at its band's rate and returns the lines and their total
+func (c Card) Price(segments []Segment, baseRate float64) ([]Line, float64) {
+	lines := make([]Line, 0, len(segments))
+	total := 0.0
+	for _, segment := range segments {
+		hours := roundCents(segment.Hours())
+		rate := c.Rate(segment.Band, baseRate)
+		amount := roundCents(hours * rate)
+		lines = append(lines, Line{Segment: segment, Hours: hours, Rate: rate, Amount: amount})
+		total += amount
+	}
+	return lines, roundCents(total)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,95 @@
+package rates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adelaide(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	require.NoError(t, err)
+	return loc
+}
+
+func bands(segments []Segment) []string {
+	out := []string{}
+	for _, segment := range segments {
+		out = append(out, segment.Band)
+	}
+	return out
+}
+
+func TestSplitWeekdayEveningNight(t *testing.T) {
+	loc := adelaide(t)
+	// Wednesday 6pm to Thursday 7am
+	start := time.Date(2024, 8, 7, 18, 0, 0, 0, loc)
+	segments := Split(start, start.Add(13*time.Hour), loc, nil)
+
+	assert.Equal(t, []string{BandWeekday, BandEvening, BandNight, BandWeekday}, bands(segments))
+	assert.Equal(t, 2.0, segments[0].Hours())
+	assert.Equal(t, 4.0, segments[1].Hours())
+	assert.Equal(t, 6.0, segments[2].Hours())
+	assert.Equal(t, 1.0, segments[3].Hours())
+}
+
+func TestSplitUsesLocalTime(t *testing.T) {
+	loc := adelaide(t)
+	// 9am to 11am Saturday in Adelaide is still Friday night in UTC
+	start := time.Date(2024, 8, 9, 23, 30, 0, 0, time.UTC)
+	segments := Split(start, start.Add(2*time.Hour), loc, nil)
+
+	require.Len(t, segments, 1)
+	assert.Equal(t, BandSaturday, segments[0].Band)
+}
+
+func TestSplitWeekendAndHolidays(t *testing.T) {
+	loc := adelaide(t)
+	holidays := Calendar{}
+	holidays.Add(time.Date(2024, 12, 25, 0, 0, 0, 0, loc))
+
+	// Saturday 10pm to Sunday 2am
+	start := time.Date(2024, 8, 10, 22, 0, 0, 0, loc)
+	segments := Split(start, start.Add(4*time.Hour), loc, holidays)
+	assert.Equal(t, []string{BandSaturday, BandSunday}, bands(segments))
+
+	// Christmas Day is a Wednesday; the holiday band covers the whole day
+	start = time.Date(2024, 12, 24, 19, 0, 0, 0, loc)
+	segments = Split(start, start.Add(30*time.Hour), loc, holidays)
+	assert.Equal(t, []string{BandWeekday, BandEvening, BandPublicHoliday, BandNight}, bands(segments))
+	assert.Equal(t, 24.0, segments[2].Hours())
+}
+
+func TestSplitAcrossDaylightSaving(t *testing.T) {
+	loc := adelaide(t)
+	// Clocks go forward at 2am on Sunday 6 October 2024, so that Sunday is 23 hours long
+	start := time.Date(2024, 10, 6, 0, 0, 0, 0, loc)
+	segments := Split(start, time.Date(2024, 10, 7, 0, 0, 0, 0, loc), loc, nil)
+
+	require.Len(t, segments, 1)
+	assert.Equal(t, BandSunday, segments[0].Band)
+	assert.Equal(t, 23.0, segments[0].Hours())
+}
+
+func TestPrice(t *testing.T) {
+	loc := adelaide(t)
+	card := Card{BandEvening: 1.1, BandSaturday: 1.5}
+
+	// Friday 6pm to 10pm then a Saturday morning
+	start := time.Date(2024, 8, 9, 18, 0, 0, 0, loc)
+	segments := Split(start, start.Add(4*time.Hour), loc, nil)
+	segments = append(segments, Split(start.Add(16*time.Hour), start.Add(17*time.Hour), loc, nil)...)
+
+	lines, total := card.Price(segments, 60)
+	require.Len(t, lines, 3)
+	assert.Equal(t, 60.0, lines[0].Rate)
+	assert.Equal(t, 66.0, lines[1].Rate)
+	assert.Equal(t, 132.0, lines[1].Amount)
+	assert.Equal(t, 90.0, lines[2].Rate)
+	assert.Equal(t, 342.0, total)
+
+	// Missing bands fall back to the base rate
+	assert.Equal(t, 60.0, card.Rate(BandNight, 60))
+}
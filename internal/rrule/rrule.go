@@ -0,0 +1,326 @@
+// Package rrule parses and expands the subset of iCalendar recurrence rules (RFC 5545)
+// used for rostering: daily, weekly and monthly rules with an interval, weekdays for
+// weekly rules, days of the month for monthly rules, and an end set by UNTIL or COUNT.
+//
+// Occurrences keep the wall clock time of the first occurrence in its location, so a
+// 9am shift stays at 9am across daylight saving changes.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// maxIterations bounds expansion of rules that never produce an occurrence, such as the
+// 31st of every second February
+const maxIterations = 100000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var weekdayCodes = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday // weekly rules only; defaults to the weekday of the first occurrence
+	ByMonthDay []int          // monthly rules only; negative days count back from the month end
+	Until      *time.Time     // last moment an occurrence may start, inclusive
+	Count      int            // number of occurrences, 0 for no limit
+	WeekStart  time.Weekday
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20250630".
+// A leading "RRULE:" is accepted. A date-only UNTIL is read in loc and covers the whole day.
+func Parse(value string, loc *time.Location) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "RRULE:"), "rrule:")
+	if value == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is repeated", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if val != Daily && val != Weekly && val != Monthly {
+				return nil, fmt.Errorf("unsupported FREQ %q: use DAILY, WEEKLY or MONTHLY", val)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive number, got %q", val)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive number, got %q", val)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(val, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := weekdays[code]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", code)
+				}
+				if !containsWeekday(rule.ByDay, day) {
+					rule.ByDay = append(rule.ByDay, day)
+				}
+			}
+		case "BYMONTHDAY":
+			for _, text := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(text)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY value %q", text)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "WKST":
+			day, ok := weekdays[val]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", val)
+			}
+			rule.WeekStart = day
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %s", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("rrule needs a FREQ")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("rrule cannot have both COUNT and UNTIL")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Weekly {
+		return nil, errors.New("BYDAY is only supported for WEEKLY rules")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != Monthly {
+		return nil, errors.New("BYMONTHDAY is only supported for MONTHLY rules")
+	}
+	return rule, nil
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		// A date covers occurrences starting at any time that day
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q: use YYYYMMDD or YYYYMMDDTHHMMSSZ", value)
+}
+
+// String renders the rule in canonical form, with UNTIL in UTC
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := []string{}
+		for _, day := range r.ByDay {
+			codes = append(codes, weekdayCodes[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := []string{}
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCodes[r.WeekStart])
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences of a series starting at dtstart whose start falls in
+// [from, to), in order. dtstart is always the first occurrence, as RFC 5545 requires,
+// and COUNT is counted from it.
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	occurrences := []time.Time{}
+	r.each(dtstart, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Next returns the first occurrence starting at or after t, if the rule has one
+func (r *Rule) Next(dtstart, t time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	r.each(dtstart, func(occurrence time.Time) bool {
+		if occurrence.Before(t) {
+			return true
+		}
+		next, found = occurrence, true
+		return false
+	})
+	return next, found
+}
+
+// Index returns how many occurrences start before t
+func (r *Rule) Index(dtstart, t time.Time) int {
+	return len(r.Between(dtstart, dtstart, t))
+}
+
+// each calls fn with every occurrence in order until fn returns false or the rule ends
+func (r *Rule) each(dtstart time.Time, fn func(time.Time) bool) {
+	count := 0
+	emit := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		if r.Count > 0 && count >= r.Count {
+			return false
+		}
+		count++
+		return fn(t)
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+
+	for i := 1; i < maxIterations; i++ {
+		var candidates []time.Time
+		switch r.Freq {
+		case Daily:
+			candidates = []time.Time{at(dtstart.Year(), dtstart.Month(), dtstart.Day()+i*r.Interval)}
+		case Weekly:
+			candidates = r.weekOccurrences(dtstart, i-1, at)
+		case Monthly:
+			candidates = r.monthOccurrences(dtstart, i-1, at)
+		}
+		for _, t := range candidates {
+			if t.Equal(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// weekOccurrences lists the occurrences in the nth active week, counting the week of dtstart as 0
+func (r *Rule) weekOccurrences(dtstart time.Time, n int, at func(int, time.Month, int) time.Time) []time.Time {
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []time.Weekday{dtstart.Weekday()}
+	}
+	offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+	weekStart := dtstart.Day() - offset + n*7*r.Interval
+
+	occurrences := []time.Time{}
+	for _, day := range days {
+		occurrences = append(occurrences, at(dtstart.Year(), dtstart.Month(), weekStart+(int(day)-int(r.WeekStart)+7)%7))
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
+
+// monthOccurrences lists the occurrences in the nth active month, counting the month of
+// dtstart as 0. Days that do not exist in a month, such as the 31st of June, are skipped.
+func (r *Rule) monthOccurrences(dtstart time.Time, n int, at func(int, time.Month, int) time.Time) []time.Time {
+	first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, dtstart.Location())
+	length := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, first.Location()).Day()
+
+	days := r.ByMonthDay
+	if len(days) == 0 {
+		days = []int{dtstart.Day()}
+	}
+	occurrences := []time.Time{}
+	seen := map[int]bool{}
+	for _, day := range days {
+		if day < 0 {
+			day = length + day + 1
+		}
+		if day < 1 || day > length || seen[day] {
+			continue
+		}
+		seen[day] = true
+		occurrences = append(occurrences, at(first.Year(), first.Month(), day))
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Before(occurrences[j]) })
+	return occurrences
+}
@@ -0,0 +1,128 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adelaide(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	require.NoError(t, err)
+	return loc
+}
+
+func dates(times []time.Time) []string {
+	out := []string{}
+	for _, t := range times {
+		out = append(out, t.Format("Mon 2006-01-02 15:04"))
+	}
+	return out
+}
+
+func TestWeeklyOnDays(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5", loc)
+	require.NoError(t, err)
+
+	// Starts on a Monday
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, loc)
+	occurrences := rule.Between(start, start, start.AddDate(1, 0, 0))
+	assert.Equal(t, []string{
+		"Mon 2024-07-01 09:00",
+		"Wed 2024-07-03 09:00",
+		"Mon 2024-07-08 09:00",
+		"Wed 2024-07-10 09:00",
+		"Mon 2024-07-15 09:00",
+	}, dates(occurrences))
+}
+
+func TestFortnightlyUntil(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;UNTIL=20240820", loc)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 7, 9, 14, 30, 0, 0, loc)
+	occurrences := rule.Between(start, start, start.AddDate(1, 0, 0))
+	assert.Equal(t, []string{
+		"Tue 2024-07-09 14:30",
+		"Tue 2024-07-23 14:30",
+		"Tue 2024-08-06 14:30",
+		"Tue 2024-08-20 14:30",
+	}, dates(occurrences))
+}
+
+func TestWeeklyKeepsLocalTimeAcrossDaylightSaving(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("FREQ=WEEKLY", loc)
+	require.NoError(t, err)
+
+	// Daylight saving starts on 6 October 2024 in Adelaide
+	start := time.Date(2024, 9, 30, 9, 0, 0, 0, loc)
+	occurrences := rule.Between(start, start, start.AddDate(0, 0, 14))
+	require.Len(t, occurrences, 2)
+	assert.Equal(t, 9, occurrences[1].Hour())
+	assert.Equal(t, 167*time.Hour, occurrences[1].Sub(occurrences[0]))
+}
+
+func TestBetweenCountsFromTheFirstOccurrence(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("FREQ=DAILY;INTERVAL=3;COUNT=4", loc)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 7, 1, 8, 0, 0, 0, loc)
+	occurrences := rule.Between(start, start.AddDate(0, 0, 4), start.AddDate(0, 1, 0))
+	assert.Equal(t, []string{"Sun 2024-07-07 08:00", "Wed 2024-07-10 08:00"}, dates(occurrences))
+	assert.Equal(t, 2, rule.Index(start, start.AddDate(0, 0, 6)))
+
+	next, ok := rule.Next(start, start.AddDate(0, 0, 8))
+	require.True(t, ok)
+	assert.Equal(t, "Wed 2024-07-10 08:00", next.Format("Mon 2006-01-02 15:04"))
+	_, ok = rule.Next(start, start.AddDate(0, 0, 10))
+	assert.False(t, ok)
+}
+
+func TestMonthlySkipsMissingDays(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("FREQ=MONTHLY;BYMONTHDAY=31,-1", loc)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 31, 10, 0, 0, 0, loc)
+	occurrences := rule.Between(start, start, time.Date(2024, 4, 1, 0, 0, 0, 0, loc))
+	assert.Equal(t, []string{
+		"Wed 2024-01-31 10:00",
+		"Thu 2024-02-29 10:00",
+		"Sun 2024-03-31 10:00",
+	}, dates(occurrences))
+}
+
+func TestParseErrors(t *testing.T) {
+	loc := adelaide(t)
+	for _, value := range []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20240101",
+		"FREQ=WEEKLY;FREQ=DAILY",
+		"FREQ=WEEKLY;BYSETPOS=1",
+	} {
+		_, err := Parse(value, loc)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestString(t *testing.T) {
+	loc := adelaide(t)
+	rule, err := Parse("freq=weekly;byday=mo,we;interval=2;until=20240630T000000Z", loc)
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20240630T000000Z", rule.String())
+
+	again, err := Parse(rule.String(), loc)
+	require.NoError(t, err)
+	assert.Equal(t, rule, again)
+}
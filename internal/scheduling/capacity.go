@@ -0,0 +1,148 @@
+package scheduling
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Conflict is a booking that breaks one or more of the worker's rules
+type Conflict struct {
+	ShiftID string    `json:"shift_id"`
+	Start   time.Time `json:"start_time"`
+	End     time.Time `json:"end_time"`
+	Reasons []Reason  `json:"reasons"`
+}
+
+// Capacity compares the hours a worker has available in a week with the hours booked and
+// the hours they would like to work
+type Capacity struct {
+	WeekStart      string  `json:"week_start"` // local Monday, YYYY-MM-DD
+	AvailableHours float64 `json:"available_hours"`
+	BookedHours    float64 `json:"booked_hours"`
+	PreferredHours float64 `json:"preferred_hours"`
+	MaxHours       float64 `json:"max_hours"`
+	RemainingHours float64 `json:"remaining_hours"` // available hours not yet booked
+	Utilization    float64 `json:"utilization"`     // booked as a percentage of available
+}
+
+// Add folds another worker's capacity for the same week into a team total
+func (c *Capacity) Add(other Capacity) {
+	c.AvailableHours += other.AvailableHours
+	c.BookedHours += other.BookedHours
+	c.PreferredHours += other.PreferredHours
+	c.MaxHours += other.MaxHours
+	c.RemainingHours += other.RemainingHours
+	c.Utilization = utilization(c.BookedHours, c.AvailableHours)
+}
+
+// Conflicts checks each booking starting between from and to against the worker's
+// availability, time off, other bookings and fatigue limits, returning those that break
+// any of them in start order
+func (w *Worker) Conflicts(from, to time.Time, loc *time.Location) []Conflict {
+	bookings := append([]Booking(nil), w.Bookings...)
+	sort.SliceStable(bookings, func(i, j int) bool {
+		return bookings[i].Start.Before(bookings[j].Start)
+	})
+
+	conflicts := []Conflict{}
+	for _, booking := range bookings {
+		if booking.Start.Before(from) || !booking.Start.Before(to) {
+			continue
+		}
+		shift := Shift{ID: booking.ShiftID, ParticipantID: booking.ParticipantID, Start: booking.Start, End: booking.End, Point: booking.Point}
+		if reasons := w.Check(shift, loc); len(reasons) > 0 {
+			conflicts = append(conflicts, Conflict{ShiftID: booking.ShiftID, Start: booking.Start, End: booking.End, Reasons: reasons})
+		}
+	}
+	return conflicts
+}
+
+// AvailableHours returns the hours the worker's weekly pattern makes available on the local
+// day starting at day, less any approved time off and capped at the day's limit. A worker
+// with no pattern has nothing recorded and gets 0.
+func (w *Worker) AvailableHours(day time.Time) float64 {
+	var free [24 * 60]bool
+	for _, window := range w.Availability {
+		if window.Weekday == day.Weekday() && window.Available {
+			for m := window.Start; m < window.end(); m++ {
+				free[m] = true
+			}
+		}
+	}
+	for _, window := range w.Availability {
+		if window.Weekday == day.Weekday() && !window.Available {
+			for m := window.Start; m < window.end(); m++ {
+				free[m] = false
+			}
+		}
+	}
+
+	next := day.AddDate(0, 0, 1)
+	for _, off := range w.TimeOff {
+		if !off.Start.Before(next) || !off.End.After(day) {
+			continue
+		}
+		from, to := 0, len(free)
+		if off.Start.After(day) {
+			from = int(math.Min(off.Start.Sub(day).Minutes(), float64(len(free))))
+		}
+		if off.End.Before(next) {
+			to = int(math.Min(off.End.Sub(day).Minutes(), float64(len(free))))
+		}
+		for m := from; m < to; m++ {
+			free[m] = false
+		}
+	}
+
+	count := 0
+	for _, minute := range free {
+		if minute {
+			count++
+		}
+	}
+	hours := float64(count) / 60
+	if limit := w.dailyLimit(day.Weekday()); limit > 0 && hours > limit {
+		hours = limit
+	}
+	return hours
+}
+
+// BookedHours totals the hours of bookings starting between from and to
+func (w *Worker) BookedHours(from, to time.Time) float64 {
+	hours := 0.0
+	for _, booking := range w.Bookings {
+		if !booking.Start.Before(from) && booking.Start.Before(to) {
+			hours += booking.End.Sub(booking.Start).Hours()
+		}
+	}
+	return hours
+}
+
+// WeekCapacity returns the worker's capacity for the Monday to Sunday week containing t,
+// taken in t's location
+func (w *Worker) WeekCapacity(t time.Time) Capacity {
+	weekStart := startOfWeek(t)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	capacity := Capacity{WeekStart: weekStart.Format("2006-01-02")}
+	for day := weekStart; day.Before(weekEnd); day = day.AddDate(0, 0, 1) {
+		capacity.AvailableHours += w.AvailableHours(day)
+	}
+	capacity.BookedHours = w.BookedHours(weekStart, weekEnd)
+	if p := w.Preferences; p != nil {
+		capacity.PreferredHours = p.PreferredHoursPerWeek
+		capacity.MaxHours = p.MaxHoursPerWeek
+	}
+	capacity.RemainingHours = math.Max(capacity.AvailableHours-capacity.BookedHours, 0)
+	capacity.Utilization = utilization(capacity.BookedHours, capacity.AvailableHours)
+	return capacity
+}
+
+// utilization returns booked hours as a percentage of available hours, to one decimal place
+func utilization(booked, available float64) float64 {
+	if available <= 0 {
+		return 0
+	}
+	return math.Round(booked/available*1000) / 10
+}
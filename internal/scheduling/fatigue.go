@@ -0,0 +1,154 @@
+package scheduling
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
+)
+
+// How an organization enforces a fatigue rule
+const (
+	EnforceBlock = "block" // the worker cannot take the shift
+	EnforceWarn  = "warn"  // the worker can take the shift, with a warning
+)
+
+// FatigueRules are the reason codes of the rules that keep workers rested
+var FatigueRules = []string{ReasonRestPeriod, ReasonDailyHours, ReasonWeeklyHours, ReasonConsecutiveDays}
+
+// travelSpeedKmh is the average speed assumed when driving between shifts, slow enough to
+// allow for roads not running straight
+const travelSpeedKmh = 40.0
+
+// Rules says how an organization enforces each fatigue rule, by reason code. Rules that
+// aren't listed block.
+type Rules map[string]string
+
+// Enforcement returns how a fatigue rule is enforced
+func (r Rules) Enforcement(rule string) string {
+	if r[rule] == EnforceWarn {
+		return EnforceWarn
+	}
+	return EnforceBlock
+}
+
+// Violation is a fatigue rule a shift would break
+type Violation struct {
+	Rule          string  `json:"rule"`        // the reason code, such as MAX_WEEKLY_HOURS
+	Enforcement   string  `json:"enforcement"` // block or warn
+	Message       string  `json:"message"`
+	Value         float64 `json:"value"` // hours worked or rested, or days worked in a row
+	Limit         float64 `json:"limit"`
+	ShiftID       string  `json:"shift_id,omitempty"`       // the neighbouring shift, for rest periods
+	TravelMinutes int     `json:"travel_minutes,omitempty"` // driving from the neighbouring shift, included in the limit
+}
+
+// Blocks reports whether the violation stops the worker taking the shift
+func (v Violation) Blocks() bool {
+	return v.Enforcement == EnforceBlock
+}
+
+// Fatigue returns every fatigue rule the worker would break by taking the shift: too little
+// rest before or after another shift, allowing for the drive between them, or too many hours
+// in the day or week or days worked in a row.
+func (w *Worker) Fatigue(shift Shift, loc *time.Location, rules Rules) []Violation {
+	violations := []Violation{}
+	start := shift.Start.In(loc)
+	bookings := w.otherBookings(shift.ID)
+
+	var minRest time.Duration
+	if p := w.Preferences; p != nil {
+		minRest = time.Duration(p.MinHoursBetweenShifts * float64(time.Hour))
+	}
+	for _, booking := range bookings {
+		var gap time.Duration
+		switch {
+		case !booking.End.After(shift.Start):
+			gap = shift.Start.Sub(booking.End)
+		case !booking.Start.Before(shift.End):
+			gap = booking.Start.Sub(shift.End)
+		default:
+			continue // overlapping, a schedule conflict rather than a rest period
+		}
+		travel := travelTime(booking.Point, shift.Point)
+		needed := minRest + travel
+		if gap >= needed {
+			continue
+		}
+		needs := formatDuration(minRest)
+		switch {
+		case travel > 0 && minRest > 0:
+			needs += " and " + formatDuration(travel) + " to travel"
+		case travel > 0:
+			needs = formatDuration(travel) + " to travel"
+		}
+		message := fmt.Sprintf("Only %s between this and the shift at %s, needs %s", formatDuration(gap),
+			booking.Start.In(loc).Format("2006-01-02 15:04"), needs)
+		violations = append(violations, Violation{
+			Rule:          ReasonRestPeriod,
+			Enforcement:   rules.Enforcement(ReasonRestPeriod),
+			Message:       message,
+			Value:         gap.Hours(),
+			Limit:         needed.Hours(),
+			ShiftID:       booking.ShiftID,
+			TravelMinutes: int(travel.Minutes()),
+		})
+	}
+
+	if limit := w.dailyLimit(start.Weekday()); limit > 0 {
+		if hours := w.hoursOn(start, loc, bookings) + shift.Hours(); hours > limit {
+			violations = append(violations, Violation{
+				Rule:        ReasonDailyHours,
+				Enforcement: rules.Enforcement(ReasonDailyHours),
+				Message:     fmt.Sprintf("Would work %s on %s, limit is %s", formatHours(hours), start.Format("2006-01-02"), formatHours(limit)),
+				Value:       hours,
+				Limit:       limit,
+			})
+		}
+	}
+
+	if p := w.Preferences; p != nil && p.MaxHoursPerWeek > 0 {
+		if hours := w.hoursInWeek(start, loc, bookings) + shift.Hours(); hours > p.MaxHoursPerWeek {
+			violations = append(violations, Violation{
+				Rule:        ReasonWeeklyHours,
+				Enforcement: rules.Enforcement(ReasonWeeklyHours),
+				Message:     fmt.Sprintf("Would work %s in the week, limit is %s", formatHours(hours), formatHours(p.MaxHoursPerWeek)),
+				Value:       hours,
+				Limit:       p.MaxHoursPerWeek,
+			})
+		}
+	}
+
+	if p := w.Preferences; p != nil && p.MaxConsecutiveDays > 0 {
+		if days := consecutiveDays(start, loc, bookings); days > p.MaxConsecutiveDays {
+			violations = append(violations, Violation{
+				Rule:        ReasonConsecutiveDays,
+				Enforcement: rules.Enforcement(ReasonConsecutiveDays),
+				Message:     fmt.Sprintf("Would work %d days in a row, limit is %d", days, p.MaxConsecutiveDays),
+				Value:       float64(days),
+				Limit:       float64(p.MaxConsecutiveDays),
+			})
+		}
+	}
+
+	return violations
+}
+
+// travelTime estimates the drive between two shifts, rounded up to the minute, or 0 when
+// either hasn't been placed
+func travelTime(from, to *geo.Point) time.Duration {
+	if from == nil || to == nil {
+		return 0
+	}
+	minutes := math.Ceil(geo.Distance(*from, *to) / travelSpeedKmh * 60)
+	return time.Duration(minutes) * time.Minute
+}
+
+// formatDuration formats a rest period or drive, in minutes when it is under an hour
+func formatDuration(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return formatHours(d.Hours())
+}
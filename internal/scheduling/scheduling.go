@@ -0,0 +1,774 @@
+// Package scheduling matches care workers to open shifts.
+//
+// A worker can only take a shift if they pass every hard constraint: the participant has not
+// blocked them, the shift falls within their weekly availability, they have no approved time
+// off, they are not already booked, they hold every required skill, they meet the
+// participant's required preferences (such as worker gender or a language spoken), the shift
+// is within the distance they will travel from home and it keeps them within their fatigue
+// limits (rest between shifts allowing for the drive between them, daily and weekly hours,
+// consecutive days worked). Organizations can have any fatigue rule only warn instead. Workers
+// who pass are ranked by a score built from soft preferences: continuity of care with the
+// participant, whether the participant has asked for them, the participant's preferred skills
+// and worker attributes, the worker's own shift and location preferences, how far they live
+// from the shift, and cost, which favours workers with room under their preferred weekly hours
+// over those the shift would push into overtime.
+//
+// Weekdays, days and weeks are all taken in the organization's local time. Weeks start on
+// Monday.
+package scheduling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
+)
+
+// Exclusion reason codes
+const (
+	ReasonBlocked          = "BLOCKED_WORKER"       // the participant has asked not to have this worker
+	ReasonCredentials      = "CREDENTIALS_NOT_MET"  // missing or lapsed mandatory credentials
+	ReasonUnavailable      = "UNAVAILABLE"          // outside the worker's weekly availability
+	ReasonTimeOff          = "TIME_OFF"             // approved leave or unavailability
+	ReasonScheduleConflict = "SCHEDULE_CONFLICT"    // already booked at the same time
+	ReasonMissingSkill     = "MISSING_SKILL"        // a required skill is missing or expired
+	ReasonRequirement      = "REQUIREMENT_NOT_MET"  // the participant requires something else of their worker
+	ReasonTooFar           = "TOO_FAR"              // further from home than the worker will travel
+	ReasonRestPeriod       = "INSUFFICIENT_REST"    // too close to another shift
+	ReasonDailyHours       = "MAX_DAILY_HOURS"      // over the worker's daily hours
+	ReasonWeeklyHours      = "MAX_WEEKLY_HOURS"     // over the worker's weekly hours
+	ReasonConsecutiveDays  = "MAX_CONSECUTIVE_DAYS" // too many days worked in a row
+)
+
+// Location preference levels
+const (
+	LocationPreferred  = "preferred"
+	LocationAcceptable = "acceptable"
+	LocationAvoid      = "avoid"
+)
+
+// A participant's relationship with a worker
+const (
+	RelationshipPreferred = "preferred"
+	RelationshipBlocked   = "blocked"
+)
+
+// Participant requirement kinds
+const (
+	RequireSkill     = "skill"      // holds a skill, by name or category
+	RequireGender    = "gender"     // is of a given gender
+	RequireLanguage  = "language"   // speaks a language
+	RequireNonSmoker = "non_smoker" // does not smoke
+	RequireNoPets    = "no_pets"    // has no pets, for participants with allergies
+)
+
+// Soft preference weights
+const (
+	continuityPoints   = 3.0  // per past shift with the participant
+	requirementPoints  = 4.0  // the worker meets one of the participant's preferences
+	continuityMaxShift = 10   // past shifts counted towards continuity
+	preferredPoints    = 10.0 // the participant has asked for this worker
+	shiftTypePoints    = 5.0  // the worker prefers this type of shift
+	unwillingPoints    = -10  // weekend, evening or early morning work the worker would rather not do
+	preferredLocation  = 5.0  // the shift is somewhere the worker prefers
+	avoidedLocation    = -10  // the shift is somewhere the worker avoids
+	travelPoints       = -0.2 // per km between the worker's home and the shift
+	withinHoursPoints  = 2.0  // the shift fits within the worker's preferred weekly hours
+	overtimePoints     = -2.0 // per hour over the worker's preferred weekly hours
+	balancePoints      = -0.1 // per hour already booked in the week, to spread work across the team
+)
+
+// Local hours used for weekend, evening and early morning preferences
+const (
+	eveningStartHour       = 20
+	earlyMorningBeforeHour = 7
+)
+
+// Shift is a shift to be filled
+type Shift struct {
+	ID             string
+	ParticipantID  string
+	ServiceType    string
+	Start          time.Time
+	End            time.Time
+	Suburb         string // where the shift takes place, matched against location preferences
+	Postcode       string
+	Point          *geo.Point // where the shift takes place, nil when it couldn't be placed
+	RequiredSkills []string   // skill names or categories the worker must hold
+	Requirements   []Requirement
+}
+
+// Requirement is something the participant needs, or would like, from their worker
+type Requirement struct {
+	Kind     string // skill, gender, language, non_smoker, no_pets
+	Value    string // the skill, gender or language
+	Required bool   // a hard constraint; otherwise meeting it adds to the worker's score
+}
+
+// Match is how a worker measures up against one of the participant's requirements
+type Match struct {
+	Kind     string `json:"kind"`
+	Value    string `json:"value,omitempty"`
+	Required bool   `json:"required"`
+	Met      bool   `json:"met"`
+	Detail   string `json:"detail"`
+}
+
+// Hours returns the length of the shift in hours
+func (s Shift) Hours() float64 {
+	return s.End.Sub(s.Start).Hours()
+}
+
+// Window is one entry in a worker's weekly availability pattern. Start and End are minutes
+// from local midnight; an End at or before Start runs to midnight.
+type Window struct {
+	Weekday        time.Weekday
+	Start          int
+	End            int
+	Available      bool    // false marks a time the worker cannot work
+	MaxHoursPerDay float64 // 0 for no limit
+}
+
+func (w Window) end() int {
+	if w.End <= w.Start {
+		return 24 * 60
+	}
+	return w.End
+}
+
+// Period is a span of time, such as approved leave
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Skill is a skill or certification held by a worker
+type Skill struct {
+	Name      string
+	Category  string
+	ExpiresAt *time.Time
+}
+
+// Location is a suburb or postcode the worker has a preference about
+type Location struct {
+	Value string
+	Level string // preferred, acceptable, avoid
+}
+
+// Booking is a shift the worker already has
+type Booking struct {
+	ShiftID       string
+	ParticipantID string
+	Start         time.Time
+	End           time.Time
+	Point         *geo.Point // where the shift takes place, for the drive to and from it
+}
+
+// Preferences are a worker's limits and shift preferences. Zero values are not enforced.
+type Preferences struct {
+	MaxHoursPerWeek       float64
+	PreferredHoursPerWeek float64
+	MaxConsecutiveDays    int
+	MinHoursBetweenShifts float64
+	MaxTravelKm           float64 // from home to the shift
+	AvoidWeekends         bool
+	AvoidEvenings         bool
+	AvoidEarlyMornings    bool
+	ShiftTypes            []string
+}
+
+// Profile is what a worker has told us about themselves that participants may have
+// preferences about
+type Profile struct {
+	Gender    string
+	Languages []string
+	Smoker    bool
+	HasPets   bool
+}
+
+// Worker is a care worker and everything the scheduler knows about them
+type Worker struct {
+	ID           string
+	Name         string
+	Availability []Window     // empty when no pattern is recorded, which places no restriction
+	TimeOff      []Period     // approved leave and unavailability
+	Skills       []Skill      // active skills; expiry is checked against the shift date
+	Preferences  *Preferences // nil when none are recorded
+	Profile      *Profile     // nil when none is recorded, which meets no requirement about it
+	Locations    []Location
+	Home         *geo.Point        // nil when the worker's home address hasn't been placed
+	Bookings     []Booking         // shifts already assigned, including a few weeks either side
+	History      map[string]int    // past shifts with each participant
+	Relations    map[string]string // preferred or blocked, by participant ID
+	Lapse        *Lapse            // nil while the worker meets the organization's mandatory credentials
+}
+
+// Lapse is when a worker stops meeting the organization's mandatory credentials
+type Lapse struct {
+	From    time.Time // shifts starting from then can't be given to the worker
+	Message string
+}
+
+// Reason explains why a worker cannot take a shift
+type Reason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Factor is one part of a candidate's score
+type Factor struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+	Detail string  `json:"detail"`
+}
+
+// Candidate is a worker who can take a shift, with their score and the fatigue rules the
+// organization only warns about that the shift would break
+type Candidate struct {
+	WorkerID string      `json:"worker_id"`
+	Name     string      `json:"name"`
+	Score    float64     `json:"score"`
+	Factors  []Factor    `json:"factors"`
+	Warnings []Violation `json:"warnings,omitempty"`
+}
+
+// Exclusion is a worker who cannot take a shift and every reason why
+type Exclusion struct {
+	WorkerID string   `json:"worker_id"`
+	Name     string   `json:"name"`
+	Reasons  []Reason `json:"reasons"`
+}
+
+// Assignment is a worker chosen for a shift by Solve
+type Assignment struct {
+	ShiftID  string  `json:"shift_id"`
+	WorkerID string  `json:"worker_id"`
+	Name     string  `json:"name"`
+	Score    float64 `json:"score"`
+}
+
+// Unfilled is a shift Solve could not fill, with why each worker was excluded
+type Unfilled struct {
+	ShiftID    string      `json:"shift_id"`
+	Exclusions []Exclusion `json:"exclusions"`
+}
+
+// Rank checks every worker against the shift under the organization's fatigue rules and
+// returns those who can take it, best first, and those who cannot
+func Rank(shift Shift, workers []*Worker, loc *time.Location, rules Rules) ([]Candidate, []Exclusion) {
+	candidates := []Candidate{}
+	exclusions := []Exclusion{}
+	for _, worker := range workers {
+		reasons, warnings := worker.Evaluate(shift, loc, rules)
+		if len(reasons) > 0 {
+			exclusions = append(exclusions, Exclusion{WorkerID: worker.ID, Name: worker.Name, Reasons: reasons})
+			continue
+		}
+		score, factors := worker.Score(shift, loc)
+		candidates = append(candidates, Candidate{WorkerID: worker.ID, Name: worker.Name, Score: score, Factors: factors, Warnings: warnings})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].Name != candidates[j].Name {
+			return candidates[i].Name < candidates[j].Name
+		}
+		return candidates[i].WorkerID < candidates[j].WorkerID
+	})
+	sort.SliceStable(exclusions, func(i, j int) bool {
+		return exclusions[i].Name < exclusions[j].Name
+	})
+	return candidates, exclusions
+}
+
+// Solve fills as many shifts as it can. It works greedily, always taking the remaining shift
+// with the fewest eligible workers next and giving it to the best of them, so hard to fill
+// shifts are not starved by easy ones. Each assignment is added to the worker's bookings, so
+// later shifts are checked against it.
+func Solve(shifts []Shift, workers []*Worker, loc *time.Location, rules Rules) ([]Assignment, []Unfilled) {
+	assignments := []Assignment{}
+	unfilled := []Unfilled{}
+
+	remaining := append([]Shift(nil), shifts...)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Start.Before(remaining[j].Start)
+	})
+	byID := map[string]*Worker{}
+	for _, worker := range workers {
+		byID[worker.ID] = worker
+	}
+
+	for len(remaining) > 0 {
+		next := -1
+		var best []Candidate
+		var excluded []Exclusion
+		for i, shift := range remaining {
+			candidates, exclusions := Rank(shift, workers, loc, rules)
+			if next == -1 || len(candidates) < len(best) {
+				next, best, excluded = i, candidates, exclusions
+			}
+			if len(candidates) == 0 {
+				break
+			}
+		}
+
+		shift := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		if len(best) == 0 {
+			unfilled = append(unfilled, Unfilled{ShiftID: shift.ID, Exclusions: excluded})
+			continue
+		}
+
+		chosen := best[0]
+		worker := byID[chosen.WorkerID]
+		worker.Bookings = append(worker.Bookings, Booking{
+			ShiftID:       shift.ID,
+			ParticipantID: shift.ParticipantID,
+			Start:         shift.Start,
+			End:           shift.End,
+			Point:         shift.Point,
+		})
+		assignments = append(assignments, Assignment{
+			ShiftID:  shift.ID,
+			WorkerID: chosen.WorkerID,
+			Name:     chosen.Name,
+			Score:    chosen.Score,
+		})
+	}
+	return assignments, unfilled
+}
+
+// Check returns every hard constraint the worker fails for the shift, with every fatigue rule
+// blocking
+func (w *Worker) Check(shift Shift, loc *time.Location) []Reason {
+	reasons, _ := w.Evaluate(shift, loc, nil)
+	return reasons
+}
+
+// Evaluate checks the worker against the shift, enforcing fatigue rules as the organization
+// has chosen. It returns every hard constraint the worker fails, including the fatigue rules
+// that block, and every fatigue rule the shift would break.
+func (w *Worker) Evaluate(shift Shift, loc *time.Location, rules Rules) ([]Reason, []Violation) {
+	reasons := []Reason{}
+	start, end := shift.Start.In(loc), shift.End.In(loc)
+
+	if w.Relations[shift.ParticipantID] == RelationshipBlocked {
+		reasons = append(reasons, Reason{Code: ReasonBlocked, Message: "The participant has asked not to have this worker"})
+	}
+
+	if w.Lapse != nil && !shift.Start.Before(w.Lapse.From) {
+		reasons = append(reasons, Reason{Code: ReasonCredentials, Message: w.Lapse.Message})
+	}
+
+	if len(w.Availability) > 0 {
+		for _, segment := range daySegments(start, end) {
+			if !w.availableFor(segment) {
+				reasons = append(reasons, Reason{
+					Code: ReasonUnavailable,
+					Message: fmt.Sprintf("Not available %s %s-%s", segment.Start.Weekday(),
+						segment.Start.Format("15:04"), clock(segment.End)),
+				})
+			}
+		}
+	}
+
+	for _, off := range w.TimeOff {
+		if off.Start.Before(shift.End) && off.End.After(shift.Start) {
+			reasons = append(reasons, Reason{
+				Code:    ReasonTimeOff,
+				Message: fmt.Sprintf("On approved leave from %s to %s", off.Start.In(loc).Format("2006-01-02 15:04"), off.End.In(loc).Format("2006-01-02 15:04")),
+			})
+			break
+		}
+	}
+
+	bookings := w.otherBookings(shift.ID)
+	for _, booking := range bookings {
+		if booking.Start.Before(shift.End) && booking.End.After(shift.Start) {
+			reasons = append(reasons, Reason{
+				Code:    ReasonScheduleConflict,
+				Message: fmt.Sprintf("Already booked %s-%s", booking.Start.In(loc).Format("2006-01-02 15:04"), booking.End.In(loc).Format("15:04")),
+			})
+		}
+	}
+
+	for _, required := range shift.RequiredSkills {
+		if message := w.skillGap(required, start); message != "" {
+			reasons = append(reasons, Reason{Code: ReasonMissingSkill, Message: message})
+		}
+	}
+
+	for _, match := range w.Matches(shift) {
+		if !match.Required || match.Met {
+			continue
+		}
+		code := ReasonRequirement
+		if match.Kind == RequireSkill {
+			code = ReasonMissingSkill
+		}
+		reasons = append(reasons, Reason{Code: code, Message: match.Detail})
+	}
+
+	if p := w.Preferences; p != nil && p.MaxTravelKm > 0 {
+		if km, ok := w.distance(shift); ok && km > p.MaxTravelKm {
+			reasons = append(reasons, Reason{
+				Code:    ReasonTooFar,
+				Message: fmt.Sprintf("Lives %.1fkm away, travels up to %.0fkm", km, p.MaxTravelKm),
+			})
+		}
+	}
+
+	violations := w.Fatigue(shift, loc, rules)
+	for _, violation := range violations {
+		if violation.Blocks() {
+			reasons = append(reasons, Reason{Code: violation.Rule, Message: violation.Message})
+		}
+	}
+
+	return reasons, violations
+}
+
+// Score rates a worker for a shift they are eligible for. Higher is better.
+func (w *Worker) Score(shift Shift, loc *time.Location) (float64, []Factor) {
+	factors := []Factor{}
+	start, end := shift.Start.In(loc), shift.End.In(loc)
+
+	if past := w.History[shift.ParticipantID]; past > 0 {
+		counted := past
+		if counted > continuityMaxShift {
+			counted = continuityMaxShift
+		}
+		factors = append(factors, Factor{
+			Name:   "continuity",
+			Points: float64(counted) * continuityPoints,
+			Detail: fmt.Sprintf("Has worked %d shifts with this participant", past),
+		})
+	}
+
+	if w.Relations[shift.ParticipantID] == RelationshipPreferred {
+		factors = append(factors, Factor{Name: "preferred_worker", Points: preferredPoints, Detail: "The participant has asked for this worker"})
+	}
+
+	for _, match := range w.Matches(shift) {
+		if !match.Required && match.Met {
+			factors = append(factors, Factor{Name: "participant_preference", Points: requirementPoints, Detail: match.Detail})
+		}
+	}
+
+	if p := w.Preferences; p != nil {
+		for _, shiftType := range p.ShiftTypes {
+			if strings.EqualFold(shiftType, shift.ServiceType) {
+				factors = append(factors, Factor{Name: "shift_type", Points: shiftTypePoints, Detail: "Prefers " + shift.ServiceType + " shifts"})
+				break
+			}
+		}
+		if p.AvoidWeekends && touchesWeekend(start, end) {
+			factors = append(factors, Factor{Name: "weekend", Points: unwillingPoints, Detail: "Prefers not to work weekends"})
+		}
+		if p.AvoidEvenings && touchesEvening(start, end) {
+			factors = append(factors, Factor{Name: "evening", Points: unwillingPoints, Detail: "Prefers not to work evenings"})
+		}
+		if p.AvoidEarlyMornings && touchesEarlyMorning(start, end) {
+			factors = append(factors, Factor{Name: "early_morning", Points: unwillingPoints, Detail: "Prefers not to work early mornings"})
+		}
+	}
+
+	for _, location := range w.Locations {
+		if location.Value == "" || !(strings.EqualFold(location.Value, shift.Suburb) || strings.EqualFold(location.Value, shift.Postcode)) {
+			continue
+		}
+		switch location.Level {
+		case LocationPreferred:
+			factors = append(factors, Factor{Name: "location", Points: preferredLocation, Detail: "Prefers working in " + location.Value})
+		case LocationAvoid:
+			factors = append(factors, Factor{Name: "location", Points: avoidedLocation, Detail: "Avoids working in " + location.Value})
+		}
+		break
+	}
+
+	if km, ok := w.distance(shift); ok {
+		factors = append(factors, Factor{Name: "travel", Points: km * travelPoints, Detail: fmt.Sprintf("Lives %.1fkm away", km)})
+	}
+
+	weekHours := w.hoursInWeek(start, loc, w.otherBookings(shift.ID))
+	if p := w.Preferences; p != nil && p.PreferredHoursPerWeek > 0 {
+		over := weekHours + shift.Hours() - p.PreferredHoursPerWeek
+		if over > 0 {
+			factors = append(factors, Factor{
+				Name:   "cost",
+				Points: over * overtimePoints,
+				Detail: fmt.Sprintf("%s over preferred weekly hours", formatHours(over)),
+			})
+		} else {
+			factors = append(factors, Factor{Name: "cost", Points: withinHoursPoints, Detail: "Within preferred weekly hours"})
+		}
+	}
+	if weekHours > 0 {
+		factors = append(factors, Factor{
+			Name:   "balance",
+			Points: weekHours * balancePoints,
+			Detail: fmt.Sprintf("Already has %s booked this week", formatHours(weekHours)),
+		})
+	}
+
+	score := 0.0
+	for _, factor := range factors {
+		score += factor.Points
+	}
+	return score, factors
+}
+
+// Matches checks the worker against each of the participant's requirements for the shift
+func (w *Worker) Matches(shift Shift) []Match {
+	matches := make([]Match, 0, len(shift.Requirements))
+	for _, requirement := range shift.Requirements {
+		met, detail := w.meets(requirement, shift.Start)
+		matches = append(matches, Match{
+			Kind:     requirement.Kind,
+			Value:    requirement.Value,
+			Required: requirement.Required,
+			Met:      met,
+			Detail:   detail,
+		})
+	}
+	return matches
+}
+
+// meets reports whether the worker meets a requirement at a time, and explains why
+func (w *Worker) meets(requirement Requirement, at time.Time) (bool, string) {
+	if requirement.Kind == RequireSkill {
+		if gap := w.skillGap(requirement.Value, at); gap != "" {
+			return false, gap
+		}
+		return true, "Holds " + requirement.Value
+	}
+
+	p := w.Profile
+	switch requirement.Kind {
+	case RequireGender:
+		if p == nil || p.Gender == "" {
+			return false, "Gender not recorded"
+		}
+		if !strings.EqualFold(p.Gender, requirement.Value) {
+			return false, "Not " + requirement.Value
+		}
+		return true, "Is " + p.Gender
+	case RequireLanguage:
+		if p != nil {
+			for _, language := range p.Languages {
+				if strings.EqualFold(language, requirement.Value) {
+					return true, "Speaks " + language
+				}
+			}
+		}
+		return false, "Does not speak " + requirement.Value
+	case RequireNonSmoker:
+		if p == nil {
+			return false, "Smoking status not recorded"
+		}
+		if p.Smoker {
+			return false, "Smokes"
+		}
+		return true, "Non-smoker"
+	case RequireNoPets:
+		if p == nil {
+			return false, "Pets not recorded"
+		}
+		if p.HasPets {
+			return false, "Has pets"
+		}
+		return true, "Has no pets"
+	}
+	return false, "Unknown requirement: " + requirement.Kind
+}
+
+// distance returns how far the worker lives from the shift in kilometres, if both have been
+// placed
+func (w *Worker) distance(shift Shift) (float64, bool) {
+	if w.Home == nil || shift.Point == nil {
+		return 0, false
+	}
+	return geo.Distance(*w.Home, *shift.Point), true
+}
+
+// availableFor reports whether a same-day stretch of a shift is covered by an available
+// window and clear of unavailable ones
+func (w *Worker) availableFor(segment Period) bool {
+	from := segment.Start.Hour()*60 + segment.Start.Minute()
+	to := minutes(segment.End)
+	covered := false
+	for _, window := range w.Availability {
+		if window.Weekday != segment.Start.Weekday() {
+			continue
+		}
+		if !window.Available {
+			if window.Start < to && window.end() > from {
+				return false
+			}
+			continue
+		}
+		if window.Start <= from && window.end() >= to {
+			covered = true
+		}
+	}
+	return covered
+}
+
+// dailyLimit returns the worker's daily hours limit for a weekday, or 0 for none
+func (w *Worker) dailyLimit(day time.Weekday) float64 {
+	for _, window := range w.Availability {
+		if window.Weekday == day && window.Available && window.MaxHoursPerDay > 0 {
+			return window.MaxHoursPerDay
+		}
+	}
+	return 0
+}
+
+// skillGap returns why the worker does not meet a required skill at a time, or "" if they do
+func (w *Worker) skillGap(required string, at time.Time) string {
+	expired := ""
+	for _, skill := range w.Skills {
+		if !strings.EqualFold(skill.Name, required) && !strings.EqualFold(skill.Category, required) {
+			continue
+		}
+		if skill.ExpiresAt == nil || skill.ExpiresAt.After(at) {
+			return ""
+		}
+		expired = fmt.Sprintf("%s expired on %s", skill.Name, skill.ExpiresAt.Format("2006-01-02"))
+	}
+	if expired != "" {
+		return expired
+	}
+	return "Missing skill: " + required
+}
+
+// otherBookings returns the worker's bookings other than the shift itself
+func (w *Worker) otherBookings(shiftID string) []Booking {
+	bookings := make([]Booking, 0, len(w.Bookings))
+	for _, booking := range w.Bookings {
+		if shiftID == "" || booking.ShiftID != shiftID {
+			bookings = append(bookings, booking)
+		}
+	}
+	return bookings
+}
+
+// hoursOn totals the hours of bookings starting on the same local day as t
+func (w *Worker) hoursOn(t time.Time, loc *time.Location, bookings []Booking) float64 {
+	day := startOfDay(t)
+	hours := 0.0
+	for _, booking := range bookings {
+		if startOfDay(booking.Start.In(loc)).Equal(day) {
+			hours += booking.End.Sub(booking.Start).Hours()
+		}
+	}
+	return hours
+}
+
+// hoursInWeek totals the hours of bookings starting in the same Monday to Sunday week as t
+func (w *Worker) hoursInWeek(t time.Time, loc *time.Location, bookings []Booking) float64 {
+	weekStart := startOfWeek(t)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	hours := 0.0
+	for _, booking := range bookings {
+		if !booking.Start.Before(weekStart) && booking.Start.Before(weekEnd) {
+			hours += booking.End.Sub(booking.Start).Hours()
+		}
+	}
+	return hours
+}
+
+// consecutiveDays counts the run of days worked that would include the local day of t
+func consecutiveDays(t time.Time, loc *time.Location, bookings []Booking) int {
+	worked := map[string]bool{}
+	for _, booking := range bookings {
+		worked[booking.Start.In(loc).Format("2006-01-02")] = true
+	}
+	day := startOfDay(t)
+	days := 1
+	for d := day.AddDate(0, 0, -1); worked[d.Format("2006-01-02")]; d = d.AddDate(0, 0, -1) {
+		days++
+	}
+	for d := day.AddDate(0, 0, 1); worked[d.Format("2006-01-02")]; d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+// daySegments splits a local time range at each midnight
+func daySegments(start, end time.Time) []Period {
+	segments := []Period{}
+	for start.Before(end) {
+		midnight := startOfDay(start).AddDate(0, 0, 1)
+		if midnight.After(end) {
+			midnight = end
+		}
+		segments = append(segments, Period{Start: start, End: midnight})
+		start = midnight
+	}
+	return segments
+}
+
+func touchesWeekend(start, end time.Time) bool {
+	for _, segment := range daySegments(start, end) {
+		if day := segment.Start.Weekday(); day == time.Saturday || day == time.Sunday {
+			return true
+		}
+	}
+	return false
+}
+
+func touchesEvening(start, end time.Time) bool {
+	for _, segment := range daySegments(start, end) {
+		if minutes(segment.End) > eveningStartHour*60 {
+			return true
+		}
+	}
+	return false
+}
+
+func touchesEarlyMorning(start, end time.Time) bool {
+	for _, segment := range daySegments(start, end) {
+		if segment.Start.Hour() < earlyMorningBeforeHour {
+			return true
+		}
+	}
+	return false
+}
+
+// minutes returns the minutes from local midnight to the end of a segment, with midnight
+// counted as the end of the day
+func minutes(t time.Time) int {
+	if t.Hour() == 0 && t.Minute() == 0 {
+		return 24 * 60
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// clock formats the end of a segment, showing midnight as 24:00
+func clock(t time.Time) string {
+	if minutes(t) == 24*60 {
+		return "24:00"
+	}
+	return t.Format("15:04")
+}
+
+func formatHours(hours float64) string {
+	if hours == float64(int(hours)) {
+		return fmt.Sprintf("%dh", int(hours))
+	}
+	return fmt.Sprintf("%.1fh", hours)
+}
@@ -0,0 +1,462 @@
+package scheduling
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/geo"
+)
+
+var adelaide, _ = time.LoadLocation("Australia/Adelaide")
+
+// at returns a local time on Monday 2 March 2026 plus the given days
+func at(days, hour, minute int) time.Time {
+	return time.Date(2026, 3, 2+days, hour, minute, 0, 0, adelaide)
+}
+
+func shiftAt(id string, days, startHour, hours int) Shift {
+	start := at(days, startHour, 0)
+	return Shift{ID: id, ParticipantID: "jane", ServiceType: "Personal Care", Start: start, End: start.Add(time.Duration(hours) * time.Hour)}
+}
+
+func codes(reasons []Reason) map[string]bool {
+	found := map[string]bool{}
+	for _, reason := range reasons {
+		found[reason.Code] = true
+	}
+	return found
+}
+
+func TestCheckAvailability(t *testing.T) {
+	worker := &Worker{ID: "w", Availability: []Window{
+		{Weekday: time.Monday, Start: 8 * 60, End: 17 * 60, Available: true},
+		{Weekday: time.Monday, Start: 12 * 60, End: 13 * 60, Available: false},
+		{Weekday: time.Tuesday, Start: 20 * 60, End: 0, Available: true},
+		{Weekday: time.Wednesday, Start: 0, End: 7 * 60, Available: true},
+	}}
+
+	tests := []struct {
+		name      string
+		shift     Shift
+		available bool
+	}{
+		{"inside a window", shiftAt("a", 0, 9, 3), true},
+		{"past the end of a window", shiftAt("b", 0, 15, 3), false},
+		{"over an unavailable block", shiftAt("c", 0, 11, 2), false},
+		{"no window that day", shiftAt("d", 3, 9, 2), false},
+		{"overnight across two windows", shiftAt("e", 1, 22, 8), true},
+		{"overnight past the next window", shiftAt("f", 1, 22, 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := !codes(worker.Check(tt.shift, adelaide))[ReasonUnavailable]; got != tt.available {
+				t.Errorf("available = %v, want %v", got, tt.available)
+			}
+		})
+	}
+
+	if reasons := (&Worker{ID: "w"}).Check(shiftAt("g", 3, 9, 2), adelaide); len(reasons) > 0 {
+		t.Errorf("worker without a pattern excluded: %v", reasons)
+	}
+}
+
+func TestCheckHardConstraints(t *testing.T) {
+	expired := at(-30, 0, 0)
+	worker := &Worker{
+		ID:      "w",
+		TimeOff: []Period{{Start: at(4, 0, 0), End: at(5, 0, 0)}},
+		Skills: []Skill{
+			{Name: "First Aid", Category: "certification"},
+			{Name: "Manual Handling", Category: "training", ExpiresAt: &expired},
+		},
+		Preferences: &Preferences{MaxHoursPerWeek: 20, MinHoursBetweenShifts: 10, MaxConsecutiveDays: 3},
+		Bookings: []Booking{
+			{ShiftID: "mon", Start: at(0, 9, 0), End: at(0, 17, 0)},
+			{ShiftID: "tue", Start: at(1, 9, 0), End: at(1, 17, 0)},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		shift Shift
+		want  string
+	}{
+		{"approved leave", shiftAt("a", 4, 9, 2), ReasonTimeOff},
+		{"overlapping booking", shiftAt("b", 0, 16, 2), ReasonScheduleConflict},
+		{"short rest after a booking", shiftAt("c", 0, 22, 2), ReasonRestPeriod},
+		{"over weekly hours", shiftAt("d", 3, 9, 6), ReasonWeeklyHours},
+		{"a third day in a row", shiftAt("e", 2, 9, 2), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := codes(worker.Check(tt.shift, adelaide))
+			if tt.want == "" && len(found) > 0 {
+				t.Errorf("unexpected reasons %v", found)
+			}
+			if tt.want != "" && !found[tt.want] {
+				t.Errorf("reasons %v, want %s", found, tt.want)
+			}
+		})
+	}
+
+	t.Run("too many days in a row", func(t *testing.T) {
+		worker.Bookings = append(worker.Bookings, Booking{ShiftID: "wed", Start: at(2, 9, 0), End: at(2, 11, 0)})
+		if !codes(worker.Check(shiftAt("f", 3, 9, 2), adelaide))[ReasonConsecutiveDays] {
+			t.Error("fourth day in a row allowed")
+		}
+	})
+
+	t.Run("skills", func(t *testing.T) {
+		shift := shiftAt("g", 6, 9, 2)
+		shift.RequiredSkills = []string{"first aid"}
+		if reasons := worker.Check(shift, adelaide); len(reasons) > 0 {
+			t.Errorf("held skill rejected: %v", reasons)
+		}
+		shift.RequiredSkills = []string{"Manual Handling", "Behaviour Support"}
+		reasons := worker.Check(shift, adelaide)
+		if len(reasons) != 2 || reasons[0].Message != "Manual Handling expired on 2026-01-31" || reasons[1].Message != "Missing skill: Behaviour Support" {
+			t.Errorf("reasons = %v", reasons)
+		}
+	})
+
+	t.Run("a shift is not checked against itself", func(t *testing.T) {
+		shift := shiftAt("mon", 0, 9, 8)
+		if codes(worker.Check(shift, adelaide))[ReasonScheduleConflict] {
+			t.Error("shift conflicts with itself")
+		}
+	})
+}
+
+func TestCheckDailyHours(t *testing.T) {
+	worker := &Worker{
+		ID:           "w",
+		Availability: []Window{{Weekday: time.Monday, Start: 6 * 60, End: 22 * 60, Available: true, MaxHoursPerDay: 8}},
+		Bookings:     []Booking{{ShiftID: "am", Start: at(0, 6, 0), End: at(0, 12, 0)}},
+	}
+	if !codes(worker.Check(shiftAt("pm", 0, 13, 3), adelaide))[ReasonDailyHours] {
+		t.Error("daily limit not enforced")
+	}
+	if codes(worker.Check(shiftAt("pm", 0, 13, 2), adelaide))[ReasonDailyHours] {
+		t.Error("shift within the daily limit rejected")
+	}
+}
+
+func TestRank(t *testing.T) {
+	shift := shiftAt("s", 5, 9, 3) // Saturday
+	shift.Suburb = "Glenelg"
+
+	regular := &Worker{ID: "regular", Name: "Regular", History: map[string]int{"jane": 4}}
+	local := &Worker{ID: "local", Name: "Local", Locations: []Location{{Value: "glenelg", Level: LocationPreferred}}}
+	weekdays := &Worker{ID: "weekdays", Name: "Weekdays only", Preferences: &Preferences{ShiftTypes: []string{"Personal Care"}, AvoidWeekends: true}}
+	away := &Worker{ID: "away", Name: "Away", TimeOff: []Period{{Start: at(5, 0, 0), End: at(6, 0, 0)}}}
+
+	candidates, exclusions := Rank(shift, []*Worker{away, weekdays, local, regular}, adelaide, nil)
+	if len(candidates) != 3 {
+		t.Fatalf("candidates = %v", candidates)
+	}
+	order := []string{candidates[0].WorkerID, candidates[1].WorkerID, candidates[2].WorkerID}
+	if order[0] != "regular" || order[1] != "local" || order[2] != "weekdays" {
+		t.Errorf("order = %v", order)
+	}
+	if candidates[0].Score != 12 || candidates[0].Factors[0].Name != "continuity" {
+		t.Errorf("regular = %+v", candidates[0])
+	}
+	// Prefers the shift type but would rather not work weekends
+	if candidates[2].Score != shiftTypePoints+unwillingPoints {
+		t.Errorf("weekdays score = %v", candidates[2].Score)
+	}
+	if len(exclusions) != 1 || exclusions[0].WorkerID != "away" || exclusions[0].Reasons[0].Code != ReasonTimeOff {
+		t.Errorf("exclusions = %v", exclusions)
+	}
+}
+
+func TestScoreCost(t *testing.T) {
+	worker := &Worker{
+		ID:          "w",
+		Preferences: &Preferences{PreferredHoursPerWeek: 10},
+		Bookings:    []Booking{{ShiftID: "mon", Start: at(0, 9, 0), End: at(0, 17, 0)}},
+	}
+	score, factors := worker.Score(shiftAt("tue", 1, 9, 4), adelaide)
+	if len(factors) != 2 || factors[0].Name != "cost" || factors[0].Points != 2*overtimePoints {
+		t.Fatalf("factors = %v", factors)
+	}
+	if want := 2*overtimePoints + 8*balancePoints; math.Abs(score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", score, want)
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	shift := shiftAt("s", 1, 9, 3)
+	shift.Requirements = []Requirement{
+		{Kind: RequireSkill, Value: "PEG Feeding", Required: true},
+		{Kind: RequireGender, Value: "female", Required: true},
+		{Kind: RequireLanguage, Value: "Vietnamese"},
+		{Kind: RequireNonSmoker},
+	}
+
+	trained := []Skill{{Name: "PEG Feeding", Category: "Healthcare"}}
+	match := &Worker{ID: "match", Name: "Match", Skills: trained,
+		Profile: &Profile{Gender: "Female", Languages: []string{"English", "vietnamese"}}}
+	partial := &Worker{ID: "partial", Name: "Partial", Skills: trained, Profile: &Profile{Gender: "female", Smoker: true}}
+	untrained := &Worker{ID: "untrained", Name: "Untrained", Profile: &Profile{Gender: "female"}}
+	unknown := &Worker{ID: "unknown", Name: "Unknown", Skills: trained}
+
+	candidates, exclusions := Rank(shift, []*Worker{unknown, untrained, partial, match}, adelaide, nil)
+	if len(candidates) != 2 || candidates[0].WorkerID != "match" || candidates[1].WorkerID != "partial" {
+		t.Fatalf("candidates = %v", candidates)
+	}
+	if candidates[0].Score != 2*requirementPoints || candidates[1].Score != 0 {
+		t.Errorf("scores = %v, %v", candidates[0].Score, candidates[1].Score)
+	}
+
+	reasons := map[string]map[string]bool{}
+	for _, exclusion := range exclusions {
+		reasons[exclusion.WorkerID] = codes(exclusion.Reasons)
+	}
+	if !reasons["untrained"][ReasonMissingSkill] || reasons["untrained"][ReasonRequirement] {
+		t.Errorf("untrained = %v", reasons["untrained"])
+	}
+	if !reasons["unknown"][ReasonRequirement] || reasons["unknown"][ReasonMissingSkill] {
+		t.Errorf("unknown = %v", reasons["unknown"])
+	}
+
+	matches := partial.Matches(shift)
+	if met := [4]bool{matches[0].Met, matches[1].Met, matches[2].Met, matches[3].Met}; met != [4]bool{true, true, false, false} {
+		t.Errorf("matches = %v", matches)
+	}
+	if matches[3].Detail != "Smokes" {
+		t.Errorf("non smoker detail = %q", matches[3].Detail)
+	}
+}
+
+func TestRelations(t *testing.T) {
+	shift := shiftAt("s", 5, 9, 3)
+	regular := &Worker{ID: "regular", Name: "Regular", History: map[string]int{"jane": 2}}
+	asked := &Worker{ID: "asked", Name: "Asked", Relations: map[string]string{"jane": RelationshipPreferred}}
+	blocked := &Worker{ID: "blocked", Name: "Blocked", History: map[string]int{"jane": 8},
+		Relations: map[string]string{"jane": RelationshipBlocked, "other": RelationshipPreferred}}
+
+	candidates, exclusions := Rank(shift, []*Worker{regular, asked, blocked}, adelaide, nil)
+	if len(candidates) != 2 || candidates[0].WorkerID != "asked" || candidates[0].Score != preferredPoints {
+		t.Fatalf("candidates = %v", candidates)
+	}
+	if len(exclusions) != 1 || exclusions[0].WorkerID != "blocked" || !codes(exclusions[0].Reasons)[ReasonBlocked] {
+		t.Errorf("exclusions = %v", exclusions)
+	}
+
+	// Relations only apply to the participant who has them
+	shift.ParticipantID = "other"
+	if reasons := blocked.Check(shift, adelaide); len(reasons) != 0 {
+		t.Errorf("reasons = %v", reasons)
+	}
+}
+
+func TestCredentialLapse(t *testing.T) {
+	lapsing := &Worker{ID: "lapsing", Name: "Lapsing", Lapse: &Lapse{From: at(3, 0, 0), Message: "First Aid expired on 2026-03-05"}}
+
+	if reasons := lapsing.Check(shiftAt("before", 2, 9, 3), adelaide); len(reasons) != 0 {
+		t.Errorf("reasons before the lapse = %v", reasons)
+	}
+	candidates, exclusions := Rank(shiftAt("after", 3, 9, 3), []*Worker{lapsing}, adelaide, nil)
+	if len(candidates) != 0 || len(exclusions) != 1 || !codes(exclusions[0].Reasons)[ReasonCredentials] {
+		t.Errorf("candidates = %v, exclusions = %v", candidates, exclusions)
+	}
+}
+
+func TestTravelDistance(t *testing.T) {
+	city := geo.Point{Lat: -34.9285, Lng: 138.6007}
+	northAdelaide := geo.Point{Lat: -34.9065, Lng: 138.5930}
+	glenelg := geo.Point{Lat: -34.9803, Lng: 138.5156}
+	victorHarbor := geo.Point{Lat: -35.5520, Lng: 138.6180}
+
+	shift := shiftAt("s", 5, 9, 3)
+	shift.Point = &city
+	nearby := &Worker{ID: "nearby", Name: "Nearby", Home: &northAdelaide}
+	across := &Worker{ID: "across", Name: "Across", Home: &glenelg}
+	farAway := &Worker{ID: "far", Name: "Far", Home: &victorHarbor, Preferences: &Preferences{MaxTravelKm: 30}}
+	unplaced := &Worker{ID: "unplaced", Name: "Unplaced"}
+
+	candidates, exclusions := Rank(shift, []*Worker{across, unplaced, farAway, nearby}, adelaide, nil)
+	if len(candidates) != 3 || candidates[0].WorkerID != "unplaced" || candidates[1].WorkerID != "nearby" || candidates[2].WorkerID != "across" {
+		t.Fatalf("candidates = %v", candidates)
+	}
+	if math.Abs(candidates[2].Score-9.6*travelPoints) > 0.2 {
+		t.Errorf("score = %v", candidates[2].Score)
+	}
+	if len(exclusions) != 1 || exclusions[0].WorkerID != "far" || !codes(exclusions[0].Reasons)[ReasonTooFar] {
+		t.Errorf("exclusions = %v", exclusions)
+	}
+
+	// Shifts that haven't been placed are not held against anyone
+	shift.Point = nil
+	if reasons := farAway.Check(shift, adelaide); len(reasons) != 0 {
+		t.Errorf("reasons = %v", reasons)
+	}
+}
+
+func TestFatigue(t *testing.T) {
+	city := geo.Point{Lat: -34.9285, Lng: 138.6007}
+	glenelg := geo.Point{Lat: -34.9803, Lng: 138.5156}
+
+	worker := &Worker{
+		ID:          "w",
+		Name:        "Worker",
+		Preferences: &Preferences{MaxHoursPerWeek: 10},
+		Bookings: []Booking{
+			{ShiftID: "beach", Start: at(0, 6, 0), End: at(0, 9, 0), Point: &glenelg},
+			{ShiftID: "tue", Start: at(1, 9, 0), End: at(1, 14, 0), Point: &city},
+		},
+	}
+	shift := shiftAt("s", 0, 9, 0)
+	shift.Start, shift.End = at(0, 9, 10), at(0, 11, 10)
+	shift.Point = &city
+
+	// Ten minutes is not long enough to drive in from Glenelg
+	violations := worker.Fatigue(shift, adelaide, nil)
+	if len(violations) != 1 {
+		t.Fatalf("violations = %v", violations)
+	}
+	rest := violations[0]
+	if rest.Rule != ReasonRestPeriod || rest.Enforcement != EnforceBlock || rest.ShiftID != "beach" || rest.TravelMinutes != 15 {
+		t.Errorf("rest = %+v", rest)
+	}
+	if rest.Message != "Only 10m between this and the shift at 2026-03-02 06:00, needs 15m to travel" {
+		t.Errorf("message = %q", rest.Message)
+	}
+
+	// Organizations can choose to only warn about a rule
+	rules := Rules{ReasonRestPeriod: EnforceWarn, ReasonWeeklyHours: EnforceBlock}
+	reasons, violations := worker.Evaluate(shift, adelaide, rules)
+	if len(reasons) != 0 || len(violations) != 1 || violations[0].Blocks() {
+		t.Errorf("reasons = %v, violations = %v", reasons, violations)
+	}
+	candidates, _ := Rank(shift, []*Worker{worker}, adelaide, rules)
+	if len(candidates) != 1 || len(candidates[0].Warnings) != 1 {
+		t.Errorf("candidates = %v", candidates)
+	}
+
+	// Without the drive the shift is only too long for the week
+	shift.Point = nil
+	shift.End = at(0, 14, 10)
+	violations = worker.Fatigue(shift, adelaide, rules)
+	if len(violations) != 1 || violations[0].Rule != ReasonWeeklyHours || violations[0].Value != 13 || violations[0].Limit != 10 {
+		t.Errorf("violations = %+v", violations)
+	}
+	if reasons := worker.Check(shift, adelaide); len(reasons) != 1 || reasons[0].Code != ReasonWeeklyHours {
+		t.Errorf("reasons = %v", reasons)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// Sam needs a break between shifts and only Sam can do the evening shift, so it is filled
+	// first even though Sam would have been the best choice for the morning
+	morning := shiftAt("morning", 0, 9, 3)
+	evening := shiftAt("evening", 0, 18, 3)
+	sam := &Worker{ID: "sam", Name: "Sam", History: map[string]int{"jane": 5}, Preferences: &Preferences{MinHoursBetweenShifts: 8}}
+	alex := &Worker{ID: "alex", Name: "Alex", Availability: []Window{{Weekday: time.Monday, Start: 8 * 60, End: 13 * 60, Available: true}}}
+	night := shiftAt("night", 0, 20, 2)
+
+	assignments, unfilled := Solve([]Shift{morning, evening, night}, []*Worker{sam, alex}, adelaide, nil)
+	got := map[string]string{}
+	for _, assignment := range assignments {
+		got[assignment.ShiftID] = assignment.WorkerID
+	}
+	if got["evening"] != "sam" || got["morning"] != "alex" {
+		t.Errorf("assignments = %v", assignments)
+	}
+	if len(unfilled) != 1 || unfilled[0].ShiftID != "night" || len(unfilled[0].Exclusions) != 2 {
+		t.Errorf("unfilled = %v", unfilled)
+	}
+	if len(sam.Bookings) != 1 {
+		t.Errorf("sam bookings = %v", sam.Bookings)
+	}
+}
+
+func TestAvailableHours(t *testing.T) {
+	worker := &Worker{
+		ID: "w",
+		Availability: []Window{
+			{Weekday: time.Monday, Start: 8 * 60, End: 17 * 60, Available: true},
+			{Weekday: time.Monday, Start: 12 * 60, End: 13 * 60, Available: false},
+			{Weekday: time.Tuesday, Start: 6 * 60, End: 22 * 60, Available: true, MaxHoursPerDay: 10},
+			{Weekday: time.Wednesday, Start: 20 * 60, End: 0, Available: true},
+		},
+		TimeOff: []Period{{Start: at(3, 0, 0), End: at(4, 0, 0)}, {Start: at(2, 22, 0), End: at(2, 23, 30)}},
+	}
+
+	tests := []struct {
+		name string
+		day  int
+		want float64
+	}{
+		{"window less an unavailable block", 0, 8},
+		{"capped at the daily limit", 1, 10},
+		{"window to midnight less partial leave", 2, 2.5},
+		{"no window", 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := worker.AvailableHours(at(tt.day, 0, 0)); got != tt.want {
+				t.Errorf("AvailableHours = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	worker.Availability = append(worker.Availability, Window{Weekday: time.Thursday, Start: 9 * 60, End: 17 * 60, Available: true})
+	if got := worker.AvailableHours(at(3, 0, 0)); got != 0 {
+		t.Errorf("day of leave = %v, want 0", got)
+	}
+}
+
+func TestWeekCapacity(t *testing.T) {
+	worker := &Worker{
+		ID: "w",
+		Availability: []Window{
+			{Weekday: time.Monday, Start: 9 * 60, End: 17 * 60, Available: true},
+			{Weekday: time.Wednesday, Start: 9 * 60, End: 17 * 60, Available: true},
+		},
+		Preferences: &Preferences{MaxHoursPerWeek: 20, PreferredHoursPerWeek: 12},
+		Bookings: []Booking{
+			{ShiftID: "mon", Start: at(0, 9, 0), End: at(0, 13, 0)},
+			{ShiftID: "next", Start: at(7, 9, 0), End: at(7, 13, 0)},
+		},
+	}
+
+	got := worker.WeekCapacity(at(2, 12, 0))
+	want := Capacity{WeekStart: "2026-03-02", AvailableHours: 16, BookedHours: 4, PreferredHours: 12, MaxHours: 20, RemainingHours: 12, Utilization: 25}
+	if got != want {
+		t.Errorf("WeekCapacity = %+v, want %+v", got, want)
+	}
+
+	team := Capacity{WeekStart: got.WeekStart}
+	team.Add(got)
+	team.Add(Capacity{AvailableHours: 4})
+	if team.AvailableHours != 20 || team.Utilization != 20 {
+		t.Errorf("team = %+v", team)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	worker := &Worker{
+		ID:           "w",
+		Availability: []Window{{Weekday: time.Monday, Start: 8 * 60, End: 17 * 60, Available: true}},
+		Preferences:  &Preferences{MinHoursBetweenShifts: 10},
+		Bookings: []Booking{
+			{ShiftID: "late", Start: at(0, 14, 0), End: at(0, 18, 0)},
+			{ShiftID: "early", Start: at(0, 8, 0), End: at(0, 12, 0)},
+			{ShiftID: "tue", Start: at(1, 9, 0), End: at(1, 12, 0)},
+		},
+	}
+
+	conflicts := worker.Conflicts(at(0, 0, 0), at(1, 0, 0), adelaide)
+	if len(conflicts) != 2 || conflicts[0].ShiftID != "early" || conflicts[1].ShiftID != "late" {
+		t.Fatalf("conflicts = %+v", conflicts)
+	}
+	if found := codes(conflicts[0].Reasons); len(found) != 1 || !found[ReasonRestPeriod] {
+		t.Errorf("early reasons = %v", conflicts[0].Reasons)
+	}
+	if found := codes(conflicts[1].Reasons); !found[ReasonUnavailable] || !found[ReasonRestPeriod] {
+		t.Errorf("late reasons = %v", conflicts[1].Reasons)
+	}
+}
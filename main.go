@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -69,6 +70,12 @@ func main() {
 	// Setup routes
 	h.SetupRoutes(router)
 
+	// Mark overdue invoices and send payment reminders in the background
+	h.StartReceivablesJob(time.Hour)
+
+	// Book the occurrences of recurring shift series up to the rolling horizon
+	h.StartShiftSeriesJob(time.Hour)
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// AccountingTestSuite covers exporting invoices and payments to accounting systems
+type AccountingTestSuite struct {
+	extendedTestSuite
+}
+
+// generateInvoice bills a completed shift and returns the invoice ID
+func (suite *AccountingTestSuite) generateInvoice(start time.Time) string {
+	shiftID := suite.createCompletedShift(start, 2, 60)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func (suite *AccountingTestSuite) export(format string) *httptest.ResponseRecorder {
+	return suite.makeAuthenticatedRequest("POST", "/api/v1/accounting/exports", map[string]interface{}{"format": format})
+}
+
+func (suite *AccountingTestSuite) TestAccountingExports() {
+	base := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	first := suite.generateInvoice(base)
+
+	var xeroExportID string
+
+	suite.Run("Xero export writes a sales invoice CSV", func() {
+		w := suite.export("xero")
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		xeroExportID = data["id"].(string)
+		suite.Equal("completed", data["status"])
+		suite.Equal(float64(1), data["invoice_count"])
+		suite.Len(data["records"].([]interface{}), 1)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/accounting/exports/"+xeroExportID+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("text/csv", w.Header().Get("Content-Type"))
+		suite.Contains(w.Body.String(), "Jane Smith,,,,,,,,,,INV-000001,430000001,")
+	})
+
+	suite.Run("Exported invoices are not exported again", func() {
+		w := suite.export("xero")
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("NOTHING_TO_EXPORT", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Payments follow invoices already exported", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+first+"/payment", map[string]interface{}{
+			"amount":    120,
+			"method":    "bank_transfer",
+			"reference": "EFT001",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		// Xero takes no payments so there is still nothing new for it
+		suite.Equal(http.StatusBadRequest, suite.export("xero").Code)
+
+		w = suite.export("myob")
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(float64(1), data["invoice_count"])
+		suite.Equal(float64(1), data["payment_count"])
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/accounting/exports/"+data["id"].(string)+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Equal("application/zip", w.Header().Get("Content-Type"))
+	})
+
+	suite.Run("The HTTP connector must be configured", func() {
+		w := suite.export("http")
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("CONNECTOR_NOT_CONFIGURED", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("The HTTP connector resumes after a failure", func() {
+		second := suite.generateInvoice(base.AddDate(0, 0, 1))
+		suite.Require().NotEmpty(second)
+
+		var mu sync.Mutex
+		received := []string{}
+		reject := "INV-000002"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			number, _ := body["number"].(string)
+			if number == "" {
+				number = body["invoice_number"].(string)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if strings.HasSuffix(r.URL.Path, "/invoices") && number == reject {
+				http.Error(w, "contact is archived", http.StatusUnprocessableEntity)
+				return
+			}
+			received = append(received, r.URL.Path+" "+number)
+			json.NewEncoder(w).Encode(map[string]string{"id": "ext-" + number})
+		}))
+		defer server.Close()
+
+		suite.handler.Config.AccountingURL = server.URL + "/api"
+		defer func() { suite.handler.Config.AccountingURL = "" }()
+
+		w := suite.export("http")
+		suite.Equal(http.StatusBadGateway, w.Code)
+		data := suite.decodeResponse(w)["data"].(map[string]interface{})
+		suite.Equal("partial", data["status"])
+		suite.Equal(float64(1), data["invoice_count"])
+		suite.Equal([]string{"/api/invoices INV-000001"}, received)
+
+		reject = ""
+		w = suite.export("http")
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		data = suite.decodeData(w)
+		suite.Equal("completed", data["status"])
+		suite.Equal([]string{
+			"/api/invoices INV-000001",
+			"/api/invoices INV-000002",
+			"/api/payments INV-000001",
+		}, received)
+
+		record := data["records"].([]interface{})[0].(map[string]interface{})
+		suite.Equal("ext-INV-000002", record["external_id"])
+	})
+
+	suite.Run("List and fetch exports", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/accounting/exports?format=http", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Len(suite.decodeData(w)["exports"].([]interface{}), 2)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/accounting/exports/"+xeroExportID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Equal("xero", suite.decodeData(w)["format"])
+	})
+
+	suite.Run("Care workers cannot create exports", func() {
+		suite.createUser("accounting-worker-id", "worker@accounting.test", "care_worker")
+		token := suite.login("worker@accounting.test")
+		w := suite.makeRequestWithToken(token, "POST", "/api/v1/accounting/exports", map[string]interface{}{"format": "xero"})
+		suite.Equal(http.StatusForbidden, w.Code)
+	})
+}
+
+// TestAccountingSuite runs the accounting export test suite
+func TestAccountingSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(AccountingTestSuite))
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+const exceptionsPath = "/api/v1/worker/availability/exceptions"
+
+// AvailabilityExceptionTestSuite covers workers requesting leave and managers reviewing it
+type AvailabilityExceptionTestSuite struct {
+	extendedTestSuite
+	monday      time.Time
+	workerID    string
+	workerToken string
+	otherID     string
+	otherToken  string
+}
+
+// SetupSuite adds two care workers who can log in
+func (suite *AvailabilityExceptionTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	suite.monday = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	suite.workerID = suite.createUser("leave-worker", "worker@leave.test", "care_worker")
+	suite.otherID = suite.createUser("leave-other", "other@leave.test", "care_worker")
+	suite.workerToken = suite.login("worker@leave.test")
+	suite.otherToken = suite.login("other@leave.test")
+}
+
+// createShift books a worker from the given hour on a day after the first Monday
+func (suite *AvailabilityExceptionTestSuite) createShift(staffID string, day, hour, hours int) string {
+	start := suite.monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	shift := models.Shift{
+		ParticipantID: suite.participantID,
+		StaffID:       &staffID,
+		StartTime:     start,
+		EndTime:       start.Add(time.Duration(hours) * time.Hour),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "scheduled",
+		HourlyRate:    60,
+	}
+	suite.Require().NoError(suite.db.Create(&shift).Error)
+	return shift.ID
+}
+
+// requestException records an exception for the worker on a day after the first Monday
+func (suite *AvailabilityExceptionTestSuite) requestException(day int, fields map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{
+		"exception_date": suite.monday.AddDate(0, 0, day).Format("2006-01-02"),
+		"exception_type": "unavailable",
+		"reason":         "Annual leave",
+	}
+	for key, value := range fields {
+		body[key] = value
+	}
+	w := suite.makeRequestWithToken(suite.workerToken, "POST", exceptionsPath, body)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)["exception"].(map[string]interface{})
+}
+
+func (suite *AvailabilityExceptionTestSuite) staffOf(shiftID string) *string {
+	var shift models.Shift
+	suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+	return shift.StaffID
+}
+
+func (suite *AvailabilityExceptionTestSuite) TestWorkerRequestsLeave() {
+	suite.Run("Requests are validated", func() {
+		date := suite.monday.Format("2006-01-02")
+		for _, body := range []map[string]interface{}{
+			{"exception_date": date, "exception_type": "holiday"},
+			{"exception_date": "next week", "exception_type": "unavailable"},
+			{"exception_date": date, "exception_type": "unavailable", "start_time": "09:00"},
+			{"exception_date": date, "exception_type": "unavailable", "start_time": "12:00", "end_time": "09:00"},
+		} {
+			w := suite.makeRequestWithToken(suite.workerToken, "POST", exceptionsPath, body)
+			suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+		}
+
+		w := suite.makeRequestWithToken(suite.workerToken, "POST", exceptionsPath, map[string]interface{}{
+			"user_id":        suite.otherID,
+			"exception_date": date,
+			"exception_type": "unavailable",
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	exception := suite.requestException(0, map[string]interface{}{"start_time": "09:00", "end_time": "12:00"})
+	suite.Equal(models.ExceptionPending, exception["status"])
+	suite.Equal("09:00", exception["start_time"])
+	suite.Equal("12:00", exception["end_time"])
+	suite.Equal(false, exception["all_day"])
+	exceptionID := exception["id"].(string)
+
+	suite.Run("Managers are told about the request", func() {
+		var notified int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationAvailabilityException, suite.userID).
+			Count(&notified)
+		suite.GreaterOrEqual(notified, int64(1))
+	})
+
+	suite.Run("Workers only see their own exceptions", func() {
+		monday := suite.monday.Format("2006-01-02")
+		w := suite.makeRequestWithToken(suite.workerToken, "GET", exceptionsPath+"?status=pending&start_date="+monday+"&end_date="+monday, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["exceptions"], 1)
+
+		w = suite.makeRequestWithToken(suite.otherToken, "GET", exceptionsPath, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["exceptions"], 0)
+
+		w = suite.makeRequestWithToken(suite.otherToken, "PUT", exceptionsPath+"/"+exceptionID, map[string]interface{}{"reason": "Mine now"})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Workers cannot review exceptions", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "POST", exceptionsPath+"/"+exceptionID+"/approve", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Partial days can become whole days", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "PUT", exceptionsPath+"/"+exceptionID, map[string]interface{}{
+			"start_time": "",
+			"end_time":   "",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		exception := suite.decodeData(w)["exception"].(map[string]interface{})
+		suite.Equal(true, exception["all_day"])
+		suite.Equal("Annual leave", exception["reason"])
+	})
+}
+
+func (suite *AvailabilityExceptionTestSuite) TestApprovalReleasesConflicts() {
+	clash := suite.createShift(suite.workerID, 1, 10, 3)
+	nextDay := suite.createShift(suite.workerID, 2, 9, 3)
+	otherShift := suite.createShift(suite.otherID, 1, 10, 3)
+
+	exceptionID := suite.requestException(1, nil)["id"].(string)
+
+	w := suite.makeRequestWithToken(suite.workerToken, "GET", exceptionsPath+"/"+exceptionID+"/conflicts", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	conflicts := suite.decodeData(w)["conflicting_shifts"].([]interface{})
+	suite.Require().Len(conflicts, 1)
+	suite.Equal(clash, conflicts[0].(map[string]interface{})["id"])
+
+	w = suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/approve", map[string]interface{}{
+		"notes":          "Enjoy the break",
+		"release_shifts": true,
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	exception := data["exception"].(map[string]interface{})
+	suite.Equal(models.ExceptionApproved, exception["status"])
+	suite.Equal(suite.userID, exception["approved_by"])
+	suite.Equal("Enjoy the break", exception["review_notes"])
+	suite.Len(data["conflicting_shifts"], 1)
+	suite.Equal([]interface{}{clash}, data["released_shifts"])
+
+	suite.Run("Released shifts go back into the open pool", func() {
+		suite.Nil(suite.staffOf(clash))
+		suite.Equal(suite.workerID, *suite.staffOf(nextDay))
+		suite.Equal(suite.otherID, *suite.staffOf(otherShift))
+
+		var audits int64
+		suite.db.Model(&models.AuditLog{}).Where("action = ? AND entity_id = ?", "release_shift", clash).Count(&audits)
+		suite.Equal(int64(1), audits)
+
+		// A third worker is free to pick it up
+		suite.createUser("leave-spare", "spare@leave.test", "care_worker")
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+clash+"/broadcast", map[string]interface{}{})
+		suite.Equal(http.StatusCreated, w.Code, w.Body.String())
+	})
+
+	suite.Run("The worker hears about the decision", func() {
+		var notification models.Notification
+		suite.Require().NoError(suite.db.Where("type = ? AND user_id = ?", models.NotificationAvailabilityException, suite.workerID).
+			Order("created_at DESC").First(&notification).Error)
+		suite.Equal("Availability exception approved", notification.Title)
+		suite.Contains(notification.Message, "1 shift(s)")
+	})
+
+	suite.Run("Exceptions are only reviewed once", func() {
+		w := suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/reject", nil)
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Changing an approved exception needs another review", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "PUT", exceptionsPath+"/"+exceptionID, map[string]interface{}{
+			"exception_date": suite.monday.AddDate(0, 0, 2).Format("2006-01-02"),
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		exception := suite.decodeData(w)["exception"].(map[string]interface{})
+		suite.Equal(models.ExceptionPending, exception["status"])
+		suite.Nil(exception["approved_by"])
+	})
+}
+
+func (suite *AvailabilityExceptionTestSuite) TestPartialDayRelease() {
+	morning := suite.createShift(suite.workerID, 3, 9, 3)
+	afternoon := suite.createShift(suite.workerID, 3, 15, 2)
+
+	exceptionID := suite.requestException(3, map[string]interface{}{"start_time": "14:00", "end_time": "16:00"})["id"].(string)
+
+	w := suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/release-shifts", nil)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/approve", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	conflicts := data["conflicting_shifts"].([]interface{})
+	suite.Require().Len(conflicts, 1)
+	suite.Equal(afternoon, conflicts[0].(map[string]interface{})["id"])
+	suite.Empty(data["released_shifts"])
+	suite.Equal(suite.workerID, *suite.staffOf(afternoon))
+
+	w = suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/release-shifts", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Equal([]interface{}{afternoon}, suite.decodeData(w)["released_shifts"])
+	suite.Nil(suite.staffOf(afternoon))
+	suite.Equal(suite.workerID, *suite.staffOf(morning))
+}
+
+func (suite *AvailabilityExceptionTestSuite) TestRejection() {
+	exceptionID := suite.requestException(4, map[string]interface{}{"exception_type": "limited", "max_hours": 4})["id"].(string)
+
+	w := suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/reject", map[string]interface{}{
+		"notes": "Short staffed that day",
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	exception := suite.decodeData(w)["exception"].(map[string]interface{})
+	suite.Equal(models.ExceptionRejected, exception["status"])
+	suite.Equal(suite.userID, exception["rejected_by"])
+	suite.Equal("Short staffed that day", exception["review_notes"])
+
+	w = suite.makeAuthenticatedRequest("POST", exceptionsPath+"/"+exceptionID+"/approve", nil)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", exceptionsPath+"?status=rejected&user_id="+suite.workerID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Len(suite.decodeData(w)["exceptions"], 1)
+
+	w = suite.makeRequestWithToken(suite.workerToken, "DELETE", exceptionsPath+"/"+exceptionID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("GET", exceptionsPath+"?status=rejected", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Len(suite.decodeData(w)["exceptions"], 0)
+}
+
+// TestAvailabilityExceptionSuite runs the availability exception test suite
+func TestAvailabilityExceptionSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(AvailabilityExceptionTestSuite))
+}
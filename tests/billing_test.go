@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// BillingTestSuite exercises invoice generation and payments end to end
+type BillingTestSuite struct {
+	extendedTestSuite
+}
+
+func (suite *BillingTestSuite) TestGenerateInvoice() {
+	base := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	first := suite.createCompletedShift(base, 2, 65.48)
+	second := suite.createCompletedShift(base.AddDate(0, 0, 1), 1.5, 65.48)
+
+	var invoiceID string
+
+	suite.Run("Generate invoice numbers sequentially and prices lines", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{first, second},
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		invoiceID = data["id"].(string)
+		suite.Equal("INV-000001", data["invoice_number"])
+		suite.Equal("draft", data["status"])
+		suite.InDelta(229.18, data["total"].(float64), 0.001)
+		suite.InDelta(229.18, data["balance_due"].(float64), 0.001)
+		suite.Len(data["lines"].([]interface{}), 2)
+
+		// Default due date follows the organization's payment terms
+		issue, _ := time.Parse(time.RFC3339, data["issue_date"].(string))
+		due, _ := time.Parse(time.RFC3339, data["due_date"].(string))
+		suite.InDelta(30*24, due.Sub(issue).Hours(), 1)
+	})
+
+	suite.Run("Shifts cannot be billed twice", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{first},
+		})
+		suite.Equal(http.StatusConflict, w.Code)
+
+		var count int64
+		suite.db.Model(&models.Invoice{}).Where("organization_id = ?", suite.orgID).Count(&count)
+		suite.Equal(int64(1), count)
+	})
+
+	suite.Run("Next invoice takes the next number", func() {
+		third := suite.createCompletedShift(base.AddDate(0, 0, 2), 1, 50)
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{third},
+			"due_date":       "2024-04-30",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal("INV-000002", suite.decodeData(w)["invoice_number"])
+	})
+
+	suite.Run("Care workers cannot bill or record payments", func() {
+		suite.createUser("billing-worker", "worker@billing.test", "care_worker")
+		token := suite.login("worker@billing.test")
+
+		w := suite.makeRequestWithToken(token, "POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{suite.createCompletedShift(base.AddDate(0, 0, 3), 1, 50)},
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(token, "POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 10,
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Partial and final payments", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 100,
+			"method": "bank_transfer",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal("draft", data["status"])
+		suite.InDelta(129.18, data["balance_due"].(float64), 0.001)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 200,
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("PAYMENT_EXCEEDS_BALANCE", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 129.18,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data = suite.decodeData(w)
+		suite.Equal("paid", data["status"])
+		suite.InDelta(0, data["balance_due"].(float64), 0.001)
+		suite.Len(data["payments"].([]interface{}), 2)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 1,
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("INVOICE_ALREADY_PAID", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("List and fetch invoices", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing?status=paid", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Len(suite.decodeData(w)["billing"].([]interface{}), 1)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID, nil)
+		suite.Equal(http.StatusOK, w.Code)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/billing/missing-invoice", nil)
+		suite.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *BillingTestSuite) TestInvoiceDownload() {
+	shiftID := suite.createCompletedShift(time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC), 3, 65.48)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	invoice := suite.decodeData(w)
+	invoiceID := invoice["id"].(string)
+
+	var first []byte
+
+	suite.Run("Renders a PDF attachment", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("application/pdf", w.Header().Get("Content-Type"))
+		suite.Contains(w.Header().Get("Content-Disposition"), invoice["invoice_number"].(string)+".pdf")
+		suite.True(bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-1.4")))
+		first = w.Body.Bytes()
+	})
+
+	suite.Run("Second download is served from the cached document", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Equal(first, w.Body.Bytes())
+
+		var count int64
+		suite.db.Model(&models.Document{}).Where("category = ? AND participant_id = ?", "invoice", suite.participantID).Count(&count)
+		suite.Equal(int64(1), count)
+	})
+
+	suite.Run("Payment invalidates the cached PDF", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 50,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var stored models.Invoice
+		suite.Require().NoError(suite.db.First(&stored, "id = ?", invoiceID).Error)
+		suite.Nil(stored.DocumentID)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.NotEqual(first, w.Body.Bytes())
+	})
+
+	suite.Run("Unknown invoice", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing/missing-invoice/download", nil)
+		suite.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+// TestBillingSuite runs the billing test suite
+func TestBillingSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(BillingTestSuite))
+}
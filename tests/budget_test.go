@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// BudgetTestSuite covers funding drawdown from completed, invoiced and edited shifts
+type BudgetTestSuite struct {
+	extendedTestSuite
+	planEnd time.Time
+}
+
+// SetupSuite gives the participant a $500 budget for a plan ending in 60 days
+func (suite *BudgetTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.planEnd = time.Now().AddDate(0, 0, 60).Truncate(24 * time.Hour)
+	suite.Require().NoError(suite.db.Model(&models.Participant{}).Where("id = ?", suite.participantID).
+		UpdateColumns(map[string]interface{}{
+			"funding_total_budget":     500,
+			"funding_used_budget":      0,
+			"funding_remaining_budget": 500,
+			"funding_plan_end_date":    suite.planEnd,
+		}).Error)
+}
+
+func (suite *BudgetTestSuite) funding() models.FundingInformation {
+	var participant models.Participant
+	suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+	return participant.Funding
+}
+
+func (suite *BudgetTestSuite) createShift(start time.Time, hours float64, rate float64) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.userID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Duration(hours * float64(time.Hour))).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       "Home",
+		"hourly_rate":    rate,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeResponse(w)
+}
+
+func (suite *BudgetTestSuite) TestDrawdown() {
+	start := time.Now().Add(-4 * time.Hour).Truncate(time.Minute)
+	created := suite.createShift(start, 2, 65.48)
+	suite.Empty(created["warnings"])
+	shiftID := created["data"].(map[string]interface{})["id"].(string)
+
+	suite.Run("Completing a shift draws down the budget", func() {
+		for _, status := range []string{"in_progress", "completed"} {
+			w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", map[string]interface{}{
+				"status": status,
+			})
+			suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		}
+
+		funding := suite.funding()
+		suite.InDelta(130.96, funding.UsedBudget, 0.001)
+		suite.InDelta(369.04, funding.RemainingBudget, 0.001)
+	})
+
+	suite.Run("Editing a completed shift redraws the difference", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{
+			"hourly_rate": 60,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		suite.InDelta(120, suite.funding().UsedBudget, 0.001)
+	})
+
+	suite.Run("Invoicing trues up shifts that never drew down", func() {
+		legacy := suite.createCompletedShift(start.AddDate(0, 0, -1), 1, 50)
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{shiftID, legacy},
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		funding := suite.funding()
+		suite.InDelta(170, funding.UsedBudget, 0.001)
+		suite.InDelta(330, funding.RemainingBudget, 0.001)
+	})
+
+	suite.Run("Ledger lists every movement", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/participants/"+suite.participantID+"/budget-ledger", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		transactions := data["transactions"].([]interface{})
+		suite.Require().Len(transactions, 3)
+
+		amounts := map[string]float64{}
+		for _, item := range transactions {
+			entry := item.(map[string]interface{})
+			amounts[entry["transaction_type"].(string)] += entry["amount"].(float64)
+		}
+		suite.InDelta(130.96, amounts["shift"], 0.001)
+		suite.InDelta(-10.96, amounts["adjustment"], 0.001)
+		suite.InDelta(50, amounts["invoice"], 0.001)
+		suite.InDelta(330, data["funding"].(map[string]interface{})["remaining_budget"].(float64), 0.001)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/participants/missing-participant/budget-ledger", nil)
+		suite.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *BudgetTestSuite) TestProjectedSpendWarning() {
+	start := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+
+	suite.Run("Booking past the remaining budget warns", func() {
+		created := suite.createShift(start, 8, 65.48)
+		suite.Require().Len(created["warnings"], 1)
+		suite.Contains(created["warnings"].([]interface{})[0], "before their plan ends on "+suite.planEnd.Format("02/01/2006"))
+	})
+
+	suite.Run("Bookings after the plan ends are not counted", func() {
+		created := suite.createShift(suite.planEnd.AddDate(0, 0, 2), 8, 65.48)
+		suite.Empty(created["warnings"])
+	})
+}
+
+// TestBudgetSuite runs the budget test suite
+func TestBudgetSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(BudgetTestSuite))
+}
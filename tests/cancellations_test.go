@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// CancellationsTestSuite covers short notice cancellation charges and how they are billed
+type CancellationsTestSuite struct {
+	extendedTestSuite
+	supportItemID string
+}
+
+// SetupSuite makes the participant agency managed with a $1000 budget and adds a support
+// item that allows short notice cancellation claims
+func (suite *CancellationsTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.Require().NoError(suite.db.Model(&models.Organization{}).Where("id = ?", suite.orgID).
+		Updates(map[string]interface{}{"ndis_registration_number": "4050000000", "abn": "12345678901"}).Error)
+	suite.Require().NoError(suite.db.Model(&models.Participant{}).Where("id = ?", suite.participantID).
+		UpdateColumns(map[string]interface{}{
+			"funding_management_type":  "agency",
+			"funding_total_budget":     1000,
+			"funding_used_budget":      0,
+			"funding_remaining_budget": 1000,
+		}).Error)
+
+	item := models.SupportItem{ItemNumber: "01_011_0107_1_1", Name: "Self-Care Weekday", Unit: "H", IsActive: true, ShortNoticeCancellation: true}
+	suite.Require().NoError(suite.db.Create(&item).Error)
+	suite.supportItemID = item.ID
+}
+
+// createShift books a two hour shift at $60 an hour starting after the given notice
+func (suite *CancellationsTestSuite) createShift(notice time.Duration) string {
+	start := time.Now().Add(notice).Truncate(time.Minute)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.userID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(2 * time.Hour).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       "Home",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	// Flat price so the charge does not depend on the time of day the test runs
+	shiftID := suite.decodeData(w)["id"].(string)
+	suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).Update("total_cost", 120).Error)
+	return shiftID
+}
+
+func (suite *CancellationsTestSuite) cancel(shiftID string, body map[string]interface{}) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", body)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *CancellationsTestSuite) usedBudget() float64 {
+	var participant models.Participant
+	suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+	return participant.Funding.UsedBudget
+}
+
+func (suite *CancellationsTestSuite) TestCancellationCharges() {
+	var charged string
+
+	suite.Run("Cancelling requires who cancelled and why", func() {
+		shiftID := suite.createShift(20 * time.Hour)
+		w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", map[string]interface{}{
+			"status": "cancelled",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+
+		w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", map[string]interface{}{
+			"status":       "cancelled",
+			"cancelled_by": "participant",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("CANCELLATION_REASON_REQUIRED", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Short notice participant cancellation is charged", func() {
+		charged = suite.createShift(24 * time.Hour)
+		suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", charged).
+			Update("support_item_id", suite.supportItemID).Error)
+
+		shift := suite.cancel(charged, map[string]interface{}{
+			"status":              "cancelled",
+			"cancelled_by":        "participant",
+			"cancellation_reason": "NSDH",
+			"cancellation_notes":  "Unwell",
+		})
+		suite.Equal("participant", shift["cancelled_by"])
+		suite.Equal("NSDH", shift["cancellation_reason"])
+		suite.InDelta(24, shift["cancellation_notice_hours"].(float64), 0.1)
+		suite.InDelta(120, shift["cancellation_charge"].(float64), 0.001)
+		suite.InDelta(120, suite.usedBudget(), 0.001)
+	})
+
+	suite.Run("Provider cancellations and cancellations with notice are not charged", func() {
+		shift := suite.cancel(suite.createShift(30*time.Hour), map[string]interface{}{
+			"status":              "cancelled",
+			"cancelled_by":        "provider",
+			"cancellation_reason": "NSDO",
+		})
+		suite.Nil(shift["cancellation_charge"])
+
+		shift = suite.cancel(suite.createShift(5*24*time.Hour), map[string]interface{}{
+			"status":              "cancelled",
+			"cancelled_by":        "participant",
+			"cancellation_reason": "NSDF",
+		})
+		suite.Nil(shift["cancellation_charge"])
+		suite.InDelta(120, suite.usedBudget(), 0.001)
+	})
+
+	suite.Run("Rescheduling returns the charge to the budget", func() {
+		shiftID := suite.createShift(34 * time.Hour)
+		suite.cancel(shiftID, map[string]interface{}{"status": "no_show", "cancellation_reason": "NSDT"})
+		suite.InDelta(240, suite.usedBudget(), 0.001)
+
+		shift := suite.cancel(shiftID, map[string]interface{}{"status": "scheduled"})
+		suite.Nil(shift["cancellation_charge"])
+		suite.Nil(shift["cancelled_at"])
+		suite.InDelta(120, suite.usedBudget(), 0.001)
+	})
+
+	suite.Run("Organization policy sets the window and the fee", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/settings", map[string]interface{}{
+			"cancellation_notice_hours": 72,
+			"cancellation_charge_rate":  90,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		shift := suite.cancel(suite.createShift(60*time.Hour), map[string]interface{}{
+			"status":              "cancelled",
+			"cancelled_by":        "participant",
+			"cancellation_reason": "NSDO",
+		})
+		suite.InDelta(108, shift["cancellation_charge"].(float64), 0.001)
+	})
+
+	suite.Run("Charged cancellations are claimed from the NDIA", func() {
+		adelaide, err := time.LoadLocation("Australia/Adelaide")
+		suite.Require().NoError(err)
+		day := time.Now().Add(24 * time.Hour).In(adelaide).Format("2006-01-02")
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/ndia-claims/generate", map[string]interface{}{
+			"start_date":     day,
+			"end_date":       day,
+			"participant_id": suite.participantID,
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		lines := suite.decodeData(w)["batch"].(map[string]interface{})["lines"].([]interface{})
+		suite.Require().Len(lines, 1)
+		line := lines[0].(map[string]interface{})
+		suite.Equal(charged, line["shift_id"])
+		suite.Equal("CANC", line["claim_type"])
+		suite.Equal("NSDH", line["cancellation_reason"])
+		suite.InDelta(120, line["amount"].(float64), 0.001)
+
+		w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+charged+"/status", map[string]interface{}{
+			"status": "scheduled",
+		})
+		suite.Equal(http.StatusConflict, w.Code)
+	})
+}
+
+func (suite *CancellationsTestSuite) TestInvoiceCancellation() {
+	shiftID := suite.createShift(40 * time.Hour)
+	shift := suite.cancel(shiftID, map[string]interface{}{
+		"status":              "cancelled",
+		"cancelled_by":        "participant",
+		"cancellation_reason": "NSDO",
+	})
+	suite.Require().NotNil(shift["cancellation_charge"])
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	lines := suite.decodeData(w)["lines"].([]interface{})
+	suite.Require().Len(lines, 1)
+	line := lines[0].(map[string]interface{})
+	suite.Equal("cancellation", line["line_type"])
+	suite.Contains(line["description"], "Short notice cancellation")
+	suite.InDelta(shift["cancellation_charge"].(float64), line["amount"].(float64), 0.001)
+}
+
+// TestCancellationsSuite runs the cancellations test suite
+func TestCancellationsSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(CancellationsTestSuite))
+}
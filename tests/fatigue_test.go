@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// FatigueTestSuite covers the rest period and working hours rules checked when shifts are
+// booked, and organizations choosing to only warn about them
+type FatigueTestSuite struct {
+	extendedTestSuite
+	loc      *time.Location
+	workerID string
+}
+
+// SetupSuite adds a care worker who needs 10 hours between shifts and works at most 20 hours
+// a week
+func (suite *FatigueTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	suite.loc = loc
+
+	suite.workerID = suite.createUser("fatigue-worker", "worker@fatigue.test", "care_worker")
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:                suite.workerID,
+		MaxHoursPerWeek:       20,
+		MaxConsecutiveDays:    5,
+		MinHoursBetweenShifts: 10,
+		IsActive:              true,
+	}).Error)
+}
+
+// SetupTest goes back to blocking every rule
+func (suite *FatigueTestSuite) SetupTest() {
+	suite.setRules("block")
+}
+
+// setRules enforces every fatigue rule the same way
+func (suite *FatigueTestSuite) setRules(enforcement string) {
+	w := suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/settings", map[string]interface{}{
+		"fatigue_rules": map[string]string{
+			"rest_period":      enforcement,
+			"daily_hours":      enforcement,
+			"weekly_hours":     enforcement,
+			"consecutive_days": enforcement,
+		},
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+}
+
+// day returns local midnight the given number of weeks ahead, so each test books a
+// separate week
+func (suite *FatigueTestSuite) day(weeks int) time.Time {
+	day := time.Now().In(suite.loc).AddDate(0, 0, 7*weeks)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, suite.loc)
+}
+
+// book asks for a shift for the worker, or an open one when staffID is empty
+func (suite *FatigueTestSuite) book(staffID string, start time.Time, hours int, location string) (int, map[string]interface{}) {
+	body := map[string]interface{}{
+		"participant_id": suite.participantID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(time.Duration(hours) * time.Hour).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       location,
+		"hourly_rate":    60,
+	}
+	if staffID != "" {
+		body["staff_id"] = staffID
+	}
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", body)
+	return w.Code, suite.decodeResponse(w)
+}
+
+func (suite *FatigueTestSuite) TestAssign() {
+	monday := suite.day(2)
+	code, _ := suite.book(suite.workerID, monday.Add(6*time.Hour), 8, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+	code, response := suite.book("", monday.Add(20*time.Hour), 3, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+	shiftID := response["data"].(map[string]interface{})["id"].(string)
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{"staff_id": suite.workerID})
+	suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+	response = suite.decodeResponse(w)
+	suite.Equal("WORKER_NOT_ELIGIBLE", response["error"].(map[string]interface{})["code"])
+	violations := response["violations"].([]interface{})
+	suite.Require().Len(violations, 1)
+	suite.Equal("INSUFFICIENT_REST", violations[0].(map[string]interface{})["rule"])
+
+	suite.setRules("warn")
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{"staff_id": suite.workerID})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	violations = suite.decodeResponse(w)["violations"].([]interface{})
+	suite.Require().Len(violations, 1)
+	suite.Equal("warn", violations[0].(map[string]interface{})["enforcement"])
+}
+
+func (suite *FatigueTestSuite) TestCreate() {
+	monday := suite.day(4)
+	code, _ := suite.book(suite.workerID, monday.Add(6*time.Hour), 8, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+
+	suite.Run("Too little rest is refused with the rules broken", func() {
+		code, response := suite.book(suite.workerID, monday.Add(20*time.Hour), 3, "Adelaide SA 5000")
+		suite.Require().Equal(http.StatusConflict, code)
+		problem := response["error"].(map[string]interface{})
+		suite.Equal("FATIGUE_RULES_BROKEN", problem["code"])
+		details := problem["details"].([]interface{})
+		suite.Require().Len(details, 1)
+		violation := details[0].(map[string]interface{})
+		suite.Equal("INSUFFICIENT_REST", violation["rule"])
+		suite.Equal("block", violation["enforcement"])
+		suite.InDelta(6, violation["value"].(float64), 0.001)
+		suite.InDelta(10, violation["limit"].(float64), 0.001)
+	})
+
+	suite.Run("Warnings come back with the shift", func() {
+		suite.setRules("warn")
+		code, response := suite.book(suite.workerID, monday.Add(20*time.Hour), 3, "Adelaide SA 5000")
+		suite.Require().Equal(http.StatusCreated, code)
+		violations := response["violations"].([]interface{})
+		suite.Require().Len(violations, 1)
+		suite.Equal("warn", violations[0].(map[string]interface{})["enforcement"])
+	})
+
+	suite.Run("Too many hours in the week", func() {
+		suite.setRules("block")
+		code, _ := suite.book(suite.workerID, monday.AddDate(0, 0, 1).Add(14*time.Hour), 6, "Adelaide SA 5000")
+		suite.Require().Equal(http.StatusCreated, code)
+		code, response := suite.book(suite.workerID, monday.AddDate(0, 0, 2).Add(14*time.Hour), 6, "Adelaide SA 5000")
+		suite.Require().Equal(http.StatusConflict, code)
+		details := response["error"].(map[string]interface{})["details"].([]interface{})
+		suite.Require().Len(details, 1)
+		suite.Equal("MAX_WEEKLY_HOURS", details[0].(map[string]interface{})["rule"])
+		suite.InDelta(23, details[0].(map[string]interface{})["value"].(float64), 0.001)
+	})
+}
+
+func (suite *FatigueTestSuite) TestSeries() {
+	monday := suite.day(10)
+	series := func(staffID string, start time.Time, hours int, count int) map[string]interface{} {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"staff_id":       staffID,
+			"start_time":     start.Format(time.RFC3339),
+			"end_time":       start.Add(time.Duration(hours) * time.Hour).Format(time.RFC3339),
+			"service_type":   "Personal Care",
+			"location":       "Adelaide SA 5000",
+			"hourly_rate":    60,
+			"rrule":          "FREQ=DAILY;COUNT=" + strconv.Itoa(count),
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		return suite.decodeData(w)
+	}
+	rules := func(conflicts interface{}) []string {
+		found := []string{}
+		for _, conflict := range conflicts.([]interface{}) {
+			conflict := conflict.(map[string]interface{})
+			suite.Equal("FATIGUE_RULES_BROKEN", conflict["code"])
+			found = append(found, conflict["details"].([]interface{})[0].(map[string]interface{})["rule"].(string))
+		}
+		return found
+	}
+
+	suite.Run("Occurrences past the weekly hours are not booked", func() {
+		data := series(suite.workerID, monday.Add(8*time.Hour), 6, 5)
+		suite.Len(data["shifts"].([]interface{}), 3)
+		suite.Equal([]string{"MAX_WEEKLY_HOURS", "MAX_WEEKLY_HOURS"}, rules(data["conflicts"]))
+	})
+
+	suite.Run("Handing a series to the worker checks their rest before each occurrence", func() {
+		otherID := suite.createUser("fatigue-evenings", "evenings@fatigue.test", "care_worker")
+		data := series(otherID, monday.Add(20*time.Hour), 2, 3)
+		seriesID := data["series"].(map[string]interface{})["id"].(string)
+		shiftID := data["shifts"].([]interface{})[0].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shiftID, map[string]interface{}{
+			"scope":    "all",
+			"staff_id": suite.workerID,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data = suite.decodeData(w)
+		suite.Empty(data["shifts"])
+		suite.Equal([]string{"INSUFFICIENT_REST", "INSUFFICIENT_REST", "INSUFFICIENT_REST"}, rules(data["conflicts"]))
+	})
+}
+
+func (suite *FatigueTestSuite) TestSettings() {
+	for _, rules := range []map[string]string{{"rest_period": "ignore"}, {"lunch_break": "warn"}} {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/settings", map[string]interface{}{"fatigue_rules": rules})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+	}
+
+	// Rules not mentioned keep their setting
+	w := suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/settings", map[string]interface{}{
+		"fatigue_rules": map[string]string{"weekly_hours": "warn"},
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	w = suite.makeAuthenticatedRequest("GET", "/api/v1/organization/settings", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	rules := suite.decodeData(w)["fatigue_rules"].(map[string]interface{})
+	suite.Equal("warn", rules["weekly_hours"])
+	suite.Equal("block", rules["rest_period"])
+}
+
+func (suite *FatigueTestSuite) TestTravel() {
+	monday := suite.day(6)
+	code, _ := suite.book(suite.workerID, monday.Add(6*time.Hour), 2, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+
+	// Ten hours and five minutes is enough rest in the city, but not after driving to Glenelg
+	start := monday.Add(18*time.Hour + 5*time.Minute)
+	code, response := suite.book(suite.workerID, start, 2, "Glenelg SA 5045")
+	suite.Require().Equal(http.StatusConflict, code)
+	violation := response["error"].(map[string]interface{})["details"].([]interface{})[0].(map[string]interface{})
+	suite.Equal("INSUFFICIENT_REST", violation["rule"])
+	suite.Greater(violation["travel_minutes"].(float64), float64(5))
+
+	code, _ = suite.book(suite.workerID, start, 2, "Adelaide SA 5000")
+	suite.Equal(http.StatusCreated, code)
+}
+
+func (suite *FatigueTestSuite) TestUpdate() {
+	monday := suite.day(8)
+	code, _ := suite.book(suite.workerID, monday.Add(6*time.Hour), 8, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+	code, response := suite.book(suite.workerID, monday.AddDate(0, 0, 1).Add(8*time.Hour), 4, "Adelaide SA 5000")
+	suite.Require().Equal(http.StatusCreated, code)
+	shiftID := response["data"].(map[string]interface{})["id"].(string)
+
+	move := map[string]interface{}{
+		"start_time": monday.Add(22 * time.Hour).Format(time.RFC3339),
+		"end_time":   monday.AddDate(0, 0, 1).Add(2 * time.Hour).Format(time.RFC3339),
+	}
+	w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, move)
+	suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+	suite.Equal("FATIGUE_RULES_BROKEN", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+
+	suite.setRules("warn")
+	w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, move)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Len(suite.decodeResponse(w)["violations"], 1)
+}
+
+func TestFatigueSuite(t *testing.T) {
+	suite.Run(t, new(FatigueTestSuite))
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// GeocodingTestSuite covers placing addresses, workers' location preferences and how far
+// workers will travel to a shift
+type GeocodingTestSuite struct {
+	extendedTestSuite
+	nearID    string // lives in North Adelaide
+	glenelgID string // lives in Glenelg and prefers working there
+	farID     string // lives in Victor Harbor and travels up to 30km
+	nearToken string
+}
+
+// SetupSuite adds three care workers
+func (suite *GeocodingTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.nearID = suite.createUser("geo-near", "near@geo.test", "care_worker")
+	suite.glenelgID = suite.createUser("geo-glenelg", "glenelg@geo.test", "care_worker")
+	suite.farID = suite.createUser("geo-far", "far@geo.test", "care_worker")
+	suite.nearToken = suite.login("near@geo.test")
+}
+
+// setHome records where a worker lives and how far they will travel
+func (suite *GeocodingTestSuite) setHome(userID string, address map[string]interface{}, maxTravelKm int) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/worker/preferences", map[string]interface{}{
+		"user_id":                userID,
+		"home_address":           address,
+		"max_travel_distance_km": maxTravelKm,
+		"willing_weekend_work":   true,
+		"willing_evening_work":   true,
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)["preferences"].(map[string]interface{})["home_address"].(map[string]interface{})
+}
+
+// openShift adds an unfilled shift next week
+func (suite *GeocodingTestSuite) openShift(location string) string {
+	start := time.Now().AddDate(0, 0, 8).Truncate(time.Hour)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(2 * time.Hour).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       location,
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func (suite *GeocodingTestSuite) TestBackfillAddresses() {
+	// Recorded before geocoding was available
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:      suite.nearID,
+		HomeAddress: models.Address{Suburb: "North Adelaide", State: "SA", Postcode: "5006"},
+		IsActive:    true,
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:      suite.glenelgID,
+		HomeAddress: models.Address{Suburb: "Nowhere", State: "SA", Postcode: "5999"},
+		IsActive:    true,
+	}).Error)
+
+	w := suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/organization/geocode", nil)
+	suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/organization/geocode", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	suite.Equal(float64(1), data["participants"])
+	suite.Equal(float64(1), data["workers"])
+	unplaced := data["unplaced"].([]interface{})
+	suite.Require().Len(unplaced, 1)
+	suite.Equal(suite.glenelgID, unplaced[0].(map[string]interface{})["user_id"])
+
+	var participant models.Participant
+	suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+	suite.Require().NotNil(participant.Address.Latitude)
+	suite.InDelta(-34.9285, *participant.Address.Latitude, 1e-4)
+
+	// Addresses already placed are left alone
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/organization/geocode", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Equal(float64(0), suite.decodeData(w)["participants"])
+	suite.Equal(float64(0), suite.decodeData(w)["workers"])
+}
+
+func (suite *GeocodingTestSuite) TestLocationPreferences() {
+	var locationID string
+
+	suite.Run("Workers record where they like to work", func() {
+		w := suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/worker/locations", map[string]interface{}{
+			"location_type":  "postcode",
+			"location_value": "50O6",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/worker/locations", map[string]interface{}{
+			"location_type":  "suburb",
+			"location_value": " Prospect ",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		location := suite.decodeData(w)["location"].(map[string]interface{})
+		suite.Equal("Prospect", location["location_value"])
+		suite.Equal("preferred", location["preference_level"])
+		suite.Equal(suite.nearID, location["user_id"])
+		locationID = location["id"].(string)
+
+		w = suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/worker/locations", map[string]interface{}{
+			"location_type":  "suburb",
+			"location_value": "prospect",
+		})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/worker/locations", map[string]interface{}{
+			"location_type":    "postcode",
+			"location_value":   "5211",
+			"preference_level": "avoid",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	})
+
+	suite.Run("Managers can record them for a worker", func() {
+		w := suite.makeRequestWithToken(suite.nearToken, "POST", "/api/v1/worker/locations", map[string]interface{}{
+			"user_id":        suite.glenelgID,
+			"location_type":  "suburb",
+			"location_value": "Glenelg",
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/worker/locations", map[string]interface{}{
+			"user_id":        suite.glenelgID,
+			"location_type":  "suburb",
+			"location_value": "Glenelg",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/worker/locations?user_id="+suite.glenelgID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["locations"], 1)
+	})
+
+	suite.Run("Workers change and remove them", func() {
+		w := suite.makeRequestWithToken(suite.nearToken, "PUT", "/api/v1/worker/locations/"+locationID, map[string]interface{}{
+			"preference_level": "nowhere",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.nearToken, "PUT", "/api/v1/worker/locations/"+locationID, map[string]interface{}{
+			"preference_level": "acceptable",
+			"notes":            "Only mornings",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		location := suite.decodeData(w)["location"].(map[string]interface{})
+		suite.Equal("acceptable", location["preference_level"])
+		suite.Equal("Only mornings", location["notes"])
+
+		w = suite.makeRequestWithToken(suite.nearToken, "GET", "/api/v1/worker/locations?preference_level=avoid", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["locations"], 1)
+
+		w = suite.makeRequestWithToken(suite.nearToken, "DELETE", "/api/v1/worker/locations/"+locationID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		w = suite.makeRequestWithToken(suite.nearToken, "PUT", "/api/v1/worker/locations/"+locationID, map[string]interface{}{
+			"notes": "Gone",
+		})
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.nearToken, "GET", "/api/v1/worker/locations", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["locations"], 1)
+	})
+}
+
+func (suite *GeocodingTestSuite) TestTravelDistance() {
+	suite.Run("Addresses are placed when they are saved", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/participants/"+suite.participantID, map[string]interface{}{
+			"address": map[string]interface{}{"street": "1 King William St", "suburb": "Adelaide", "state": "SA", "postcode": "5000"},
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var participant models.Participant
+		suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+		suite.Require().NotNil(participant.Address.Latitude)
+		suite.InDelta(138.6007, *participant.Address.Longitude, 1e-4)
+
+		home := suite.setHome(suite.nearID, map[string]interface{}{"suburb": "North Adelaide", "state": "SA", "postcode": "5006"}, 50)
+		suite.InDelta(-34.9065, home["latitude"], 1e-4)
+		suite.setHome(suite.glenelgID, map[string]interface{}{"suburb": "Glenelg", "state": "SA", "postcode": "5045"}, 50)
+		suite.setHome(suite.farID, map[string]interface{}{"suburb": "Victor Harbor", "state": "SA", "postcode": "5211"}, 30)
+
+		// Coordinates placed by hand are kept
+		home = suite.setHome(suite.farID, map[string]interface{}{"suburb": "Victor Harbor", "state": "SA", "postcode": "5211",
+			"latitude": -35.56, "longitude": 138.63}, 30)
+		suite.Equal(-35.56, home["latitude"])
+	})
+
+	suite.Run("Suggestions leave out workers who live too far away and rank the rest by distance", func() {
+		shiftID := suite.openShift("Participant home")
+
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal([]string{suite.nearID, suite.glenelgID}, workerIDs(data["candidates"]))
+
+		excluded := data["excluded"].([]interface{})
+		suite.Require().Len(excluded, 1)
+		far := excluded[0].(map[string]interface{})
+		suite.Equal(suite.farID, far["worker_id"])
+		suite.Equal("TOO_FAR", far["reasons"].([]interface{})[0].(map[string]interface{})["code"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/broadcast", map[string]interface{}{})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal([]string{suite.nearID, suite.glenelgID}, workerIDs(suite.decodeData(w)["recipients"]))
+	})
+
+	suite.Run("Shifts away from the participant's home are placed from their location", func() {
+		shiftID := suite.openShift("Beach outing, Glenelg SA 5045")
+
+		var shift models.Shift
+		suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+		suite.Require().NotNil(shift.Latitude)
+		suite.InDelta(-34.9803, *shift.Latitude, 1e-4)
+
+		// The Glenelg worker lives there and prefers working there
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal([]string{suite.glenelgID, suite.nearID}, workerIDs(suite.decodeData(w)["candidates"]))
+
+		// Moving the shift moves where it is placed
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{"location": "Participant home"})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var moved models.Shift
+		suite.Require().NoError(suite.db.First(&moved, "id = ?", shiftID).Error)
+		suite.Nil(moved.Latitude)
+	})
+}
+
+// TestGeocodingSuite runs the geocoding test suite
+func TestGeocodingSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(GeocodingTestSuite))
+}
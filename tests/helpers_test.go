@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/config"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/handlers"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// extendedTestSuite is a base suite backed by an in-memory database with the extended schema.
+// Each embedding suite gets its own organization, admin user and participant.
+type extendedTestSuite struct {
+	suite.Suite
+	router        *gin.Engine
+	db            *gorm.DB
+	handler       *handlers.Handler
+	accessToken   string
+	orgID         string
+	userID        string
+	participantID string
+	uploadPath    string
+}
+
+// SetupSuite runs once before all tests
+func (suite *extendedTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	suite.Require().NoError(err)
+
+	// A single connection keeps every query on the same in-memory database
+	sqlDB, err := db.DB()
+	suite.Require().NoError(err)
+	sqlDB.SetMaxOpenConns(1)
+
+	suite.Require().NoError(models.MigrateExtendedDB(db))
+	suite.db = db
+
+	uploadPath, err := os.MkdirTemp("", "crm-uploads-")
+	suite.Require().NoError(err)
+	suite.uploadPath = uploadPath
+
+	cfg := &config.Config{
+		JWTSecret:          "test-secret-key-for-testing-only",
+		JWTExpiry:          24 * time.Hour,
+		RefreshTokenExpiry: 7 * 24 * time.Hour,
+		UploadPath:         uploadPath,
+	}
+
+	suite.handler = handlers.NewHandler(db, cfg)
+	suite.router = gin.New()
+	suite.handler.SetupRoutes(suite.router)
+
+	suite.seedTestData()
+	suite.accessToken = suite.login("admin@test.com")
+}
+
+// TearDownSuite runs once after all tests
+func (suite *extendedTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+	os.RemoveAll(suite.uploadPath)
+}
+
+// seedTestData creates the organization, admin user and participant shared by the tests
+func (suite *extendedTestSuite) seedTestData() {
+	org := models.Organization{
+		ID:    "test-org-id",
+		Name:  "Test Organization",
+		Email: "test@example.com",
+	}
+	suite.Require().NoError(suite.db.Create(&org).Error)
+	suite.orgID = org.ID
+	suite.Require().NoError(models.SetupOrganizationDefaults(suite.db, org.ID))
+
+	suite.userID = suite.createUser("test-user-id", "admin@test.com", "admin")
+
+	participant := models.Participant{
+		ID:             "test-participant-id",
+		FirstName:      "Jane",
+		LastName:       "Smith",
+		DateOfBirth:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "430000001",
+		Address:        models.Address{State: "SA", Postcode: "5000"},
+		OrganizationID: org.ID,
+		IsActive:       true,
+	}
+	suite.Require().NoError(suite.db.Create(&participant).Error)
+	suite.participantID = participant.ID
+}
+
+// createUser adds an active user to the test organization with the password "password"
+func (suite *extendedTestSuite) createUser(id, email, role string) string {
+	user := models.User{
+		ID:             id,
+		Email:          email,
+		PasswordHash:   "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // "password"
+		FirstName:      "Test",
+		LastName:       role,
+		Role:           role,
+		OrganizationID: suite.orgID,
+		IsActive:       true,
+	}
+	suite.Require().NoError(suite.db.Create(&user).Error)
+	return user.ID
+}
+
+// login performs login and returns the access token
+func (suite *extendedTestSuite) login(email string) string {
+	body, _ := json.Marshal(map[string]interface{}{
+		"email":    email,
+		"password": "password",
+	})
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	var response map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return response["data"].(map[string]interface{})["token"].(string)
+}
+
+// makeAuthenticatedRequest helper method to make authenticated requests as the admin user
+func (suite *extendedTestSuite) makeAuthenticatedRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
+	return suite.makeRequestWithToken(suite.accessToken, method, path, body)
+}
+
+// makeRequestWithToken makes a JSON request with the given access token
+func (suite *extendedTestSuite) makeRequestWithToken(token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+
+	req := httptest.NewRequest(method, path, reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// uploadFile posts a multipart form with a single "file" field as the admin user
+func (suite *extendedTestSuite) uploadFile(path, filename, content string) *httptest.ResponseRecorder {
+	return suite.uploadFileWithToken(suite.accessToken, path, filename, content)
+}
+
+// uploadFileWithToken posts a multipart form with a single "file" field with the given access token
+func (suite *extendedTestSuite) uploadFileWithToken(token, path, filename, content string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	suite.Require().NoError(err)
+	_, err = part.Write([]byte(content))
+	suite.Require().NoError(err)
+	suite.Require().NoError(writer.Close())
+
+	req := httptest.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+// createCompletedShift inserts a completed shift for the test participant
+func (suite *extendedTestSuite) createCompletedShift(start time.Time, hours float64, rate float64) string {
+	shift := models.Shift{
+		ParticipantID: suite.participantID,
+		StaffID:       &suite.userID,
+		StartTime:     start,
+		EndTime:       start.Add(time.Duration(hours * float64(time.Hour))),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "completed",
+		HourlyRate:    rate,
+	}
+	suite.Require().NoError(suite.db.Create(&shift).Error)
+	return shift.ID
+}
+
+// bookShift asks for a shift next week with the given worker, or an open one when staffID is
+// empty. Fields are added to the request.
+func (suite *extendedTestSuite) bookShift(staffID string, day int, fields map[string]interface{}) (int, map[string]interface{}) {
+	start := time.Now().AddDate(0, 0, 7+day).Truncate(time.Hour)
+	body := map[string]interface{}{
+		"participant_id": suite.participantID,
+		"start_time":     start.Format(time.RFC3339),
+		"end_time":       start.Add(2 * time.Hour).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+	}
+	if staffID != "" {
+		body["staff_id"] = staffID
+	}
+	for key, value := range fields {
+		body[key] = value
+	}
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", body)
+	return w.Code, suite.decodeResponse(w)
+}
+
+// requireCredential makes a credential mandatory for the organization and records it, verified,
+// for each worker with the given expiry, where a zero expiry never lapses. The returned func
+// retires the credential so later tests are not held to it.
+func (suite *extendedTestSuite) requireCredential(name string, holders map[string]time.Time) func() {
+	credential := models.MandatoryCredential{OrganizationID: suite.orgID, Name: name, IsActive: true}
+	suite.Require().NoError(suite.db.Create(&credential).Error)
+
+	verified := time.Now()
+	for userID, expiry := range holders {
+		skill := models.WorkerSkill{UserID: userID, SkillCategory: "Certifications", SkillName: name, ProficiencyLevel: "advanced", VerifiedAt: &verified, IsActive: true}
+		if !expiry.IsZero() {
+			expiry := expiry
+			skill.ExpiryDate = &expiry
+		}
+		suite.Require().NoError(suite.db.Create(&skill).Error)
+	}
+	return func() { suite.db.Model(&credential).Update("is_active", false) }
+}
+
+// decodeResponse unmarshals a response body
+func (suite *extendedTestSuite) decodeResponse(w *httptest.ResponseRecorder) map[string]interface{} {
+	var response map[string]interface{}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return response
+}
+
+// decodeData unmarshals a response body and returns its data object
+func (suite *extendedTestSuite) decodeData(w *httptest.ResponseRecorder) map[string]interface{} {
+	data, _ := suite.decodeResponse(w)["data"].(map[string]interface{})
+	return data
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// InvoiceCorrectionsTestSuite covers credit notes against invoice lines, voiding and re-issue
+type InvoiceCorrectionsTestSuite struct {
+	extendedTestSuite
+}
+
+// generateInvoice bills a $120 shift for each start time
+func (suite *InvoiceCorrectionsTestSuite) generateInvoice(starts ...time.Time) map[string]interface{} {
+	shiftIDs := []string{}
+	for _, start := range starts {
+		shiftIDs = append(shiftIDs, suite.createCompletedShift(start, 2, 60))
+	}
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      shiftIDs,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *InvoiceCorrectionsTestSuite) invoice(invoiceID string) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *InvoiceCorrectionsTestSuite) usedBudget() float64 {
+	var participant models.Participant
+	suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+	return participant.Funding.UsedBudget
+}
+
+func (suite *InvoiceCorrectionsTestSuite) TestInvoiceCorrections() {
+	day := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+
+	suite.Run("Credit notes reference invoice lines and return funding", func() {
+		before := suite.usedBudget()
+		invoice := suite.generateInvoice(day, day.Add(4*time.Hour))
+		suite.InDelta(before+240, suite.usedBudget(), 0.001)
+
+		line := invoice["lines"].([]interface{})[0].(map[string]interface{})
+		credit := func(amount float64) int {
+			w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoice["id"].(string)+"/credit-notes", map[string]interface{}{
+				"reason": "Worker left early",
+				"lines":  []map[string]interface{}{{"invoice_line_id": line["id"], "amount": amount}},
+			})
+			return w.Code
+		}
+		suite.Require().Equal(http.StatusCreated, credit(30))
+		suite.InDelta(before+210, suite.usedBudget(), 0.001)
+
+		// Only $90 is left to credit on the line
+		suite.Equal(http.StatusBadRequest, credit(100))
+
+		data := suite.invoice(invoice["id"].(string))
+		suite.InDelta(210, data["balance_due"].(float64), 0.001)
+		creditNote := data["credit_notes"].([]interface{})[0].(map[string]interface{})
+		lines := creditNote["lines"].([]interface{})
+		suite.Require().Len(lines, 1)
+		suite.Equal(line["id"], lines[0].(map[string]interface{})["invoice_line_id"])
+		suite.Equal(line["shift_id"], lines[0].(map[string]interface{})["shift_id"])
+	})
+
+	suite.Run("Paid invoices cannot be voided", func() {
+		invoice := suite.generateInvoice(day.Add(-24 * time.Hour))
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoice["id"].(string)+"/payment", map[string]interface{}{"amount": 10})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoice["id"].(string)+"/void", map[string]interface{}{"reason": "Wrong rate"})
+		suite.Equal(http.StatusConflict, w.Code)
+	})
+
+	suite.Run("Voiding releases shifts and re-issue keeps the chain", func() {
+		invoice := suite.generateInvoice(day.Add(-48 * time.Hour))
+		invoiceID := invoice["id"].(string)
+		shiftID := invoice["lines"].([]interface{})[0].(map[string]interface{})["shift_id"].(string)
+		before := suite.usedBudget()
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/void", map[string]interface{}{"reason": "Wrong rate"})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("void", suite.decodeData(w)["status"])
+		suite.InDelta(0, suite.decodeData(w)["balance_due"].(float64), 0.001)
+
+		var shift models.Shift
+		suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+		suite.Nil(shift.InvoiceID)
+		// The delivered shift still commits its funding while it is unbilled
+		suite.InDelta(before, suite.usedBudget(), 0.001)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/void", map[string]interface{}{"reason": "Again"})
+		suite.Equal(http.StatusConflict, w.Code)
+
+		// Correct the rate before billing again
+		suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).
+			Updates(map[string]interface{}{"hourly_rate": 65, "total_cost": 130}).Error)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/reissue", map[string]interface{}{})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		reissued := suite.decodeData(w)
+		suite.NotEqual(invoice["invoice_number"], reissued["invoice_number"])
+		suite.Equal(invoiceID, reissued["replaces_invoice_id"])
+		suite.InDelta(130, reissued["total"].(float64), 0.001)
+		suite.InDelta(before+10, suite.usedBudget(), 0.001)
+
+		original := suite.invoice(invoiceID)
+		suite.Equal(reissued["id"], original["replaced_by_invoice_id"])
+		chain := original["chain"].([]interface{})
+		suite.Require().Len(chain, 2)
+		suite.Equal(invoice["invoice_number"], chain[0].(map[string]interface{})["invoice_number"])
+		suite.Equal("void", chain[0].(map[string]interface{})["status"])
+		suite.Equal(reissued["invoice_number"], chain[1].(map[string]interface{})["invoice_number"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/reissue", map[string]interface{}{})
+		suite.Equal(http.StatusConflict, w.Code)
+
+		// Re-issuing an active invoice voids it in the same step
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+reissued["id"].(string)+"/reissue", map[string]interface{}{})
+		suite.Equal(http.StatusBadRequest, w.Code)
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+reissued["id"].(string)+"/reissue", map[string]interface{}{"reason": "Wrong due date"})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["chain"].([]interface{}), 3)
+		suite.Equal("void", suite.invoice(reissued["id"].(string))["status"])
+	})
+}
+
+// TestInvoiceCorrectionsSuite runs the invoice corrections test suite
+func TestInvoiceCorrectionsSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(InvoiceCorrectionsTestSuite))
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// InvoiceDeliveryTestSuite covers external contacts, payer resolution and invoice delivery
+type InvoiceDeliveryTestSuite struct {
+	extendedTestSuite
+	mailer *recordingMailer
+}
+
+// SetupSuite makes the participant plan managed and captures outgoing email
+func (suite *InvoiceDeliveryTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.mailer = &recordingMailer{}
+	suite.handler.Mailer = suite.mailer
+
+	suite.Require().NoError(suite.db.Model(&models.Participant{}).Where("id = ?", suite.participantID).
+		Update("funding_management_type", "plan").Error)
+}
+
+func (suite *InvoiceDeliveryTestSuite) createContact(body map[string]interface{}) string {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/contacts", body)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func (suite *InvoiceDeliveryTestSuite) generateInvoice() string {
+	shiftID := suite.createCompletedShift(time.Now().Add(-72*time.Hour).Truncate(time.Hour), 2, 60)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func (suite *InvoiceDeliveryTestSuite) payer() map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/participants/"+suite.participantID+"/contacts", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)["payer"].(map[string]interface{})
+}
+
+func (suite *InvoiceDeliveryTestSuite) TestInvoiceDelivery() {
+	suite.Run("Email delivery needs an email address", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/contacts", map[string]interface{}{
+			"type":         "plan_manager",
+			"company_name": "No Email Plan Management",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	planManager := suite.createContact(map[string]interface{}{
+		"type":         "plan_manager",
+		"company_name": "Plan Partners",
+		"contact_name": "Sam Lee",
+		"email":        "accounts@planpartners.test",
+	})
+
+	suite.Run("Plan managed participants are billed to their plan manager", func() {
+		suite.Equal("Jane Smith", suite.payer()["name"])
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/contacts", map[string]interface{}{
+			"external_contact_id": planManager,
+			"role":                "plan_manager",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/contacts", map[string]interface{}{
+			"external_contact_id": planManager,
+			"role":                "plan_manager",
+		})
+		suite.Equal(http.StatusConflict, w.Code)
+
+		payer := suite.payer()
+		suite.Equal("Plan Partners", payer["name"])
+		suite.Equal("email", payer["channel"])
+		suite.Equal(true, payer["is_plan_manager"])
+	})
+
+	suite.Run("Invoices are emailed to the payer with the PDF attached", func() {
+		invoiceID := suite.generateInvoice()
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/send", nil)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal("sent", suite.decodeData(w)["status"])
+
+		messages := suite.mailer.sent()
+		suite.Require().Len(messages, 1)
+		suite.Equal([]string{"accounts@planpartners.test"}, messages[0].To)
+		suite.Contains(messages[0].Body, "Dear Plan Partners,")
+		suite.Require().Len(messages[0].Attachments, 1)
+		suite.Equal("application/pdf", messages[0].Attachments[0].ContentType)
+		suite.True(bytes.HasPrefix(messages[0].Attachments[0].Data, []byte("%PDF")))
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal("sent", data["status"])
+		deliveries := data["deliveries"].([]interface{})
+		suite.Require().Len(deliveries, 1)
+		suite.Equal("accounts@planpartners.test", deliveries[0].(map[string]interface{})["recipient"])
+	})
+
+	suite.Run("Portal payers are queued and exported for upload", func() {
+		portal := suite.createContact(map[string]interface{}{
+			"type":              "other",
+			"company_name":      "Family Trust",
+			"preferred_channel": "portal",
+			"portal_name":       "Trust Payments Portal",
+		})
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/contacts", map[string]interface{}{
+			"external_contact_id": portal,
+			"role":                "other",
+			"is_payer":            true,
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal("Family Trust", suite.payer()["name"])
+
+		invoiceID := suite.generateInvoice()
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/send", nil)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal("queued", suite.decodeData(w)["status"])
+		suite.Len(suite.mailer.sent(), 1)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+invoiceID+"/send", nil)
+		suite.Equal(http.StatusConflict, w.Code)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/portal-exports", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("application/zip", w.Header().Get("Content-Type"))
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		suite.Require().NoError(err)
+		suite.Require().Len(archive.File, 2)
+		suite.Equal("manifest.csv", archive.File[1].Name)
+		file, err := archive.File[1].Open()
+		suite.Require().NoError(err)
+		manifest, _ := io.ReadAll(file)
+		suite.Contains(string(manifest), "Trust Payments Portal")
+
+		var invoice models.Invoice
+		suite.Require().NoError(suite.db.First(&invoice, "id = ?", invoiceID).Error)
+		suite.Equal("sent", invoice.Status)
+		suite.Contains(string(manifest), invoice.InvoiceNumber)
+		suite.Equal(invoice.InvoiceNumber+".pdf", archive.File[0].Name)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/portal-exports", nil)
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("Deleting a payer falls back to the plan manager", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/contacts?type=other", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		contacts := suite.decodeData(w)["contacts"].([]interface{})
+		suite.Require().Len(contacts, 1)
+
+		w = suite.makeAuthenticatedRequest("DELETE", "/api/v1/contacts/"+contacts[0].(map[string]interface{})["id"].(string), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("Plan Partners", suite.payer()["name"])
+	})
+}
+
+// TestInvoiceDeliverySuite runs the invoice delivery test suite
+func TestInvoiceDeliverySuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(InvoiceDeliveryTestSuite))
+}
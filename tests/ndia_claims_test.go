@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// NDIAClaimsTestSuite covers bulk claim generation and remittance reconciliation
+type NDIAClaimsTestSuite struct {
+	extendedTestSuite
+	supportItemID string
+}
+
+// SetupSuite adds the registration number and a support item on top of the base data
+func (suite *NDIAClaimsTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.Require().NoError(suite.db.Model(&models.Organization{}).Where("id = ?", suite.orgID).
+		Updates(map[string]interface{}{"ndis_registration_number": "4050000000", "abn": "12345678901"}).Error)
+
+	item := models.SupportItem{ItemNumber: "01_011_0107_1_1", Name: "Self-Care Weekday", Unit: "H", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&item).Error)
+	suite.supportItemID = item.ID
+}
+
+// createClaimableShift inserts a completed shift with a support item
+func (suite *NDIAClaimsTestSuite) createClaimableShift(start time.Time) string {
+	shiftID := suite.createCompletedShift(start, 2, 67.56)
+	suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).
+		Update("support_item_id", suite.supportItemID).Error)
+	return shiftID
+}
+
+func (suite *NDIAClaimsTestSuite) TestClaimLifecycle() {
+	first := suite.createClaimableShift(time.Date(2024, 8, 5, 9, 0, 0, 0, time.UTC))
+	second := suite.createClaimableShift(time.Date(2024, 8, 6, 9, 0, 0, 0, time.UTC))
+	suite.createCompletedShift(time.Date(2024, 8, 7, 9, 0, 0, 0, time.UTC), 1, 50) // no support item
+
+	var batchID string
+	var references []string
+
+	suite.Run("Generate batch from claimable shifts", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/ndia-claims/generate", map[string]interface{}{
+			"start_date": "2024-08-01",
+			"end_date":   "2024-08-31",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		batch := data["batch"].(map[string]interface{})
+		batchID = batch["id"].(string)
+		suite.Equal("CLM-000001", batch["batch_number"])
+		suite.Equal(float64(2), batch["line_count"])
+		suite.InDelta(270.24, batch["total_amount"].(float64), 0.001)
+		suite.Len(data["skipped"], 1)
+
+		var shift models.Shift
+		suite.db.First(&shift, "id = ?", first)
+		suite.NotNil(shift.ClaimLineID)
+	})
+
+	suite.Run("Claimed shifts are not claimed or invoiced again", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/ndia-claims/generate", map[string]interface{}{
+			"start_date": "2024-08-01",
+			"end_date":   "2024-08-31",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{first},
+		})
+		suite.Equal(http.StatusConflict, w.Code)
+	})
+
+	suite.Run("Download bulk payment request", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/ndia-claims/"+batchID+"/download", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Contains(w.Header().Get("Content-Type"), "text/csv")
+
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		suite.Require().NoError(err)
+		suite.Require().Len(records, 3)
+		suite.Equal("RegistrationNumber", records[0][0])
+		suite.Equal("4050000000", records[1][0])
+		suite.Equal("430000001", records[1][1])
+		suite.Equal("01_011_0107_1_1", records[1][4])
+		suite.Equal("2.00", records[1][6])
+		suite.Equal("67.56", records[1][8])
+		references = []string{records[1][5], records[2][5]}
+	})
+
+	suite.Run("Import remittance", func() {
+		remittance := "ClaimReference,PaidTotalAmount,Payment Request Number,Payment Request Status,Error Message\n" +
+			references[0] + ",135.12,PR100,SUCCESSFUL,\n" +
+			references[1] + ",0,PR101,ERROR,SBNF - No service booking found\n" +
+			"UNKNOWN-REF,10,PR102,SUCCESSFUL,\n"
+
+		w := suite.uploadFile("/api/v1/ndia-claims/remittance", "remittance.csv", remittance)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(float64(1), data["paid"])
+		suite.Equal(float64(1), data["rejected"])
+		suite.Len(data["unmatched"], 1)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/ndia-claims/"+batchID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		batch := suite.decodeData(w)
+		suite.Equal("reconciled", batch["status"])
+		suite.InDelta(135.12, batch["paid_amount"].(float64), 0.001)
+
+		var rejected models.NDIAClaimLine
+		suite.db.First(&rejected, "claim_reference = ?", references[1])
+		suite.Equal("rejected", rejected.Status)
+		suite.Equal("SBNF", rejected.RejectionCode)
+
+		// The rejected line releases its shift for correction and re-claim
+		var shift models.Shift
+		suite.db.First(&shift, "id = ?", second)
+		suite.Nil(shift.ClaimLineID)
+	})
+}
+
+// TestNDIAClaimsSuite runs the NDIA claims test suite
+func TestNDIAClaimsSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(NDIAClaimsTestSuite))
+}
@@ -0,0 +1,271 @@
+package tests
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// OpenShiftTestSuite covers broadcasting open shifts and workers claiming them
+type OpenShiftTestSuite struct {
+	extendedTestSuite
+	monday   time.Time
+	anaID    string
+	benID    string
+	catID    string // avoids working in the participant's postcode
+	anaToken string
+	benToken string
+	catToken string
+}
+
+// SetupSuite adds three care workers who can log in
+func (suite *OpenShiftTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	suite.monday = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	suite.anaID = suite.createUser("open-ana", "ana@openshifts.test", "care_worker")
+	suite.benID = suite.createUser("open-ben", "ben@openshifts.test", "care_worker")
+	suite.catID = suite.createUser("open-cat", "cat@openshifts.test", "care_worker")
+	suite.anaToken = suite.login("ana@openshifts.test")
+	suite.benToken = suite.login("ben@openshifts.test")
+	suite.catToken = suite.login("cat@openshifts.test")
+
+	suite.Require().NoError(suite.db.Create(&models.WorkerLocationPreference{
+		UserID:          suite.catID,
+		LocationType:    "postcode",
+		LocationValue:   "5000",
+		PreferenceLevel: "avoid",
+		IsActive:        true,
+	}).Error)
+}
+
+// broadcastShift creates an open shift on the given day after the first Monday and broadcasts it
+func (suite *OpenShiftTestSuite) broadcastShift(day int, mode string) (string, string) {
+	from := suite.monday.AddDate(0, 0, day).Add(9 * time.Hour)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"start_time":     from.Format("2006-01-02T15:04:05"),
+		"end_time":       from.Add(3 * time.Hour).Format("2006-01-02T15:04:05"),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	shiftID := suite.decodeData(w)["id"].(string)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/broadcast", map[string]interface{}{
+		"mode":    mode,
+		"message": "Sick call, please help",
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return shiftID, suite.decodeData(w)["broadcast"].(map[string]interface{})["id"].(string)
+}
+
+func (suite *OpenShiftTestSuite) openShifts(token string) []interface{} {
+	w := suite.makeRequestWithToken(token, "GET", "/api/v1/open-shifts", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeResponse(w)["data"].([]interface{})
+}
+
+func (suite *OpenShiftTestSuite) TestApprovalClaims() {
+	shiftID, broadcastID := suite.broadcastShift(0, "")
+
+	var broadcast models.ShiftBroadcast
+	suite.Require().NoError(suite.db.First(&broadcast, "id = ?", broadcastID).Error)
+	suite.Equal(models.ClaimApproval, broadcast.Mode)
+	suite.Equal(2, broadcast.RecipientCount)
+
+	var notified int64
+	suite.db.Model(&models.Notification{}).Where("type = ? AND user_id IN ?", models.NotificationOpenShift, []string{suite.anaID, suite.benID}).Count(&notified)
+	suite.Equal(int64(2), notified)
+
+	suite.Run("A shift can only have one open broadcast", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/broadcast", nil)
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Workers avoiding the location are not offered the shift", func() {
+		suite.Empty(suite.openShifts(suite.catToken))
+		w := suite.makeRequestWithToken(suite.catToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	var anaClaim, benClaim string
+	suite.Run("Recipients claim the shift for approval", func() {
+		suite.Len(suite.openShifts(suite.anaToken), 1)
+
+		w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal(models.ClaimPending, suite.decodeData(w)["status"])
+		anaClaim = suite.decodeData(w)["id"].(string)
+
+		w = suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+
+		// The database also refuses a second claim, in case two requests race past the check
+		duplicate := models.ShiftClaim{BroadcastID: broadcastID, ShiftID: shiftID, UserID: suite.anaID}
+		suite.Error(suite.db.Create(&duplicate).Error)
+
+		w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		benClaim = suite.decodeData(w)["id"].(string)
+
+		var claims int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationShiftClaim, suite.userID).Count(&claims)
+		suite.Equal(int64(2), claims)
+	})
+
+	suite.Run("Only managers approve claims", func() {
+		w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-claims/"+anaClaim+"/approve", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Approving a claim gives the worker the shift and turns down the others", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-claims/"+anaClaim+"/approve", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(models.ClaimApproved, suite.decodeData(w)["status"])
+
+		var shift models.Shift
+		suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+		suite.Require().NotNil(shift.StaffID)
+		suite.Equal(suite.anaID, *shift.StaffID)
+		suite.Equal(models.ShiftPublished, shift.PublishStatus)
+
+		var claim models.ShiftClaim
+		suite.Require().NoError(suite.db.First(&claim, "id = ?", benClaim).Error)
+		suite.Equal(models.ClaimRejected, claim.Status)
+		suite.NotEmpty(claim.Reason)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/shift-broadcasts/"+broadcastID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(models.BroadcastFilled, data["status"])
+		suite.Equal(suite.anaID, data["filled_by"])
+		suite.Len(data["recipients"], 2)
+		suite.Len(data["claims"], 2)
+
+		var results int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND user_id IN ?", models.NotificationClaimResult, []string{suite.anaID, suite.benID}).Count(&results)
+		suite.Equal(int64(2), results)
+		suite.Empty(suite.openShifts(suite.benToken))
+	})
+
+	suite.Run("Decided claims cannot be decided again", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-claims/"+benClaim+"/approve", nil)
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+}
+
+func (suite *OpenShiftTestSuite) TestCredentialChecks() {
+	_, broadcastID := suite.broadcastShift(3, "approval")
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	anaClaim := suite.decodeData(w)["id"].(string)
+
+	// Both workers' screening has lapsed by the time the shift starts
+	lapsed := suite.monday.AddDate(0, 0, 1)
+	defer suite.requireCredential("Worker Screening", map[string]time.Time{suite.anaID: lapsed, suite.benID: lapsed})()
+
+	suite.Run("Workers with a lapsed credential cannot claim", func() {
+		w := suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal("CREDENTIALS_NOT_MET", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Claims made before the credential lapsed cannot be approved", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-claims/"+anaClaim+"/approve", nil)
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal("CREDENTIALS_NOT_MET", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+}
+
+func (suite *OpenShiftTestSuite) TestFirstComeClaims() {
+	shiftID, broadcastID := suite.broadcastShift(1, "first_come")
+
+	// Both workers claim at once; only one can get the shift
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i, token := range []string{suite.anaToken, suite.benToken} {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			codes[i] = suite.makeRequestWithToken(token, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil).Code
+		}(i, token)
+	}
+	wg.Wait()
+	suite.ElementsMatch([]int{http.StatusCreated, http.StatusConflict}, codes)
+
+	winner := suite.anaID
+	if codes[1] == http.StatusCreated {
+		winner = suite.benID
+	}
+	var shift models.Shift
+	suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+	suite.Require().NotNil(shift.StaffID)
+	suite.Equal(winner, *shift.StaffID)
+
+	var claims []models.ShiftClaim
+	suite.Require().NoError(suite.db.Where("broadcast_id = ?", broadcastID).Order("status").Find(&claims).Error)
+	suite.Require().Len(claims, 2)
+	suite.Equal(models.ClaimApproved, claims[0].Status)
+	suite.Equal(winner, claims[0].UserID)
+	suite.Equal(models.ClaimRejected, claims[1].Status)
+
+	var broadcast models.ShiftBroadcast
+	suite.Require().NoError(suite.db.First(&broadcast, "id = ?", broadcastID).Error)
+	suite.Equal(models.BroadcastFilled, broadcast.Status)
+}
+
+func (suite *OpenShiftTestSuite) TestRejectAndCancel() {
+	_, broadcastID := suite.broadcastShift(2, "approval")
+
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	anaClaim := suite.decodeData(w)["id"].(string)
+	w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/open-shifts/"+broadcastID+"/claim", nil)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	benClaim := suite.decodeData(w)["id"].(string)
+
+	suite.Run("Rejecting a claim records the reason", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-claims/"+anaClaim+"/reject", map[string]interface{}{
+			"reason": "Needs a driver",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(models.ClaimRejected, data["status"])
+		suite.Equal("Needs a driver", data["reason"])
+	})
+
+	suite.Run("Cancelling a broadcast turns down waiting claims", func() {
+		w := suite.makeAuthenticatedRequest("DELETE", "/api/v1/shift-broadcasts/"+broadcastID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var claim models.ShiftClaim
+		suite.Require().NoError(suite.db.First(&claim, "id = ?", benClaim).Error)
+		suite.Equal(models.ClaimRejected, claim.Status)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/shift-broadcasts?status=cancelled", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["broadcasts"], 1)
+	})
+}
+
+// TestOpenShiftSuite runs the open shift test suite
+func TestOpenShiftSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(OpenShiftTestSuite))
+}
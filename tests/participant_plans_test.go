@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// ParticipantPlansTestSuite covers plan history and drawdown from category budgets
+type ParticipantPlansTestSuite struct {
+	extendedTestSuite
+	coreItemID     string
+	capacityItemID string
+}
+
+// SetupSuite adds a Core and a Capacity Building support item
+func (suite *ParticipantPlansTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	core := models.SupportItem{ItemNumber: "01_011_0107_1_1", Name: "Self-Care Weekday", SupportCategoryNumber: "1", Unit: "H", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&core).Error)
+	suite.coreItemID = core.ID
+
+	capacity := models.SupportItem{ItemNumber: "15_037_0117_1_3", Name: "Assistance With Decision Making", SupportCategoryNumber: "15", Unit: "H", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&capacity).Error)
+	suite.capacityItemID = capacity.ID
+}
+
+func (suite *ParticipantPlansTestSuite) createPlan(body map[string]interface{}) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/plans", body)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+// invoiceShift completes and invoices a shift for a support item so it draws down the budget
+func (suite *ParticipantPlansTestSuite) invoiceShift(start time.Time, supportItemID string) string {
+	shiftID := suite.createCompletedShift(start, 2, 50)
+	suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).
+		Update("support_item_id", supportItemID).Error)
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return shiftID
+}
+
+func (suite *ParticipantPlansTestSuite) budgetLine(planID, purpose string) models.PlanBudgetLine {
+	var line models.PlanBudgetLine
+	suite.Require().NoError(suite.db.Where("plan_id = ? AND purpose = ?", planID, purpose).First(&line).Error)
+	return line
+}
+
+func (suite *ParticipantPlansTestSuite) TestPlanLifecycle() {
+	var firstPlanID, secondPlanID string
+
+	suite.Run("Reject a category outside its purpose", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/plans", map[string]interface{}{
+			"start_date": "2024-01-01",
+			"end_date":   "2024-12-31",
+			"budget_lines": []map[string]interface{}{
+				{"purpose": "capacity_building", "support_category_number": "01", "allocated": 100},
+			},
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("Shifts draw from their category budget", func() {
+		plan := suite.createPlan(map[string]interface{}{
+			"plan_number": "P-2024",
+			"start_date":  "2024-01-01",
+			"end_date":    "2024-12-31",
+			"budget_lines": []map[string]interface{}{
+				{"purpose": "core", "allocated": 10000},
+				{"purpose": "capacity_building", "support_category_number": "15", "allocated": 2000},
+			},
+		})
+		firstPlanID = plan["id"].(string)
+		suite.InDelta(12000, plan["total_budget"].(float64), 0.001)
+
+		suite.invoiceShift(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), suite.coreItemID)
+		suite.invoiceShift(time.Date(2024, 8, 5, 9, 0, 0, 0, time.UTC), suite.capacityItemID)
+
+		suite.InDelta(100, suite.budgetLine(firstPlanID, "core").Used, 0.001)
+		capacity := suite.budgetLine(firstPlanID, "capacity_building")
+		suite.InDelta(100, capacity.Used, 0.001)
+		suite.InDelta(1900, capacity.Remaining, 0.001)
+	})
+
+	suite.Run("A replacement plan ends the previous one and takes over its later shifts", func() {
+		plan := suite.createPlan(map[string]interface{}{
+			"plan_number":     "P-2024B",
+			"start_date":      "2024-07-01",
+			"end_date":        "2025-06-30",
+			"management_type": "plan",
+			"budget_lines": []map[string]interface{}{
+				{"purpose": "core", "allocated": 8000},
+				{"purpose": "capacity_building", "allocated": 3000},
+			},
+		})
+		secondPlanID = plan["id"].(string)
+
+		var previous models.ParticipantPlan
+		suite.Require().NoError(suite.db.First(&previous, "id = ?", firstPlanID).Error)
+		suite.Equal("2024-06-30", previous.EndDate.Format("2006-01-02"))
+
+		suite.InDelta(100, suite.budgetLine(firstPlanID, "core").Used, 0.001)
+		suite.InDelta(0, suite.budgetLine(firstPlanID, "capacity_building").Used, 0.001)
+		suite.InDelta(100, suite.budgetLine(secondPlanID, "capacity_building").Used, 0.001)
+	})
+
+	suite.Run("Plans cannot overlap a later plan", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/participants/"+suite.participantID+"/plans", map[string]interface{}{
+			"start_date":   "2024-06-01",
+			"end_date":     "2024-08-01",
+			"budget_lines": []map[string]interface{}{{"purpose": "core", "allocated": 100}},
+		})
+		suite.Equal(http.StatusConflict, w.Code)
+
+		var previous models.ParticipantPlan
+		suite.Require().NoError(suite.db.First(&previous, "id = ?", firstPlanID).Error)
+		suite.Equal("2024-06-30", previous.EndDate.Format("2006-01-02"))
+	})
+
+	suite.Run("Current plan is exposed on the participant", func() {
+		today := time.Now().UTC()
+		current := suite.createPlan(map[string]interface{}{
+			"start_date":   today.AddDate(0, 0, -30).Format("2006-01-02"),
+			"end_date":     today.AddDate(0, 11, 0).Format("2006-01-02"),
+			"budget_lines": []map[string]interface{}{{"purpose": "core", "allocated": 5000}},
+		})
+		suite.Equal("current", current["status"])
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/participants/"+suite.participantID+"/plans/"+current["id"].(string), map[string]interface{}{
+			"budget_lines": []map[string]interface{}{{"purpose": "capital", "allocated": 1000}},
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.InDelta(6000, suite.decodeData(w)["total_budget"].(float64), 0.001)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/participants/"+suite.participantID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		data := suite.decodeData(w)
+		plan := data["current_plan"].(map[string]interface{})
+		suite.Equal(current["id"], plan["id"])
+		suite.Len(plan["budget_lines"], 2)
+		suite.InDelta(6000, data["funding"].(map[string]interface{})["total_budget"].(float64), 0.001)
+	})
+
+	suite.Run("Past plans are kept", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/participants/"+suite.participantID+"/plans", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		plans := suite.decodeData(w)["plans"].([]interface{})
+		suite.Require().Len(plans, 3)
+		suite.Equal("current", plans[0].(map[string]interface{})["status"])
+		suite.Equal("ended", plans[2].(map[string]interface{})["status"])
+		suite.Equal(firstPlanID, plans[2].(map[string]interface{})["id"])
+	})
+}
+
+// TestParticipantPlansSuite runs the participant plans test suite
+func TestParticipantPlansSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ParticipantPlansTestSuite))
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// ParticipantRequirementTestSuite covers the skills and worker attributes a participant needs,
+// and how they limit who can be booked with them
+type ParticipantRequirementTestSuite struct {
+	extendedTestSuite
+	joID    string // female, speaks Greek and holds PEG feeding
+	joToken string
+	lenID   string // male, smokes and holds nothing
+}
+
+// SetupSuite adds two care workers, only one of whom suits the participant
+func (suite *ParticipantRequirementTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.joID = suite.createUser("req-jo", "jo@requirements.test", "care_worker")
+	suite.lenID = suite.createUser("req-len", "len@requirements.test", "care_worker")
+	suite.joToken = suite.login("jo@requirements.test")
+
+	suite.Require().NoError(suite.db.Create(&models.WorkerSkill{
+		UserID:           suite.joID,
+		SkillCategory:    "Clinical",
+		SkillName:        "PEG Feeding",
+		ProficiencyLevel: "advanced",
+		IsActive:         true,
+	}).Error)
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:   suite.lenID,
+		Gender:   "male",
+		Smoker:   true,
+		IsActive: true,
+	}).Error)
+}
+
+// addRequirement records a requirement for the participant, or one of their shifts
+func (suite *ParticipantRequirementTestSuite) addRequirement(path string, body map[string]interface{}) string {
+	w := suite.makeAuthenticatedRequest("POST", path, body)
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func matchKinds(list interface{}) map[string]bool {
+	met := map[string]bool{}
+	for _, item := range list.([]interface{}) {
+		match := item.(map[string]interface{})
+		met[match["kind"].(string)] = match["met"].(bool)
+	}
+	return met
+}
+
+func (suite *ParticipantRequirementTestSuite) TestRequirements() {
+	path := "/api/v1/participants/" + suite.participantID + "/requirements"
+
+	suite.Run("Requirements are validated", func() {
+		w := suite.makeAuthenticatedRequest("POST", path, map[string]interface{}{"kind": "skill"})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("POST", path, map[string]interface{}{"kind": "height", "value": "tall"})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.joToken, "POST", path, map[string]interface{}{"kind": "non_smoker"})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.addRequirement(path, map[string]interface{}{"kind": "skill", "value": "peg feeding"})
+	suite.addRequirement(path, map[string]interface{}{"kind": "non_smoker"})
+	suite.addRequirement(path, map[string]interface{}{"kind": "language", "value": "Greek", "strength": "preferred"})
+	genderID := suite.addRequirement(path, map[string]interface{}{"kind": "gender", "value": "female", "strength": "preferred"})
+
+	suite.Run("Requirements are listed", func() {
+		w := suite.makeAuthenticatedRequest("GET", path, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["requirements"], 4)
+	})
+
+	suite.Run("Workers who don't meet them cannot be booked", func() {
+		code, body := suite.bookShift(suite.lenID, 1, nil)
+		suite.Require().Equal(http.StatusConflict, code, body)
+		errorBody := body["error"].(map[string]interface{})
+		suite.Equal("REQUIREMENTS_NOT_MET", errorBody["code"])
+		suite.Len(errorBody["details"], 2)
+	})
+
+	var shiftID string
+	suite.Run("Workers who meet them can", func() {
+		w := suite.makeRequestWithToken(suite.joToken, "POST", "/api/v1/worker/preferences/", map[string]interface{}{
+			"gender":    "female",
+			"languages": []string{"English", "Greek"},
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		code, body := suite.bookShift(suite.joID, 2, nil)
+		suite.Require().Equal(http.StatusCreated, code, body)
+		shiftID = body["data"].(map[string]interface{})["id"].(string)
+	})
+
+	suite.Run("The shift explains why the worker matches", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		body := suite.decodeResponse(w)
+		suite.Len(body["requirements"], 4)
+
+		match := body["match"].(map[string]interface{})
+		suite.Equal(suite.joID, match["staff_id"])
+		suite.Equal(true, match["eligible"])
+		suite.Equal(map[string]bool{"skill": true, "non_smoker": true, "language": true, "gender": true}, matchKinds(match["requirements"]))
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID+"?staff_id="+suite.lenID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		match = suite.decodeResponse(w)["match"].(map[string]interface{})
+		suite.Equal(false, match["eligible"])
+		suite.Equal(map[string]bool{"skill": false, "non_smoker": false, "language": false, "gender": false}, matchKinds(match["requirements"]))
+	})
+
+	suite.Run("Suggestions exclude workers who don't meet them", func() {
+		code, body := suite.bookShift("", 3, nil)
+		suite.Require().Equal(http.StatusCreated, code, body)
+		openID := body["data"].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+openID+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal([]string{suite.joID}, workerIDs(data["candidates"]))
+		best := data["candidates"].([]interface{})[0].(map[string]interface{})
+		factors := []string{}
+		for _, factor := range best["factors"].([]interface{}) {
+			factors = append(factors, factor.(map[string]interface{})["name"].(string))
+		}
+		suite.Contains(factors, "participant_preference")
+
+		excluded := map[string][]string{}
+		for _, item := range data["excluded"].([]interface{}) {
+			worker := item.(map[string]interface{})
+			for _, reason := range worker["reasons"].([]interface{}) {
+				code := reason.(map[string]interface{})["code"].(string)
+				excluded[worker["worker_id"].(string)] = append(excluded[worker["worker_id"].(string)], code)
+			}
+		}
+		suite.Contains(excluded[suite.lenID], "MISSING_SKILL")
+		suite.Contains(excluded[suite.lenID], "REQUIREMENT_NOT_MET")
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+openID+"/assign", map[string]interface{}{
+			"staff_id": suite.lenID,
+		})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Shift requirements only apply to that shift", func() {
+		code, body := suite.bookShift("", 4, nil)
+		suite.Require().Equal(http.StatusCreated, code, body)
+		openID := body["data"].(map[string]interface{})["id"].(string)
+		suite.addRequirement("/api/v1/shifts/"+openID+"/requirements", map[string]interface{}{"kind": "no_pets"})
+
+		var prefs models.WorkerPreferences
+		suite.Require().NoError(suite.db.Where("user_id = ?", suite.joID).First(&prefs).Error)
+		suite.Require().NoError(suite.db.Model(&prefs).Update("has_pets", true).Error)
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+openID+"/assign", map[string]interface{}{
+			"staff_id": suite.joID,
+		})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("GET", path, nil)
+		suite.Len(suite.decodeData(w)["requirements"], 4)
+		w = suite.makeAuthenticatedRequest("GET", path+"?include_shifts=true", nil)
+		suite.Len(suite.decodeData(w)["requirements"], 5)
+
+		code, body = suite.bookShift(suite.joID, 5, nil)
+		suite.Equal(http.StatusCreated, code, body)
+	})
+
+	suite.Run("Requirements can be changed and removed", func() {
+		w := suite.makeAuthenticatedRequest("PUT", path+"/"+genderID, map[string]interface{}{
+			"kind": "gender", "value": "male",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("required", suite.decodeData(w)["strength"])
+
+		code, body := suite.bookShift(suite.joID, 6, nil)
+		suite.Equal(http.StatusConflict, code, body)
+
+		w = suite.makeAuthenticatedRequest("DELETE", path+"/"+genderID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		w = suite.makeAuthenticatedRequest("DELETE", path+"/"+genderID, nil)
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+
+		code, body = suite.bookShift(suite.joID, 6, nil)
+		suite.Equal(http.StatusCreated, code, body)
+	})
+}
+
+// TestParticipantRequirementSuite runs the participant requirement test suite
+func TestParticipantRequirementSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ParticipantRequirementTestSuite))
+}
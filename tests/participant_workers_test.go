@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// ParticipantWorkerTestSuite covers participants asking for particular workers or never to
+// see one again, and the continuity of care report
+type ParticipantWorkerTestSuite struct {
+	extendedTestSuite
+	sarahID string // the participant's favourite
+	tomID   string // blocked after an incident
+	workers string
+}
+
+// SetupSuite adds two care workers
+func (suite *ParticipantWorkerTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.sarahID = suite.createUser("pw-sarah", "sarah@workers.test", "care_worker")
+	suite.tomID = suite.createUser("pw-tom", "tom@workers.test", "care_worker")
+	suite.workers = "/api/v1/participants/" + suite.participantID + "/workers"
+}
+
+func (suite *ParticipantWorkerTestSuite) TestContinuityReport() {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	now := time.Now().In(loc)
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 9, 0, 0, 0, loc)
+
+	suite.createCompletedShift(thisMonth.AddDate(0, -1, 0), 2, 60)
+	suite.createCompletedShift(thisMonth.AddDate(0, -1, 1), 2, 60)
+	suite.createCompletedShift(thisMonth, 2, 60)
+	id := suite.createCompletedShift(thisMonth.AddDate(0, 0, 1), 2, 60)
+	suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", id).Update("staff_id", suite.sarahID).Error)
+	// Shifts before the report starts aren't counted
+	suite.createCompletedShift(thisMonth.AddDate(0, -3, 0), 2, 60)
+
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/reports/participants?months=2", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	continuity := suite.decodeData(w)["continuity_of_care"].(map[string]interface{})
+	suite.Equal([]interface{}{thisMonth.AddDate(0, -1, 0).Format("2006-01"), thisMonth.Format("2006-01")}, continuity["months"])
+	suite.Equal(1.5, continuity["average_workers_per_month"])
+
+	participants := continuity["participants"].([]interface{})
+	suite.Require().Len(participants, 1)
+	months := participants[0].(map[string]interface{})["months"].([]interface{})
+	suite.Equal(float64(1), months[0].(map[string]interface{})["workers"])
+	suite.Equal(float64(2), months[0].(map[string]interface{})["shifts"])
+	suite.Equal(float64(2), months[1].(map[string]interface{})["workers"])
+}
+
+func (suite *ParticipantWorkerTestSuite) TestPreferredAndBlockedWorkers() {
+	// Tom already has a shift booked with the participant
+	code, body := suite.bookShift(suite.tomID, 1, nil)
+	suite.Require().Equal(http.StatusCreated, code, body)
+	bookedID := body["data"].(map[string]interface{})["id"].(string)
+
+	suite.Run("Relationships are validated", func() {
+		w := suite.makeAuthenticatedRequest("PUT", suite.workers+"/"+suite.tomID, map[string]interface{}{"relationship": "blocked"})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("PUT", suite.workers+"/"+suite.tomID, map[string]interface{}{"relationship": "disliked", "reason": "Rude"})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("PUT", suite.workers+"/nobody", map[string]interface{}{"relationship": "blocked", "reason": "Rude"})
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+	})
+
+	suite.Run("Managers record who the participant wants and doesn't want", func() {
+		w := suite.makeAuthenticatedRequest("PUT", suite.workers+"/"+suite.sarahID, map[string]interface{}{
+			"relationship": "preferred", "reason": "Only Sarah on weekends",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal(suite.userID, suite.decodeData(w)["recorded_by"])
+
+		w = suite.makeAuthenticatedRequest("PUT", suite.workers+"/"+suite.tomID, map[string]interface{}{
+			"relationship": "preferred", "reason": "Friendly",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Empty(suite.decodeResponse(w)["booked"])
+
+		// Changing the relationship replaces it, and lists the shifts to reassign
+		w = suite.makeAuthenticatedRequest("PUT", suite.workers+"/"+suite.tomID, map[string]interface{}{
+			"relationship": "blocked", "reason": "Family complaint after incident",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		booked := suite.decodeResponse(w)["booked"].([]interface{})
+		suite.Require().Len(booked, 1)
+		suite.Equal(bookedID, booked[0].(map[string]interface{})["id"])
+
+		w = suite.makeAuthenticatedRequest("GET", suite.workers, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["workers"], 2)
+
+		w = suite.makeAuthenticatedRequest("GET", suite.workers+"?relationship=blocked", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		workers := suite.decodeData(w)["workers"].([]interface{})
+		suite.Require().Len(workers, 1)
+		blocked := workers[0].(map[string]interface{})
+		suite.Equal("Family complaint after incident", blocked["reason"])
+		suite.Equal(suite.tomID, blocked["user"].(map[string]interface{})["id"])
+	})
+
+	suite.Run("Blocked workers cannot be booked", func() {
+		code, body := suite.bookShift(suite.tomID, 2, nil)
+		suite.Require().Equal(http.StatusConflict, code, body)
+		suite.Equal("WORKER_BLOCKED", body["error"].(map[string]interface{})["code"])
+
+		code, body = suite.bookShift(suite.sarahID, 2, nil)
+		suite.Require().Equal(http.StatusCreated, code, body)
+		shiftID := body["data"].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{"staff_id": suite.tomID})
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal("WORKER_BLOCKED", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Suggestions favour preferred workers and exclude blocked ones", func() {
+		code, body := suite.bookShift("", 3, nil)
+		suite.Require().Equal(http.StatusCreated, code, body)
+		openID := body["data"].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+openID+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal([]string{suite.sarahID}, workerIDs(data["candidates"]))
+		factors := []string{}
+		for _, factor := range data["candidates"].([]interface{})[0].(map[string]interface{})["factors"].([]interface{}) {
+			factors = append(factors, factor.(map[string]interface{})["name"].(string))
+		}
+		suite.Contains(factors, "preferred_worker")
+
+		excluded := data["excluded"].([]interface{})
+		suite.Require().Len(excluded, 1)
+		tom := excluded[0].(map[string]interface{})
+		suite.Equal(suite.tomID, tom["worker_id"])
+		suite.Equal("BLOCKED_WORKER", tom["reasons"].([]interface{})[0].(map[string]interface{})["code"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+openID+"/assign", map[string]interface{}{"staff_id": suite.tomID})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Removing a block allows bookings again", func() {
+		w := suite.makeRequestWithToken(suite.login("sarah@workers.test"), "DELETE", suite.workers+"/"+suite.tomID, nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("DELETE", suite.workers+"/"+suite.tomID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		w = suite.makeAuthenticatedRequest("DELETE", suite.workers+"/"+suite.tomID, nil)
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+
+		code, body := suite.bookShift(suite.tomID, 4, nil)
+		suite.Equal(http.StatusCreated, code, body)
+	})
+}
+
+// TestParticipantWorkerSuite runs the participant worker test suite
+func TestParticipantWorkerSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ParticipantWorkerTestSuite))
+}
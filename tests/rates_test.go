@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// PenaltyRatesTestSuite covers band pricing of shifts, the rate card and public holidays
+type PenaltyRatesTestSuite struct {
+	extendedTestSuite
+}
+
+func (suite *PenaltyRatesTestSuite) createShift(start, end string, rate float64) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.userID,
+		"start_time":     start,
+		"end_time":       end,
+		"service_type":   "Personal Care",
+		"location":       "Home",
+		"hourly_rate":    rate,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *PenaltyRatesTestSuite) TestBandPricing() {
+	suite.Run("Configure the rate card", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/organization/rate-card", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Equal(1.0, suite.decodeData(w)["saturday_multiplier"])
+
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/rate-card", map[string]interface{}{
+			"evening_multiplier":        1.1,
+			"saturday_multiplier":       1.5,
+			"public_holiday_multiplier": 2,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(1.1, suite.decodeData(w)["evening_multiplier"])
+	})
+
+	suite.Run("Maintain public holidays", func() {
+		holiday := map[string]interface{}{"date": "2024-12-25", "name": "Christmas Day", "state": "South Australia"}
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/public-holidays", holiday)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Equal("SA", suite.decodeData(w)["state"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/public-holidays", holiday)
+		suite.Equal(http.StatusConflict, w.Code)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/public-holidays", map[string]interface{}{
+			"date": "2024-11-04", "name": "Melbourne Cup", "state": "VIC",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/public-holidays", map[string]interface{}{
+			"date": "2024-01-26", "name": "Australia Day", "state": "Atlantis",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/public-holidays?state=SA&year=2024", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		suite.Len(suite.decodeData(w)["public_holidays"], 1)
+	})
+
+	var shiftID string
+
+	suite.Run("Shift across the evening boundary prices each band", func() {
+		// Friday 6pm to 10pm in Adelaide
+		shift := suite.createShift("2024-08-09T18:00:00", "2024-08-09T22:00:00", 60)
+		shiftID = shift["id"].(string)
+		suite.InDelta(252, shift["total_cost"].(float64), 0.001)
+
+		bands := shift["cost_bands"].([]interface{})
+		suite.Require().Len(bands, 2)
+		suite.Equal("weekday", bands[0].(map[string]interface{})["band"])
+		suite.Equal("evening", bands[1].(map[string]interface{})["band"])
+		suite.InDelta(66, bands[1].(map[string]interface{})["rate"].(float64), 0.001)
+	})
+
+	suite.Run("Public holidays follow the participant's state", func() {
+		christmas := suite.createShift("2024-12-25T09:00:00", "2024-12-25T11:00:00", 60)
+		suite.InDelta(240, christmas["total_cost"].(float64), 0.001)
+
+		// Melbourne Cup is not a holiday in South Australia
+		cup := suite.createShift("2024-11-04T09:00:00", "2024-11-04T11:00:00", 60)
+		suite.InDelta(120, cup["total_cost"].(float64), 0.001)
+	})
+
+	suite.Run("Changing the rate reprices the bands", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{
+			"hourly_rate": 50,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.InDelta(210, suite.decodeData(w)["total_cost"].(float64), 0.001)
+
+		var count int64
+		suite.db.Model(&models.ShiftCostBand{}).Where("shift_id = ?", shiftID).Count(&count)
+		suite.Equal(int64(2), count)
+	})
+
+	suite.Run("Invoices carry a line per band", func() {
+		suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).Update("status", "completed").Error)
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{shiftID},
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		suite.InDelta(210, data["total"].(float64), 0.001)
+		lines := data["lines"].([]interface{})
+		suite.Require().Len(lines, 2)
+		suite.Contains(lines[1].(map[string]interface{})["description"], "(Evening)")
+		suite.InDelta(55, lines[1].(map[string]interface{})["unit_price"].(float64), 0.001)
+	})
+}
+
+// TestPenaltyRatesSuite runs the penalty rates test suite
+func TestPenaltyRatesSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(PenaltyRatesTestSuite))
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/notify"
+	"github.com/stretchr/testify/suite"
+)
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func (m *recordingMailer) Send(msg notify.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *recordingMailer) sent() []notify.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]notify.Message(nil), m.messages...)
+}
+
+// ReceivablesTestSuite covers overdue detection, payment reminders, credit notes and aged receivables
+type ReceivablesTestSuite struct {
+	extendedTestSuite
+	mailer *recordingMailer
+}
+
+// SetupSuite makes the participant plan managed and captures outgoing email
+func (suite *ReceivablesTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.mailer = &recordingMailer{}
+	suite.handler.Mailer = suite.mailer
+
+	suite.Require().NoError(suite.db.Model(&models.Participant{}).Where("id = ?", suite.participantID).
+		UpdateColumns(map[string]interface{}{
+			"funding_management_type":    "plan",
+			"funding_plan_manager_name":  "Plan Partners",
+			"funding_plan_manager_email": "accounts@planpartners.test",
+		}).Error)
+}
+
+// invoiceDrafted bills a $120 shift and backdates the invoice to the given age in days,
+// due 30 days after issue, without sending it
+func (suite *ReceivablesTestSuite) invoiceDrafted(daysAgo int) string {
+	shiftID := suite.createCompletedShift(time.Now().AddDate(0, 0, -daysAgo-1), 2, 60)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"shift_ids":      []string{shiftID},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	invoiceID := suite.decodeData(w)["id"].(string)
+
+	issued := time.Now().AddDate(0, 0, -daysAgo)
+	suite.Require().NoError(suite.db.Model(&models.Invoice{}).Where("id = ?", invoiceID).
+		Updates(map[string]interface{}{"issue_date": issued, "due_date": issued.AddDate(0, 0, 30)}).Error)
+	return invoiceID
+}
+
+// invoiceIssued drafts an invoice of the given age and marks it sent to the payer
+func (suite *ReceivablesTestSuite) invoiceIssued(daysAgo int) string {
+	invoiceID := suite.invoiceDrafted(daysAgo)
+	suite.Require().NoError(suite.db.Model(&models.Invoice{}).Where("id = ?", invoiceID).Update("status", "sent").Error)
+	return invoiceID
+}
+
+func (suite *ReceivablesTestSuite) invoice(invoiceID string) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/billing/"+invoiceID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *ReceivablesTestSuite) runReceivables() map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/receivables/run", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *ReceivablesTestSuite) TestReceivables() {
+	recent := suite.invoiceIssued(10)
+	late := suite.invoiceIssued(45)
+	veryLate := suite.invoiceIssued(100)
+	unsent := suite.invoiceDrafted(45)
+
+	suite.Run("Reminder sequence must be increasing days", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/settings", map[string]interface{}{
+			"payment_reminder_days": "14,7",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("Invoices past their due date are marked overdue and reminded", func() {
+		run := suite.runReceivables()
+		suite.Equal(float64(2), run["marked_overdue"])
+		suite.Equal(float64(2), run["reminders_sent"])
+
+		suite.Equal("sent", suite.invoice(recent)["status"])
+		suite.Equal("overdue", suite.invoice(late)["status"])
+
+		messages := suite.mailer.sent()
+		suite.Require().Len(messages, 2)
+		suite.Equal([]string{"accounts@planpartners.test"}, messages[0].To)
+		suite.Contains(messages[0].Subject, "is overdue")
+		suite.Contains(messages[0].Body, "Dear Plan Partners,")
+
+		// 70 days overdue is past every step, so only the last one is sent
+		var reminder models.InvoiceReminder
+		suite.Require().NoError(suite.db.First(&reminder, "invoice_id = ?", veryLate).Error)
+		suite.Equal(3, reminder.Step)
+		suite.Equal("sent", reminder.Status)
+	})
+
+	suite.Run("Drafts past their due date have not been sent, so are left alone", func() {
+		suite.Equal("draft", suite.invoice(unsent)["status"])
+
+		var reminders int64
+		suite.db.Model(&models.InvoiceReminder{}).Where("invoice_id = ?", unsent).Count(&reminders)
+		suite.Equal(int64(0), reminders)
+
+		// Out of the aged receivables below
+		suite.Require().NoError(suite.db.Delete(&models.Invoice{}, "id = ?", unsent).Error)
+	})
+
+	suite.Run("Each reminder step is only sent once", func() {
+		run := suite.runReceivables()
+		suite.Equal(float64(0), run["marked_overdue"])
+		suite.Equal(float64(0), run["reminders_sent"])
+		suite.Len(suite.mailer.sent(), 2)
+	})
+
+	suite.Run("Partial payments and credit notes reduce the balance", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+late+"/payment", map[string]interface{}{"amount": 50})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("overdue", suite.decodeData(w)["status"])
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+late+"/credit-notes", map[string]interface{}{
+			"amount": 80,
+			"reason": "Shift was shorter than booked",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+late+"/credit-notes", map[string]interface{}{
+			"amount": 20,
+			"reason": "Shift was shorter than booked",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.InDelta(20, data["credited_amount"].(float64), 0.001)
+		suite.InDelta(50, data["balance_due"].(float64), 0.001)
+		creditNote := data["credit_notes"].([]interface{})[0].(map[string]interface{})
+		suite.Equal("CN-000001", creditNote["credit_note_number"])
+
+		// Paying the rest settles the invoice
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/billing/"+late+"/payment", map[string]interface{}{"amount": 50})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("paid", suite.decodeData(w)["status"])
+	})
+
+	suite.Run("Aged receivables are broken down per participant and plan manager", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/reports/aged-receivables", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+
+		totals := data["totals"].(map[string]interface{})
+		suite.InDelta(120, totals["current"].(float64), 0.001)
+		suite.InDelta(0, totals["days_30"].(float64), 0.001)
+		suite.InDelta(120, totals["days_90_plus"].(float64), 0.001)
+		suite.InDelta(240, totals["total"].(float64), 0.001)
+		suite.InDelta(120, totals["overdue"].(float64), 0.001)
+
+		participants := data["participants"].([]interface{})
+		suite.Require().Len(participants, 1)
+		participant := participants[0].(map[string]interface{})
+		suite.Equal("Jane Smith", participant["participant_name"])
+		suite.Equal(float64(2), participant["invoice_count"])
+
+		planManagers := data["plan_managers"].([]interface{})
+		suite.Require().Len(planManagers, 1)
+		manager := planManagers[0].(map[string]interface{})
+		suite.Equal("Plan Partners", manager["plan_manager_name"])
+		suite.InDelta(240, manager["total"].(float64), 0.001)
+
+		// Looking back to before the recent invoice was issued leaves it out
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/reports/aged-receivables?as_at="+time.Now().AddDate(0, 0, -20).Format("2006-01-02"), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.InDelta(120, suite.decodeData(w)["totals"].(map[string]interface{})["total"].(float64), 0.001)
+	})
+}
+
+// TestReceivablesSuite runs the receivables test suite
+func TestReceivablesSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ReceivablesTestSuite))
+}
@@ -0,0 +1,325 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// RosterTestSuite covers roster templates and publishing rosters to staff
+type RosterTestSuite struct {
+	extendedTestSuite
+	mailer      *recordingMailer
+	workerID    string
+	workerToken string
+}
+
+// SetupSuite adds a care worker and captures outgoing email
+func (suite *RosterTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.mailer = &recordingMailer{}
+	suite.handler.Mailer = suite.mailer
+
+	suite.workerID = suite.createUser("roster-worker-id", "worker@roster.test", "care_worker")
+	suite.workerToken = suite.login("worker@roster.test")
+}
+
+// periodStart returns a Monday at least a week away, in the organization's timezone
+func (suite *RosterTestSuite) periodStart() time.Time {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+}
+
+func (suite *RosterTestSuite) workerShifts() []interface{} {
+	w := suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/shifts?staff_id="+suite.workerID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)["shifts"].([]interface{})
+}
+
+func (suite *RosterTestSuite) publish(from, to time.Time) map[string]interface{} {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/rosters/publish", map[string]interface{}{
+		"start_date": from.Format("2006-01-02"),
+		"end_date":   to.Format("2006-01-02"),
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *RosterTestSuite) TestRosterPublishing() {
+	start := suite.periodStart()
+	templateShift := func(day int, startTime, endTime string) map[string]interface{} {
+		return map[string]interface{}{
+			"day_offset":     day,
+			"start_time":     startTime,
+			"end_time":       endTime,
+			"participant_id": suite.participantID,
+			"staff_id":       suite.workerID,
+			"service_type":   "Personal Care",
+			"location":       "Participant home",
+			"hourly_rate":    60,
+		}
+	}
+
+	suite.Run("Template shifts must fall within the period", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates", map[string]interface{}{
+			"name":   "Too long",
+			"shifts": []interface{}{templateShift(14, "09:00", "12:00")},
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates", map[string]interface{}{
+		"name": "Jane fortnight",
+		"shifts": []interface{}{
+			templateShift(0, "09:00", "12:00"),
+			templateShift(2, "22:00", "06:00"),
+		},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	template := suite.decodeData(w)
+	templateID := template["id"].(string)
+	suite.Equal(float64(14), template["period_days"])
+	overnight := template["shifts"].([]interface{})[1].(map[string]interface{})
+	suite.Equal(float64(8*60), overnight["duration_minutes"])
+
+	var draftID string
+	suite.Run("Templates are stamped onto a date range as draft shifts", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates/"+templateID+"/apply", map[string]interface{}{
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   start.AddDate(0, 0, 27).Format("2006-01-02"),
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		shifts := data["shifts"].([]interface{})
+		suite.Require().Len(shifts, 4)
+		suite.Empty(data["conflicts"])
+		first := shifts[0].(map[string]interface{})
+		draftID = first["id"].(string)
+		suite.Equal("draft", first["publish_status"])
+		firstStart, err := time.Parse(time.RFC3339, first["start_time"].(string))
+		suite.Require().NoError(err)
+		suite.True(firstStart.Equal(start.Add(9 * time.Hour)))
+
+		// Stamping the same range again clashes with every shift
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates/"+templateID+"/apply", map[string]interface{}{
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   start.AddDate(0, 0, 27).Format("2006-01-02"),
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Empty(suite.decodeData(w)["shifts"])
+		suite.Len(suite.decodeData(w)["conflicts"], 4)
+	})
+
+	suite.Run("Workers do not see draft shifts", func() {
+		suite.Empty(suite.workerShifts())
+		w := suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/shifts/"+draftID, nil)
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/rosters/changes?start_date="+start.Format("2006-01-02")+
+			"&end_date="+start.AddDate(0, 0, 13).Format("2006-01-02"), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(float64(2), data["draft_shifts"])
+		workers := data["workers"].([]interface{})
+		suite.Require().Len(workers, 1)
+		suite.Len(workers[0].(map[string]interface{})["new"], 2)
+	})
+
+	suite.Run("Publishing releases the period and notifies each worker", func() {
+		data := suite.publish(start, start.AddDate(0, 0, 13))
+		suite.Equal(float64(2), data["publication"].(map[string]interface{})["shifts_published"])
+		suite.Len(suite.workerShifts(), 2)
+
+		messages := suite.mailer.sent()
+		suite.Require().Len(messages, 1)
+		suite.Equal([]string{"worker@roster.test"}, messages[0].To)
+		suite.Contains(messages[0].Body, "New shifts:")
+		suite.Contains(messages[0].Body, "09:00-12:00 with Jane Smith")
+	})
+
+	suite.Run("Republishing reports new, changed and removed shifts", func() {
+		shifts := suite.workerShifts()
+		suite.Require().Len(shifts, 2)
+		var morning, overnight string
+		for _, s := range shifts {
+			shift := s.(map[string]interface{})
+			shiftStart, _ := time.Parse(time.RFC3339, shift["start_time"].(string))
+			if shiftStart.Equal(start.Add(9 * time.Hour)) {
+				morning = shift["id"].(string)
+			} else {
+				overnight = shift["id"].(string)
+			}
+		}
+		suite.Require().NotEmpty(morning)
+		suite.Require().NotEmpty(overnight)
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+morning, map[string]interface{}{
+			"start_time": start.Add(10 * time.Hour).Format("2006-01-02T15:04:05"),
+			"end_time":   start.Add(13 * time.Hour).Format("2006-01-02T15:04:05"),
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		w = suite.makeAuthenticatedRequest("DELETE", "/api/v1/shifts/"+overnight, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"staff_id":       suite.workerID,
+			"start_time":     start.AddDate(0, 0, 4).Add(14 * time.Hour).Format("2006-01-02T15:04:05"),
+			"end_time":       start.AddDate(0, 0, 4).Add(16 * time.Hour).Format("2006-01-02T15:04:05"),
+			"service_type":   "Community Access",
+			"location":       "Library",
+			"hourly_rate":    60,
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.publish(start, start.AddDate(0, 0, 13))
+		workers := data["workers"].([]interface{})
+		suite.Require().Len(workers, 1)
+		changes := workers[0].(map[string]interface{})
+		suite.Len(changes["new"], 1)
+		suite.Len(changes["changed"], 1)
+		suite.Len(changes["removed"], 1)
+
+		messages := suite.mailer.sent()
+		suite.Require().Len(messages, 2)
+		suite.Contains(messages[1].Body, "10:00-13:00 with Jane Smith at Participant home (was ")
+		suite.Contains(messages[1].Body, "Removed shifts:")
+		suite.Len(suite.workerShifts(), 2)
+	})
+
+	suite.Run("Publishing an unchanged roster notifies nobody", func() {
+		data := suite.publish(start, start.AddDate(0, 0, 13))
+		suite.Empty(data["workers"])
+		suite.Len(suite.mailer.sent(), 2)
+	})
+
+	suite.Run("Workers read their notifications", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/notifications", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(float64(2), data["unread"])
+		notifications := data["notifications"].([]interface{})
+		suite.Require().Len(notifications, 2)
+		notification := notifications[0].(map[string]interface{})
+		suite.Equal("roster_published", notification["type"])
+
+		w = suite.makeRequestWithToken(suite.workerToken, "PATCH", "/api/v1/notifications/"+notification["id"].(string)+"/read", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/notifications?unread=true", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["notifications"], 1)
+
+		// Other users cannot see them
+		w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/notifications/"+notification["id"].(string)+"/read", nil)
+		suite.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *RosterTestSuite) TestTemplateChecks() {
+	start := suite.periodStart().AddDate(0, 0, 56)
+
+	// One worker needs ten hours between shifts, the other has been blocked by the participant
+	restedID := suite.createUser("roster-rested", "rested@roster.test", "care_worker")
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:                restedID,
+		MaxHoursPerWeek:       40,
+		MaxConsecutiveDays:    5,
+		MinHoursBetweenShifts: 10,
+		IsActive:              true,
+	}).Error)
+	blockedID := suite.createUser("roster-blocked", "blocked@roster.test", "care_worker")
+	w := suite.makeAuthenticatedRequest("PUT", "/api/v1/participants/"+suite.participantID+"/workers/"+blockedID, map[string]interface{}{
+		"relationship": "blocked", "reason": "Asked for someone else",
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	templateShift := func(staffID string, day int, startTime, endTime string) map[string]interface{} {
+		return map[string]interface{}{
+			"day_offset":     day,
+			"start_time":     startTime,
+			"end_time":       endTime,
+			"participant_id": suite.participantID,
+			"staff_id":       staffID,
+			"service_type":   "Personal Care",
+			"location":       "Adelaide SA 5000",
+			"hourly_rate":    60,
+		}
+	}
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates", map[string]interface{}{
+		"name":        "Checked week",
+		"period_days": 7,
+		"shifts": []interface{}{
+			templateShift(restedID, 0, "09:00", "17:00"),
+			templateShift(restedID, 0, "20:00", "23:00"),
+			templateShift(blockedID, 1, "09:00", "12:00"),
+		},
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	templateID := suite.decodeData(w)["id"].(string)
+
+	// The evening shift is checked against the day shift stamped before it
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/roster-templates/"+templateID+"/apply", map[string]interface{}{
+		"start_date": start.Format("2006-01-02"),
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	suite.Len(data["shifts"], 1)
+	conflicts := data["conflicts"].([]interface{})
+	suite.Require().Len(conflicts, 2)
+	rest := conflicts[0].(map[string]interface{})
+	suite.Equal("FATIGUE_RULES_BROKEN", rest["code"])
+	suite.Equal("INSUFFICIENT_REST", rest["details"].([]interface{})[0].(map[string]interface{})["rule"])
+	suite.Equal("WORKER_BLOCKED", conflicts[1].(map[string]interface{})["code"])
+}
+
+func (suite *RosterTestSuite) TestWorkerReassignment() {
+	day := suite.periodStart().AddDate(0, 0, 70)
+	otherID := suite.createUser("roster-other", "other@roster.test", "care_worker")
+	otherToken := suite.login("other@roster.test")
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.workerID,
+		"start_time":     day.Add(14 * time.Hour).Format("2006-01-02T15:04:05"),
+		"end_time":       day.Add(16 * time.Hour).Format("2006-01-02T15:04:05"),
+		"service_type":   "Community Access",
+		"location":       "Library",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	shiftID := suite.decodeData(w)["id"].(string)
+	suite.publish(day, day)
+
+	suite.Run("Reassigned shifts wait for the roster to be published again", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{"staff_id": otherID})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("draft", suite.decodeData(w)["publish_status"])
+
+		w = suite.makeRequestWithToken(otherToken, "GET", "/api/v1/shifts/"+shiftID, nil)
+		suite.Equal(http.StatusNotFound, w.Code, w.Body.String())
+
+		workers := suite.publish(day, day)["workers"].([]interface{})
+		suite.Len(workers, 2)
+
+		w = suite.makeRequestWithToken(otherToken, "GET", "/api/v1/shifts/"+shiftID, nil)
+		suite.Equal(http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+// TestRosterSuite runs the roster test suite
+func TestRosterSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(RosterTestSuite))
+}
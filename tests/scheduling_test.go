@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// SchedulingTestSuite covers suggesting, assigning and automatically filling open shifts
+type SchedulingTestSuite struct {
+	extendedTestSuite
+	monday time.Time
+	samID  string // regular worker with a history of shifts with the participant
+	alexID string // works weekday business hours, holds First Aid
+	kimID  string // on approved leave for the first Monday
+}
+
+// SetupSuite adds three care workers with availability, skills and leave
+func (suite *SchedulingTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	suite.monday = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	suite.samID = suite.createWorker("sched-sam", "Sam")
+	suite.alexID = suite.createWorker("sched-alex", "Alex")
+	suite.kimID = suite.createWorker("sched-kim", "Kim")
+
+	for i := 1; i <= 3; i++ {
+		id := suite.createCompletedShift(time.Now().AddDate(0, 0, -7*i), 2, 60)
+		suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", id).Update("staff_id", suite.samID).Error)
+	}
+
+	for day := 1; day <= 5; day++ {
+		suite.Require().NoError(suite.db.Create(&models.WorkerAvailability{
+			UserID:         suite.alexID,
+			DayOfWeek:      day,
+			StartTime:      time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC),
+			EndTime:        time.Date(0, 1, 1, 18, 0, 0, 0, time.UTC),
+			IsAvailable:    true,
+			MaxHoursPerDay: 8,
+			IsActive:       true,
+		}).Error)
+	}
+	suite.Require().NoError(suite.db.Create(&models.WorkerSkill{
+		UserID:           suite.alexID,
+		SkillCategory:    "certification",
+		SkillName:        "First Aid",
+		ProficiencyLevel: "advanced",
+		IsActive:         true,
+	}).Error)
+
+	suite.Require().NoError(suite.db.Create(&models.WorkerAvailabilityException{
+		UserID:        suite.kimID,
+		ExceptionDate: time.Date(suite.monday.Year(), suite.monday.Month(), suite.monday.Day(), 0, 0, 0, 0, time.UTC),
+		ExceptionType: "unavailable",
+		Reason:        "Annual leave",
+		IsApproved:    true,
+	}).Error)
+}
+
+func (suite *SchedulingTestSuite) createWorker(id, name string) string {
+	suite.createUser(id, id+"@scheduling.test", "care_worker")
+	suite.Require().NoError(suite.db.Model(&models.User{}).Where("id = ?", id).Update("first_name", name).Error)
+	return id
+}
+
+// createOpenShift books a shift with nobody assigned, starting hours after the first Monday
+func (suite *SchedulingTestSuite) createOpenShift(start time.Duration, hours int) map[string]interface{} {
+	from := suite.monday.Add(start)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"start_time":     from.Format("2006-01-02T15:04:05"),
+		"end_time":       from.Add(time.Duration(hours) * time.Hour).Format("2006-01-02T15:04:05"),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func workerIDs(list interface{}) []string {
+	ids := []string{}
+	for _, item := range list.([]interface{}) {
+		ids = append(ids, item.(map[string]interface{})["worker_id"].(string))
+	}
+	return ids
+}
+
+func (suite *SchedulingTestSuite) TestSuggestAndAssign() {
+	shift := suite.createOpenShift(9*time.Hour, 3)
+	shiftID := shift["id"].(string)
+	suite.Nil(shift["staff_id"])
+
+	suite.Run("Open shifts can be listed", func() {
+		day := suite.monday.Format("2006-01-02")
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts?open=true&start_date="+day+"&end_date="+day, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		shifts := suite.decodeData(w)["shifts"].([]interface{})
+		suite.Require().Len(shifts, 1)
+		suite.Equal(shiftID, shifts[0].(map[string]interface{})["id"])
+	})
+
+	suite.Run("Workers are ranked and exclusions explained", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal([]string{suite.samID, suite.alexID}, workerIDs(data["candidates"]))
+		best := data["candidates"].([]interface{})[0].(map[string]interface{})
+		suite.Equal(float64(9), best["score"])
+		suite.Equal("continuity", best["factors"].([]interface{})[0].(map[string]interface{})["name"])
+
+		excluded := data["excluded"].([]interface{})
+		suite.Require().Len(excluded, 1)
+		kim := excluded[0].(map[string]interface{})
+		suite.Equal(suite.kimID, kim["worker_id"])
+		suite.Equal("TIME_OFF", kim["reasons"].([]interface{})[0].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Required skills exclude workers without them", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+shiftID+"/suggestions?skills=First%20Aid", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal([]string{suite.alexID}, workerIDs(data["candidates"]))
+		suite.Len(data["excluded"], 2)
+	})
+
+	suite.Run("Ineligible workers cannot be assigned", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{
+			"staff_id": suite.kimID,
+		})
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		errorBody := suite.decodeResponse(w)["error"].(map[string]interface{})
+		suite.Equal("WORKER_NOT_ELIGIBLE", errorBody["code"])
+		suite.Len(errorBody["details"], 1)
+	})
+
+	suite.Run("Eligible workers are assigned", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{
+			"staff_id": suite.alexID,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(suite.alexID, suite.decodeData(w)["staff_id"])
+
+		// Alex is now booked, so an overlapping shift goes to someone else
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/shifts/"+suite.createOpenShift(10*time.Hour, 1)["id"].(string)+"/suggestions", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal([]string{suite.samID}, workerIDs(suite.decodeData(w)["candidates"]))
+	})
+
+	suite.Run("Assigned shifts are only taken off their worker on request", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{
+			"staff_id": suite.samID,
+		})
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal("SHIFT_ALREADY_ASSIGNED", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+
+		var shift models.Shift
+		suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+		suite.Equal(suite.alexID, *shift.StaffID)
+	})
+}
+
+func (suite *SchedulingTestSuite) TestCredentialChecks() {
+	// Sam would usually be chosen, but his screening lapses the week before
+	shift := suite.createOpenShift(21*24*time.Hour+24*time.Hour+9*time.Hour, 2)["id"].(string)
+	defer suite.requireCredential("Worker Screening", map[string]time.Time{
+		suite.samID:  suite.monday.AddDate(0, 0, 14),
+		suite.alexID: {},
+	})()
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/rosters/solve", map[string]interface{}{
+		"start_date": suite.monday.AddDate(0, 0, 22).Format("2006-01-02"),
+		"end_date":   suite.monday.AddDate(0, 0, 22).Format("2006-01-02"),
+		"dry_run":    true,
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	assignments := suite.decodeData(w)["assignments"].([]interface{})
+	suite.Require().Len(assignments, 1)
+	suite.Equal(shift, assignments[0].(map[string]interface{})["shift_id"])
+	suite.Equal(suite.alexID, assignments[0].(map[string]interface{})["worker_id"])
+}
+
+func (suite *SchedulingTestSuite) TestSolveRoster() {
+	// A week later, when nobody is on leave
+	base := 7 * 24 * time.Hour
+	evening := suite.createOpenShift(base+19*time.Hour, 2)["id"].(string)
+	late := suite.createOpenShift(base+20*time.Hour, 2)["id"].(string)
+	morning := suite.createOpenShift(base+24*time.Hour+9*time.Hour, 2)["id"].(string)
+
+	body := map[string]interface{}{
+		"start_date": suite.monday.AddDate(0, 0, 7).Format("2006-01-02"),
+		"end_date":   suite.monday.AddDate(0, 0, 8).Format("2006-01-02"),
+		"dry_run":    true,
+	}
+	solve := func() map[string]interface{} {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/rosters/solve", body)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		return suite.decodeData(w)
+	}
+	assigned := func(data map[string]interface{}) map[string]string {
+		result := map[string]string{}
+		for _, a := range data["assignments"].([]interface{}) {
+			assignment := a.(map[string]interface{})
+			result[assignment["shift_id"].(string)] = assignment["worker_id"].(string)
+		}
+		return result
+	}
+
+	suite.Run("A dry run proposes assignments without saving them", func() {
+		data := solve()
+		suite.Equal(float64(3), data["open_shifts"])
+		suite.Empty(data["unfilled"])
+		// Sam is preferred for both evening shifts but can only take one of them
+		suite.Equal(map[string]string{evening: suite.samID, late: suite.kimID, morning: suite.samID}, assigned(data))
+
+		var open int64
+		suite.db.Model(&models.Shift{}).Where("id IN ? AND staff_id IS NULL", []string{evening, late, morning}).Count(&open)
+		suite.Equal(int64(3), open)
+	})
+
+	suite.Run("Solving saves the assignments and reports unfilled shifts", func() {
+		// Kim cannot work evenings
+		suite.Require().NoError(suite.db.Create(&models.WorkerAvailability{
+			UserID:      suite.kimID,
+			DayOfWeek:   int(time.Monday),
+			StartTime:   time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+			EndTime:     time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC),
+			IsAvailable: true,
+			IsActive:    true,
+		}).Error)
+
+		body["dry_run"] = false
+		data := solve()
+		got := assigned(data)
+		suite.Equal(suite.samID, got[evening])
+		suite.Equal(suite.samID, got[morning])
+		unfilled := data["unfilled"].([]interface{})
+		suite.Require().Len(unfilled, 1)
+		suite.Equal(late, unfilled[0].(map[string]interface{})["shift_id"])
+		suite.Len(unfilled[0].(map[string]interface{})["exclusions"], 3)
+
+		var shift models.Shift
+		suite.Require().NoError(suite.db.First(&shift, "id = ?", evening).Error)
+		suite.Require().NotNil(shift.StaffID)
+		suite.Equal(suite.samID, *shift.StaffID)
+	})
+}
+
+func (suite *SchedulingTestSuite) TestAutoAssign() {
+	suite.Require().NoError(suite.db.Model(&models.OrganizationSettings{}).
+		Where("organization_id = ?", suite.orgID).Update("auto_assign_shifts", true).Error)
+	defer suite.db.Model(&models.OrganizationSettings{}).
+		Where("organization_id = ?", suite.orgID).Update("auto_assign_shifts", false)
+
+	shift := suite.createOpenShift(14*24*time.Hour+13*time.Hour, 2)
+	suite.Equal(suite.samID, shift["staff_id"])
+}
+
+// TestSchedulingSuite runs the scheduling test suite
+func TestSchedulingSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(SchedulingTestSuite))
+}
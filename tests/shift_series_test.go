@@ -0,0 +1,413 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// ShiftSeriesTestSuite covers recurring shift series and edits to their occurrences
+type ShiftSeriesTestSuite struct {
+	extendedTestSuite
+}
+
+// nextMonday returns 9am on a Monday at least a week away, in the organization's timezone
+func (suite *ShiftSeriesTestSuite) nextMonday() time.Time {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, loc)
+}
+
+func (suite *ShiftSeriesTestSuite) seriesShifts(seriesID string) []models.Shift {
+	var shifts []models.Shift
+	suite.Require().NoError(suite.db.Where("series_id = ?", seriesID).Order("start_time").Find(&shifts).Error)
+	return shifts
+}
+
+// createParticipant adds a participant of the test's own, which keeps its series out of
+// the main test's listing
+func (suite *ShiftSeriesTestSuite) createParticipant(id, ndisNumber string) string {
+	participant := models.Participant{
+		ID:             id,
+		FirstName:      "Sam",
+		LastName:       "Jones",
+		DateOfBirth:    time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     ndisNumber,
+		Address:        models.Address{State: "SA", Postcode: "5000"},
+		OrganizationID: suite.orgID,
+		IsActive:       true,
+	}
+	suite.Require().NoError(suite.db.Create(&participant).Error)
+	return participant.ID
+}
+
+func (suite *ShiftSeriesTestSuite) TestOccurrenceChecks() {
+	monday := suite.nextMonday().AddDate(0, 0, 28)
+	local := "2006-01-02T15:04:05"
+
+	participantID := suite.createParticipant("series-checks-participant", "430000002")
+
+	// First aid is mandatory, and one worker's lapses after the first week
+	credential := models.MandatoryCredential{OrganizationID: suite.orgID, Name: "First Aid", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&credential).Error)
+	defer suite.db.Model(&credential).Update("is_active", false)
+
+	lapsingID := suite.createUser("series-lapsing", "lapsing@series.test", "care_worker")
+	blockedID := suite.createUser("series-blocked", "blocked@series.test", "care_worker")
+	expiry := monday.AddDate(0, 0, 3)
+	verified := time.Now()
+	for _, skill := range []models.WorkerSkill{
+		{UserID: lapsingID, SkillCategory: "Certifications", SkillName: "First Aid", ProficiencyLevel: "advanced", ExpiryDate: &expiry, VerifiedAt: &verified, IsActive: true},
+		{UserID: blockedID, SkillCategory: "Certifications", SkillName: "First Aid", ProficiencyLevel: "advanced", VerifiedAt: &verified, IsActive: true},
+	} {
+		suite.Require().NoError(suite.db.Create(&skill).Error)
+	}
+
+	series := func(staffID string, start time.Time) map[string]interface{} {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", map[string]interface{}{
+			"participant_id": participantID,
+			"staff_id":       staffID,
+			"start_time":     start.Format(local),
+			"end_time":       start.Add(2 * time.Hour).Format(local),
+			"service_type":   "Personal Care",
+			"location":       "Participant home",
+			"hourly_rate":    60,
+			"rrule":          "FREQ=WEEKLY;COUNT=3",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		return suite.decodeData(w)
+	}
+	codes := func(conflicts interface{}) []string {
+		found := []string{}
+		for _, conflict := range conflicts.([]interface{}) {
+			found = append(found, conflict.(map[string]interface{})["code"].(string))
+		}
+		return found
+	}
+
+	suite.Run("Credentials are checked at each occurrence", func() {
+		data := series(lapsingID, monday)
+		suite.Len(data["shifts"].([]interface{}), 1)
+		suite.Equal([]string{"CREDENTIALS_NOT_MET", "CREDENTIALS_NOT_MET"}, codes(data["conflicts"]))
+		conflict := data["conflicts"].([]interface{})[0].(map[string]interface{})
+		suite.NotEmpty(conflict["details"])
+	})
+
+	suite.Run("Changing the worker checks each upcoming occurrence", func() {
+		data := series(blockedID, monday)
+		seriesID := data["series"].(map[string]interface{})["id"].(string)
+		suite.Require().Len(data["shifts"].([]interface{}), 3)
+
+		shifts := suite.seriesShifts(seriesID)
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[0].ID, map[string]interface{}{
+			"scope":    "all",
+			"staff_id": lapsingID,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data = suite.decodeData(w)
+		suite.Empty(data["shifts"])
+		suite.Equal([]string{"SCHEDULE_CONFLICT", "CREDENTIALS_NOT_MET", "CREDENTIALS_NOT_MET"}, codes(data["conflicts"]))
+	})
+
+	suite.Run("Occurrences regenerated after the worker is blocked are not booked", func() {
+		data := series(blockedID, monday.Add(5*time.Hour))
+		seriesID := data["series"].(map[string]interface{})["id"].(string)
+		suite.Require().Len(data["shifts"].([]interface{}), 3)
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/participants/"+participantID+"/workers/"+blockedID, map[string]interface{}{
+			"relationship": "blocked", "reason": "Asked for someone else",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		shifts := suite.seriesShifts(seriesID)
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[1].ID, map[string]interface{}{
+			"scope":      "following",
+			"start_time": shifts[1].StartTime.Add(time.Hour).Format(time.RFC3339),
+			"end_time":   shifts[1].EndTime.Add(time.Hour).Format(time.RFC3339),
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data = suite.decodeData(w)
+		suite.Empty(data["shifts"])
+		suite.Equal([]string{"WORKER_BLOCKED", "WORKER_BLOCKED"}, codes(data["conflicts"]))
+	})
+}
+
+func (suite *ShiftSeriesTestSuite) TestOccurrenceEdits() {
+	start := suite.nextMonday().AddDate(0, 0, 84)
+	local := "2006-01-02T15:04:05"
+	participantID := suite.createParticipant("series-edits-participant", "430000004")
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", map[string]interface{}{
+		"participant_id": participantID,
+		"staff_id":       suite.userID,
+		"start_time":     start.Format(local),
+		"end_time":       start.Add(2 * time.Hour).Format(local),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+		"rrule":          "FREQ=WEEKLY;COUNT=3",
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	seriesID := suite.decodeData(w)["series"].(map[string]interface{})["id"].(string)
+	shifts := suite.seriesShifts(seriesID)
+	suite.Require().Len(shifts, 3)
+
+	suite.Run("A single occurrence can be left open", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[1].ID, map[string]interface{}{
+			"scope":    "all",
+			"staff_id": "",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[1].ID, map[string]interface{}{
+			"scope":    "this",
+			"staff_id": "",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		opened := suite.seriesShifts(seriesID)[1]
+		suite.Nil(opened.StaffID)
+		suite.True(opened.SeriesOverride)
+	})
+
+	suite.Run("Regenerated occurrences are soft deleted and their slots booked again", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[0].ID, map[string]interface{}{
+			"scope":    "all",
+			"end_time": start.Add(3 * time.Hour).Format(local),
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var removed []models.Shift
+		suite.Require().NoError(suite.db.Unscoped().Where("id IN ?", []string{shifts[0].ID, shifts[2].ID}).Find(&removed).Error)
+		suite.Require().Len(removed, 2)
+		for _, shift := range removed {
+			suite.True(shift.DeletedAt.Valid)
+		}
+
+		regenerated := suite.seriesShifts(seriesID)
+		suite.Require().Len(regenerated, 3)
+		suite.True(regenerated[0].StartTime.Equal(start))
+		suite.True(regenerated[0].EndTime.Equal(start.Add(3 * time.Hour)))
+		suite.Equal(shifts[1].ID, regenerated[1].ID)
+		suite.True(regenerated[2].EndTime.Equal(shifts[2].StartTime.Add(3 * time.Hour)))
+	})
+}
+
+func (suite *ShiftSeriesTestSuite) TestReassignedOccurrences() {
+	start := suite.nextMonday().AddDate(0, 0, 56)
+	local := "2006-01-02T15:04:05"
+
+	participantID := suite.createParticipant("series-reassigned-participant", "430000003")
+	otherID := suite.createUser("series-other", "other@series.test", "care_worker")
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", map[string]interface{}{
+		"participant_id": participantID,
+		"staff_id":       suite.userID,
+		"start_time":     start.Format(local),
+		"end_time":       start.Add(2 * time.Hour).Format(local),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+		"rrule":          "FREQ=WEEKLY;COUNT=3",
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	seriesID := suite.decodeData(w)["series"].(map[string]interface{})["id"].(string)
+	shifts := suite.seriesShifts(seriesID)
+	suite.Require().Len(shifts, 3)
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shifts[1].ID+"/assign", map[string]interface{}{
+		"staff_id": otherID,
+		"reassign": true,
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	suite.Run("Series edits leave an occurrence given to another worker alone", func() {
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[0].ID, map[string]interface{}{
+			"scope":    "all",
+			"location": "Day program",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		shifts := suite.seriesShifts(seriesID)
+		suite.Require().Len(shifts, 3)
+		suite.False(shifts[0].SeriesOverride)
+		suite.True(shifts[1].SeriesOverride)
+		suite.Equal(otherID, *shifts[1].StaffID)
+		suite.Equal("Participant home", shifts[1].Location)
+		suite.Equal("Day program", shifts[2].Location)
+	})
+}
+
+func (suite *ShiftSeriesTestSuite) TestShiftSeries() {
+	monday := suite.nextMonday()
+	local := "2006-01-02T15:04:05"
+
+	body := map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.userID,
+		"start_time":     monday.Format(local),
+		"end_time":       monday.Add(2 * time.Hour).Format(local),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+		"rrule":          "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6",
+	}
+
+	suite.Run("Invalid rules are rejected", func() {
+		invalid := map[string]interface{}{}
+		for k, v := range body {
+			invalid[k] = v
+		}
+		invalid["rrule"] = "FREQ=YEARLY"
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", invalid)
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("INVALID_RRULE", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	// The staff member is already booked on the first Wednesday
+	wednesday := monday.AddDate(0, 0, 2)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       suite.userID,
+		"start_time":     wednesday.Add(time.Hour).Format(local),
+		"end_time":       wednesday.Add(3 * time.Hour).Format(local),
+		"service_type":   "Community Access",
+		"location":       "Library",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	var seriesID string
+	suite.Run("Occurrences are booked and conflicts reported one by one", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-series", body)
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		seriesID = data["series"].(map[string]interface{})["id"].(string)
+
+		suite.Len(data["shifts"].([]interface{}), 5)
+		conflicts := data["conflicts"].([]interface{})
+		suite.Require().Len(conflicts, 1)
+		conflict := conflicts[0].(map[string]interface{})
+		suite.Equal("SCHEDULE_CONFLICT", conflict["code"])
+		occurrence, err := time.Parse(time.RFC3339, conflict["occurrence_start"].(string))
+		suite.Require().NoError(err)
+		suite.True(occurrence.Equal(wednesday))
+
+		shifts := suite.seriesShifts(seriesID)
+		suite.Require().Len(shifts, 5)
+		suite.True(shifts[0].StartTime.Equal(monday))
+		suite.Greater(shifts[0].TotalCost, 0.0)
+	})
+	suite.Require().NotEmpty(seriesID)
+
+	suite.Run("Editing this occurrence leaves the rest of the series alone", func() {
+		shifts := suite.seriesShifts(seriesID)
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[0].ID, map[string]interface{}{
+			"scope":    "this",
+			"location": "Community centre",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		shifts = suite.seriesShifts(seriesID)
+		suite.Equal("Community centre", shifts[0].Location)
+		suite.True(shifts[0].SeriesOverride)
+		suite.Equal("Participant home", shifts[1].Location)
+
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[1].ID, map[string]interface{}{
+			"scope": "this",
+			"rrule": "FREQ=DAILY",
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	var laterID string
+	suite.Run("Editing this and following occurrences splits the series", func() {
+		secondMonday := monday.AddDate(0, 0, 7)
+		shifts := suite.seriesShifts(seriesID)
+		suite.Require().True(shifts[1].StartTime.Equal(secondMonday))
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+seriesID+"/occurrences/"+shifts[1].ID, map[string]interface{}{
+			"scope":      "following",
+			"start_time": secondMonday.Add(time.Hour).Format(local),
+			"end_time":   secondMonday.Add(3 * time.Hour).Format(local),
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		later := data["series"].(map[string]interface{})
+		laterID = later["id"].(string)
+		suite.NotEqual(seriesID, laterID)
+		suite.Equal(seriesID, later["previous_id"])
+		suite.Equal("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", later["rrule"])
+
+		var original models.ShiftSeries
+		suite.Require().NoError(suite.db.First(&original, "id = ?", seriesID).Error)
+		suite.Equal(models.ShiftSeriesEnded, original.Status)
+		suite.Contains(original.RRule, "UNTIL=")
+		suite.Len(suite.seriesShifts(seriesID), 1)
+
+		moved := suite.seriesShifts(laterID)
+		suite.Require().Len(moved, 4)
+		for _, shift := range moved {
+			suite.Equal(10, shift.StartTime.In(monday.Location()).Hour())
+		}
+		suite.True(moved[0].StartTime.Equal(secondMonday.Add(time.Hour)))
+	})
+	suite.Require().NotEmpty(laterID)
+
+	suite.Run("Editing the whole series updates upcoming occurrences in place", func() {
+		shifts := suite.seriesShifts(laterID)
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shift-series/"+laterID+"/occurrences/"+shifts[2].ID, map[string]interface{}{
+			"scope":       "all",
+			"location":    "Day program",
+			"hourly_rate": 65,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["shifts"].([]interface{}), 4)
+
+		for _, shift := range suite.seriesShifts(laterID) {
+			suite.Equal("Day program", shift.Location)
+			suite.Equal(65.0, shift.HourlyRate)
+		}
+		var series models.ShiftSeries
+		suite.Require().NoError(suite.db.First(&series, "id = ?", laterID).Error)
+		suite.Equal("Day program", series.Location)
+	})
+
+	suite.Run("Occurrences can be deleted one at a time or all together", func() {
+		shifts := suite.seriesShifts(laterID)
+		w := suite.makeAuthenticatedRequest("DELETE", "/api/v1/shift-series/"+laterID+"/occurrences/"+shifts[0].ID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(float64(1), suite.decodeData(w)["deleted"])
+
+		w = suite.makeAuthenticatedRequest("DELETE", "/api/v1/shift-series/"+laterID+"/occurrences/"+shifts[1].ID+"?scope=all", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(float64(3), suite.decodeData(w)["deleted"])
+		suite.Empty(suite.seriesShifts(laterID))
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/shift-series/"+laterID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(models.ShiftSeriesEnded, suite.decodeData(w)["status"])
+	})
+
+	suite.Run("Series can be listed", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/shift-series?participant_id="+suite.participantID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["series"].([]interface{}), 2)
+	})
+}
+
+// TestShiftSeriesSuite runs the shift series test suite
+func TestShiftSeriesSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ShiftSeriesTestSuite))
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// ShiftSwapTestSuite covers workers handing over and swapping shifts with manager approval
+type ShiftSwapTestSuite struct {
+	extendedTestSuite
+	monday   time.Time
+	anaID    string
+	benID    string
+	calID    string
+	anaToken string
+	benToken string
+	calToken string
+}
+
+// SetupSuite adds three care workers who can log in
+func (suite *ShiftSwapTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	suite.monday = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	suite.anaID = suite.createUser("swap-ana", "ana@swaps.test", "care_worker")
+	suite.benID = suite.createUser("swap-ben", "ben@swaps.test", "care_worker")
+	suite.calID = suite.createUser("swap-cal", "cal@swaps.test", "care_worker")
+	suite.anaToken = suite.login("ana@swaps.test")
+	suite.benToken = suite.login("ben@swaps.test")
+	suite.calToken = suite.login("cal@swaps.test")
+}
+
+// createShift books a worker for three hours from the given hour on a day after the first Monday
+func (suite *ShiftSwapTestSuite) createShift(staffID string, day, hour int) string {
+	from := suite.monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id": suite.participantID,
+		"staff_id":       staffID,
+		"start_time":     from.Format("2006-01-02T15:04:05"),
+		"end_time":       from.Add(3 * time.Hour).Format("2006-01-02T15:04:05"),
+		"service_type":   "Personal Care",
+		"location":       "Participant home",
+		"hourly_rate":    60,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	return suite.decodeData(w)["id"].(string)
+}
+
+func (suite *ShiftSwapTestSuite) staffOf(shiftID string) string {
+	var shift models.Shift
+	suite.Require().NoError(suite.db.First(&shift, "id = ?", shiftID).Error)
+	suite.Require().NotNil(shift.StaffID)
+	return *shift.StaffID
+}
+
+func (suite *ShiftSwapTestSuite) TestDropRequest() {
+	shiftID := suite.createShift(suite.anaID, 0, 9)
+	suite.createShift(suite.calID, 0, 10)
+
+	suite.Run("Workers can only offer their own shifts", func() {
+		w := suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+			"shift_id": shiftID,
+			"type":     "drop",
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+		"shift_id": shiftID,
+		"type":     "drop",
+		"note":     "Family commitment",
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	swapID := suite.decodeData(w)["id"].(string)
+
+	suite.Run("Eligible workers are told about the offer", func() {
+		var notified int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationShiftSwap, suite.benID).Count(&notified)
+		suite.Equal(int64(1), notified)
+
+		w := suite.makeRequestWithToken(suite.benToken, "GET", "/api/v1/shift-swaps", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["swap_requests"], 1)
+	})
+
+	suite.Run("A shift can only be offered once at a time", func() {
+		w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+			"shift_id": shiftID,
+			"type":     "drop",
+		})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Workers with an overlapping shift cannot accept", func() {
+		w := suite.makeRequestWithToken(suite.calToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		errorBody := suite.decodeResponse(w)["error"].(map[string]interface{})
+		suite.Equal("WORKER_NOT_ELIGIBLE", errorBody["code"])
+		reasons := errorBody["details"].([]interface{})[0].(map[string]interface{})["reasons"].([]interface{})
+		suite.Equal("SCHEDULE_CONFLICT", reasons[0].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Accepting waits for a manager", func() {
+		w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(models.SwapAccepted, suite.decodeData(w)["status"])
+		suite.Equal(suite.anaID, suite.staffOf(shiftID))
+
+		var notified int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND user_id = ?", models.NotificationShiftSwap, suite.userID).Count(&notified)
+		suite.Equal(int64(1), notified)
+
+		w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps/"+swapID+"/approve", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Approving moves the shift and records each step", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-swaps/"+swapID+"/approve", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(models.SwapApproved, suite.decodeData(w)["status"])
+		suite.Equal(suite.benID, suite.staffOf(shiftID))
+
+		w = suite.makeRequestWithToken(suite.anaToken, "GET", "/api/v1/shift-swaps/"+swapID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		history := suite.decodeData(w)["history"].([]interface{})
+		suite.Require().Len(history, 3)
+		suite.Equal("approve_shift_swap", history[2].(map[string]interface{})["action"])
+
+		var reassigned int64
+		suite.db.Model(&models.AuditLog{}).Where("action = ? AND entity_id = ?", "reassign_shift", shiftID).Count(&reassigned)
+		suite.Equal(int64(1), reassigned)
+
+		var results int64
+		suite.db.Model(&models.Notification{}).Where("type = ? AND title = ? AND user_id IN ?", models.NotificationShiftSwap,
+			"Shift swap approved", []string{suite.anaID, suite.benID}).Count(&results)
+		suite.Equal(int64(2), results)
+	})
+}
+
+func (suite *ShiftSwapTestSuite) TestSwapRequest() {
+	anaShift := suite.createShift(suite.anaID, 1, 9)
+	benShift := suite.createShift(suite.benID, 2, 9)
+
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+		"shift_id":      anaShift,
+		"type":          "swap",
+		"swap_shift_id": benShift,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	suite.Equal(suite.benID, data["target_user_id"])
+	swapID := data["id"].(string)
+
+	w = suite.makeRequestWithToken(suite.calToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+	suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+	w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/shift-swaps/"+swapID+"/approve", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Equal(suite.benID, suite.staffOf(anaShift))
+	suite.Equal(suite.anaID, suite.staffOf(benShift))
+}
+
+func (suite *ShiftSwapTestSuite) TestStaleRequest() {
+	shiftID := suite.createShift(suite.anaID, 3, 9)
+
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+		"shift_id":       shiftID,
+		"type":           "drop",
+		"target_user_id": suite.benID,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	swapID := suite.decodeData(w)["id"].(string)
+
+	w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	suite.Run("Shifts reassigned in the meantime are not moved", func() {
+		suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).Update("staff_id", suite.calID).Error)
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-swaps/"+swapID+"/approve", nil)
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal(suite.calID, suite.staffOf(shiftID))
+	})
+
+	suite.Run("Managers can reject with a reason", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shift-swaps/"+swapID+"/reject", map[string]interface{}{
+			"reason": "Shift reassigned",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Equal(models.SwapRejected, data["status"])
+		suite.Equal("Shift reassigned", data["reason"])
+
+		w = suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps/"+swapID+"/cancel", nil)
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+}
+
+func (suite *ShiftSwapTestSuite) TestWorkerCredentials() {
+	shiftID := suite.createShift(suite.anaID, 5, 9)
+	w := suite.makeRequestWithToken(suite.anaToken, "POST", "/api/v1/shift-swaps", map[string]interface{}{
+		"shift_id":       shiftID,
+		"type":           "drop",
+		"target_user_id": suite.benID,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+	swapID := suite.decodeData(w)["id"].(string)
+	w = suite.makeRequestWithToken(suite.benToken, "POST", "/api/v1/shift-swaps/"+swapID+"/accept", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+	// Ben's screening lapses before the shift
+	defer suite.requireCredential("Worker Screening", map[string]time.Time{
+		suite.anaID: {},
+		suite.benID: suite.monday.AddDate(0, 0, 1),
+	})()
+
+	w = suite.makeAuthenticatedRequest("POST", "/api/v1/shift-swaps/"+swapID+"/approve", nil)
+	suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+	suite.Equal("CREDENTIALS_NOT_MET", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	suite.Equal(suite.anaID, suite.staffOf(shiftID))
+}
+
+// TestShiftSwapSuite runs the shift swap test suite
+func TestShiftSwapSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(ShiftSwapTestSuite))
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// SupportItemsTestSuite covers catalogue import and price-capped shift pricing
+type SupportItemsTestSuite struct {
+	extendedTestSuite
+	superAdminToken string
+}
+
+// SetupSuite adds a super admin, who alone can import the shared catalogue
+func (suite *SupportItemsTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.createUser("catalogue-super-admin", "super@catalogue.test", "super_admin")
+	suite.superAdminToken = suite.login("super@catalogue.test")
+}
+
+const supportCatalogueCSV = `Support Item Number,Support Item Name,Registration Group Number,Registration Group Name,Support Category Number,Support Category Name,Unit,Quote,Start date,End Date,ACT,NSW,NT,QLD,SA,TAS,VIC,WA,Remote,Very Remote,Non-Face-to-Face Support Provision,Provider Travel,Short Notice Cancellations.,NDIA Requested Reports,Irregular SIL Supports,Type
+01_011_0107_1_1,Assistance With Self-Care Activities - Standard - Weekday Daytime,0107,Daily Personal Activities,1,Assistance with Daily Life,H,N,20240701,99991231,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$67.56,$94.58,$101.34,Y,Y,Y,Y,N,Price Limited Supports
+`
+
+// importCatalogue uploads a catalogue file to the import endpoint as the super admin
+func (suite *SupportItemsTestSuite) importCatalogue(filename, content string) *httptest.ResponseRecorder {
+	return suite.uploadFileWithToken(suite.superAdminToken, "/api/v1/support-items/import", filename, content)
+}
+
+func (suite *SupportItemsTestSuite) TestCatalogueAndPriceCaps() {
+	var itemID string
+
+	suite.Run("Import catalogue", func() {
+		w := suite.importCatalogue("catalogue.csv", supportCatalogueCSV)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(float64(1), suite.decodeData(w)["created"])
+
+		// Re-importing updates in place
+		w = suite.importCatalogue("catalogue.csv", supportCatalogueCSV)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(float64(1), suite.decodeData(w)["updated"])
+
+		var prices int64
+		suite.db.Model(&models.SupportItemPrice{}).Count(&prices)
+		suite.Equal(int64(10), prices)
+
+		w = suite.makeAuthenticatedRequest("GET", "/api/v1/support-items?search=self-care", nil)
+		suite.Require().Equal(http.StatusOK, w.Code)
+		items := suite.decodeData(w)["support_items"].([]interface{})
+		suite.Require().Len(items, 1)
+		itemID = items[0].(map[string]interface{})["id"].(string)
+	})
+
+	suite.Run("Organization admins cannot change the shared catalogue", func() {
+		w := suite.uploadFile("/api/v1/support-items/import", "catalogue.csv", supportCatalogueCSV)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Reject unsupported file types", func() {
+		w := suite.importCatalogue("catalogue.txt", supportCatalogueCSV)
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	shift := func(start, end string, rate float64) map[string]interface{} {
+		return map[string]interface{}{
+			"participant_id":  suite.participantID,
+			"staff_id":        suite.userID,
+			"start_time":      start,
+			"end_time":        end,
+			"service_type":    "Personal Care",
+			"support_item_id": itemID,
+			"location":        "Home",
+			"hourly_rate":     rate,
+		}
+	}
+
+	suite.Run("Block rates above the price limit", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", shift("2024-08-05T09:00:00Z", "2024-08-05T11:00:00Z", 70))
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal("PRICE_CAP_EXCEEDED", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Accept rates within the price limit", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", shift("2024-08-05T09:00:00Z", "2024-08-05T11:00:00Z", 67.56))
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		response := suite.decodeResponse(w)
+		suite.Empty(response["warnings"])
+
+		shiftID := response["data"].(map[string]interface{})["id"].(string)
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{"hourly_rate": 80})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("Warn instead of block when configured", func() {
+		suite.Require().NoError(suite.db.Model(&models.OrganizationSettings{}).
+			Where("organization_id = ?", suite.orgID).Update("price_cap_enforcement", "warn").Error)
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", shift("2024-08-06T09:00:00Z", "2024-08-06T11:00:00Z", 70))
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		suite.Len(suite.decodeResponse(w)["warnings"], 1)
+	})
+
+	suite.Run("Remote participants use the remote price limit", func() {
+		suite.Require().NoError(suite.db.Model(&models.OrganizationSettings{}).
+			Where("organization_id = ?", suite.orgID).Update("price_cap_enforcement", "block").Error)
+		suite.Require().NoError(suite.db.Model(&models.Participant{}).
+			Where("id = ?", suite.participantID).Update("remoteness", "remote").Error)
+
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", shift("2024-08-07T09:00:00Z", "2024-08-07T11:00:00Z", 90))
+		suite.Equal(http.StatusCreated, w.Code, w.Body.String())
+	})
+}
+
+// TestSupportItemsSuite runs the support catalogue test suite
+func TestSupportItemsSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(SupportItemsTestSuite))
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// TravelTestSuite covers travel recorded on shifts, travel billing and worker reimbursements
+type TravelTestSuite struct {
+	extendedTestSuite
+	supportItemID string
+	adelaide      *time.Location
+}
+
+// SetupSuite makes the participant agency managed and adds a support item that allows
+// provider travel along with the activity based transport item
+func (suite *TravelTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	var err error
+	suite.adelaide, err = time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.db.Model(&models.Organization{}).Where("id = ?", suite.orgID).
+		Updates(map[string]interface{}{"ndis_registration_number": "4050000000", "abn": "12345678901"}).Error)
+	suite.Require().NoError(suite.db.Model(&models.Participant{}).Where("id = ?", suite.participantID).
+		UpdateColumns(map[string]interface{}{
+			"funding_management_type":  "agency",
+			"funding_total_budget":     1000,
+			"funding_used_budget":      0,
+			"funding_remaining_budget": 1000,
+		}).Error)
+
+	item := models.SupportItem{ItemNumber: "01_011_0107_1_1", Name: "Self-Care Weekday", Unit: "H", IsActive: true, ProviderTravel: true}
+	suite.Require().NoError(suite.db.Create(&item).Error)
+	suite.supportItemID = item.ID
+
+	transport := models.SupportItem{ItemNumber: "04_590_0125_6_1", Name: "Activity Based Transport", Unit: "E", IsActive: true}
+	suite.Require().NoError(suite.db.Create(&transport).Error)
+}
+
+// startShift books a two hour shift at $60 an hour and starts it
+func (suite *TravelTestSuite) startShift(start time.Time) string {
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/shifts", map[string]interface{}{
+		"participant_id":  suite.participantID,
+		"staff_id":        suite.userID,
+		"start_time":      start.Format(time.RFC3339),
+		"end_time":        start.Add(2 * time.Hour).Format(time.RFC3339),
+		"service_type":    "Personal Care",
+		"location":        "Home",
+		"hourly_rate":     60,
+		"support_item_id": suite.supportItemID,
+	})
+	suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+	// Flat price so the cost does not depend on the time of day the test runs
+	shiftID := suite.decodeData(w)["id"].(string)
+	suite.Require().NoError(suite.db.Model(&models.Shift{}).Where("id = ?", shiftID).Update("total_cost", 120).Error)
+
+	w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", map[string]interface{}{"status": "in_progress"})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return shiftID
+}
+
+func (suite *TravelTestSuite) complete(shiftID string, body map[string]interface{}) map[string]interface{} {
+	body["status"] = "completed"
+	w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+shiftID+"/status", body)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)
+}
+
+func (suite *TravelTestSuite) usedBudget() float64 {
+	var participant models.Participant
+	suite.Require().NoError(suite.db.First(&participant, "id = ?", suite.participantID).Error)
+	return participant.Funding.UsedBudget
+}
+
+func (suite *TravelTestSuite) reimbursements() []interface{} {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/reimbursements", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	return suite.decodeData(w)["reimbursements"].([]interface{})
+}
+
+func (suite *TravelTestSuite) TestTravel() {
+	now := time.Now().Truncate(time.Minute)
+	claimed := suite.startShift(now.Add(-6 * time.Hour))
+	invoiced := suite.startShift(now.Add(-3 * time.Hour))
+
+	suite.Run("Travel is only recorded on completion", func() {
+		w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/shifts/"+claimed+"/status", map[string]interface{}{
+			"status":    "cancelled",
+			"travel_km": 10,
+		})
+		suite.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("Completing a shift draws down its travel within the caps", func() {
+		shift := suite.complete(claimed, map[string]interface{}{
+			"travel_time_minutes": 45,
+			"travel_km":           10,
+			"activity_km":         20,
+		})
+		suite.Equal(float64(45), shift["travel_time_minutes"])
+		suite.Equal(float64(10), shift["travel_km"])
+
+		// 120 service + 30 minutes of travel at $60 + 30 km at $0.99
+		suite.InDelta(179.70, suite.usedBudget(), 0.001)
+
+		reimbursements := suite.reimbursements()
+		suite.Require().Len(reimbursements, 1)
+		reimbursement := reimbursements[0].(map[string]interface{})
+		suite.Equal("pending", reimbursement["status"])
+		suite.InDelta(26.40, reimbursement["amount"].(float64), 0.001)
+	})
+
+	suite.Run("Correcting travel adjusts the budget and the reimbursement", func() {
+		suite.complete(claimed, map[string]interface{}{"travel_km": 20})
+		suite.InDelta(189.60, suite.usedBudget(), 0.001)
+		suite.InDelta(35.20, suite.reimbursements()[0].(map[string]interface{})["amount"].(float64), 0.001)
+	})
+
+	suite.Run("Travel time defaults to the worker's availability", func() {
+		start := now.Add(-3 * time.Hour).In(suite.adelaide)
+		suite.Require().NoError(suite.db.Create(&models.WorkerAvailability{
+			UserID:            suite.userID,
+			DayOfWeek:         int(start.Weekday()),
+			StartTime:         time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndTime:           time.Date(0, 1, 1, 23, 59, 0, 0, time.UTC),
+			IsAvailable:       true,
+			TravelTimeMinutes: 20,
+			IsActive:          true,
+		}).Error)
+
+		shift := suite.complete(invoiced, map[string]interface{}{"travel_km": 5})
+		suite.Equal(float64(20), shift["travel_time_minutes"])
+	})
+
+	suite.Run("Invoices carry travel as separate lines", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": suite.participantID,
+			"shift_ids":      []string{invoiced},
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		data := suite.decodeData(w)
+		lines := data["lines"].([]interface{})
+		suite.Require().Len(lines, 3)
+		suite.Equal("travel", lines[1].(map[string]interface{})["line_type"])
+		suite.InDelta(0.33, lines[1].(map[string]interface{})["quantity"].(float64), 0.001)
+		suite.Equal("transport", lines[2].(map[string]interface{})["line_type"])
+		suite.InDelta(4.95, lines[2].(map[string]interface{})["amount"].(float64), 0.001)
+		suite.InDelta(144.75, data["total"].(float64), 0.001)
+	})
+
+	suite.Run("NDIA claims carry provider travel and transport lines", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/ndia-claims/generate", map[string]interface{}{
+			"start_date":     now.AddDate(0, 0, -1).In(suite.adelaide).Format("2006-01-02"),
+			"end_date":       now.In(suite.adelaide).Format("2006-01-02"),
+			"participant_id": suite.participantID,
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+
+		batch := suite.decodeData(w)["batch"].(map[string]interface{})
+		suite.InDelta(189.60, batch["total_amount"].(float64), 0.001)
+		lines := batch["lines"].([]interface{})
+		suite.Require().Len(lines, 4)
+
+		claimTypes := []string{}
+		for _, line := range lines {
+			claimTypes = append(claimTypes, line.(map[string]interface{})["claim_type"].(string))
+		}
+		suite.Equal([]string{"", "TRAN", "TRAN", ""}, claimTypes)
+		suite.Equal("04_590_0125_6_1", lines[3].(map[string]interface{})["support_item_number"])
+	})
+
+	suite.Run("Managers approve and pay reimbursements", func() {
+		reimbursementID := suite.reimbursements()[0].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("PATCH", "/api/v1/reimbursements/"+reimbursementID+"/status", map[string]interface{}{"status": "paid"})
+		suite.Equal(http.StatusBadRequest, w.Code)
+
+		for _, status := range []string{"approved", "paid"} {
+			w = suite.makeAuthenticatedRequest("PATCH", "/api/v1/reimbursements/"+reimbursementID+"/status", map[string]interface{}{"status": status})
+			suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		}
+		suite.Equal("paid", suite.decodeData(w)["status"])
+	})
+}
+
+// TestTravelSuite runs the travel test suite
+func TestTravelSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(TravelTestSuite))
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+// WorkerCapacityTestSuite covers availability conflicts and weekly capacity reports
+type WorkerCapacityTestSuite struct {
+	extendedTestSuite
+	monday      time.Time
+	workerID    string
+	workerToken string
+	otherID     string
+}
+
+// SetupSuite adds a weekday worker with leave on Wednesday and three shifts, and a second
+// worker with no availability recorded
+func (suite *WorkerCapacityTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	suite.Require().NoError(err)
+	day := time.Now().In(loc).AddDate(0, 0, 7)
+	for day.Weekday() != time.Monday {
+		day = day.AddDate(0, 0, 1)
+	}
+	suite.monday = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	suite.workerID = suite.createUser("capacity-worker", "worker@capacity.test", "care_worker")
+	suite.workerToken = suite.login("worker@capacity.test")
+	suite.otherID = suite.createUser("capacity-other", "other@capacity.test", "care_worker")
+
+	for weekday := 1; weekday <= 5; weekday++ {
+		suite.Require().NoError(suite.db.Create(&models.WorkerAvailability{
+			UserID:         suite.workerID,
+			DayOfWeek:      weekday,
+			StartTime:      time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+			EndTime:        time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC),
+			IsAvailable:    true,
+			MaxHoursPerDay: 8,
+			IsActive:       true,
+		}).Error)
+	}
+	suite.Require().NoError(suite.db.Create(&models.WorkerPreferences{
+		UserID:                suite.workerID,
+		MaxHoursPerWeek:       38,
+		PreferredHoursPerWeek: 30,
+		MaxConsecutiveDays:    5,
+		MinHoursBetweenShifts: 10,
+		IsActive:              true,
+	}).Error)
+	wednesday := suite.monday.AddDate(0, 0, 2)
+	suite.Require().NoError(suite.db.Create(&models.WorkerAvailabilityException{
+		UserID:        suite.workerID,
+		ExceptionDate: time.Date(wednesday.Year(), wednesday.Month(), wednesday.Day(), 0, 0, 0, 0, time.UTC),
+		ExceptionType: "unavailable",
+		Reason:        "Appointment",
+		IsApproved:    true,
+	}).Error)
+
+	suite.createShift(suite.workerID, 0, 9, 4)  // fine
+	suite.createShift(suite.workerID, 0, 15, 4) // runs past 17:00 and too soon after the first
+	suite.createShift(suite.workerID, 2, 9, 3)  // on leave
+	suite.createShift(suite.otherID, 1, 9, 5)
+}
+
+func (suite *WorkerCapacityTestSuite) createShift(staffID string, day, hour, hours int) {
+	start := suite.monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	suite.Require().NoError(suite.db.Create(&models.Shift{
+		ParticipantID: suite.participantID,
+		StaffID:       &staffID,
+		StartTime:     start,
+		EndTime:       start.Add(time.Duration(hours) * time.Hour),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "scheduled",
+		HourlyRate:    60,
+	}).Error)
+}
+
+func (suite *WorkerCapacityTestSuite) week() string {
+	return "?start_date=" + suite.monday.Format("2006-01-02") + "&end_date=" + suite.monday.AddDate(0, 0, 6).Format("2006-01-02")
+}
+
+func (suite *WorkerCapacityTestSuite) TestAvailabilityConflicts() {
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/worker/capacity/conflicts"+suite.week(), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	suite.Equal(float64(3), data["total_conflicts"])
+
+	byReason := data["by_reason"].(map[string]interface{})
+	suite.Equal(float64(1), byReason["UNAVAILABLE"])
+	suite.Equal(float64(1), byReason["TIME_OFF"])
+	suite.Equal(float64(2), byReason["INSUFFICIENT_REST"])
+
+	// The other worker has no availability pattern, so nothing conflicts
+	workers := data["workers"].([]interface{})
+	suite.Require().Len(workers, 1)
+	suite.Equal(suite.workerID, workers[0].(map[string]interface{})["user_id"])
+
+	suite.Run("Workers only see their own conflicts", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/worker/capacity/conflicts"+suite.week(), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(float64(3), suite.decodeData(w)["total_conflicts"])
+
+		w = suite.makeRequestWithToken(suite.workerToken, "GET", "/api/v1/worker/capacity/conflicts?user_id="+suite.otherID, nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Invalid ranges are rejected", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/worker/capacity/conflicts?start_date=2026-02-01&end_date=2026-01-01", nil)
+		suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
+
+func (suite *WorkerCapacityTestSuite) TestWeeklyCapacity() {
+	// A range starting midweek is widened to whole weeks
+	w := suite.makeAuthenticatedRequest("GET", "/api/v1/worker/capacity/weekly?start_date="+
+		suite.monday.AddDate(0, 0, 2).Format("2006-01-02")+"&end_date="+suite.monday.AddDate(0, 0, 3).Format("2006-01-02"), nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	suite.Equal(suite.monday.Format("2006-01-02"), data["start_date"])
+	suite.Equal("Australia/Adelaide", data["timezone"])
+
+	var worker map[string]interface{}
+	for _, item := range data["workers"].([]interface{}) {
+		if item.(map[string]interface{})["user_id"] == suite.workerID {
+			worker = item.(map[string]interface{})
+		}
+	}
+	suite.Require().NotNil(worker)
+	weeks := worker["weeks"].([]interface{})
+	suite.Require().Len(weeks, 1)
+	week := weeks[0].(map[string]interface{})
+	suite.Equal(float64(32), week["available_hours"]) // four days of eight hours, Wednesday off
+	suite.Equal(float64(11), week["booked_hours"])
+	suite.Equal(float64(30), week["preferred_hours"])
+	suite.Equal(float64(38), week["max_hours"])
+	suite.Equal(float64(21), week["remaining_hours"])
+	suite.Equal(34.4, week["utilization"])
+
+	team := data["team"].([]interface{})
+	suite.Require().Len(team, 1)
+	suite.Equal(float64(16), team[0].(map[string]interface{})["booked_hours"])
+}
+
+// TestWorkerCapacitySuite runs the worker capacity test suite
+func TestWorkerCapacitySuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(WorkerCapacityTestSuite))
+}
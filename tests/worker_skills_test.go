@@ -0,0 +1,274 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-ago-crm-backend/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+const skillsPath = "/api/v1/worker/skills/"
+
+// WorkerSkillTestSuite covers workers recording their certifications, managers verifying
+// them, and the mandatory credentials checked when shifts are assigned
+type WorkerSkillTestSuite struct {
+	extendedTestSuite
+	workerID    string
+	workerToken string
+	otherID     string
+}
+
+// SetupSuite adds two care workers and requires the default credentials
+func (suite *WorkerSkillTestSuite) SetupSuite() {
+	suite.extendedTestSuite.SetupSuite()
+
+	suite.workerID = suite.createUser("skills-worker", "worker@skills.test", "care_worker")
+	suite.otherID = suite.createUser("skills-other", "other@skills.test", "care_worker")
+	suite.workerToken = suite.login("worker@skills.test")
+
+	w := suite.makeAuthenticatedRequest("POST", "/api/v1/organization/credentials/defaults", nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	suite.Require().Len(suite.decodeData(w)["added"], len(models.DefaultMandatoryCredentials))
+}
+
+// addSkill records a skill for a worker and returns its ID
+func (suite *WorkerSkillTestSuite) addSkill(userID, name string, expiry time.Time, verified bool) string {
+	skill := models.WorkerSkill{
+		UserID:           userID,
+		SkillCategory:    "Safety",
+		SkillName:        name,
+		ProficiencyLevel: "intermediate",
+		ExpiryDate:       &expiry,
+		IsActive:         true,
+	}
+	if verified {
+		now := time.Now()
+		skill.VerifiedBy, skill.VerifiedAt = &suite.userID, &now
+	}
+	suite.Require().NoError(suite.db.Create(&skill).Error)
+	return skill.ID
+}
+
+func (suite *WorkerSkillTestSuite) TestSkillLifecycle() {
+	w := suite.makeRequestWithToken(suite.workerToken, "POST", skillsPath, map[string]interface{}{
+		"skill_category":       "Emergency Response",
+		"skill_name":           "First Aid",
+		"certification_number": "FA-1001",
+		"expiry_date":          time.Now().AddDate(1, 0, 0).Format("2006-01-02"),
+	})
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	skill := suite.decodeData(w)["skill"].(map[string]interface{})
+	suite.Equal("intermediate", skill["proficiency_level"])
+	suite.Equal(false, skill["is_verified"])
+	skillID := skill["id"].(string)
+
+	suite.Run("Requests are validated", func() {
+		for _, body := range []map[string]interface{}{
+			{"skill_category": "Safety"},
+			{"skill_category": "Safety", "skill_name": "CPR", "proficiency_level": "master"},
+			{"skill_category": "Safety", "skill_name": "CPR", "expiry_date": "next year"},
+		} {
+			w := suite.makeRequestWithToken(suite.workerToken, "POST", skillsPath, body)
+			suite.Equal(http.StatusBadRequest, w.Code, w.Body.String())
+		}
+
+		w := suite.makeRequestWithToken(suite.workerToken, "POST", skillsPath, map[string]interface{}{
+			"user_id":        suite.otherID,
+			"skill_category": "Safety",
+			"skill_name":     "CPR",
+		})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Workers cannot verify their own skills", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "POST", skillsPath+skillID+"/verify", nil)
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Managers verify skills", func() {
+		w := suite.makeAuthenticatedRequest("POST", skillsPath+skillID+"/verify", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		skill := suite.decodeData(w)["skill"].(map[string]interface{})
+		suite.Equal(true, skill["is_verified"])
+		suite.Equal(suite.userID, skill["verified_by"])
+
+		var audits int64
+		suite.db.Model(&models.AuditLog{}).Where("action = ? AND entity_id = ?", "verify_skill", skillID).Count(&audits)
+		suite.Equal(int64(1), audits)
+	})
+
+	suite.Run("The worker's compliance reflects the verified skill", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "GET", skillsPath, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := suite.decodeData(w)
+		suite.Len(data["skills"], 1)
+		compliance := data["compliance"].(map[string]interface{})
+		suite.Equal(false, compliance["compliant"])
+		suite.Len(compliance["gaps"], len(models.DefaultMandatoryCredentials)-1)
+	})
+
+	suite.Run("Changing the expiry needs another verification", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "PUT", skillsPath+skillID, map[string]interface{}{
+			"expiry_date": time.Now().AddDate(2, 0, 0).Format("2006-01-02"),
+			"notes":       "Renewed",
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		skill := suite.decodeData(w)["skill"].(map[string]interface{})
+		suite.Equal(false, skill["is_verified"])
+		suite.Equal("Renewed", skill["notes"])
+
+		w = suite.makeRequestWithToken(suite.workerToken, "PUT", skillsPath+skillID, map[string]interface{}{"proficiency_level": "expert"})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal("expert", suite.decodeData(w)["skill"].(map[string]interface{})["proficiency_level"])
+	})
+
+	suite.Run("Skills can be deleted", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "DELETE", skillsPath+skillID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w = suite.makeRequestWithToken(suite.workerToken, "GET", skillsPath, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["skills"], 0)
+	})
+}
+
+func (suite *WorkerSkillTestSuite) TestExpiringSkills() {
+	expired := suite.addSkill(suite.otherID, "Manual Handling", time.Now().AddDate(0, 0, -3), true)
+	soon := suite.addSkill(suite.otherID, "Medication Administration", time.Now().AddDate(0, 0, 10), true)
+	suite.addSkill(suite.otherID, "Manual Handling Refresher", time.Now().AddDate(0, 6, 0), true)
+
+	w := suite.makeAuthenticatedRequest("GET", skillsPath+"expiring?user_id="+suite.otherID, nil)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	data := suite.decodeData(w)
+	skills := data["skills"].([]interface{})
+	suite.Require().Len(skills, 2)
+	suite.Equal(expired, skills[0].(map[string]interface{})["skill"].(map[string]interface{})["id"])
+	suite.Equal(soon, skills[1].(map[string]interface{})["skill"].(map[string]interface{})["id"])
+	suite.Equal(float64(1), data["expired"])
+
+	w = suite.makeRequestWithToken(suite.workerToken, "GET", skillsPath+"expiring?user_id="+suite.otherID, nil)
+	suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+
+	w = suite.makeAuthenticatedRequest("POST", skillsPath+expired+"/verify", nil)
+	suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+}
+
+func (suite *WorkerSkillTestSuite) TestMandatoryCredentials() {
+	suite.Run("Defaults are only added once", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/organization/credentials/defaults", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Len(suite.decodeData(w)["added"], 0)
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/organization/credentials", map[string]interface{}{"name": "cpr"})
+		suite.Equal(http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	suite.Run("Workers cannot change credentials", func() {
+		w := suite.makeRequestWithToken(suite.workerToken, "POST", "/api/v1/organization/credentials", map[string]interface{}{"name": "Driver Licence"})
+		suite.Equal(http.StatusForbidden, w.Code, w.Body.String())
+	})
+
+	suite.Run("Credentials can be added and retired", func() {
+		w := suite.makeAuthenticatedRequest("POST", "/api/v1/organization/credentials", map[string]interface{}{
+			"name":     "Driver Licence",
+			"category": "Transport",
+		})
+		suite.Require().Equal(http.StatusCreated, w.Code, w.Body.String())
+		credentialID := suite.decodeResponse(w)["data"].(map[string]interface{})["id"].(string)
+
+		w = suite.makeAuthenticatedRequest("PUT", "/api/v1/organization/credentials/"+credentialID, map[string]interface{}{
+			"name":      "Driver Licence",
+			"category":  "Transport",
+			"is_active": false,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		suite.Equal(false, suite.decodeResponse(w)["data"].(map[string]interface{})["is_active"])
+
+		w = suite.makeAuthenticatedRequest("DELETE", "/api/v1/organization/credentials/"+credentialID, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+func (suite *WorkerSkillTestSuite) TestShiftAssignmentChecksCredentials() {
+	compliant := suite.createUser("skills-compliant", "compliant@skills.test", "care_worker")
+	for _, credential := range models.DefaultMandatoryCredentials {
+		suite.addSkill(compliant, credential.Name, time.Now().AddDate(1, 0, 0), true)
+	}
+	suite.addSkill(suite.otherID, "CPR", time.Now().AddDate(0, 0, -1), true)
+
+	suite.Run("Workers missing credentials are refused", func() {
+		code, response := suite.bookShift(suite.otherID, 0, nil)
+		suite.Require().Equal(http.StatusConflict, code, response)
+		failure := response["error"].(map[string]interface{})
+		suite.Equal("CREDENTIALS_NOT_MET", failure["code"])
+		gaps := failure["details"].([]interface{})
+		suite.Len(gaps, len(models.DefaultMandatoryCredentials))
+
+		statuses := map[string]string{}
+		for _, gap := range gaps {
+			gap := gap.(map[string]interface{})
+			statuses[gap["credential"].(string)] = gap["status"].(string)
+		}
+		suite.Equal(models.CredentialExpired, statuses["CPR"])
+		suite.Equal(models.CredentialMissing, statuses["First Aid"])
+	})
+
+	suite.Run("Overrides need a reason", func() {
+		code, response := suite.bookShift(suite.otherID, 0, map[string]interface{}{"override_credentials": true})
+		suite.Require().Equal(http.StatusBadRequest, code, response)
+		suite.Equal("OVERRIDE_REASON_REQUIRED", response["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Managers can override with a reason", func() {
+		code, response := suite.bookShift(suite.otherID, 0, map[string]interface{}{
+			"override_credentials": true,
+			"override_reason":      "Renewal booked, only available worker",
+		})
+		suite.Require().Equal(http.StatusCreated, code, response)
+		shiftID := response["data"].(map[string]interface{})["id"].(string)
+
+		var audit models.AuditLog
+		suite.Require().NoError(suite.db.Where("action = ? AND entity_id = ?", "override_credentials", shiftID).First(&audit).Error)
+		suite.Require().NotNil(audit.UserID)
+		suite.Equal(suite.userID, *audit.UserID)
+	})
+
+	suite.Run("Compliant workers are accepted", func() {
+		code, response := suite.bookShift(compliant, 1, nil)
+		suite.Require().Equal(http.StatusCreated, code, response)
+		shiftID := response["data"].(map[string]interface{})["id"].(string)
+
+		w := suite.makeAuthenticatedRequest("PUT", "/api/v1/shifts/"+shiftID, map[string]interface{}{"staff_id": suite.otherID})
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+
+		w = suite.makeAuthenticatedRequest("POST", "/api/v1/shifts/"+shiftID+"/assign", map[string]interface{}{"staff_id": suite.workerID, "reassign": true})
+		suite.Require().Equal(http.StatusConflict, w.Code, w.Body.String())
+		suite.Equal("CREDENTIALS_NOT_MET", suite.decodeResponse(w)["error"].(map[string]interface{})["code"])
+	})
+
+	suite.Run("Credentials must still be current when the shift starts", func() {
+		code, response := suite.bookShift(compliant, 400, nil)
+		suite.Require().Equal(http.StatusConflict, code, response)
+	})
+
+	suite.Run("Compliance report lists workers who can't be assigned", func() {
+		w := suite.makeAuthenticatedRequest("GET", "/api/v1/organization/credentials/compliance?non_compliant=true", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		workers := suite.decodeData(w)["workers"].([]interface{})
+		for _, worker := range workers {
+			suite.NotEqual(compliant, worker.(map[string]interface{})["user_id"])
+		}
+		suite.GreaterOrEqual(len(workers), 2)
+	})
+}
+
+// TestWorkerSkillSuite runs the worker skill test suite
+func TestWorkerSkillSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	suite.Run(t, new(WorkerSkillTestSuite))
+}